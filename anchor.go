@@ -0,0 +1,97 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// AnchorAt is what a bare clock time (like "9:15am") is taken relative to, set from the anchor config key. Zero means
+// today. Otherwise the time is the first one with that clock reading at or after AnchorAt, which is normally the last
+// event on the track.
+var AnchorAt time.Time
+
+// bareClockTime matches time text with no date in it. Anything with "yesterday", a weekday, and so on, doesn't match, so
+// those still work as written.
+var bareClockTime = regexp.MustCompile(`(?i)^(at\s+)?(\d{1,2}(:\d\d)?\s*[ap]\.?m\.?|\d{1,2}:\d\d|noon|midnight)$`)
+
+// clockFormats are tried in order on a bare clock time, after it has been cleaned up by parseClock.
+var clockFormats = []string{"3:04PM", "3PM", "15:04"}
+
+// parseClock reads a bare clock time as hours and minutes after midnight. dateparser makes a mess of some of these ("1am"
+// comes back as January), so they don't go through it.
+func parseClock(text string) (time.Duration, bool) {
+	text = strings.ToUpper(strings.Join(strings.Fields(text), ""))
+	text = strings.ReplaceAll(strings.TrimPrefix(text, "AT"), ".", "")
+	switch text {
+	case "NOON":
+		return 12 * time.Hour, true
+	case "MIDNIGHT":
+		return 0, true
+	}
+	for _, layout := range clockFormats {
+		if t, err := time.Parse(layout, text); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+		}
+	}
+	return 0, false
+}
+
+// Anchor puts a time parsed from text on the right day according to AnchorAt. Times that aren't a bare clock time are
+// returned as is. The second return value explains what was done, if it changed anything.
+func Anchor(text string, t time.Time) (time.Time, string) {
+	if !bareClockTime.MatchString(text) {
+		return t, ""
+	}
+	clock, ok := parseClock(text)
+	if !ok {
+		return t, ""
+	}
+
+	if AnchorAt.IsZero() {
+		day := Calendar.Day(Clock.Now())
+		at := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local).Add(clock)
+		if at.Before(day) {
+			// After midnight, but before daystart, so still part of today.
+			at = at.AddDate(0, 0, 1)
+		}
+		if at.Equal(t) {
+			return t, ""
+		}
+		Debug.Debug("anchored time", "text", text, "from", t.Format(timelog.TimeFormat), "to", at.Format(timelog.TimeFormat), "anchor", "today")
+		return at, fmt.Sprintf("has no date, so it is %s today", at.Format("3:04PM"))
+	}
+
+	day := Calendar.Day(AnchorAt)
+	at := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local).Add(clock)
+	if at.Before(AnchorAt) {
+		at = at.AddDate(0, 0, 1)
+	}
+	Debug.Debug("anchored time", "text", text, "from", t.Format(timelog.TimeFormat), "to", at.Format(timelog.TimeFormat), "anchor", AnchorAt.Format(timelog.TimeFormat))
+	return at, fmt.Sprintf("has no date, so it is the first %s after the last event (%s)", at.Format("3:04PM"), AnchorAt.Format(timelog.TimeFormat))
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AttachDir is where relative attachment paths are from, the directory the timelog is in. With the timelog on stdin
+// it is the current directory.
+func AttachDir(config map[string]string) string {
+	if config["logfile"] == "-" {
+		dir, _ := os.Getwd()
+		return dir
+	}
+	dir, _ := filepath.Abs(filepath.Dir(config["logfile"]))
+	return dir
+}
+
+// AttachmentName works out how to refer to a file in attach metadata. Files next to the timelog (or below it) are
+// relative to it, so they move with it, files in your home directory start with ~, and anything else is absolute. The
+// file has to exist.
+func AttachmentName(file, dir string) (string, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+
+	if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel), nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return "~/" + filepath.ToSlash(rel), nil
+		}
+	}
+	return path, nil
+}
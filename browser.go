@@ -0,0 +1,223 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BrowserVisit is a page visit from an exported browser history.
+type BrowserVisit struct {
+	At     time.Time
+	Domain string
+}
+
+// ReadBrowserHistory reads the exported browser history files matching a comma separated list of glob patterns, the
+// browserhistory config. Nothing here talks to the browser (or anything else), it only reads the exports you made.
+func ReadBrowserHistory(patterns string) ([]BrowserVisit, error) {
+	visits := []BrowserVisit{}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid browserhistory pattern %q: %w", pattern, err)
+		}
+		for _, file := range files {
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			found, err := ParseBrowserHistory(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			visits = append(visits, found...)
+		}
+	}
+	return visits, nil
+}
+
+// ParseBrowserHistory parses an exported browser history. This may be the JSON from Google Takeout, or CSV with a
+// header row naming a url column and a time column (time, date, timestamp, visit_time, or similar), as most history
+// export extensions write. A CSV time that can't be read is an error, rather than quietly losing every visit.
+func ParseBrowserHistory(raw []byte) ([]BrowserVisit, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return parseTakeoutHistory(raw)
+	}
+	return parseCSVHistory(raw)
+}
+
+func parseTakeoutHistory(raw []byte) ([]BrowserVisit, error) {
+	takeout := struct {
+		History []struct {
+			URL  string `json:"url"`
+			Usec int64  `json:"time_usec"`
+		} `json:"Browser History"`
+	}{}
+	if err := json.Unmarshal(raw, &takeout); err != nil {
+		return nil, err
+	}
+
+	visits := []BrowserVisit{}
+	for _, item := range takeout.History {
+		if domain := visitDomain(item.URL); domain != "" && item.Usec > 0 {
+			visits = append(visits, BrowserVisit{At: time.UnixMicro(item.Usec), Domain: domain})
+		}
+	}
+	return visits, nil
+}
+
+func parseCSVHistory(raw []byte) ([]BrowserVisit, error) {
+	r := csv.NewReader(bytes.NewReader(raw))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	urlcol, timecol := -1, -1
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.NewReplacer("_", "", " ", "").Replace(strings.TrimSpace(name)))
+		switch name {
+		case "url":
+			urlcol = i
+		case "time", "date", "timestamp", "visittime", "visitdate", "lastvisittime", "datetime":
+			if timecol < 0 {
+				timecol = i
+			}
+		}
+	}
+	if urlcol < 0 || timecol < 0 {
+		return nil, errors.New("expected a header row with url and time columns")
+	}
+
+	visits := []BrowserVisit{}
+	for i, record := range records[1:] {
+		if len(record) <= urlcol || len(record) <= timecol {
+			continue
+		}
+		domain := visitDomain(record[urlcol])
+		if domain == "" {
+			continue
+		}
+		at, ok := parseVisitTime(record[timecol])
+		if !ok {
+			return nil, fmt.Errorf("row %v: unrecognized time %q", i+1, record[timecol])
+		}
+		visits = append(visits, BrowserVisit{At: at, Domain: domain})
+	}
+	return visits, nil
+}
+
+// parseVisitTime understands Unix times (in seconds, milliseconds, or microseconds, going by how big they are) and the
+// usual ways of writing a date and time. Times without a zone are local.
+func parseVisitTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		switch {
+		case n > 1e14:
+			return time.UnixMicro(int64(n)), true
+		case n > 1e11:
+			return time.UnixMilli(int64(n)), true
+		default:
+			return time.Unix(int64(n), 0), true
+		}
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "1/2/2006 15:04:05", "1/2/2006, 3:04:05 PM"} {
+		if at, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// visitDomain is the host part of a URL, without any "www.". Things like file and about pages have no host, and are
+// skipped.
+func visitDomain(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// BrowserHints sums up the visits on the day starting at begin, an hour at a time. Each hour with any visits gets a
+// line with the most visited domains, up to top of them, and how many visits each had. The hours are counted from
+// begin, so a day that starts at 4am lists the small hours after the evening.
+func BrowserHints(visits []BrowserVisit, begin time.Time, top int) []string {
+	end := begin.AddDate(0, 0, 1)
+	hours := make([]map[string]int, int(end.Sub(begin)/time.Hour)+1)
+	for _, v := range visits {
+		if v.At.Before(begin) || !v.At.Before(end) {
+			continue
+		}
+		hour := int(v.At.Sub(begin) / time.Hour)
+		if hours[hour] == nil {
+			hours[hour] = map[string]int{}
+		}
+		hours[hour][v.Domain]++
+	}
+
+	lines := []string{}
+	for hour, counts := range hours {
+		if counts == nil {
+			continue
+		}
+		domains := []string{}
+		for domain := range counts {
+			domains = append(domains, domain)
+		}
+		sort.Slice(domains, func(i, j int) bool {
+			if counts[domains[i]] != counts[domains[j]] {
+				return counts[domains[i]] > counts[domains[j]]
+			}
+			return domains[i] < domains[j]
+		})
+		if len(domains) > top {
+			domains = domains[:top]
+		}
+		for i, domain := range domains {
+			domains[i] = fmt.Sprintf("%s (%d)", domain, counts[domain])
+		}
+		at := begin.Add(time.Duration(hour) * time.Hour).In(begin.Location())
+		lines = append(lines, fmt.Sprintf("%s  %s", at.Format("15:04"), strings.Join(domains, ", ")))
+	}
+	return lines
+}
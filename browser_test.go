@@ -0,0 +1,145 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadBrowserHistory(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		// Google Takeout. Pages without a host and visits without a time are skipped.
+		"BrowserHistory.json": fmt.Sprintf(`{"Browser History": [
+			{"title": "a", "url": "https://github.com/milochristiansen/timeclock", "time_usec": %d},
+			{"title": "b", "url": "https://github.com/", "time_usec": %d},
+			{"title": "c", "url": "https://www.example.com/", "time_usec": %d},
+			{"title": "d", "url": "about:blank", "time_usec": %d},
+			{"title": "e", "url": "https://nowhen.example/"}
+		]}`, at(13, 9, 10).UnixMicro(), at(13, 9, 40).UnixMicro(), at(13, 10, 5).UnixMicro(), at(13, 9, 15).UnixMicro()),
+		// An extension's CSV with Unix milliseconds.
+		"extension.csv": fmt.Sprintf("Title,URL,Visit Time\n"+
+			"x,https://github.com/x,%d\n"+
+			"y,https://news.ycombinator.com/,%d\n"+
+			"z,https://docs.go.dev/,%d\n"+
+			"w,https://early.example/,%d\n",
+			at(13, 9, 50).UnixMilli(), at(13, 9, 55).UnixMilli(), at(14, 1, 30).UnixMilli(), at(13, 3, 0).UnixMilli()),
+		// And one with written out local times, and a row too short to have a time.
+		"dates.csv": "url,date\n" +
+			"http://www.Example.com/a,2026-10-13 10:20:00\n" +
+			"https://go.dev/,2026-10-13T09:05:00\n" +
+			"https://short.example/\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	visits, err := ReadBrowserHistory(filepath.Join(dir, "*.json") + ", " + filepath.Join(dir, "*.csv") + ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(visits) != 9 {
+		t.Errorf("got %d visits, want 9: %v", len(visits), visits)
+	}
+
+	// The day starts at 4am, so the visit at 1:30 the next morning is on it, after the evening, and the one at 3am
+	// that morning isn't.
+	got := BrowserHints(visits, at(13, 4, 0), 2)
+	want := []string{
+		"09:00  github.com (3), go.dev (1)",
+		"10:00  example.com (2)",
+		"01:00  docs.go.dev (1)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hints are\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := BrowserHints(visits, at(20, 0, 0), 3); len(got) != 0 {
+		t.Errorf("hints for a day without visits are %v", got)
+	}
+}
+
+func TestParseVisitTime(t *testing.T) {
+	want := time.Date(2026, 10, 13, 9, 30, 0, 0, time.Local)
+	for _, s := range []string{
+		fmt.Sprint(want.Unix()),
+		fmt.Sprint(want.UnixMilli()),
+		fmt.Sprint(want.UnixMicro()),
+		want.Format(time.RFC3339),
+		" 2026-10-13 09:30:00 ",
+		"2026-10-13T09:30:00",
+		"2026-10-13 09:30",
+		"10/13/2026 09:30:00",
+		"10/13/2026, 9:30:00 AM",
+	} {
+		if at, ok := parseVisitTime(s); !ok || !at.Equal(want) {
+			t.Errorf("%q read as %v, %v", s, at, ok)
+		}
+	}
+	for _, s := range []string{"", "yesterday", "13.10.2026 09:30"} {
+		if at, ok := parseVisitTime(s); ok {
+			t.Errorf("%q read as %v", s, at)
+		}
+	}
+}
+
+func TestParseBrowserHistoryErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"truncated JSON":  `{"Browser History": [{"url": "https://github.com/"`,
+		"wrong JSON":      `{"Browser History": {"url": 7}}`,
+		"no time column":  "url,title\nhttps://github.com/,GitHub\n",
+		"no url column":   "link,time\nhttps://github.com/,1791000000\n",
+		"bad quoting":     "url,time\n\"https://github.com/,1791000000\n",
+		"unreadable time": "url,time\nhttps://github.com/,13.10.2026 09:30\n",
+		"not history":     "\x00\x01\x02 binary",
+	} {
+		if visits, err := ParseBrowserHistory([]byte(content)); err == nil {
+			t.Errorf("%s parsed as %v", name, visits)
+		}
+	}
+	if visits, err := ParseBrowserHistory(nil); err != nil || len(visits) != 0 {
+		t.Errorf("an empty file gave %v, %v", visits, err)
+	}
+
+	// Reading a broken file says which one it was.
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.csv")
+	if err := os.WriteFile(path, []byte("url\nhttps://github.com/\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBrowserHistory(path); err == nil || !strings.HasPrefix(err.Error(), path+": ") {
+		t.Errorf("broken file gave %v", err)
+	}
+	if _, err := ReadBrowserHistory(filepath.Join(dir, "[")); err == nil || !strings.Contains(err.Error(), "invalid browserhistory pattern") {
+		t.Errorf("bad pattern gave %v", err)
+	}
+}
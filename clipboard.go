@@ -0,0 +1,71 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// clipboardCommand returns the command that puts its stdin on the system clipboard, or nil if there isn't one.
+func clipboardCommand() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"pbcopy"}
+	case "windows":
+		// clip.exe mangles anything that isn't plain ASCII, PowerShell doesn't.
+		return []string{"powershell.exe", "-NoProfile", "-Command", "$input | Set-Clipboard"}
+	}
+
+	candidates := [][]string{}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append(candidates, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		candidates = append(candidates, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// CopyToClipboard puts the text on the system clipboard. This uses pbcopy on macOS, PowerShell on Windows, and wl-copy,
+// xclip, or xsel elsewhere, depending on which is installed and whether there is a Wayland or X11 display.
+func CopyToClipboard(text []byte) error {
+	command := clipboardCommand()
+	if command == nil {
+		return errors.New("no clipboard found, install wl-copy (Wayland), or xclip or xsel (X11)")
+	}
+	Debug.Debug("copying to clipboard", "command", command, "bytes", len(text))
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(text)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// TakeCodePatterns pulls the time code patterns out of the arguments and returns the known codes they match, sorted.
+// A pattern is a time code argument (starting with ':') that is either a glob ("client-*:dev") or a regular expression
+// between slashes ("/^client-.*:dev$/"). Globs work one level at a time, so '*' doesn't match a ':'. Either may end in
+// a wildcard like ":...", which is kept on every code matched. A pattern that matches nothing is an error, since
+// quietly reporting on everything instead would be worse.
+func TakeCodePatterns(args []string, codes []string) ([]string, []string) {
+	rest, out, err := MatchCodePatterns(args, codes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.As(err, &NoCodesMatch{}) {
+			os.Exit(1)
+		}
+		os.Exit(2)
+	}
+	return rest, out
+}
+
+// NoCodesMatch is the error for a time code pattern that is fine, but doesn't match any of the codes.
+type NoCodesMatch struct {
+	Pattern string
+}
+
+func (err NoCodesMatch) Error() string {
+	return fmt.Sprintf("No time codes match %q.", err.Pattern)
+}
+
+// MatchCodePatterns is TakeCodePatterns, returning an error for an invalid pattern (or a [NoCodesMatch]) rather than
+// exiting.
+func MatchCodePatterns(args []string, codes []string) ([]string, []string, error) {
+	rest := make([]string, 0, len(args))
+	matched := map[string]bool{}
+	var parents []string
+
+	for _, arg := range args {
+		pattern, ok := strings.CutPrefix(arg, ":")
+		if !ok {
+			rest = append(rest, arg)
+			continue
+		}
+		base, _, wild := timelog.CutWildcard(pattern)
+		suffix := pattern[len(base):]
+
+		var match func(code string) bool
+		switch {
+		case len(base) > 1 && strings.HasPrefix(base, "/") && strings.HasSuffix(base, "/"):
+			re, err := regexp.Compile(base[1 : len(base)-1])
+			if err != nil {
+				return nil, nil, fmt.Errorf("Invalid time code pattern %q: %v", arg, err)
+			}
+			match = re.MatchString
+		case strings.ContainsAny(base, "*?["):
+			// Globs are compared like codes are (see timelog.CodeKey), so a/b and A:B match the same things.
+			glob := strings.ReplaceAll(timelog.CodeKey(base, FoldCodeCase), ":", "/")
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, nil, fmt.Errorf("Invalid time code pattern %q: %v", arg, err)
+			}
+			match = func(code string) bool {
+				ok, _ := path.Match(glob, strings.ReplaceAll(timelog.CodeKey(code, FoldCodeCase), ":", "/"))
+				return ok
+			}
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+
+		// With a wildcard on the end the pattern can be for a code that is only ever a parent.
+		against := codes
+		if wild {
+			if parents == nil {
+				parents = timelog.WithParents(codes)
+			}
+			against = parents
+		}
+
+		n := 0
+		for _, code := range against {
+			// These aren't real codes, see report.Options.Codes.
+			if code == "empty" || code == "all" {
+				continue
+			}
+			if match(code) {
+				matched[code+suffix] = true
+				n++
+			}
+		}
+		Debug.Debug("time code pattern", "pattern", arg, "matched", n)
+		if n == 0 {
+			return nil, nil, NoCodesMatch{arg}
+		}
+	}
+
+	out := make([]string, 0, len(matched))
+	for code := range matched {
+		out = append(out, code)
+	}
+	sort.Strings(out)
+	return rest, out, nil
+}
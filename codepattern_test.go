@@ -0,0 +1,128 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+var patternCodes = []string{"client-a:dev", "Client-B:Dev", "client-b/ops", "client-c:dev:frontend", "acme:dev", "empty", "all"}
+
+func TestMatchCodePatterns(t *testing.T) {
+	defer func(fold bool) { FoldCodeCase = fold }(FoldCodeCase)
+
+	tests := []struct {
+		fold  bool
+		args  []string
+		rest  string
+		codes string
+	}{
+		// Globs stay on one level and compare like codes do, so case and separators don't matter when folding.
+		{true, []string{"last", ":client-*:dev", "week"}, "last,week", "Client-B:Dev,client-a:dev"},
+		{false, []string{":client-*:dev"}, "", "client-a:dev"},
+		{true, []string{":CLIENT-?/OPS"}, "", "client-b/ops"},
+		{true, []string{":*:dev:*"}, "", "Client-B:Dev:*,acme:dev:*,client-a:dev:*,client-c:dev:*"},
+		// A wildcard on the end is kept, and can match codes that are only parents, whatever separates them.
+		{true, []string{":client-*:dev:..."}, "", "Client-B:Dev:...,client-a:dev:...,client-c:dev:..."},
+		// Regular expressions match anywhere unless anchored, and always against the code as written.
+		{true, []string{":/dev/"}, "", "acme:dev,client-a:dev,client-c:dev:frontend"},
+		{true, []string{":/^client-.:dev$/"}, "", "client-a:dev"},
+		{true, []string{":/(?i)^client-b:/"}, "", "Client-B:Dev"},
+		{true, []string{":/^client-[ab]$/:..."}, "", "client-a:...,client-b:..."},
+		// Ordinary codes and the special ones are left for the caller, and two patterns add up.
+		{true, []string{":acme", ":empty", ":acme:*", ":/^acme/", ":client-a*:dev"}, ":acme,:empty,:acme:*", "acme:dev,client-a:dev"},
+	}
+	for _, test := range tests {
+		FoldCodeCase = test.fold
+		rest, codes, err := MatchCodePatterns(test.args, patternCodes)
+		if err != nil {
+			t.Errorf("%v: %v", test.args, err)
+			continue
+		}
+		if strings.Join(rest, ",") != test.rest || strings.Join(codes, ",") != test.codes {
+			t.Errorf("%v (fold %v) gave %q and %q, want %q and %q", test.args, test.fold, rest, codes, test.rest, test.codes)
+		}
+	}
+}
+
+func TestMatchCodePatternsErrors(t *testing.T) {
+	for _, test := range []struct {
+		arg     string
+		invalid bool
+	}{
+		{":/client-(/", true},
+		{":/[z-a]/:...", true},
+		{":client-[", true},
+		{":/^Client-B$/", false},
+		{":/empty/", false},
+		{":nobody-*", false},
+	} {
+		_, _, err := MatchCodePatterns([]string{"today", test.arg}, patternCodes)
+		nomatch := errors.As(err, &NoCodesMatch{})
+		switch {
+		case err == nil:
+			t.Errorf("%s matched", test.arg)
+		case test.invalid && (nomatch || !strings.HasPrefix(err.Error(), "Invalid time code pattern")):
+			t.Errorf("%s gave %v, want it to be invalid", test.arg, err)
+		case !test.invalid && (!nomatch || err.Error() != `No time codes match "`+test.arg+`".`):
+			t.Errorf("%s gave %v, want no match", test.arg, err)
+		}
+	}
+}
+
+// The patterns are turned into codes first, which the report filter then treats like any other codes. A pattern given
+// to the filter as it is would only match a code spelled that way.
+func TestCodePatternsFilter(t *testing.T) {
+	defer func(fold bool) { FoldCodeCase = fold }(FoldCodeCase)
+	FoldCodeCase = true
+
+	_, codes, err := MatchCodePatterns([]string{":client-*:dev:..."}, patternCodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := report.CodeFilter(codes, FoldCodeCase)
+	literal := report.CodeFilter([]string{"client-*:dev:..."}, FoldCodeCase)
+	for code, want := range map[string]bool{
+		"client-a:dev":          true,
+		"CLIENT-A/DEV":          true,
+		"client-b:dev":          true,
+		"client-b:dev:review":   true,
+		"client-c:dev:frontend": true,
+		"client-b:ops":          false,
+		"acme:dev":              false,
+		"":                      false,
+	} {
+		p := &timelog.Period{Code: code}
+		if filter(p) != want {
+			t.Errorf("%q filtered as %v, want %v", code, !want, want)
+		}
+		if literal(p) {
+			t.Errorf("%q matched the pattern given to the filter as a code", code)
+		}
+	}
+}
@@ -0,0 +1,829 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Command is a command word, with everything help and completion need to know about it. Topics are help that isn't
+// about any one command, and are included in the help for the commands that list them in See.
+type Command struct {
+	Name    string
+	Aliases []string
+	Usage   string // The arguments, eg "[range] [:code...]".
+	Summary string // One line for the list of commands.
+
+	// Paragraphs separated by blank lines, wrapped to the terminal when printed. Lines starting with a tab are examples,
+	// and are printed as they are.
+	Help string
+
+	See      []string // Topics to include after the help.
+	Examples []string // Arguments, printed after the program name.
+
+	Flags       []string // For completion, along with the global flags.
+	Subcommands []string // For completion, only as the first argument.
+
+	Topic  bool // A help topic, not a command.
+	Hidden bool // Left out of the list of commands and completion.
+}
+
+// GlobalFlags are the flags that work with any command, they are taken out of the arguments before anything else.
+var GlobalFlags = []string{
+	"--durations", "--choose", "--track", "--meta", "--non-interactive", "--allow-backdate", "--yes", "--logfile",
+	"--config", "--strict", "--debug", "--debug-file", "--now", "--today",
+}
+
+// Commands is every command, in the order they are listed in. Dispatch, help, and completion all go by this, so a new
+// command needs an entry here as well as a case in main.
+var Commands = []*Command{
+	{
+		Name:    "time",
+		Usage:   "<time>",
+		Summary: "Change the time of the last event.",
+		Help: `Change the time of the last event to the time given. A time that isn't today, or is far from now, has to be
+confirmed like it does for a new event, or allowed with --yes. A time with no date is anchored to the event before
+the one being changed.`,
+		Examples: []string{"time 9:15am", "time 10 minutes ago"},
+	},
+	{
+		Name:    "code",
+		Usage:   "<code>",
+		Summary: "Change the time code of the last event.",
+		Help: `Change the time code of the last event to the one given, creating it if it is new. This is how new codes
+are made, since a new event only picks up codes that are already known. Time codes may not contain spaces!`,
+		Examples: []string{"code Customer:Dev"},
+	},
+	{
+		Name:    "desc",
+		Aliases: []string{"note"},
+		Usage:   "[description]",
+		Summary: "Change the description of the last event.",
+		Help: `Change the description of the last event to the one given. With no argument, the description is read from
+stdin if it is piped, or edited in $EDITOR otherwise. This allows multi-line descriptions.`,
+		Examples: []string{"desc Fixed the login page.", "desc < notes.txt"},
+	},
+	{
+		Name:    "fill-from-git",
+		Usage:   "[range] [--all]",
+		Summary: "Describe events with the commits you made at the time.",
+		Help: `For each event today (or in the given time range) with a code but no description, offer the commits you
+made then in the gitrepos repositories as the description. --all uses all of them without asking.`,
+		See:      []string{"ranges"},
+		Flags:    []string{"--all"},
+		Examples: []string{"fill-from-git", "fill-from-git yesterday --all"},
+	},
+	{
+		Name:    "edit",
+		Usage:   "[number|time]",
+		Summary: "Edit recent events in $EDITOR.",
+		Help: `Open events in $EDITOR as timelog text, and write them back after checking they still parse. With no
+argument the last event is edited, a number edits that many of the most recent events, and a time edits the event in
+effect at that time.`,
+		Flags:    []string{"--editor"},
+		Examples: []string{"edit", "edit 3", "edit yesterday 2pm"},
+	},
+	{
+		Name:    "edit-log",
+		Usage:   "[range]",
+		Summary: "Edit the whole timelog, or a range of it, in $EDITOR.",
+		Help: `Open the whole timelog, or the events in a time range, in $EDITOR. The changes are shown for confirmation,
+and nothing is written unless the edited log parses.`,
+		See:      []string{"ranges"},
+		Examples: []string{"edit-log", "edit-log last week"},
+	},
+	{
+		Name:    "reconstruct",
+		Usage:   "[date] [--hints browser]",
+		Summary: "Rebuild a day you forgot to track.",
+		Help: `Like edit-log for a single day, today unless a date is given, to rebuild a day you forgot to track.
+--hints browser lists the sites you visited most each hour, from the exports in the browserhistory config.`,
+		Flags:    []string{"--hints"},
+		Examples: []string{"reconstruct yesterday --hints browser"},
+	},
+	{
+		Name:    "import",
+		Usage:   "<file|->",
+		Summary: "Stage events from another timelog for review.",
+		Help: `Read events from a timelog file (or - for stdin) into the staging area, leaving out any already in the log
+or staged. Nothing reaches the timelog until it has been through review.`,
+		Examples: []string{"import laptop.log", "import - < exported.log"},
+	},
+	{
+		Name:    "review",
+		Usage:   "[range] [--list]",
+		Summary: "Accept or reject staged events.",
+		Help: `Open the staged events (all of them, or those in a time range) in $EDITOR grouped by day. Fix codes and
+descriptions, remove lines to reject them, and the rest are added to the timelog. --list only prints them.`,
+		See:      []string{"ranges"},
+		Flags:    []string{"--list"},
+		Examples: []string{"review", "review --list"},
+	},
+	{
+		Name:    "sync",
+		Usage:   "pull|push|status [source...]",
+		Summary: "Sync with the sources in the syncfile.",
+		Help: `sync pull stages new events from the sources in the syncfile for review, sync push sends them the events
+they don't have yet, and sync status shows what is waiting each way. Name sources to only sync those.`,
+		Subcommands: []string{"pull", "push", "status"},
+		Examples:    []string{"sync status", "sync pull jira"},
+	},
+	{
+		Name:    "export",
+		Usage:   "all [file|-] | csv|ical [range] [:code...] [flags]",
+		Summary: "Write a zip file of everything, or the periods as CSV or a calendar.",
+		Help: `export all writes a zip file of everything: the timelog, codes, config (with secrets left out), ledgers,
+and statistics, as JSON. To stdout if the file is -.
+
+export csv writes a row for each period in a time range (or the whole log) with the codes given, as CSV to stdout.
+--columns <list> picks the columns, from begin, end, date, week, duration, minutes, code, desc, track, billable, and
+meta:<key>, and --delimiter <char> the delimiter, the defaults are from the csvcolumns and csvdelimiter config.
+--no-header leaves out the header row.
+
+export ical writes the same periods as an iCalendar file, to lay them over your calendar. Each period is an event
+with the description as its summary and the code as its category.`,
+		See:         []string{"ranges", "codes", "filters"},
+		Subcommands: []string{"all", "csv", "ical"},
+		Flags:       []string{"--columns", "--delimiter", "--no-header", "--overlap", "--where", "--transform"},
+		Examples: []string{
+			"export all backup.zip",
+			"export csv last month :Employer:... > hours.csv",
+			"export csv this week --columns date,code,minutes --delimiter ';'",
+			"export ical last month :Acme:... > acme.ics",
+		},
+	},
+	{
+		Name:    "purge",
+		Usage:   "--before <date> [--summarize]",
+		Summary: "Remove old events, after making a backup.",
+		Help: `purge --before <date> removes every event before that day, after making a backup and asking. --summarize
+keeps daily totals for each code.`,
+		Flags:    []string{"--before", "--summarize"},
+		Examples: []string{"purge --before 2024-01-01 --summarize"},
+	},
+	{
+		Name:    "migrate",
+		Usage:   "[--dry-run]",
+		Summary: "Upgrade the timelog to the current format version.",
+		Help: `Upgrade a timelog written by an older version of timeclock to the format this one writes. Every event is
+checked to read back the same once it is upgraded, and the old log is backed up next to it before anything is written.
+--dry-run only checks.
+
+Old logs can always be read, and most upgrades happen just by writing the log back. When one can't, commands that
+would change the log are refused until it has been migrated.`,
+		Flags:    []string{"--dry-run"},
+		Examples: []string{"migrate --dry-run", "migrate"},
+	},
+	{
+		Name:    "replica",
+		Summary: "Show the devices writing to the replica directory.",
+		Help: `Show the devices writing to the replica directory and how much each has done. Syncing with it happens every
+run, see the replica config.`,
+	},
+	{
+		Name:    "serve",
+		Usage:   "[--addr host:port]",
+		Summary: "Serve the timelog over a small REST API.",
+		Help: `Serve the timelog over HTTP, for clocking in and out from other tools and devices: list events, add one,
+change the last one, and run a report as JSON. It listens on the serveaddr (only this machine by default). Every request
+needs the servetoken, or without one the token serve keeps in the servetokenfile, as 'Authorization: Bearer <token>'.
+Bodies are sent as 'Content-Type: application/json'. See the README for the endpoints.`,
+		Flags:    []string{"--addr"},
+		Examples: []string{"serve", "serve --addr 192.168.1.10:8737"},
+	},
+	{
+		Name:    "plan",
+		Usage:   "[week [date]] | <command or event>",
+		Summary: "Plan time, and compare the week against the plan.",
+		Help: `Anything after plan works on the plan file instead of the timelog, so plan tomorrow 9am :Acme meeting plans
+a block, and plan edit-log edits the plan. plan or plan week [date] shows the week so far plus what is planned, against
+budgets and schedule.`,
+		Subcommands: []string{"week"},
+		Examples:    []string{"plan tomorrow 9am :Acme Design review", "plan week", "plan recent"},
+	},
+	{
+		Name:    "status",
+		Usage:   "[--copy]",
+		Summary: "Print the current event and how long it has been open.",
+		Help: `Prints the current last event, and how long it has been open. Anything still open on other tracks is
+mentioned too. With --copy it is put on the clipboard as well.`,
+		Flags: []string{"--copy"},
+	},
+	{
+		Name:     "recent",
+		Usage:    "[number]",
+		Summary:  "Print the last few events.",
+		Help:     `Prints the last few events, 10 unless a number is given.`,
+		Examples: []string{"recent", "recent 25"},
+	},
+	{
+		Name:    "since",
+		Summary: "Print the time since the last event.",
+		Help:    `Prints the time elapsed since the current last event.`,
+	},
+	{
+		Name:    "since-last",
+		Usage:   "[name] [:code...] [--peek]",
+		Summary: "Sum up everything logged since the last time you asked.",
+		Help: `Sums up everything logged since since-last was last run: the time on each code, what was done on it, and the
+total. When it was last run is kept in the sincelastfile, and the first run covers the last week. Give a name to keep
+a digest of its own, eg for each person you have a 1:1 with. --peek leaves the last run where it was.`,
+		See:      []string{"codes", "filters"},
+		Flags:    []string{"--peek", "--overlap", "--where", "--transform"},
+		Examples: []string{"since-last", "since-last boss :Acme:...", "since-last --peek"},
+	},
+	{
+		Name:    "total",
+		Usage:   "<time> [time] [:code...] [filters]",
+		Summary: "Print the total time for a few days.",
+		Help: `Prints the total time from the day of a given time until today, or the day of a second time. Periods count
+for the day they begin on. Totals are cached in the cachefile, so this is cheap enough for a status bar.`,
+		See:      []string{"codes", "filters"},
+		Flags:    []string{"--overlap", "--where", "--transform"},
+		Examples: []string{"total today", "total monday :Employer:..."},
+	},
+	{
+		Name:    "report",
+		Usage:   "<range> [:code...] [template] [flags]",
+		Summary: "Print a report of the time in a range.",
+		Help: `Print a report of the periods in a time range, with the totals for each code. You must give a time to start
+from, and the report runs until now unless you give a second one. If no code is given, all is used.
+
+With --top <n> only the n codes with the most time get their own totals, the rest are added together as other. With
+--round <duration> billed time is rounded to that unit, add --reconcile to make the rounded periods add up to the
+rounded totals.
+
+With --grid csv or --grid xlsx a weekly timesheet grid is written instead of using a template. With --export <preset>
+the time for each code on each day is written as CSV for importing into payroll, adp and workday are built in.
+--timesheet writes whichever of those the timesheet config names, usually set by an employer profile. With --format
+json the report is written as JSON for scripts, with durations in seconds.
+
+-o <file> or --output <file> writes to a file instead of stdout, with strftime tokens like %Y and %V filled in from the
+start of the report. --copy puts the report on the clipboard as well. --finalize locks the range once the report is
+written, so the events in it can't be changed. --plan <file> compares against that plan rather than the planfile, see
+the plan.tmpl report. --logs <name>,... reports on those logs from the logs config merged together, with subtotals
+for each, instead of on the timelog.
+
+If the first argument is the name of a report preset from a .sctime project file, it is replaced with the preset's
+arguments, and anything after it is added on the end.`,
+		See: []string{"ranges", "codes", "filters", "templates"},
+		Flags: []string{
+			"--top", "--round", "--reconcile", "--grid", "--export", "--timesheet", "--format", "--output", "-o", "--copy",
+			"--finalize", "--plan", "--logs", "--overlap", "--where", "--transform",
+		},
+		Examples: []string{
+			"report last week",
+			"report june 1st july 1st :all :empty",
+			"report last month :Customer:... --round 15m invoice.tmpl",
+			"report last week :Employer:... approval.tmpl --finalize -o timesheet-%G-W%V.txt",
+			"report this week --where location=office --transform auto-break=30m/6h",
+			"report last week --format json",
+			"report last week --logs client,personal",
+			"report last week :Employer:... --timesheet > hours.csv",
+		},
+	},
+	{
+		Name:    "invoice",
+		Usage:   "<range> [:code...] [template] [flags] | list | mark-sent|mark-paid|discard <number>",
+		Summary: "Make an invoice, and keep track of the ones you sent.",
+		Help: `Like report, but uses the invoice.tmpl template by default and records the invoice as a draft in the
+invoicefile. Time that was already invoiced is refused. Without an end time, the invoice runs until now.
+
+invoice list lists invoices, invoice mark-sent <number> and invoice mark-paid <number> change their state, and invoice
+discard <number> deletes a draft.`,
+		See:         []string{"ranges", "codes", "filters", "templates"},
+		Flags:       []string{"--round", "--reconcile", "--output", "-o", "--copy", "--finalize", "--overlap", "--where", "--transform"},
+		Subcommands: []string{"list", "mark-sent", "mark-paid", "discard"},
+		Examples:    []string{"invoice last month :Customer:...", "invoice list", "invoice mark-sent 2026-0014"},
+	},
+	{
+		Name:    "attach",
+		Usage:   "[--at <time>] <file...>",
+		Summary: "Attach files to an event.",
+		Help: `Attach files (screenshots, deliverables) to the last event, or the one in effect at --at <time>, as attach
+metadata. Relative paths are relative to the timelog's directory. check finds missing ones, and the html.tmpl report
+links to them.`,
+		Flags:    []string{"--at"},
+		Examples: []string{"attach shots/login-fixed.png", "attach --at \"yesterday 3pm\" designs/header.svg"},
+	},
+	{
+		Name:    "expense",
+		Usage:   "<amount> [currency] [:code] [note] | list [range]",
+		Summary: "Record money spent on a code.",
+		Help: `expense <amount> [currency] [:code] [note] records money spent, on the last event, the one in effect at
+--at <time>, or a whole day with --day <date>. --receipt <file> keeps the path to the receipt. expense list lists them,
+in a time range if one is given. Reports and invoices include the expenses in their range.`,
+		Flags:       []string{"--receipt", "--at", "--day"},
+		Subcommands: []string{"list"},
+		Examples:    []string{"expense 12.50 EUR Train ticket --receipt ~/receipts/train.pdf", "expense list last month"},
+	},
+	{
+		Name:    "travel",
+		Usage:   "<distance> [:code] [note] [--from <place>] [--to <place>] | list [range]",
+		Summary: "Record a trip for a code.",
+		Help: `travel <distance> [:code] [note] --from <place> --to <place> records a trip, attached like an expense. The
+distance is in distanceunit unless it says, eg 42km or 26mi. Trips on codes with a mileage rate are invoiced. travel
+list lists them, in a time range if one is given.`,
+		Flags:       []string{"--from", "--to", "--at", "--day"},
+		Subcommands: []string{"list"},
+		Examples:    []string{"travel 42km :Acme --from Home --to \"Acme HQ\" Kickoff meeting", "travel list last month"},
+	},
+	{
+		Name:    "info",
+		Usage:   "[--plain|--unused [--prune] [--add]|--tree [range]]",
+		Summary: "List the known time codes.",
+		Help: `List all known time codes, with when each was last used, how many events use it, and the time on it in total
+and in the last 30 days. With --plain only the codes are listed, one per line.
+
+With --unused codes in the timecode file that are never used, and used codes that aren't in it, are listed. Add
+--prune to remove the unused ones from the file, and --add to add empty sections for the missing ones.
+
+With --tree the codes are drawn as a tree, with the time under each one. Give a time range to only count that, eg info
+--tree last month.`,
+		Flags:    []string{"--plain", "--unused", "--prune", "--add", "--tree"},
+		Examples: []string{"info", "info --tree last month"},
+	},
+	{
+		Name:    "check",
+		Usage:   "[range]",
+		Summary: "Check working time rules, attachments, and future events.",
+		Help: `Check the last 30 days, or a time range, against the working time limits in the restrules config, and list
+where they were broken. Files attached to events that have gone missing, events in the future, and events on the
+same track at the same time are listed too. Exits with 1 if anything was found, so it can go in a script.`,
+		See:      []string{"ranges"},
+		Examples: []string{"check", "check last month"},
+	},
+	{
+		Name:    "stats",
+		Usage:   "--switches|--heatmap [--csv] [range]",
+		Summary: "Show context switches, or when you work.",
+		Help: `stats --switches counts how often the code changed each day in the last 30 days, or a time range, how long
+you stuck with a code on average, and the most common switches from one code to another.
+
+stats --heatmap shows the time tracked in each hour of each day of the week, over the same range. With --csv it is
+written as CSV instead.`,
+		See:      []string{"filters"},
+		Flags:    []string{"--switches", "--heatmap", "--csv", "--overlap", "--where", "--transform"},
+		Examples: []string{"stats --switches last week", "stats --heatmap --csv this year"},
+	},
+	{
+		Name:    "standup",
+		Usage:   "[day|range] [:code...]",
+		Summary: "List what you did on each code, for a standup.",
+		Help: `List the distinct descriptions logged for each code on a day, as a bullet list to paste into a standup.
+Without a day it is the last day before today with anything logged, so on a Monday it is usually Friday. With two times
+it covers the range between them. The same task logged more than once is only listed once.`,
+		See:      []string{"ranges", "codes", "filters"},
+		Flags:    []string{"--overlap", "--where", "--transform"},
+		Examples: []string{"standup", "standup yesterday :Acme:...", "standup last monday"},
+	},
+	{
+		Name:    "test",
+		Usage:   "[--explain] <event>",
+		Summary: "Show what an event would be, without writing it.",
+		Help: `Process all following input as if you were creating an event, but don't actually write anything to the
+timelog. With --explain every time and time code candidate is listed, along with what was removed from the
+description and why.`,
+		Flags:    []string{"--explain"},
+		Examples: []string{"test --explain fixed the 2031 build :Customer"},
+	},
+	{
+		Name:     "help",
+		Usage:    "[command|topic|all]",
+		Summary:  "Show help for a command.",
+		Help:     `Show the help for a command or topic, or the list of commands. help all shows everything at once.`,
+		Examples: []string{"help report", "help ranges", "report --help"},
+	},
+	{
+		Name:        "completion",
+		Usage:       "bash|zsh|fish",
+		Summary:     "Print a shell completion script.",
+		Subcommands: []string{"bash", "zsh", "fish"},
+		Help: `Print a script that completes commands, flags, time codes, and report templates for the given shell. Load it
+from your shell's startup file.`,
+		Examples: []string{"completion bash > ~/.local/share/bash-completion/completions/timeclock"},
+	},
+	{
+		Name:    "doctor",
+		Summary: "Check the config, files, and integrations for problems.",
+		Help: `Check that the files and directories the config names exist (or can be created) and can be written, that
+the timelog parses, that the time code settings make sense, that the report templates compile, that no locks cover
+events that are gone, and that git, the sync sources, the clipboard, and your editor can be found. Each problem is
+printed with what to do about it. Nothing is changed, and sync commands are only looked for, never run. Exits with 1 if
+anything is broken, warnings alone don't count.`,
+	},
+	{
+		Name:    "version",
+		Summary: "Print the version of timeclock.",
+		Help: `Print the version, the commit it was built from and when, and the timelog format version it writes. Logs
+are written with a comment on the first line saying which format version they are in, and a log from a newer version
+than this one can read isn't written back. --version works too.`,
+	},
+	{
+		Name:        "docs",
+		Usage:       "man [dir] | cheatsheet",
+		Summary:     "Generate man pages or a cheatsheet.",
+		Subcommands: []string{"man", "cheatsheet"},
+		Help: `docs man writes a timeclock(1) man page, and one for each command like timeclock-report(1), to dir (or the
+current directory). docs cheatsheet prints every command's usage and examples as Markdown. Both are generated from the
+same text as this help, for packaging.`,
+		Examples: []string{"docs man /usr/share/man/man1", "docs cheatsheet > CHEATSHEET.md"},
+	},
+	{
+		Name:   "gen",
+		Hidden: true,
+	},
+	{
+		Name:   "__complete",
+		Hidden: true,
+	},
+
+	{
+		Name:    "event",
+		Topic:   true,
+		Summary: "Creating events, which is what happens without a command word.",
+		Help: `With no command word, the entire command line is used to define a new event. To be valid, all that is
+required is a time. If the time and/or time code are the first things on the command line they will be stripped and
+the remaining text will be used as the description. If they are embedded in the main body of the text, then the whole
+text is used unmodified. To define a new code, create the event, then set the code with code.
+
+	timeclock now :Customer Did a thing.
+	timeclock Did a thing for :Customer at 10:00am
+
+With --track <name> the event goes on its own track, which runs alongside the main one. Other commands take --track
+too. --meta key=value adds metadata to the event, on top of the meta config key. If more than one code matches,
+--choose <n> picks one without asking.
+
+With --strict the input is [@time] [:code] description instead, with no guessing. The time is now, -15m, 9:15, 9:15AM,
+2026-10-14T09:15, or 2026/10/14 09:15.
+
+A time that isn't today, or is far from now, has to be confirmed, or allowed with --yes. A time with no date is today,
+or after the last event with anchor=last. An event before the last one is refused unless you confirm it, or pass
+--allow-backdate.
+
+When no code is found, the code from a .sctime file (with discover=true) is used, or one picked by the gitcodes config
+for the current git repository.
+
+{ticket} (from the git branch), {branch}, {date}, {code}, and {last_desc} in the description are filled in, and the desc
+code setting can give a code a description template.
+
+	timeclock now :Customer {ticket} review`,
+	},
+	{
+		Name:    "ranges",
+		Topic:   true,
+		Summary: "How time ranges are given.",
+		Help: `A range is one or two times, anywhere in the arguments, in about any way you would say them: yesterday, last
+week, june 1st, 2026-10-01, 3 days ago, monday 9am. With one time the range runs from it to now, with two it runs
+between them, whichever order they are in. A date on its own, or today, yesterday, or tomorrow, is the start of that
+day (at daystart, if it is set).
+
+	last week
+	june 1st july 1st
+	yesterday 2pm today`,
+	},
+	{
+		Name:    "codes",
+		Topic:   true,
+		Summary: "Picking which time codes to include.",
+		Help: `Time codes start with a colon, and only periods with one of the codes given are included. A code doesn't
+include its children unless you ask: :Customer:... is Customer and everything under it, :Customer:* is its direct
+children, and each more :* is one more level down. Globs like :client-*:dev and regular expressions between slashes
+like :/^client-.*:dev$/ match every known code they fit, and it's an error if they fit none. Case doesn't matter
+(unless foldcodecase is false), and / and . work the same as :.
+
+The special code all is every period with a code, and empty is every period without one, so use both to see
+everything.`,
+	},
+	{
+		Name:    "filters",
+		Topic:   true,
+		Summary: "Filtering and transforming periods before they are counted.",
+		Help: `--where key=value only includes periods with that metadata, and may be given more than once.
+--overlap full|split|main sets how time overlapping between tracks is counted, or the overlap config if it isn't given.
+
+--transform <name>[=<arg>] changes the periods before they are counted, after the ones in the transforms config. The
+transforms are round, split-midnight, night-shift, merge-gaps, breaks, and auto-break, which inserts unpaid breaks,
+eg auto-break=30m/6h.`,
+	},
+	{
+		Name:    "templates",
+		Topic:   true,
+		Summary: "How a report template is picked.",
+		Help: `Any word that is the name of a template picks it, the first one if there are several. Otherwise the report
+uses default.tmpl, and an invoice uses invoice.tmpl. Templates in the reportsdir are loaded over the built-in ones, so
+one with the same name as a built-in template is used instead of it.
+
+The built-in templates are approval.tmpl, billing.tmpl, bydevice.tmpl, bytask.tmpl, byweek.tmpl, default.tmpl,
+estimates.tmpl, expenses.tmpl, focus.tmpl, html.tmpl, invoice.tmpl, plan.tmpl, retainer.tmpl, travel.tmpl, and
+utilization.tmpl. The README has the details of each, and of what templates can use.`,
+	},
+	{
+		Name:    "flags",
+		Topic:   true,
+		Summary: "Flags that work with any command.",
+		Help: `--logfile <path> uses that timelog instead of the configured one. A path of - reads the timelog from stdin,
+and nothing is written back. --config <path> reads that config file instead, and any config key can be set from the
+environment as well, eg SCTIME_LOGFILE.
+
+--track <name> works on that track instead of the main one. --durations sets how durations are shown, like the
+durations config. --non-interactive never asks anything, and fails where it would have.
+
+--debug traces how the input was understood to stderr, --debug-file <path> appends the trace to a file instead.
+
+--now <time> pins the time for this run, as a date and time (2026/10/14 09:15, 2026-10-14T09:15:00Z), a time of day,
+or a duration from now like -2h. --today <date> keeps the time of day but moves it to that date.`,
+	},
+}
+
+// LookupCommand finds a command or topic by its name or one of its aliases, or returns nil.
+func LookupCommand(word string) *Command {
+	for _, c := range Commands {
+		if c.Name == word {
+			return c
+		}
+		for _, alias := range c.Aliases {
+			if alias == word {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// CommandFor is the name of the command a word runs, or blank if it isn't a command and the arguments are a new event.
+func CommandFor(word string) string {
+	c := LookupCommand(word)
+	if c == nil || c.Topic {
+		return ""
+	}
+	return c.Name
+}
+
+// helpWidth is how wide help is wrapped, the terminal if stdout is one, capped so paragraphs stay readable.
+func helpWidth() int {
+	width := TerminalWidth()
+	if width == 0 || width > 100 {
+		return 100
+	}
+	if width < 40 {
+		return 40
+	}
+	return width
+}
+
+// PrintCommands writes the list of commands and topics, for help with no arguments.
+func PrintCommands(w io.Writer, width int) {
+	fmt.Fprintln(w, "Usage: timeclock <command> [arguments...], or timeclock <event> to add an event.")
+
+	pad := 0
+	for _, c := range Commands {
+		if !c.Hidden && len(c.Name) > pad {
+			pad = len(c.Name)
+		}
+	}
+	list := func(topics bool) {
+		for _, c := range Commands {
+			if c.Hidden || c.Topic != topics {
+				continue
+			}
+			for i, line := range report.WrapText(c.Summary, width-pad-6) {
+				name := ""
+				if i == 0 {
+					name = c.Name
+				}
+				fmt.Fprintf(w, "    %-*s  %s\n", pad, name, line)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Commands:")
+	list(false)
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Help topics:")
+	list(true)
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Run 'timeclock help <command>' for more about a command or topic, or 'timeclock help all' for")
+	fmt.Fprintln(w, "everything at once.")
+}
+
+// PrintHelp writes the full help for a command or topic, with the topics it refers to and its examples.
+func PrintHelp(w io.Writer, c *Command, width int) {
+	if c.Topic {
+		fmt.Fprintln(w, c.Summary)
+	} else {
+		fmt.Fprintln(w, strings.TrimSpace("Usage: timeclock "+c.Name+" "+c.Usage))
+		if len(c.Aliases) > 0 {
+			fmt.Fprintln(w, "Also: "+strings.Join(c.Aliases, ", "))
+		}
+	}
+	fmt.Fprintln(w, "")
+	printParagraphs(w, c.Help, width)
+
+	for _, name := range c.See {
+		topic := LookupCommand(name)
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, strings.TrimSuffix(topic.Summary, ".")+":")
+		printParagraphs(w, topic.Help, width)
+	}
+
+	if len(c.Examples) > 0 {
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, "Examples:")
+		for _, example := range c.Examples {
+			fmt.Fprintln(w, "    timeclock "+example)
+		}
+	}
+}
+
+// printParagraphs writes help text indented and wrapped to width, see Command.Help.
+func printParagraphs(w io.Writer, text string, width int) {
+	for i, paragraph := range strings.Split(text, "\n\n") {
+		if i > 0 {
+			fmt.Fprintln(w, "")
+		}
+
+		prose := []string{}
+		flush := func() {
+			for _, line := range report.WrapText(strings.Join(prose, " "), width-4) {
+				fmt.Fprintln(w, "    "+line)
+			}
+			prose = prose[:0]
+		}
+		for _, line := range strings.Split(paragraph, "\n") {
+			if example, ok := strings.CutPrefix(line, "\t"); ok {
+				if len(prose) > 0 {
+					flush()
+				}
+				fmt.Fprintln(w, "        "+example)
+				continue
+			}
+			prose = append(prose, line)
+		}
+		if len(prose) > 0 {
+			flush()
+		}
+	}
+}
+
+// HelpCommand is 'help', with the arguments after it.
+func HelpCommand(args []string) {
+	width := helpWidth()
+	switch {
+	case len(args) == 0:
+		PrintCommands(os.Stdout, width)
+	case len(args) == 1 && args[0] == "all":
+		for _, c := range Commands {
+			if c.Hidden {
+				continue
+			}
+			if c.Topic {
+				fmt.Println(strings.Repeat("=", width))
+			} else {
+				fmt.Println(strings.Repeat("-", width))
+			}
+			PrintHelp(os.Stdout, c, width)
+		}
+	case len(args) == 1:
+		c := LookupCommand(args[0])
+		if c == nil || c.Hidden {
+			fmt.Fprintf(os.Stderr, "There is no command or help topic called %q, see 'timeclock help' for the list.\n", args[0])
+			os.Exit(2)
+		}
+		PrintHelp(os.Stdout, c, width)
+	default:
+		fmt.Fprintln(os.Stderr, "'help' takes one command or topic.")
+		os.Exit(2)
+	}
+}
+
+// completionScripts are the scripts printed by 'completion', which all hand the work back to '__complete'.
+var completionScripts = map[string]string{
+	"bash": `_timeclock() {
+	local IFS=$'\n'
+	COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _timeclock timeclock
+`,
+	"zsh": `#compdef timeclock
+_timeclock() {
+	local -a candidates
+	candidates=("${(@f)$("${words[1]}" __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	compadd -a candidates
+}
+compdef _timeclock timeclock
+`,
+	"fish": `complete -c timeclock -f -a '(timeclock __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`,
+}
+
+// CompletionCommand is 'completion', which prints the script for a shell.
+func CompletionCommand(args []string) {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		fmt.Fprintln(os.Stderr, "Expected 'completion bash', 'completion zsh', or 'completion fish'.")
+		os.Exit(2)
+	}
+	fmt.Print(completionScripts[args[0]])
+}
+
+// CompleteCommand is '__complete', used by the completion scripts. The arguments are the words on the command line
+// after the program name, the last being the one to complete (which may be blank). The candidates are printed one per
+// line: commands for the first word, then flags, subcommands, time codes, and templates.
+func CompleteCommand(args []string, codes []string, templates []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	// The global flags have already been taken out, along with their values, so the first word is the command.
+	words, word := args[:len(args)-1], args[len(args)-1]
+
+	candidates := []string{}
+	var command *Command
+	if len(words) > 0 {
+		command = LookupCommand(words[0])
+	}
+	switch {
+	case strings.HasPrefix(word, "-"):
+		candidates = append(candidates, GlobalFlags...)
+		if command != nil {
+			candidates = append(candidates, command.Flags...)
+		}
+	case strings.HasPrefix(word, ":"):
+		for _, code := range codes {
+			candidates = append(candidates, ":"+code)
+		}
+		if command != nil && (command.Name == "report" || command.Name == "invoice" || command.Name == "total") {
+			candidates = append(candidates, ":all", ":empty")
+		}
+	case len(words) == 0:
+		for _, c := range Commands {
+			if !c.Hidden && !c.Topic {
+				candidates = append(candidates, c.Name)
+				candidates = append(candidates, c.Aliases...)
+			}
+		}
+	case command != nil && command.Name == "help" && len(words) == 1:
+		for _, c := range Commands {
+			if !c.Hidden {
+				candidates = append(candidates, c.Name)
+			}
+		}
+		candidates = append(candidates, "all")
+	default:
+		if command != nil && len(words) == 1 {
+			candidates = append(candidates, command.Subcommands...)
+		}
+		if command != nil && (command.Name == "report" || command.Name == "invoice") {
+			candidates = append(candidates, templates...)
+		}
+	}
+
+	sort.Strings(candidates)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(word)) {
+			fmt.Println(candidate)
+		}
+	}
+}
+
+// TemplateNames is the names of every report template, built in or in the reportsdir, for completion.
+func TemplateNames(dir string, info timelog.CodeInfo) []string {
+	templates, err := report.LoadTemplates(dir, info, Durations)
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, t := range templates.Templates() {
+		if strings.HasSuffix(t.Name(), ".tmpl") {
+			names = append(names, t.Name())
+		}
+	}
+	return names
+}
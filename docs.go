@@ -0,0 +1,229 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DocsCommand is 'docs', which writes the man pages or the cheatsheet generated from Commands.
+func DocsCommand(args []string) {
+	switch {
+	case len(args) >= 1 && len(args) <= 2 && args[0] == "man":
+		dir := "."
+		if len(args) == 2 {
+			dir = args[1]
+		}
+		err := WriteManPages(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing man pages:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case len(args) == 1 && args[0] == "cheatsheet":
+		WriteCheatsheet(os.Stdout)
+	default:
+		fmt.Fprintln(os.Stderr, "Expected 'docs man [dir]' or 'docs cheatsheet'.")
+		os.Exit(2)
+	}
+}
+
+// docsDate is the date the docs say they are from. SOURCE_DATE_EPOCH is used if it is set, so packages built from the
+// same source come out the same (see https://reproducible-builds.org/specs/source-date-epoch/).
+func docsDate() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now()
+}
+
+// WriteManPages writes timeclock.1, with the list of commands and the help topics, and a timeclock-<command>.1 for each
+// command to dir.
+func WriteManPages(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	date := docsDate().Format("2006-01-02")
+
+	page := &bytes.Buffer{}
+	fmt.Fprintf(page, ".TH TIMECLOCK 1 %s timeclock \"User Commands\"\n", date)
+	fmt.Fprintln(page, ".SH NAME")
+	fmt.Fprintln(page, `timeclock \- track time with whatever you type`)
+	fmt.Fprintln(page, ".SH SYNOPSIS")
+	fmt.Fprintln(page, `.B timeclock`)
+	fmt.Fprintln(page, `[\fIflags\fR] \fIcommand\fR [\fIarguments\fR...]`)
+	fmt.Fprintln(page, ".br")
+	fmt.Fprintln(page, `.B timeclock`)
+	fmt.Fprintln(page, `[\fIflags\fR] \fIevent\fR...`)
+	fmt.Fprintln(page, ".SH DESCRIPTION")
+	manParagraphs(page, LookupCommand("event").Help)
+	fmt.Fprintln(page, ".SH COMMANDS")
+	for _, c := range Commands {
+		if c.Hidden || c.Topic {
+			continue
+		}
+		fmt.Fprintln(page, ".TP")
+		fmt.Fprintf(page, "\\fB%s\\fR %s\n", manEscape(c.Name), manEscape(c.Usage))
+		fmt.Fprintf(page, "%s See \\fBtimeclock\\-%s\\fR(1).\n", manEscape(c.Summary), manEscape(c.Name))
+	}
+	for _, c := range Commands {
+		if !c.Topic || c.Name == "event" {
+			continue
+		}
+		fmt.Fprintf(page, ".SH %s\n", strings.ToUpper(manEscape(c.Name)))
+		manParagraphs(page, c.Help)
+	}
+	fmt.Fprintln(page, ".SH FILES")
+	fmt.Fprintln(page, ".TP")
+	fmt.Fprintln(page, `.I $XDG_CONFIG_HOME/sctime/config.ini`)
+	fmt.Fprintln(page, "The config file, see the README for the keys in it. Any key can also be set with an SCTIME_ variable, eg SCTIME_LOGFILE.")
+	fmt.Fprintln(page, ".SH SEE ALSO")
+	see := []string{}
+	for _, c := range Commands {
+		if !c.Hidden && !c.Topic {
+			see = append(see, `\fBtimeclock\-`+manEscape(c.Name)+`\fR(1)`)
+		}
+	}
+	fmt.Fprintln(page, strings.Join(see, ",\n"))
+
+	pages := map[string][]byte{"timeclock.1": page.Bytes()}
+	for _, c := range Commands {
+		if c.Hidden || c.Topic {
+			continue
+		}
+		page := &bytes.Buffer{}
+		name := "timeclock-" + c.Name
+		fmt.Fprintf(page, ".TH %s 1 %s timeclock \"User Commands\"\n", strings.ToUpper(manEscape(name)), date)
+		fmt.Fprintln(page, ".SH NAME")
+		fmt.Fprintf(page, "%s \\- %s\n", manEscape(name), manEscape(strings.TrimSuffix(c.Summary, ".")))
+		fmt.Fprintln(page, ".SH SYNOPSIS")
+		fmt.Fprintf(page, ".B timeclock %s\n", manEscape(c.Name))
+		if c.Usage != "" {
+			fmt.Fprintln(page, manEscape(c.Usage))
+		}
+		for _, alias := range c.Aliases {
+			fmt.Fprintln(page, ".br")
+			fmt.Fprintf(page, ".B timeclock %s\n", manEscape(alias))
+			if c.Usage != "" {
+				fmt.Fprintln(page, manEscape(c.Usage))
+			}
+		}
+		fmt.Fprintln(page, ".SH DESCRIPTION")
+		manParagraphs(page, c.Help)
+		for _, name := range c.See {
+			topic := LookupCommand(name)
+			fmt.Fprintf(page, ".SS %s\n", manEscape(strings.TrimSuffix(topic.Summary, ".")))
+			manParagraphs(page, topic.Help)
+		}
+		if len(c.Examples) > 0 {
+			fmt.Fprintln(page, ".SH EXAMPLES")
+			fmt.Fprintln(page, ".nf")
+			for _, example := range c.Examples {
+				fmt.Fprintln(page, manEscape("timeclock "+example))
+			}
+			fmt.Fprintln(page, ".fi")
+		}
+		fmt.Fprintln(page, ".SH SEE ALSO")
+		fmt.Fprintln(page, `\fBtimeclock\fR(1)`)
+		pages[name+".1"] = page.Bytes()
+	}
+
+	for name, content := range pages {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, content, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d man pages to %s\n", len(pages), dir)
+	return nil
+}
+
+// manParagraphs writes help text (see Command.Help) as roff, leaving the wrapping to man.
+func manParagraphs(w io.Writer, text string) {
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		fmt.Fprintln(w, ".PP")
+		examples := false
+		for _, line := range strings.Split(paragraph, "\n") {
+			example, ok := strings.CutPrefix(line, "\t")
+			switch {
+			case ok && !examples:
+				fmt.Fprintln(w, ".RS")
+				fmt.Fprintln(w, ".nf")
+			case !ok && examples:
+				fmt.Fprintln(w, ".fi")
+				fmt.Fprintln(w, ".RE")
+			}
+			examples = ok
+			if ok {
+				line = example
+			}
+			fmt.Fprintln(w, manEscape(line))
+		}
+		if examples {
+			fmt.Fprintln(w, ".fi")
+			fmt.Fprintln(w, ".RE")
+		}
+	}
+}
+
+// manEscape makes text safe to put in roff, so backslashes and dashes come out as typed and a line can't be taken for
+// a request.
+func manEscape(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// WriteCheatsheet writes a Markdown page with every command's usage and examples on it.
+func WriteCheatsheet(w io.Writer) {
+	fmt.Fprintln(w, "# timeclock cheatsheet")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Anything that isn't a command is a new event, eg `timeclock now :Customer Did a thing.`")
+	fmt.Fprintln(w, "Run `timeclock help <command>` for the details of any of these.")
+	for _, c := range Commands {
+		if c.Hidden || c.Topic {
+			continue
+		}
+		fmt.Fprintln(w, "")
+		fmt.Fprintf(w, "## %s\n", c.Name)
+		fmt.Fprintln(w, "")
+		fmt.Fprintln(w, c.Summary)
+		fmt.Fprintln(w, "")
+		fmt.Fprintf(w, "\t%s\n", strings.TrimSpace("timeclock "+c.Name+" "+c.Usage))
+		for _, example := range c.Examples {
+			fmt.Fprintf(w, "\ttimeclock %s\n", example)
+		}
+	}
+}
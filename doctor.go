@@ -0,0 +1,529 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// doctorPath is one of the config settings that names a file or directory, and what is done with it.
+type doctorPath struct {
+	Key    string
+	Dir    bool // A directory rather than a file.
+	Writes bool // Written to, not just read.
+	Mkdir  bool // The directory it is in is created if needed.
+}
+
+// doctorPaths are the settings DoctorCommand checks. The patterns (archives, gitrepos, browserhistory) are checked with
+// the integrations instead.
+var doctorPaths = []doctorPath{
+	{Key: "logfile", Writes: true},
+	{Key: "reportsdir", Dir: true},
+	{Key: "codefile"},
+	{Key: "ratesfile"},
+	{Key: "invoicefile", Writes: true},
+	{Key: "exportfile"},
+	{Key: "profilefile"},
+	{Key: "cachefile", Writes: true, Mkdir: true},
+	{Key: "lockfile", Writes: true},
+	{Key: "stagingfile", Writes: true},
+	{Key: "syncfile"},
+	{Key: "syncstate", Writes: true, Mkdir: true},
+	{Key: "replica", Dir: true, Writes: true, Mkdir: true},
+	{Key: "planfile", Writes: true},
+	{Key: "expensefile", Writes: true},
+	{Key: "travelfile", Writes: true},
+	{Key: "sincelastfile", Writes: true, Mkdir: true},
+	{Key: "servetokenfile", Writes: true, Mkdir: true},
+}
+
+// doctor prints the results of the checks as they are made, and keeps count of what went wrong.
+type doctor struct {
+	configfile string
+	problems   int
+	warnings   int
+}
+
+func (d *doctor) section(name string) {
+	fmt.Println()
+	fmt.Println(name)
+}
+
+func (d *doctor) ok(format string, args ...any) {
+	fmt.Printf("    ok    %s\n", fmt.Sprintf(format, args...))
+}
+
+// warn is for things that work, but probably not the way you wanted.
+func (d *doctor) warn(what, fix string) {
+	d.warnings++
+	fmt.Printf("    warn  %s\n          %s\n", what, fix)
+}
+
+// fail is for things that are broken.
+func (d *doctor) fail(what, fix string) {
+	d.problems++
+	fmt.Printf("    FAIL  %s\n          %s\n", what, fix)
+}
+
+// DoctorCommand checks that everything the config points at is there and usable, and that the log, codes file,
+// templates, locks, and integrations are all in working order. Each problem comes with what to do about it. Nothing is
+// changed, and the sync commands aren't run, only looked for. Exits with 1 if anything is broken.
+func DoctorCommand(config map[string]string, configfile string) {
+	d := &doctor{configfile: configfile}
+
+	fmt.Println("Config")
+	d.ok("config file %s", configfile)
+	for _, p := range doctorPaths {
+		d.checkPath(p, config[p.Key])
+	}
+
+	d.section("Timelog")
+	d.checkLog(config["logfile"])
+
+	d.section("Time codes")
+	info := d.checkCodes(config["codefile"])
+
+	d.section("Templates")
+	templates, err := report.LoadTemplates(config["reportsdir"], info, Durations)
+	if err != nil {
+		d.fail(err.Error(), "Fix the template it names, or move it out of "+config["reportsdir"]+" for now.")
+	} else {
+		n := 0
+		for _, t := range templates.Templates() {
+			if strings.HasSuffix(t.Name(), ".tmpl") {
+				n++
+			}
+		}
+		d.ok("%d template(s) in %s", n, config["reportsdir"])
+	}
+
+	d.section("Locks")
+	d.checkLocks(config["lockfile"], config["logfile"])
+
+	d.section("Integrations")
+	d.checkIntegrations(config)
+
+	fmt.Println()
+	if d.problems == 0 && d.warnings == 0 {
+		fmt.Println("Everything looks fine.")
+		return
+	}
+	fmt.Printf("%d problem(s), %d warning(s).\n", d.problems, d.warnings)
+	if d.problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkPath checks that a file or directory from the config exists (or can be created) and can be used.
+func (d *doctor) checkPath(p doctorPath, path string) {
+	if path == "" {
+		return
+	}
+	if p.Key == "logfile" && path == "-" {
+		d.ok("logfile is read from stdin")
+		return
+	}
+
+	what := p.Key + " " + path
+	fix := fmt.Sprintf("Fix that, or set %s to somewhere else in %s.", p.Key, d.configfile)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		parent := filepath.Dir(path)
+		if p.Mkdir {
+			d.ok("%s (not created yet)", what)
+			return
+		}
+		if !p.Writes {
+			d.ok("%s (not there, which is fine)", what)
+			return
+		}
+		if pinfo, err := os.Stat(parent); err != nil || !pinfo.IsDir() {
+			d.fail(what+": the directory it goes in doesn't exist", fmt.Sprintf("Create it with 'mkdir -p %s', or set %s to somewhere else in %s.", parent, p.Key, d.configfile))
+			return
+		}
+		if err := writableDir(parent); err != nil {
+			d.fail(what+": can't be created, "+err.Error(), fix)
+			return
+		}
+		d.ok("%s (not created yet)", what)
+		return
+	}
+	if err != nil {
+		d.fail(what+": "+err.Error(), fix)
+		return
+	}
+
+	switch {
+	case p.Dir && !info.IsDir():
+		d.fail(what+": should be a directory, but isn't", fix)
+		return
+	case !p.Dir && info.IsDir():
+		d.fail(what+": should be a file, but is a directory", fix)
+		return
+	}
+
+	if p.Writes {
+		if p.Dir {
+			err = writableDir(path)
+		} else {
+			var f *os.File
+			f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+			if err == nil {
+				f.Close()
+			}
+		}
+		if err != nil {
+			d.fail(what+": can't be written, "+err.Error(), "Check the permissions, 'chmod u+w "+path+"' may do it.")
+			return
+		}
+	} else if !p.Dir {
+		f, err := os.Open(path)
+		if err != nil {
+			d.fail(what+": can't be read, "+err.Error(), "Check the permissions, 'chmod u+r "+path+"' may do it.")
+			return
+		}
+		f.Close()
+	}
+	d.ok("%s", what)
+}
+
+// writableDir checks that files can be made in a directory, by making one.
+func writableDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".timeclock-doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkLog parses the log and checks its format version.
+func (d *doctor) checkLog(path string) {
+	if path == "-" {
+		d.ok("skipped, the log is read from stdin")
+		return
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		d.ok("no timelog yet, the first event creates it")
+		return
+	}
+	if err != nil {
+		d.fail("can't read "+path+": "+err.Error(), "Fix the permissions, or set logfile to somewhere else in "+d.configfile+".")
+		return
+	}
+
+	log, problems := timelog.ParseTimeLogLenient(string(content))
+	for _, problem := range problems {
+		d.fail(fmt.Sprintf("line %d: %v: %q", problem.Line, problem.Err, problem.Text),
+			"Fix or delete the line, 'timeclock edit-log' checks it parses before writing. Until then the log can't be changed.")
+	}
+
+	version := timelog.FormatVersionOf(string(content))
+	if version > timelog.FormatVersion {
+		d.fail(fmt.Sprintf("the log is format version %d, this version of timeclock only knows up to %d", version, timelog.FormatVersion),
+			"Upgrade timeclock. Until then the log is only read, never written.")
+	}
+	for _, m := range timelog.PendingMigrations(version) {
+		if !m.Automatic {
+			d.warn(fmt.Sprintf("the log is format version %d and needs migrating (%s)", version, m.What),
+				"Run 'timeclock migrate', it backs the log up first.")
+			break
+		}
+	}
+
+	for _, set := range log.Conflicts() {
+		d.warn(fmt.Sprintf("%d events at %s from different hosts", len(set), set[0].At.Format(timelog.TimeFormat)),
+			"These are probably the same thing synced from two machines, delete all but one with 'timeclock edit-log'.")
+	}
+	if len(problems) == 0 {
+		d.ok("%s parses, %d event(s), format version %d", path, len(log), version)
+	}
+}
+
+// checkCodes checks every setting in the codes file is one that is used, with a value that makes sense. The parsed
+// file is returned for loading the templates with.
+func (d *doctor) checkCodes(path string) timelog.CodeInfo {
+	info := timelog.CodeInfo{}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		d.ok("no codes file, every code uses the defaults")
+		return info
+	}
+	if err != nil {
+		d.fail("can't read "+path+": "+err.Error(), "Fix the permissions, or set codefile to somewhere else in "+d.configfile+".")
+		return info
+	}
+	ParseINISections(string(raw), info)
+
+	codes := make([]string, 0, len(info))
+	for code := range info {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	bad := false
+	for _, code := range codes {
+		keys := make([]string, 0, len(info[code]))
+		for k := range info[code] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		section := "[" + code + "]"
+		if code == "" {
+			section = "the defaults"
+		}
+		for _, k := range keys {
+			v := info[code][k]
+			known, err := checkCodeSetting(k, v)
+			switch {
+			case !known:
+				bad = true
+				d.warn(fmt.Sprintf("%s: unknown setting %q", section, k), "Nothing reads it, check the spelling in "+path+".")
+			case err != nil:
+				bad = true
+				d.fail(fmt.Sprintf("%s: %s=%s: %v", section, k, v, err), "Fix it in "+path+", until then it is ignored.")
+			}
+		}
+	}
+	if !bad {
+		d.ok("%s, %d code(s)", path, len(codes))
+	}
+	return info
+}
+
+// checkCodeSetting checks the value of one time code setting. known is false for settings nothing reads.
+func checkCodeSetting(key, value string) (known bool, err error) {
+	switch key {
+	case "billable", "rollover", "taxinclusive", "taxexempt":
+		_, err = strconv.ParseBool(value)
+		if err != nil {
+			err = errors.New("should be true or false")
+		}
+	case "focus":
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "deep", "shallow":
+		default:
+			err = errors.New("should be deep or shallow")
+		}
+	case "rate", "mileage", "tax":
+		var f float64
+		f, err = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || f < 0 {
+			err = errors.New("should be a number, zero or more")
+		}
+	case "retainer", "budget":
+		var d time.Duration
+		d, err = time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			err = errors.New("should be a duration like 20h")
+		}
+	case "currency":
+		if !currencyCode.MatchString(value) {
+			err = errors.New("should be a three letter code like EUR")
+		}
+	case "ticketpattern":
+		if _, err := regexp.Compile(value); err != nil {
+			return true, errors.New("should be a regular expression")
+		}
+	case "taxname", "desc":
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// checkLocks looks for locks that can't be read, are backwards, or don't cover anything anymore.
+func (d *doctor) checkLocks(path, logfile string) {
+	locks, err := LoadLocks(path)
+	if err != nil {
+		d.fail(path+": "+err.Error(), "Fix or delete the line, each is the begin, end, and finalized time separated by tabs.")
+		return
+	}
+	if len(locks) == 0 {
+		d.ok("nothing is locked")
+		return
+	}
+
+	log := timelog.TimeLog{}
+	if logfile != "-" {
+		content, _ := os.ReadFile(logfile)
+		log, _ = timelog.ParseTimeLogLenient(string(content))
+	}
+	stale := false
+	for _, l := range locks {
+		what := l.Begin.Format(timelog.TimeFormat) + " to " + l.End.Format(timelog.TimeFormat)
+		if !l.End.After(l.Begin) {
+			stale = true
+			d.fail("lock "+what+" ends before it begins", "Delete its line from "+path+" and lock the range again.")
+			continue
+		}
+		covered := false
+		for _, e := range log {
+			if !e.At.Before(l.Begin) && e.At.Before(l.End) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			stale = true
+			d.warn("lock "+what+" covers no events, they were probably purged or archived", "Delete its line from "+path+" if it isn't needed anymore.")
+		}
+	}
+	if !stale {
+		d.ok("%d lock(s)", len(locks))
+	}
+}
+
+// checkIntegrations checks the things outside timeclock it uses can be found. Nothing is run but git.
+func (d *doctor) checkIntegrations(config map[string]string) {
+	checked := false
+
+	if config["gitrepos"] != "" {
+		checked = true
+		if _, err := exec.LookPath("git"); err != nil {
+			d.fail("gitrepos is set, but git isn't installed", "Install git, or clear gitrepos in "+d.configfile+".")
+		} else if repos, err := GitRepos(config["gitrepos"]); err != nil {
+			d.fail(err.Error(), "Fix gitrepos in "+d.configfile+".")
+		} else if len(repos) == 0 {
+			d.warn("gitrepos "+config["gitrepos"]+" matches no directories", "Check the patterns in "+d.configfile+".")
+		} else {
+			for _, repo := range repos {
+				if _, err := git(repo, "rev-parse", "--git-dir"); err != nil {
+					d.warn("git repo "+repo+" isn't a git repository", "Change gitrepos so it doesn't match it.")
+					continue
+				}
+				d.ok("git repo %s", repo)
+			}
+		}
+	}
+
+	for _, key := range []string{"archives", "browserhistory"} {
+		if config[key] == "" {
+			continue
+		}
+		checked = true
+		found := 0
+		for _, pattern := range strings.Split(config[key], ",") {
+			pattern = strings.TrimSpace(pattern)
+			if pattern == "" {
+				continue
+			}
+			files, err := filepath.Glob(pattern)
+			if err != nil {
+				d.fail(fmt.Sprintf("invalid %s pattern %q: %v", key, pattern, err), "Fix "+key+" in "+d.configfile+".")
+			}
+			found += len(files)
+		}
+		if found == 0 {
+			d.warn(key+" "+config[key]+" matches no files", "Check the patterns in "+d.configfile+".")
+		} else {
+			d.ok("%s, %d file(s)", key, found)
+		}
+	}
+
+	raw, err := os.ReadFile(config["syncfile"])
+	if err == nil {
+		checked = true
+		sources, err := ParseSyncSources(string(raw))
+		if err != nil {
+			d.fail(config["syncfile"]+": "+err.Error(), "Fix it in "+config["syncfile"]+".")
+		}
+		for _, src := range sources {
+			d.checkSyncSource(src)
+		}
+	}
+
+	if config["replica"] != "" {
+		checked = true
+		device := config["device"]
+		if device == "" {
+			device, _ = os.Hostname()
+		}
+		d.ok("replica %s, as device %s", config["replica"], device)
+	}
+
+	if c := clipboardCommand(); c == nil {
+		d.warn("no clipboard command found, --copy won't work", "Install wl-copy, xclip, or xsel.")
+	} else if _, err := exec.LookPath(c[0]); err != nil {
+		d.warn("clipboard command "+c[0]+" not found, --copy won't work", "Install it, or check your $PATH.")
+	} else {
+		d.ok("clipboard, with %s", c[0])
+	}
+
+	editor := EditorCommand()
+	if _, err := exec.LookPath(editor[0]); err != nil {
+		d.warn("editor "+editor[0]+" not found, edit and edit-log won't work", "Set $VISUAL or $EDITOR to an editor you have.")
+	} else {
+		d.ok("editor, with %s", editor[0])
+	}
+
+	if !checked {
+		d.ok("nothing else is set up")
+	}
+}
+
+// checkSyncSource checks a sync source's file or commands can be found, without running anything.
+func (d *doctor) checkSyncSource(src *SyncSource) {
+	what := "sync source " + src.Name
+	if src.Kind == "timelog" {
+		dir := filepath.Dir(src.Path)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			d.fail(what+": "+dir+" doesn't exist", "If it is a shared folder, check it is mounted. Otherwise fix the path.")
+			return
+		}
+		d.ok("%s, %s", what, src.Path)
+		return
+	}
+
+	commands := []string{}
+	if src.CanPull() {
+		commands = append(commands, src.Pull)
+	}
+	if src.CanPush() {
+		commands = append(commands, src.Push)
+	}
+	for _, command := range commands {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(fields[0]); err != nil {
+			d.fail(what+": "+fields[0]+" not found", "Install it, or use the full path in the source's command.")
+			return
+		}
+	}
+	d.ok("%s, commands found", what)
+}
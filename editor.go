@@ -0,0 +1,219 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// EditorCommand returns the user's preferred editor command, split into the program and its arguments.
+func EditorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if cmd := strings.Fields(os.Getenv(env)); len(cmd) > 0 {
+			return cmd
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// EditText writes text to a temporary file, opens it in the user's editor, and returns the contents once the editor
+// exits. The suffix is used for the temporary file name so editors can pick a sensible mode.
+func EditText(text string, suffix string) (string, error) {
+	file, err := os.CreateTemp("", "sctime-*"+suffix)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(text)
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	RequireInteractive("editor-required", "an editor would be opened")
+
+	editor := EditorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// ReadNote reads a (possibly multi-line) description. If stdin is piped it is read in full, otherwise the user's
+// editor is opened with the current description. Lines starting with '#' are dropped in the editor, as in git.
+func ReadNote(current string) (string, error) {
+	if !StdinIsTerminal() {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		note := strings.TrimSpace(string(content))
+		if note == "" {
+			return "", errors.New("Empty description on stdin, aborting.")
+		}
+		return note, nil
+	}
+
+	text, err := EditText(current+"\n\n# Enter the event description. Lines starting with '#' are ignored.\n", ".txt")
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	note := strings.TrimSpace(strings.Join(lines, "\n"))
+	if note == "" {
+		return "", errors.New("Empty description, aborting.")
+	}
+	return note, nil
+}
+
+// EditEvents opens the given events in the user's editor as timelog text and parses them back once the editor exits.
+// The header is added to the top of the text as comments. See [EditTimelogText] for the details.
+func EditEvents(events timelog.TimeLog, header string) (timelog.TimeLog, error) {
+	text := new(strings.Builder)
+	err := events.FormatFile(text)
+	if err != nil {
+		return nil, err
+	}
+	return EditTimelogText(text.String(), header)
+}
+
+// EditTimelogText opens raw timelog text in the user's editor and parses it once the editor exits. If the result
+// doesn't parse the error is shown and the user is offered another go, with their edits intact. The header is added to
+// the top of the text as comments, after the format version line if the text starts with one, so it is still read in
+// the version it was written in. Returns nil if the user aborts or deletes everything.
+func EditTimelogText(content string, header string) (timelog.TimeLog, error) {
+	text := new(strings.Builder)
+	if timelog.FormatVersionOf(content) != 0 {
+		version, rest, _ := strings.Cut(content, "\n")
+		text.WriteString(version + "\n")
+		content = rest
+	}
+	for _, line := range strings.Split(header, "\n") {
+		fmt.Fprintln(text, "# "+line)
+	}
+	text.WriteString(content)
+
+	content = text.String()
+	for {
+		var err error
+		content, err = EditText(content, ".log")
+		if err != nil {
+			return nil, err
+		}
+
+		edited, err := timelog.ParseTimeLogString(content)
+		if err == nil {
+			for _, item := range edited {
+				err = item.Validate()
+				if err != nil {
+					break
+				}
+			}
+		}
+		if err == nil {
+			if len(edited) == 0 {
+				return nil, nil
+			}
+			return edited, nil
+		}
+
+		PrintParseError(err)
+
+		if !Confirm("Edit again") {
+			return nil, errors.New("Edited text is not a valid timelog, nothing changed.")
+		}
+	}
+}
+
+// DiffEvents compares two sets of events line by line, returning the events only in old prefixed with "-" and the
+// events only in new prefixed with "+". Events are compared by their text, so moving an event is a removal and an
+// addition.
+func DiffEvents(old, new timelog.TimeLog) []string {
+	counts := map[string]int{}
+	for _, item := range new {
+		counts[diffLine(item)]++
+	}
+
+	out := []string{}
+	for _, item := range old {
+		line := diffLine(item)
+		if counts[line] > 0 {
+			counts[line]--
+			continue
+		}
+		out = append(out, "- "+strings.ReplaceAll(line, "\n", "\n  "))
+	}
+
+	for _, item := range new {
+		line := diffLine(item)
+		if counts[line] > 0 {
+			counts[line]--
+			out = append(out, "+ "+strings.ReplaceAll(line, "\n", "\n  "))
+		}
+	}
+	return out
+}
+
+// diffLine is an event as shown in a diff, metadata included so changes to it show up.
+func diffLine(e *timelog.Event) string {
+	line := e.String()
+	keys := make([]string, 0, len(e.Meta))
+	for k := range e.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		line += "\n; " + k + ": " + e.Meta[k]
+	}
+	return line
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// LoadExpenses reads the expense ledger, see [timelog.ParseExpenses]. A missing ledger is just empty.
+func LoadExpenses(path string) ([]*timelog.Expense, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*timelog.Expense{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return timelog.ParseExpenses(string(content))
+}
+
+// SaveExpenses writes the expense ledger.
+func SaveExpenses(path string, expenses []*timelog.Expense) error {
+	return os.WriteFile(path, []byte(timelog.FormatExpenses(expenses)), 0666)
+}
+
+// Attach works out what an expense or trip (what) goes on: the day given with --day, the event in effect at the time
+// given with --at, or the last event. The code is the one given, or the event's. It exits if there is no event, or no
+// code.
+func Attach(log timelog.TimeLog, atflag, dayflag, code, what string) (time.Time, bool, string) {
+	if dayflag != "" {
+		at, _ := ParseTimeRange(strings.Fields(dayflag))
+		if code == "" {
+			fmt.Fprintf(os.Stderr, "The %s needs a :code to go on a day.\n", what)
+			os.Exit(2)
+		}
+		return Calendar.Day(*at), true, code
+	}
+
+	var event *timelog.Event
+	if atflag != "" {
+		if begin, _, ok := SelectEvents(log, strings.Fields(atflag)); ok {
+			event = log[begin]
+		}
+	} else {
+		event = log.Last(Track)
+	}
+	if event == nil {
+		fmt.Fprintf(os.Stderr, "No event to attach the %s to.\n", what)
+		os.Exit(1)
+	}
+	if code == "" {
+		code = event.Code
+	}
+	if code == "" {
+		fmt.Fprintf(os.Stderr, "The event has no code, give the %s one with :code.\n", what)
+		os.Exit(2)
+	}
+	return event.At, false, code
+}
+
+// currencyCode is what a currency looks like on the command line, so it can be told apart from the note.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ExpenseCommand handles 'expense'. 'expense list' lists the expenses, in a time range if one is given. Otherwise the
+// arguments are an amount, an optional currency, an optional :code, and a note, and the expense is attached to the
+// last event, the event in effect at --at, or the day given with --day.
+func ExpenseCommand(args []string, log timelog.TimeLog, info timelog.CodeInfo, path string) {
+	expenses, err := LoadExpenses(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading expense ledger:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(10)
+	}
+
+	if len(args) > 0 && args[0] == "list" {
+		shown := expenses
+		if len(args) > 1 {
+			begin, end := ParseTimeRange(args[1:])
+			shown = []*timelog.Expense{}
+			for _, e := range expenses {
+				if !e.At.Before(*begin) && (end == nil || e.At.Before(*end)) {
+					shown = append(shown, e)
+				}
+			}
+		}
+		if len(shown) == 0 {
+			fmt.Fprintln(os.Stderr, "No expenses.")
+			return
+		}
+		for _, e := range shown {
+			fmt.Println(e.String())
+		}
+		fmt.Println("Total:", formatCurrencies(timelog.ExpenseTotals(shown)))
+		return
+	}
+
+	args, receipt := TakeFlagValue(args, "--receipt")
+	args, atflag := TakeFlagValue(args, "--at")
+	args, dayflag := TakeFlagValue(args, "--day")
+	if atflag != "" && dayflag != "" {
+		fmt.Fprintln(os.Stderr, "An expense goes on an event (--at) or a day (--day), not both.")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "'expense' needs an amount, eg 'expense 12.50 EUR :Acme Train ticket'.")
+		os.Exit(2)
+	}
+
+	e := &timelog.Expense{}
+	e.Amount, err = strconv.ParseFloat(args[0], 64)
+	if err != nil || !(e.Amount > 0) || math.IsInf(e.Amount, 1) {
+		fmt.Fprintf(os.Stderr, "Invalid amount %q, expected a number like 12.50.\n", args[0])
+		os.Exit(2)
+	}
+	args = args[1:]
+	if len(args) > 0 && currencyCode.MatchString(args[0]) {
+		e.Currency, args = args[0], args[1:]
+	}
+	if len(args) > 0 && strings.HasPrefix(args[0], ":") {
+		e.Code, args = strings.TrimPrefix(args[0], ":"), args[1:]
+	}
+	e.Note = strings.Join(args, " ")
+
+	e.At, e.Day, e.Code = Attach(log, atflag, dayflag, e.Code, "expense")
+
+	if e.Currency == "" {
+		_, e.Currency, _ = info.Rate(e.Code)
+	}
+	if receipt != "" {
+		e.Receipt, err = filepath.Abs(receipt)
+		if err == nil {
+			_, err = os.Stat(e.Receipt)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error finding receipt:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	err = SaveExpenses(path, append(expenses, e))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing expense ledger:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(10)
+	}
+	fmt.Println("Added expense:", e.String())
+}
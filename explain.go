@@ -0,0 +1,101 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Explain is filled in by ParseLine with everything it decided, if it isn't nil. This is what 'test --explain' prints.
+var Explain *Explanation
+
+// Explanation records how ParseLine understood its input.
+type Explanation struct {
+	Input string
+
+	Times    []ExplainedTime // Every time found, the first is the one used.
+	Rounded  time.Time       // The time that was actually used, after rounding.
+	Anchored string          // Why the time was moved to another day, if it was. See Anchor.
+
+	Candidates []FoundCode // Every time code candidate, best first.
+	Code       *FoundCode  // The candidate used, nil if there wasn't one.
+	CodeWhy    string
+
+	Edits []ExplainedEdit // What was done to the input to make the description, in order.
+	Desc  string
+}
+
+// ExplainedTime is a time found in the input, and the text it came from.
+type ExplainedTime struct {
+	Text string
+	Time time.Time
+}
+
+// ExplainedEdit is a change made to get from the input to the description.
+type ExplainedEdit struct {
+	Text string
+	Why  string
+}
+
+// Print writes the explanation in a form meant for people.
+func (e *Explanation) Print(w io.Writer) {
+	fmt.Fprintf(w, "Input: %q\n", e.Input)
+
+	fmt.Fprintln(w, "Times found:")
+	for i, t := range e.Times {
+		used := ""
+		if i == 0 {
+			used = fmt.Sprintf(" (used, rounded to %s)", e.Rounded.Format(timelog.TimeFormat))
+		}
+		fmt.Fprintf(w, "  %d: %q is %s%s\n", i+1, t.Text, t.Time.Format(timelog.TimeFormat), used)
+	}
+	if e.Anchored != "" && len(e.Times) > 0 {
+		fmt.Fprintf(w, "  %q %s\n", e.Times[0].Text, e.Anchored)
+	}
+
+	switch {
+	case len(e.Candidates) == 0 && e.Code != nil:
+		fmt.Fprintf(w, "Time codes: none in the input, [%s] is %s.\n", e.Code.Code, e.CodeWhy)
+	case len(e.Candidates) == 0:
+		fmt.Fprintln(w, "Time codes: none, only words starting with ':' are considered.")
+	default:
+		fmt.Fprintln(w, "Time code candidates, a lower distance is a closer match:")
+		for i, c := range e.Candidates {
+			used := ""
+			if e.Code != nil && *e.Code == c {
+				used = " (used, " + e.CodeWhy + ")"
+			}
+			fmt.Fprintf(w, "  %d: :%s matches [%s], distance %d%s\n", i+1, c.Found, c.Code, c.Distance, used)
+		}
+	}
+
+	fmt.Fprintln(w, "Description:")
+	for _, edit := range e.Edits {
+		fmt.Fprintf(w, "  %q %s\n", edit.Text, edit.Why)
+	}
+	fmt.Fprintf(w, "  The result is %q\n", e.Desc)
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// ExportRow is the time for one code on one day, which is what payroll systems generally want.
+type ExportRow struct {
+	Date     time.Time // Midnight at the start of the day.
+	Week     string    // The week, eg "2026-W42", see timelog.WeekOf.
+	Code     string
+	Duration time.Duration
+	Hours    float64
+	Billable bool
+}
+
+// ExportRows totals periods by day and code. Periods count for the day they begin on. The rows are sorted by date,
+// then code.
+func ExportRows(periods []*timelog.Period, info timelog.CodeInfo) []*ExportRow {
+	rows := []*ExportRow{}
+	for day, codes := range timelog.TotalsByDay(periods, Calendar, FoldCodeCase) {
+		week := Calendar.WeekOf(day)
+		for code, d := range codes {
+			rows = append(rows, &ExportRow{
+				Date:     day,
+				Week:     fmt.Sprintf("%04d-W%02d", week.Year, week.Number),
+				Code:     code,
+				Duration: d,
+				Hours:    d.Hours(),
+				Billable: info.Billable(code),
+			})
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if !rows[i].Date.Equal(rows[j].Date) {
+			return rows[i].Date.Before(rows[j].Date)
+		}
+		return rows[i].Code < rows[j].Code
+	})
+	return rows
+}
+
+// ExportPeriods picks out the periods for 'export csv' and 'export ical' from the arguments left after their own
+// flags: a time range (or the whole log), codes, and the report filters.
+func ExportPeriods(args []string, log timelog.TimeLog, config map[string]string, capNow bool) []*timelog.Period {
+	args, filters := TakeReportFilters(args, config)
+	exportlog := WithArchives(log, config["archives"])
+	args, codes := TakeCodePatterns(args, append(exportlog.Codes(), "empty", "all"))
+	found, _ := FindAllTimecodes(args, append(exportlog.Codes(), "empty", "all"))
+	for _, f := range found {
+		codes = append(codes, f[0].Code)
+	}
+
+	// Time codes were picked out above, what's left is the range.
+	rangeArgs := []string{}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, ":") {
+			rangeArgs = append(rangeArgs, arg)
+		}
+	}
+
+	var periods []*timelog.Period
+	if len(rangeArgs) == 0 {
+		periods = exportlog.PeriodsWith(OverlapPolicy)
+	} else if begin, end := ParseTimeRange(rangeArgs); end == nil {
+		periods = exportlog.After(*begin).PeriodsWith(OverlapPolicy)
+	} else {
+		periods = exportlog.Between(*begin, *end).PeriodsWith(OverlapPolicy)
+	}
+	if capNow {
+		periods, _ = timelog.CapPeriods(periods, Clock.Now())
+	}
+	return filters.Apply(periods, report.CodeFilter(codes, FoldCodeCase))
+}
+
+// builtinExports are the built in export presets. These follow the usual import layouts for ADP and Workday time
+// data, but every payroll setup is a little different, so any of it can be overridden in the export file.
+const builtinExports = `
+[adp]
+columns="Co Code,Batch ID,File #,Pay Date,Temp Dept,Reg Hours"
+Co Code=
+Batch ID={{ .Week }}
+File #=
+Pay Date={{ .Date.Format "01/02/2006" }}
+Temp Dept={{ .Code }}
+Reg Hours={{ printf "%.2f" .Hours }}
+
+[workday]
+columns="Worker ID,Date,Time Type,Quantity,Unit,Project,Comment"
+Worker ID=
+Date={{ .Date.Format "2006-01-02" }}
+Time Type=Regular
+Quantity={{ printf "%.2f" .Hours }}
+Unit=Hours
+Project={{ .Code }}
+Comment=
+`
+
+// ExportPresets returns all the export presets, the built in ones with the user's file merged over them.
+func ExportPresets(user string) map[string]map[string]string {
+	presets := map[string]map[string]string{}
+	ParseINISections(builtinExports, presets)
+	ParseINISections(user, presets)
+	delete(presets, "")
+	return presets
+}
+
+// ExportPreset is a CSV layout. Each column has a header and a template for its value, which is run with an
+// [ExportRow].
+type ExportPreset struct {
+	Name    string
+	Columns []string
+	Values  []*template.Template
+}
+
+// ParseExportPreset builds an export preset from its settings. The "columns" setting lists the headers in order
+// (as a line of CSV), and each header has a setting with the template for its value. Headers without a setting are
+// left blank. Each template is tried on an empty row, so a field that isn't in [ExportRow] is an error here rather than
+// after the report is built.
+func ParseExportPreset(name string, settings map[string]string) (*ExportPreset, error) {
+	columns, err := csv.NewReader(strings.NewReader(settings["columns"])).Read()
+	if err != nil {
+		return nil, fmt.Errorf("export preset %q: malformed columns: %w", name, err)
+	}
+
+	preset := &ExportPreset{Name: name, Columns: columns}
+	for _, col := range columns {
+		tmpl, err := template.New(col).Parse(settings[col])
+		if err != nil {
+			return nil, fmt.Errorf("export preset %q: column %q: %w", name, col, err)
+		}
+		err = tmpl.Execute(io.Discard, &ExportRow{})
+		if err != nil {
+			return nil, fmt.Errorf("export preset %q: column %q: %w", name, col, err)
+		}
+		preset.Values = append(preset.Values, tmpl)
+	}
+	return preset, nil
+}
+
+// Rows lays out the export rows using the preset, headers first.
+func (preset *ExportPreset) Rows(rows []*ExportRow) ([][]string, error) {
+	out := [][]string{preset.Columns}
+	for _, row := range rows {
+		line := make([]string, 0, len(preset.Values))
+		for i, tmpl := range preset.Values {
+			b := &strings.Builder{}
+			err := tmpl.Execute(b, row)
+			if err != nil {
+				return nil, fmt.Errorf("export preset %q: column %q: %w", preset.Name, preset.Columns[i], err)
+			}
+			line = append(line, b.String())
+		}
+		out = append(out, line)
+	}
+	return out, nil
+}
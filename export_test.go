@@ -0,0 +1,213 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestBuiltinExportPresets(t *testing.T) {
+	presets := ExportPresets("")
+	tests := []struct {
+		name    string
+		columns string
+	}{
+		{"adp", "Co Code|Batch ID|File #|Pay Date|Temp Dept|Reg Hours"},
+		{"workday", "Worker ID|Date|Time Type|Quantity|Unit|Project|Comment"},
+	}
+	for _, test := range tests {
+		preset, err := ParseExportPreset(test.name, presets[test.name])
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := strings.Join(preset.Columns, "|"); got != test.columns {
+			t.Errorf("%s columns are %q, want %q", test.name, got, test.columns)
+		}
+	}
+	if _, ok := presets[""]; ok {
+		t.Error("keys outside a section made a preset")
+	}
+}
+
+func TestParseExportPresetErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+	}{
+		{"malformed columns", map[string]string{"columns": `"Date,Hours`}},
+		{"template syntax", map[string]string{"columns": "Hours", "Hours": "{{ .Hours "}},
+		{"unknown field", map[string]string{"columns": "Hours", "Hours": "{{ .Minutes }}"}},
+		{"unknown function", map[string]string{"columns": "Hours", "Hours": "{{ round .Hours }}"}},
+	}
+	for _, test := range tests {
+		if _, err := ParseExportPreset("custom", test.settings); err == nil {
+			t.Errorf("%s: no error", test.name)
+		} else if !strings.Contains(err.Error(), `"custom"`) {
+			t.Errorf("%s: error %q doesn't name the preset", test.name, err)
+		}
+	}
+}
+
+func TestExportPresetRows(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+	log := timelog.TimeLog{
+		{At: at(13, 9, 0), Code: "Acme:Dev"},
+		{At: at(13, 10, 30), Code: "Lunch"},
+		{At: at(13, 11, 0), Code: "acme:dev"},
+		{At: at(13, 12, 0), Code: ""},
+		{At: at(12, 23, 0), Code: "Acme:Ops"},
+		{At: at(13, 1, 15), Code: ""},
+	}
+	periods := log.PeriodsWith(OverlapPolicy)
+	timelog.CanonicalCodes(periods, FoldCodeCase)
+	info := timelog.CodeInfo{"Acme": {"billable": "true"}}
+	rows := ExportRows(periods, info)
+
+	// The period over midnight counts for the Monday, and Acme:Dev's two periods on the Tuesday are one row. The gap
+	// before 9AM is time without a code, which gets a row like any other.
+	want := []struct {
+		date  time.Time
+		code  string
+		hours float64
+		bill  bool
+	}{
+		{at(12, 0, 0), "Acme:Ops", 2.25, true},
+		{at(13, 0, 0), "", 7.75, false},
+		{at(13, 0, 0), "Acme:Dev", 2.5, true},
+		{at(13, 0, 0), "Lunch", 0.5, false},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, w := range want {
+		r := rows[i]
+		if !r.Date.Equal(w.date) || r.Code != w.code || r.Hours != w.hours || r.Billable != w.bill || r.Week != "2026-W42" {
+			t.Errorf("row %d is %+v, want %v %s %v billable %v", i, r, w.date, w.code, w.hours, w.bill)
+		}
+	}
+
+	// A user preset, and one that overrides a column of a built in one.
+	presets := ExportPresets(`
+[adp]
+File #=1234
+
+[hours]
+columns="Day,Code,Minutes"
+Day={{ .Date.Format "2006-01-02" }}
+Code={{ .Code }}
+Minutes={{ .Duration.Minutes }}
+`)
+	hours, err := ParseExportPreset("hours", presets["hours"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := hours.Rows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRows := [][]string{
+		{"Day", "Code", "Minutes"},
+		{"2026-10-12", "Acme:Ops", "135"},
+		{"2026-10-13", "", "465"},
+		{"2026-10-13", "Acme:Dev", "150"},
+		{"2026-10-13", "Lunch", "30"},
+	}
+	checkRows(t, "hours", got, wantRows)
+
+	adp, err := ParseExportPreset("adp", presets["adp"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = adp.Rows(rows[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRows(t, "adp", got, [][]string{
+		{"Co Code", "Batch ID", "File #", "Pay Date", "Temp Dept", "Reg Hours"},
+		{"", "2026-W42", "1234", "10/12/2026", "Acme:Ops", "2.25"},
+	})
+}
+
+func checkRows(t *testing.T, name string, got, want [][]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d rows, want %d: %q", name, len(got), len(want), got)
+		return
+	}
+	for i := range want {
+		if strings.Join(got[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("%s: row %d is %q, want %q", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestExportPeriods(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+	}
+	log := timelog.TimeLog{
+		{At: at(12, 9), Code: "Acme:Dev"},
+		{At: at(12, 11), Code: "Beta"},
+		{At: at(12, 12), Code: ""},
+		{At: at(13, 9), Code: "Acme:Ops"},
+		{At: at(13, 10), Code: ""},
+	}
+	tests := []struct {
+		args []string
+		want []time.Time // When each period begins.
+	}{
+		{nil, []time.Time{at(12, 9), at(12, 11), at(13, 9)}},
+		{[]string{":empty"}, []time.Time{at(12, 12)}},
+		// Only codes is the whole log for those codes, not a range that can't be read.
+		{[]string{":Beta"}, []time.Time{at(12, 11)}},
+		{[]string{":Acme:..."}, []time.Time{at(12, 9), at(13, 9)}},
+		{[]string{":*:ops"}, []time.Time{at(13, 9)}},
+	}
+	for _, test := range tests {
+		var periods []*timelog.Period
+		runAt(t, at(14, 12), func() {
+			periods = ExportPeriods(test.args, log, map[string]string{}, false)
+		})
+		got := []time.Time{}
+		for _, p := range periods {
+			got = append(got, p.Begin)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%v exported %v, want %v", test.args, got, test.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(test.want[i]) {
+				t.Errorf("%v exported %v, want %v", test.args, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// ExportFormat is the version of the 'export all' archive layout. Bump it for any change that could break something
+// reading the old one.
+const ExportFormat = 1
+
+// Redacted stands in for anything left out of an export because it might be a secret.
+const Redacted = "[redacted]"
+
+// ExportManifest is manifest.json, what is in the archive.
+type ExportManifest struct {
+	Format  int               `json:"format"`  // ExportFormat
+	Created time.Time         `json:"created"` // When the export was made.
+	Files   map[string]string `json:"files"`   // Every other file in the archive, and what it is.
+}
+
+// ExportEvent is one event in timelog.json, which is every event (including archives) in order.
+type ExportEvent struct {
+	At    time.Time         `json:"at"`              // RFC 3339, with the local offset at the time.
+	Track string            `json:"track,omitempty"` // Missing for the default track.
+	Code  string            `json:"code"`            // Empty for events that stop the clock.
+	Desc  string            `json:"desc"`
+	Meta  map[string]string `json:"meta,omitempty"`
+}
+
+// ExportStats is stats.json, worked out from the periods with a code. All times are in hours.
+type ExportStats struct {
+	Events int        `json:"events"`
+	First  *time.Time `json:"first,omitempty"` // The first event, missing in an empty timelog.
+	Last   *time.Time `json:"last,omitempty"`  // And the last.
+
+	Total float64                       `json:"total"` // Everything with a code.
+	Codes map[string]float64            `json:"codes"` // By code.
+	Days  map[string]map[string]float64 `json:"days"`  // By the day each period begins (2006-01-02), then code.
+	Weeks map[string]map[string]float64 `json:"weeks"` // By ISO week (2006-W01), then code.
+}
+
+// exportHours converts totals to hours for the export.
+func exportHours(totals map[string]time.Duration) map[string]float64 {
+	out := map[string]float64{}
+	for code, d := range totals {
+		out[code] = d.Hours()
+	}
+	return out
+}
+
+// ExportStatistics works out stats.json for the log.
+func ExportStatistics(log timelog.TimeLog) *ExportStats {
+	stats := &ExportStats{
+		Events: len(log),
+		Codes:  map[string]float64{},
+		Days:   map[string]map[string]float64{},
+		Weeks:  map[string]map[string]float64{},
+	}
+	if len(log) > 0 {
+		stats.First, stats.Last = &log[0].At, &log[len(log)-1].At
+	}
+
+	periods := timelog.FilterOutPeriods(log.PeriodsWith(OverlapPolicy), "", FoldCodeCase)
+	for _, p := range periods {
+		stats.Total += p.Length().Hours()
+	}
+	stats.Codes = exportHours(timelog.Totals(periods, FoldCodeCase))
+	for day, totals := range timelog.TotalsByDay(periods, Calendar, FoldCodeCase) {
+		stats.Days[day.Format("2006-01-02")] = exportHours(totals)
+	}
+	for week, totals := range timelog.TotalsByWeek(periods, Calendar, FoldCodeCase) {
+		stats.Weeks[fmt.Sprintf("%04d-W%02d", week.Year, week.Number)] = exportHours(totals)
+	}
+	return stats
+}
+
+// secretWords mark config keys (and settings in other files) whose values are left out of exports. Keys are compared
+// without case or separators, so "apikey" covers "API_KEY" and "api-key" too.
+var secretWords = []string{"secret", "token", "password", "passwd", "auth", "credential", "apikey", "privatekey"}
+
+// IsSecret is true for a setting that looks like it holds a secret.
+func IsSecret(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "", " ", "", ".", "").Replace(strings.ToLower(key))
+	for _, word := range secretWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactConfig returns a copy of the config with anything that might be a secret replaced with Redacted.
+func RedactConfig(config map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range config {
+		if IsSecret(k) && v != "" {
+			v = Redacted
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// RedactINI returns INI text with the values of the given keys, and any that look secret, replaced with Redacted.
+// Everything else, comments included, is left as it was.
+func RedactINI(raw string, keys ...string) string {
+	lines := strings.Split(raw, "\n")
+	for i, line := range lines {
+		k, _, ok := strings.Cut(line, "=")
+		if trimmed := strings.TrimSpace(line); !ok || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") ||
+			strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		secret := IsSecret(k)
+		for _, key := range keys {
+			secret = secret || k == key
+		}
+		if secret {
+			lines[i] = k + "=" + Redacted
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExportAll writes an archive of everything there is: the timelog (and archives) both as they are and as JSON, the
+// timecode information, the config with anything secret left out, the other files the config points at, and some
+// statistics. See ExportManifest and the README for the layout.
+func ExportAll(w io.Writer, content []byte, config map[string]string, codeinfo timelog.CodeInfo) error {
+	archive := zip.NewWriter(w)
+	manifest := &ExportManifest{Format: ExportFormat, Created: Clock.Now(), Files: map[string]string{}}
+
+	add := func(name, what string, data []byte) error {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: manifest.Created})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		manifest.Files[name] = what
+		return err
+	}
+	addJSON := func(name, what string, v interface{}) error {
+		raw, err := json.MarshalIndent(v, "", "\t")
+		if err != nil {
+			return err
+		}
+		return add(name, what, append(raw, '\n'))
+	}
+
+	// The timelog, as it is on disk.
+	err := add("raw/"+filepath.Base(config["logfile"]), "The timelog file, exactly as it is.", content)
+	if err != nil {
+		return err
+	}
+	archives := ReadArchives(config["archives"])
+	for _, shard := range archives {
+		err = add("raw/archives/"+filepath.Base(shard.Name), "An archived timelog file, exactly as it is.", []byte(shard.Content))
+		if err != nil {
+			return err
+		}
+	}
+
+	// And everything else the config points at, if it exists. Syncing runs commands, which could have anything in them.
+	files := []struct{ key, what string }{
+		{"codefile", "Timecode information, see codes.json."},
+		{"ratesfile", "Currency exchange rates."},
+		{"invoicefile", "The invoice ledger."},
+		{"expensefile", "The expense ledger."},
+		{"travelfile", "The travel log."},
+		{"exportfile", "Export presets."},
+		{"profilefile", "Employer profiles."},
+		{"lockfile", "Finalized ranges."},
+		{"stagingfile", "Imported events waiting for review."},
+		{"syncfile", "Sync sources, with the commands left out."},
+		{"syncstate", "Sync cursors and ID mappings."},
+	}
+	for _, file := range files {
+		if config[file.key] == "" {
+			continue
+		}
+		raw, err := os.ReadFile(config[file.key])
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(config[file.key], ".ini") {
+			raw = []byte(RedactINI(string(raw), "pull", "push"))
+		}
+		err = add("raw/"+file.key+filepath.Ext(config[file.key]), file.what, raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The structured versions.
+	full, problems := timelog.ParseShards(append(archives, timelog.Shard{Name: config["logfile"], Content: string(content)}), 0)
+	if len(problems) > 0 {
+		return fmt.Errorf("%v malformed line(s) in the timelog, fix them first so nothing is left out", len(problems))
+	}
+	events := make([]*ExportEvent, 0, len(full))
+	for _, e := range full {
+		events = append(events, &ExportEvent{At: e.At, Track: e.Track, Code: e.Code, Desc: e.Desc, Meta: e.Meta})
+	}
+	err = addJSON("timelog.json", "Every event, archives included, in order.", events)
+	if err != nil {
+		return err
+	}
+
+	info := timelog.CodeInfo{}
+	for code, settings := range codeinfo {
+		info[code] = RedactConfig(settings)
+	}
+	err = addJSON("codes.json", "Timecode information, by code then setting. \"\" is the defaults for every code.", info)
+	if err != nil {
+		return err
+	}
+	err = addJSON("config.json", "The config in effect, with anything that looked secret redacted.", RedactConfig(config))
+	if err != nil {
+		return err
+	}
+	err = addJSON("stats.json", "Totals in hours, overall, by code, by day, and by week.", ExportStatistics(full))
+	if err != nil {
+		return err
+	}
+
+	// The manifest goes last so it can list everything else.
+	manifest.Files["manifest.json"] = "This file."
+	Debug.Debug("exporting everything", "files", len(manifest.Files))
+	err = addJSON("manifest.json", "This file.", manifest)
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestIsSecret(t *testing.T) {
+	for key, want := range map[string]bool{
+		"servetoken":    true,
+		"GITHUB_TOKEN":  true,
+		"password":      true,
+		"smtp.passwd":   true,
+		"api_key":       true,
+		"API-Key":       true,
+		"apikey":        true,
+		"client secret": true,
+		"private_key":   true,
+		"authorization": true,
+		"credentials":   true,
+		"logfile":       false,
+		"rate":          false,
+		"currency":      false,
+		"key":           false,
+		"weekstart":     false,
+		"billable":      false,
+	} {
+		if got := IsSecret(key); got != want {
+			t.Errorf("IsSecret(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	config := map[string]string{"logfile": "/home/me/sctime.log", "servetoken": "hunter2", "apikey": "", "weekstart": "monday"}
+	got := RedactConfig(config)
+	want := map[string]string{"logfile": "/home/me/sctime.log", "servetoken": Redacted, "apikey": "", "weekstart": "monday"}
+	if len(got) != len(want) {
+		t.Fatalf("RedactConfig = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("RedactConfig kept %s=%q, want %q", k, got[k], v)
+		}
+	}
+	if config["servetoken"] != "hunter2" {
+		t.Error("RedactConfig changed the config it was given")
+	}
+}
+
+func TestRedactINI(t *testing.T) {
+	raw := strings.Join([]string{
+		"# token=in a comment is left alone",
+		"; password=so is this",
+		"rate=100",
+		"",
+		"[Acme]",
+		"rate = 120",
+		"api_key=\"abc123\"",
+		"  Password =  s3cr=t  ",
+		"pull=curl -H 'Authorization: Bearer xyz' https://example.com",
+		"",
+		"[Acme]",
+		"apikey=def456",
+		"[weird=section]",
+		"notes=no secrets here",
+	}, "\n")
+	want := strings.Join([]string{
+		"# token=in a comment is left alone",
+		"; password=so is this",
+		"rate=100",
+		"",
+		"[Acme]",
+		"rate = 120",
+		"api_key=" + Redacted,
+		"Password=" + Redacted,
+		"pull=" + Redacted,
+		"",
+		"[Acme]",
+		"apikey=" + Redacted,
+		"[weird=section]",
+		"notes=no secrets here",
+	}, "\n")
+	if got := RedactINI(raw, "pull"); got != want {
+		t.Errorf("RedactINI gave\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportAll(t *testing.T) {
+	clock := Clock
+	defer func() { Clock = clock }()
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.Local)
+	Clock = timelog.FixedClock(now)
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	content := "2026/10/12 09:00AM [Acme:Dev] review\n2026/10/12 10:30AM []\n"
+	config := map[string]string{
+		"logfile":    write("sctime.log", content),
+		"codefile":   write("codes.ini", "[Acme]\nrate=120\nclient_secret=sh-codesecret\n"),
+		"syncfile":   write("sync.ini", "[jira]\npull=jira-export --token sh-synctoken\nkind=jira\n"),
+		"servetoken": "sh-servetoken",
+		"password":   "sh-password",
+		"API_KEY":    "sh-apikey",
+		"weekstart":  "monday",
+	}
+	codeinfo := timelog.CodeInfo{"Acme": {"rate": "120", "client_secret": "sh-codesecret"}}
+
+	var b bytes.Buffer
+	err := ExportAll(&b, []byte(content), config, codeinfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No secret gets out, in any file.
+	for name, raw := range files {
+		if bytes.Contains(raw, []byte("sh-")) {
+			t.Errorf("%s has a secret in it:\n%s", name, raw)
+		}
+	}
+
+	var manifest ExportManifest
+	err = json.Unmarshal(files["manifest.json"], &manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Format != ExportFormat || !manifest.Created.Equal(now) {
+		t.Errorf("manifest is format %d, made %v", manifest.Format, manifest.Created)
+	}
+	for _, name := range []string{"raw/sctime.log", "raw/codefile.ini", "raw/syncfile.ini", "timelog.json", "codes.json", "config.json", "stats.json", "manifest.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+		if manifest.Files[name] == "" {
+			t.Errorf("manifest doesn't describe %s", name)
+		}
+	}
+	if len(manifest.Files) != len(files) {
+		t.Errorf("manifest lists %d files, the archive has %d", len(manifest.Files), len(files))
+	}
+
+	if string(files["raw/sctime.log"]) != content {
+		t.Errorf("raw log is %q", files["raw/sctime.log"])
+	}
+	if !bytes.Contains(files["raw/syncfile.ini"], []byte("kind=jira")) || !bytes.Contains(files["raw/codefile.ini"], []byte("rate=120")) {
+		t.Errorf("settings that aren't secret were lost:\n%s\n%s", files["raw/syncfile.ini"], files["raw/codefile.ini"])
+	}
+
+	var cfg map[string]string
+	err = json.Unmarshal(files["config.json"], &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg["weekstart"] != "monday" || cfg["password"] != Redacted || cfg["API_KEY"] != Redacted {
+		t.Errorf("config.json is %v", cfg)
+	}
+	var codes timelog.CodeInfo
+	err = json.Unmarshal(files["codes.json"], &codes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codes["Acme"]["rate"] != "120" || codes["Acme"]["client_secret"] != Redacted {
+		t.Errorf("codes.json is %v", codes)
+	}
+
+	var events []ExportEvent
+	err = json.Unmarshal(files["timelog.json"], &events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Code != "Acme:Dev" || events[0].Desc != "review" || events[1].Code != "" {
+		t.Errorf("timelog.json is %+v", events)
+	}
+
+	var stats ExportStats
+	err = json.Unmarshal(files["stats.json"], &stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Events != 2 || stats.Total != 1.5 || stats.Codes["Acme:Dev"] != 1.5 ||
+		stats.Days["2026-10-12"]["Acme:Dev"] != 1.5 || stats.Weeks["2026-W42"]["Acme:Dev"] != 1.5 {
+		t.Errorf("stats.json is %+v", stats)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// CSVColumns are the columns 'export csv' knows, in the order they are listed in errors. Any metadata key works too,
+// as meta:<key>.
+var CSVColumns = []string{"begin", "end", "date", "week", "duration", "minutes", "code", "desc", "track", "billable"}
+
+// ParseCSVColumns parses a comma separated list of columns for 'export csv', eg "begin,end,duration,code,desc".
+func ParseCSVColumns(list string) ([]string, error) {
+	columns := []string{}
+	for _, col := range strings.Split(list, ",") {
+		col = strings.TrimSpace(col)
+		if col == "" {
+			continue
+		}
+		// Metadata keys keep their case, everything else is a name from CSVColumns.
+		if key, ok := strings.CutPrefix(col, "meta:"); ok && key != "" {
+			columns = append(columns, col)
+			continue
+		}
+		col = strings.ToLower(col)
+		if !slices.Contains(CSVColumns, col) {
+			return nil, fmt.Errorf("unknown column %q, expected one of %s, or meta:<key>", col, strings.Join(CSVColumns, ", "))
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns given")
+	}
+	return columns, nil
+}
+
+// ParseCSVDelimiter parses the delimiter for 'export csv'. It is a single character, or "tab".
+func ParseCSVDelimiter(delim string) (rune, error) {
+	if delim == "tab" || delim == `\t` {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(delim)
+	if size == 0 || size != len(delim) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("the delimiter needs to be one character (other than a quote), or tab, not %q", delim)
+	}
+	return r, nil
+}
+
+// PeriodRows lays out periods for 'export csv', with a row for each period and a header row first if header is set.
+// Times are local, as 2006-01-02 15:04, and durations are decimal hours with two places, which spreadsheets and
+// payroll systems all read without help.
+func PeriodRows(periods []*timelog.Period, columns []string, info timelog.CodeInfo, header bool) [][]string {
+	rows := [][]string{}
+	if header {
+		rows = append(rows, columns)
+	}
+	for _, p := range periods {
+		row := make([]string, 0, len(columns))
+		for _, col := range columns {
+			switch col {
+			case "begin":
+				row = append(row, p.Begin.Local().Format("2006-01-02 15:04"))
+			case "end":
+				row = append(row, p.End.Local().Format("2006-01-02 15:04"))
+			case "date":
+				row = append(row, p.Day(Calendar).Format("2006-01-02"))
+			case "week":
+				week := Calendar.WeekOf(p.Day(Calendar))
+				row = append(row, fmt.Sprintf("%04d-W%02d", week.Year, week.Number))
+			case "duration":
+				row = append(row, strconv.FormatFloat(p.Length().Hours(), 'f', 2, 64))
+			case "minutes":
+				row = append(row, strconv.FormatFloat(p.Length().Minutes(), 'f', 0, 64))
+			case "code":
+				row = append(row, p.Code)
+			case "desc":
+				row = append(row, p.Desc)
+			case "track":
+				row = append(row, p.Track)
+			case "billable":
+				row = append(row, strconv.FormatBool(info.Billable(p.Code)))
+			default:
+				row = append(row, p.Meta[strings.TrimPrefix(col, "meta:")])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// WriteDelimited writes rows as CSV with the given delimiter, see ParseCSVDelimiter.
+func WriteDelimited(w io.Writer, rows [][]string, delim rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = delim
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// ExportCSVCommand handles 'export csv', which writes a row for each period in the range (or the whole log) with a
+// code matching the ones given, with the columns and delimiter from the flags or the config.
+func ExportCSVCommand(args []string, log timelog.TimeLog, config map[string]string, info timelog.CodeInfo, capNow bool) {
+	args, columnsflag := TakeFlagValue(args, "--columns")
+	args, delimflag := TakeFlagValue(args, "--delimiter")
+	args, noheader := TakeFlag(args, "--no-header")
+
+	// Bad flags are the user's fault, a bad config is the config's.
+	columns, err := ParseCSVColumns(config["csvcolumns"])
+	exit := 6
+	if columnsflag != "" {
+		columns, err = ParseCSVColumns(columnsflag)
+		exit = 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid csv columns:", err)
+		os.Exit(exit)
+	}
+	delim, err := ParseCSVDelimiter(config["csvdelimiter"])
+	exit = 6
+	if delimflag != "" {
+		delim, err = ParseCSVDelimiter(delimflag)
+		exit = 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid csv delimiter:", err)
+		os.Exit(exit)
+	}
+
+	periods := ExportPeriods(args, log, config, capNow)
+	err = WriteDelimited(os.Stdout, PeriodRows(periods, columns, info, !noheader), delim)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing CSV:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestParseCSVColumns(t *testing.T) {
+	tests := []struct {
+		list string
+		want string // The columns joined with commas, or "error".
+	}{
+		{"begin,end,duration,code,desc", "begin,end,duration,code,desc"},
+		{" Begin , END,,minutes ", "begin,end,minutes"},
+		{"date,week,track,billable", "date,week,track,billable"},
+		{"code,meta:Ticket,meta:client id", "code,meta:Ticket,meta:client id"},
+		{"code,meta:", "error"},
+		{"code,hours", "error"},
+		{"", "error"},
+		{" , ", "error"},
+	}
+	for _, test := range tests {
+		columns, err := ParseCSVColumns(test.list)
+		got := strings.Join(columns, ",")
+		if err != nil {
+			got = "error"
+		}
+		if got != test.want {
+			t.Errorf("ParseCSVColumns(%q) = %q (%v), want %q", test.list, got, err, test.want)
+		}
+	}
+}
+
+func TestParseCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		delim string
+		want  rune // 0 for an error.
+	}{
+		{",", ','},
+		{";", ';'},
+		{"|", '|'},
+		{"tab", '\t'},
+		{`\t`, '\t'},
+		{"§", '§'},
+		{"", 0},
+		{";;", 0},
+		{`"`, 0},
+		{"\n", 0},
+		{"\r", 0},
+		{"\xff", 0},
+	}
+	for _, test := range tests {
+		got, err := ParseCSVDelimiter(test.delim)
+		if (err != nil) != (test.want == 0) || got != test.want {
+			t.Errorf("ParseCSVDelimiter(%q) = %q (%v), want %q", test.delim, got, err, test.want)
+		}
+	}
+}
+
+func TestPeriodRows(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+	periods := []*timelog.Period{
+		{Begin: at(12, 9, 0), End: at(12, 10, 30), Code: "Acme:Dev", Desc: "fixing bug", Meta: map[string]string{"ticket": "42"}},
+		{Begin: at(18, 23, 0), End: at(19, 0, 20), Code: "Internal", Desc: "said \"hi\", then left\nfor the night", Track: "phone"},
+	}
+	info := timelog.CodeInfo{"Acme": {"billable": "true"}}
+	columns := []string{"begin", "end", "date", "week", "duration", "minutes", "code", "desc", "track", "billable", "meta:ticket"}
+	rows := PeriodRows(periods, columns, info, true)
+	want := [][]string{
+		columns,
+		{"2026-10-12 09:00", "2026-10-12 10:30", "2026-10-12", "2026-W42", "1.50", "90", "Acme:Dev", "fixing bug", "", "true", "42"},
+		{"2026-10-18 23:00", "2026-10-19 00:20", "2026-10-18", "2026-W42", "1.33", "80", "Internal", "said \"hi\", then left\nfor the night", "phone", "false", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d is\n%q\nwant\n%q", i, rows[i], want[i])
+		}
+	}
+	if rows := PeriodRows(periods, columns, info, false); len(rows) != 2 {
+		t.Errorf("without a header there are %d rows, want 2", len(rows))
+	}
+
+	// Whatever the delimiter, a spreadsheet reads back what was written, quotes, delimiters, and line breaks included.
+	for _, delim := range []rune{',', ';', '\t', '|'} {
+		b := &strings.Builder{}
+		err := WriteDelimited(b, rows, delim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := csv.NewReader(strings.NewReader(b.String()))
+		r.Comma = delim
+		reread, err := r.ReadAll()
+		if err != nil {
+			t.Fatalf("delimiter %q: %v\n%s", delim, err, b)
+		}
+		for i := range rows {
+			if strings.Join(reread[i], "\x00") != strings.Join(rows[i], "\x00") {
+				t.Errorf("delimiter %q: row %d read back as %q", delim, i, reread[i])
+			}
+		}
+	}
+}
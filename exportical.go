@@ -0,0 +1,122 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// icalTime is the UTC form of an iCalendar DATE-TIME, which saves putting time zones in the file.
+const icalTime = "20060102T150405Z"
+
+// WriteICal writes the periods as an iCalendar file (RFC 5545) with a VEVENT for each, so tracked time can be laid
+// over a calendar. The summary is the first line of the description (or the code, without one), the code is the
+// category, and the whole description goes in the description if there is more than a line of it. The UIDs only
+// depend on when and on which track a period began, so importing again updates the events instead of doubling them.
+func WriteICal(w io.Writer, periods []*timelog.Period, now time.Time) error {
+	b := bufio.NewWriter(w)
+	line := func(name, value string) {
+		icalLine(b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//timeclock//timeclock//EN")
+	line("CALSCALE", "GREGORIAN")
+	for _, p := range periods {
+		uid := p.Begin.UTC().Format(icalTime)
+		if p.Track != "" {
+			uid += "-" + p.Track
+		}
+
+		first, rest, more := strings.Cut(p.Desc, "\n")
+		summary := strings.TrimSpace(first)
+		if summary == "" {
+			summary = p.Code
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", icalText(uid+"@timeclock"))
+		line("DTSTAMP", now.UTC().Format(icalTime))
+		line("DTSTART", p.Begin.UTC().Format(icalTime))
+		line("DTEND", p.End.UTC().Format(icalTime))
+		line("SUMMARY", icalText(summary))
+		if p.Code != "" {
+			line("CATEGORIES", icalText(p.Code))
+		}
+		if more && strings.TrimSpace(rest) != "" {
+			line("DESCRIPTION", icalText(p.Desc))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Flush()
+}
+
+// icalText escapes a TEXT value. Control characters other than tabs and line breaks aren't allowed in one, so they
+// are dropped, carriage returns included.
+func icalText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\t' && r != '\n') || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icalLine writes a content line, folded so no line is longer than 75 octets, without splitting a character.
+func icalLine(w io.StringWriter, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// The space starting a continuation line counts.
+		limit = 74
+	}
+	w.WriteString(s + "\r\n")
+}
+
+// ExportICalCommand handles 'export ical', which writes the periods in the range (or the whole log) with the codes
+// given as an iCalendar file to stdout.
+func ExportICalCommand(args []string, log timelog.TimeLog, config map[string]string, capNow bool) {
+	periods := ExportPeriods(args, log, config, capNow)
+	err := WriteICal(os.Stdout, periods, Clock.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing calendar:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
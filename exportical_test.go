@@ -0,0 +1,165 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestICalText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{`C:\work`, `C:\\work`},
+		{"a;b,c", `a\;b\,c`},
+		{"one\ntwo", `one\ntwo`},
+		{"one\r\ntwo", `one\ntwo`},
+		{"one\rtwo", "onetwo"},
+		{"tab\tand\x00nul\x1bescape\x7f", "tab\tandnulescape"},
+		{"日本語, ok", `日本語\, ok`},
+	}
+	for _, test := range tests {
+		if got := icalText(test.in); got != test.want {
+			t.Errorf("icalText(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestICalLine(t *testing.T) {
+	tests := []struct {
+		name, line string
+	}{
+		{"short", "SUMMARY:short"},
+		{"exactly 75", "SUMMARY:" + strings.Repeat("x", 67)},
+		{"ascii", "DESCRIPTION:" + strings.Repeat("abcdefghij", 30)},
+		{"multibyte", "SUMMARY:" + strings.Repeat("日本語", 40)},
+		{"mixed", "SUMMARY:" + strings.Repeat("a€😀", 50)},
+	}
+	for _, test := range tests {
+		var b strings.Builder
+		icalLine(&b, test.line)
+		out := b.String()
+
+		if !strings.HasSuffix(out, "\r\n") {
+			t.Errorf("%s: %q doesn't end with CRLF", test.name, out)
+			continue
+		}
+		physical := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+		for i, l := range physical {
+			if len(l) > 75 {
+				t.Errorf("%s: line %d is %d octets", test.name, i, len(l))
+			}
+			if !utf8.ValidString(l) {
+				t.Errorf("%s: line %d splits a character: %q", test.name, i, l)
+			}
+			if i > 0 && !strings.HasPrefix(l, " ") {
+				t.Errorf("%s: continuation line %d doesn't start with a space: %q", test.name, i, l)
+			}
+		}
+		if len(test.line) <= 75 && len(physical) != 1 {
+			t.Errorf("%s: folded a line that fits: %q", test.name, out)
+		}
+
+		if got := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); got != test.line {
+			t.Errorf("%s: unfolds to %q, want %q", test.name, got, test.line)
+		}
+	}
+}
+
+func TestWriteICal(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, time.October, 12, hour, minute, 0, 0, time.UTC)
+	}
+	periods := []*timelog.Period{
+		{Begin: at(9, 0), End: at(10, 30), Code: "Acme", Desc: "Fix the build, again\n\nIt broke; twice.", Track: "laptop"},
+		{Begin: at(11, 0), End: at(11, 15), Code: "Acme/Support"},
+		{Begin: at(13, 0), End: at(14, 0), Desc: "Lunch\n   "},
+	}
+
+	var b strings.Builder
+	err := WriteICal(&b, periods, at(18, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+		t.Errorf("line without CRLF in %q", out)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//timeclock//timeclock//EN",
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VEVENT",
+		"UID:20261012T090000Z-laptop@timeclock",
+		"DTSTAMP:20261012T180000Z",
+		"DTSTART:20261012T090000Z",
+		"DTEND:20261012T103000Z",
+		`SUMMARY:Fix the build\, again`,
+		"CATEGORIES:Acme",
+		`DESCRIPTION:Fix the build\, again\n\nIt broke\; twice.`,
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:20261012T110000Z@timeclock",
+		"DTSTAMP:20261012T180000Z",
+		"DTSTART:20261012T110000Z",
+		"DTEND:20261012T111500Z",
+		"SUMMARY:Acme/Support",
+		"CATEGORIES:Acme/Support",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:20261012T130000Z@timeclock",
+		"DTSTAMP:20261012T180000Z",
+		"DTSTART:20261012T130000Z",
+		"DTEND:20261012T140000Z",
+		"SUMMARY:Lunch",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+	if out != want {
+		t.Errorf("WriteICal wrote:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestWriteICalLocalTimes(t *testing.T) {
+	zone := time.FixedZone("UTC-5", -5*60*60)
+	begin := time.Date(2026, time.October, 12, 23, 30, 0, 0, zone)
+	periods := []*timelog.Period{{Begin: begin, End: begin.Add(time.Hour), Code: "Acme"}}
+
+	var b strings.Builder
+	if err := WriteICal(&b, periods, begin); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"DTSTART:20261013T043000Z", "DTEND:20261013T053000Z", "UID:20261013T043000Z@timeclock"} {
+		if !strings.Contains(b.String(), line+"\r\n") {
+			t.Errorf("missing %q in %q", line, b.String())
+		}
+	}
+}
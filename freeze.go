@@ -0,0 +1,79 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// freezeDateFormats are the formats --today accepts.
+var freezeDateFormats = []string{"2006/01/02", "2006-01-02"}
+
+// FreezeTime works out the time to pin the clock to for --now and --today. --today moves the real time of day to
+// another date. --now is a date and time, which is used as is, or a time of day or a duration like -2h, which go from
+// the real time (or the --today date, if that was given too). Unlike an event time, nothing is rounded, and seconds are
+// kept if given, demos and bug reports want exactly what they asked for.
+func FreezeTime(now, today string, real time.Time) (time.Time, bool) {
+	base := real.Local()
+	if today != "" {
+		ok := false
+		for _, layout := range freezeDateFormats {
+			d, err := time.ParseInLocation(layout, today, time.Local)
+			if err != nil {
+				continue
+			}
+			base = time.Date(d.Year(), d.Month(), d.Day(), base.Hour(), base.Minute(), base.Second(), base.Nanosecond(), time.Local)
+			ok = true
+			break
+		}
+		if !ok {
+			return time.Time{}, false
+		}
+	}
+	if now == "" {
+		return base, true
+	}
+
+	if strings.HasPrefix(now, "-") || strings.HasPrefix(now, "+") {
+		d, err := time.ParseDuration(now)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return base.Add(d), true
+	}
+	if t, err := time.Parse(time.RFC3339, now); err == nil {
+		return t, true
+	}
+	for _, f := range strictFormats {
+		t, err := time.ParseInLocation(f.layout, strings.ToUpper(now), time.Local)
+		if err != nil {
+			continue
+		}
+		if !f.dated {
+			t = time.Date(base.Year(), base.Month(), base.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
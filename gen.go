@@ -0,0 +1,89 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/milochristiansen/timeclock/timelog/gen"
+)
+
+// GenCommand writes a made up timelog to stdout, see the gen package. It takes --days <n> (default 365), --from <date>
+// (default that many days ago), --codes <a,b,...>, --noise <0-1> (default 0.3), --seed <n>, and --weekends.
+func GenCommand(args []string) {
+	opts := gen.Options{Days: 365, Noise: 0.3}
+
+	args, days := TakeFlagValue(args, "--days")
+	args, from := TakeFlagValue(args, "--from")
+	args, codes := TakeFlagValue(args, "--codes")
+	args, noise := TakeFlagValue(args, "--noise")
+	args, seed := TakeFlagValue(args, "--seed")
+	args, opts.Weekends = TakeFlag(args, "--weekends")
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected arguments to gen: %s\n", strings.Join(args, " "))
+		os.Exit(2)
+	}
+
+	var err error
+	if days != "" {
+		opts.Days, err = strconv.Atoi(days)
+		if err != nil || opts.Days < 1 {
+			fmt.Fprintln(os.Stderr, "--days needs a number, starting from 1.")
+			os.Exit(2)
+		}
+	}
+	if noise != "" {
+		opts.Noise, err = strconv.ParseFloat(noise, 64)
+		if err != nil || opts.Noise < 0 || opts.Noise > 1 {
+			fmt.Fprintln(os.Stderr, "--noise needs a number from 0 to 1.")
+			os.Exit(2)
+		}
+	}
+	if seed != "" {
+		opts.Seed, err = strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "--seed needs a whole number.")
+			os.Exit(2)
+		}
+	}
+	for _, code := range strings.Split(codes, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			opts.Codes = append(opts.Codes, code)
+		}
+	}
+
+	opts.Begin = Clock.Now().AddDate(0, 0, -opts.Days)
+	if from != "" {
+		begin, _ := ParseTimeRange(strings.Fields(from))
+		opts.Begin = *begin
+	}
+
+	err = gen.Generate(opts).FormatFile(os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
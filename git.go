@@ -0,0 +1,207 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// git runs a git command in dir and returns its output, trimmed.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// NormalizeRemote turns the many ways of writing a remote URL into host/path, so "git@github.com:acme/site.git" and
+// "https://github.com/acme/site" both become "github.com/acme/site".
+func NormalizeRemote(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if i := strings.Index(url, ":"); i >= 0 && !strings.Contains(url[:i], "/") {
+		// scp style, user@host:path
+		url = url[:i] + "/" + url[i+1:]
+	}
+	if i := strings.Index(url, "@"); i >= 0 && i < strings.Index(url+"/", "/") {
+		url = url[i+1:]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
+
+// GitCodes are the patterns for working out a default time code from the git repository, from the gitcodes config.
+var GitCodes []GitCode
+
+// FindDefaultCode returns the time code to use for a new event with none in the input, and why. The code from a
+// .sctime file comes first, then GitCodes are tried against the repository the current directory is in. Returns blanks
+// if there is no default.
+func FindDefaultCode() (string, string) {
+	if DefaultCode != "" {
+		return DefaultCode, "the default code"
+	}
+	if len(GitCodes) == 0 {
+		return "", ""
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", ""
+	}
+	if code, name := GitRepoCode(wd, GitCodes); code != "" {
+		return code, "from the git repository " + name
+	}
+	return "", ""
+}
+
+// GitCode is a pattern from the gitcodes config, and the time code for repositories that match it.
+type GitCode struct {
+	Pattern string
+	Code    string
+}
+
+// ParseGitCodes parses the gitcodes config, a comma separated list of pattern=code pairs.
+func ParseGitCodes(config string) ([]GitCode, error) {
+	codes := []GitCode{}
+	for _, pair := range strings.Split(config, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		pattern, code, ok := strings.Cut(pair, "=")
+		pattern, code = strings.TrimSpace(pattern), strings.TrimSpace(code)
+		if !ok || pattern == "" || code == "" {
+			return nil, fmt.Errorf("expected pattern=code, got %q", pair)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %w", pattern, err)
+		}
+		codes = append(codes, GitCode{Pattern: pattern, Code: code})
+	}
+	return codes, nil
+}
+
+// GitRepoCode works out a time code for the git repository dir is in. Each pattern is matched against the name of the
+// repository and each of its remotes (see NormalizeRemote) with path.Match, and the first one that matches wins.
+// Returns the code and what matched, or blanks if dir isn't in a repository or nothing matched.
+func GitRepoCode(dir string, codes []GitCode) (string, string) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		Debug.Debug("not in a git repository", "dir", dir, "err", err)
+		return "", ""
+	}
+	names := []string{filepath.Base(top)}
+	remotes, _ := git(dir, "config", "--get-regexp", `^remote\..*\.url$`)
+	for _, line := range strings.Split(remotes, "\n") {
+		if _, url, ok := strings.Cut(line, " "); ok {
+			names = append(names, NormalizeRemote(url))
+		}
+	}
+	Debug.Debug("git repository", "top", top, "names", names)
+
+	for _, c := range codes {
+		for _, name := range names {
+			if matched, _ := path.Match(c.Pattern, name); matched {
+				return c.Code, name
+			}
+		}
+	}
+	return "", ""
+}
+
+// Commit is a commit from one of the repositories in the gitrepos config.
+type Commit struct {
+	At      time.Time
+	Repo    string
+	Hash    string
+	Subject string
+}
+
+// GitRepos finds the repositories matching a comma separated list of glob patterns, the gitrepos config. Anything
+// matched that isn't a directory is skipped.
+func GitRepos(patterns string) ([]string, error) {
+	repos := []string{}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		dirs, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gitrepos pattern %q: %w", pattern, err)
+		}
+		for _, dir := range dirs {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				repos = append(repos, dir)
+			}
+		}
+	}
+	return repos, nil
+}
+
+// AuthoredCommits lists the commits you made in the repositories between begin and end, oldest first. "You" is
+// whoever user.email is in each repository, commits on every branch count.
+func AuthoredCommits(repos []string, begin, end time.Time) ([]Commit, error) {
+	commits := []Commit{}
+	for _, repo := range repos {
+		email, err := git(repo, "config", "user.email")
+		if err != nil {
+			return nil, fmt.Errorf("%s: can't tell which commits are yours, user.email isn't set: %w", repo, err)
+		}
+
+		// --since stops at the first commit older than it rather than skipping over it, and goes by the committer date,
+		// so give it a day of slack and check the author dates below.
+		out, err := git(repo, "log", "--all", "--no-merges", "--author="+email, "--format=%at%x09%h%x09%s",
+			"--since="+begin.Add(-24*time.Hour).Format(time.RFC3339), "--until="+end.Format(time.RFC3339))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repo, err)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			unix, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			at := time.Unix(unix, 0)
+			if at.Before(begin) || !at.Before(end) {
+				continue
+			}
+			commits = append(commits, Commit{At: at, Repo: filepath.Base(repo), Hash: parts[1], Subject: parts[2]})
+		}
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].At.Before(commits[j].At)
+	})
+	return commits, nil
+}
@@ -2,16 +2,18 @@ module github.com/milochristiansen/timeclock
 
 go 1.19
 
+require (
+	github.com/lithammer/fuzzysearch v1.1.8
+	github.com/manifoldco/promptui v0.9.0
+	github.com/markusmobius/go-dateparser v0.0.0-20220211203457-60965b2d2bfb
+	github.com/milochristiansen/ledger v0.0.0-20220804000643-8da493bd9ad0
+	golang.org/x/text v0.9.0
+)
+
 require (
 	github.com/chzyer/readline v0.0.0-20180603132655-2972be24d48e // indirect
 	github.com/hablullah/go-hijri v1.0.2 // indirect
 	github.com/hablullah/go-juliandays v1.0.0 // indirect
 	github.com/jalaali/go-jalaali v0.0.0-20210801064154-80525e88d958 // indirect
-	github.com/lithammer/fuzzysearch v1.1.8 // indirect
-	github.com/manifoldco/promptui v0.9.0 // indirect
-	github.com/markusmobius/go-dateparser v0.0.0-20220211203457-60965b2d2bfb // indirect
-	github.com/milochristiansen/ledger v0.0.0-20220804000643-8da493bd9ad0 // indirect
-	github.com/snabb/isoweek v1.0.3 // indirect
 	golang.org/x/sys v0.5.0 // indirect
-	golang.org/x/text v0.9.0 // indirect
 )
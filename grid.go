@@ -0,0 +1,188 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/report"
+)
+
+// WeekGrid lays out weeks as a standard timesheet grid, the way most corporate timesheet systems want it: a row for
+// each code in each week, with the hours for each day of the week (from Calendar.WeekStart) and the week total. Each
+// week ends with a total row. Hours are decimal with two places, and blank cells mean nothing was logged that day.
+func WeekGrid(weeks []*report.ReportWeek) [][]string {
+	header := []string{"Week", "Code"}
+	for d := 0; d < 7; d++ {
+		header = append(header, ((Calendar.WeekStart + time.Weekday(d)) % 7).String())
+	}
+	header = append(header, "Total")
+	rows := [][]string{header}
+
+	for _, w := range weeks {
+		week := fmt.Sprintf("%04d-W%02d", w.Year, w.Number)
+
+		dates := []string{week, "Date"}
+		for d := 0; d < 7; d++ {
+			dates = append(dates, w.FirstDay.AddDate(0, 0, d).Format("2006-01-02"))
+		}
+		rows = append(rows, append(dates, ""))
+
+		codes := make([]string, 0, len(w.Totals))
+		for code := range w.Totals {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+
+		for _, code := range codes {
+			rows = append(rows, gridRow(week, code, w.Totals[code]))
+		}
+		rows = append(rows, gridRow(week, "Total", w.Daily))
+	}
+	return rows
+}
+
+func gridRow(week, code string, days [8]time.Duration) []string {
+	row := []string{week, code}
+	for _, d := range days {
+		if d == 0 {
+			row = append(row, "")
+			continue
+		}
+		row = append(row, strconv.FormatFloat(d.Hours(), 'f', 2, 64))
+	}
+	return row
+}
+
+// WriteCSV writes a grid as CSV.
+func WriteCSV(w io.Writer, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// WriteXLSX writes a grid as a single sheet Excel workbook. Cells that look like numbers are written as numbers, so
+// they can be added up, everything else is written as text.
+//
+// This is the bare minimum a spreadsheet program will accept, there is no styling of any kind.
+func WriteXLSX(w io.Writer, rows [][]string) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(rows)},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(fw, f.content)
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func xlsxSheet(rows [][]string) string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			if cell == "" {
+				continue
+			}
+			ref := xlsxColumn(c) + strconv.Itoa(r+1)
+			if xlsxNumber(cell) {
+				fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, cell)
+				continue
+			}
+			fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t>`, ref)
+			xml.EscapeText(b, []byte(cell))
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// xlsxNumber is true if the cell is a plain decimal number. strconv.ParseFloat would also take "NaN", "Inf", and hex,
+// and a code spelled like one of those isn't something Excel can read as a number.
+func xlsxNumber(cell string) bool {
+	digits, dot := 0, false
+	for i, r := range cell {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '-' && i == 0:
+		case r == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+// xlsxColumn converts a zero based column number to spreadsheet letters, A through Z, then AA and so on.
+func xlsxColumn(c int) string {
+	name := ""
+	for c++; c > 0; c = (c - 1) / 26 {
+		name = string(rune('A'+(c-1)%26)) + name
+	}
+	return name
+}
+
+const xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="Timesheet" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
@@ -0,0 +1,193 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// gridWeeks builds the report weeks for the grid from a log, with weeks starting on the given day.
+func gridWeeks(t *testing.T, log timelog.TimeLog, start time.Weekday) []*report.ReportWeek {
+	t.Helper()
+	cal := Calendar
+	t.Cleanup(func() { Calendar = cal })
+	Calendar.WeekStart = start
+
+	begin, end := log[0].At.AddDate(0, 0, -7), log[len(log)-1].At.AddDate(0, 0, 7)
+	data, err := report.Build(report.Options{Log: log, Begin: &begin, End: &end, Calendar: Calendar, FoldCodeCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data.Weeks
+}
+
+func TestWeekGrid(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+	// 2026/10/12 is a Monday. The Tuesday night period runs over midnight, and counts for Tuesday.
+	log := timelog.TimeLog{
+		{At: at(12, 9, 0), Code: "Beta"},
+		{At: at(12, 10, 30), Code: ""},
+		{At: at(13, 23, 0), Code: "Acme"},
+		{At: at(14, 1, 15), Code: "Beta"},
+		{At: at(14, 2, 15), Code: ""},
+		{At: at(18, 10, 0), Code: "Acme"},
+		{At: at(18, 11, 0), Code: ""},
+	}
+
+	tests := []struct {
+		name  string
+		start time.Weekday
+		want  [][]string
+	}{
+		{"weeks from monday", time.Monday, [][]string{
+			{"Week", "Code", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday", "Total"},
+			{"2026-W42", "Date", "2026-10-12", "2026-10-13", "2026-10-14", "2026-10-15", "2026-10-16", "2026-10-17", "2026-10-18", ""},
+			{"2026-W42", "Acme", "", "2.25", "", "", "", "", "1.00", "3.25"},
+			{"2026-W42", "Beta", "1.50", "", "1.00", "", "", "", "", "2.50"},
+			{"2026-W42", "Total", "1.50", "2.25", "1.00", "", "", "", "1.00", "5.75"},
+		}},
+		{"weeks from sunday", time.Sunday, [][]string{
+			{"Week", "Code", "Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Total"},
+			{"2026-W42", "Date", "2026-10-11", "2026-10-12", "2026-10-13", "2026-10-14", "2026-10-15", "2026-10-16", "2026-10-17", ""},
+			{"2026-W42", "Acme", "", "", "2.25", "", "", "", "", "2.25"},
+			{"2026-W42", "Beta", "", "1.50", "", "1.00", "", "", "", "2.50"},
+			{"2026-W42", "Total", "", "1.50", "2.25", "1.00", "", "", "", "4.75"},
+			{"2026-W43", "Date", "2026-10-18", "2026-10-19", "2026-10-20", "2026-10-21", "2026-10-22", "2026-10-23", "2026-10-24", ""},
+			{"2026-W43", "Acme", "1.00", "", "", "", "", "", "", "1.00"},
+			{"2026-W43", "Total", "1.00", "", "", "", "", "", "", "1.00"},
+		}},
+	}
+	for _, test := range tests {
+		got := WeekGrid(gridWeeks(t, log, test.start))
+		checkRows(t, test.name, got, test.want)
+	}
+}
+
+func TestWriteCSVGrid(t *testing.T) {
+	var b bytes.Buffer
+	err := WriteCSV(&b, [][]string{{"Week", "Code"}, {"2026-W42", "Acme, Inc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Week,Code\n2026-W42,\"Acme, Inc\"\n"; b.String() != want {
+		t.Errorf("WriteCSV wrote %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	rows := [][]string{
+		{"Week", "Code", "Monday", "Total"},
+		{"2026-W42", "R&D <lab>", "1.50", "-2"},
+		{"2026-W42", "NaN", "", "Inf"},
+	}
+	var b bytes.Buffer
+	err := WriteXLSX(&b, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(raw)
+
+		// Every part has to be well formed XML or Excel refuses the whole file.
+		d := xml.NewDecoder(bytes.NewReader(raw))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("%s: %v", f.Name, err)
+				break
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	var sheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R      string `xml:"r,attr"`
+				T      string `xml:"t,attr"`
+				V      string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	err = xml.Unmarshal([]byte(files["xl/worksheets/sheet1.xml"]), &sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, row := range sheet.Rows {
+		for _, c := range row.Cells {
+			if c.T == "inlineStr" {
+				got = append(got, c.R+"="+c.Inline)
+			} else {
+				got = append(got, c.R+"#"+c.V)
+			}
+		}
+	}
+	want := "A1=Week B1=Code C1=Monday D1=Total A2=2026-W42 B2=R&D <lab> C2#1.50 D2#-2 A3=2026-W42 B3=NaN D3=Inf"
+	if strings.Join(got, " ") != want {
+		t.Errorf("sheet cells are\n%s\nwant\n%s", strings.Join(got, " "), want)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for c, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(c); got != want {
+			t.Errorf("xlsxColumn(%d) = %q, want %q", c, got, want)
+		}
+	}
+}
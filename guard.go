@@ -0,0 +1,73 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// SurpriseWindow is how far from now a parsed time can be before it needs confirming, from the surprise config key.
+// Zero turns the check off.
+var SurpriseWindow = 12 * time.Hour
+
+// Surprising returns why a parsed time looks like a misreading, or "" if it looks fine. dateparser will happily read a
+// ticket number as a year, so anything too far from now, or not today, gets a second look.
+func Surprising(at, now time.Time) string {
+	if SurpriseWindow <= 0 {
+		return ""
+	}
+
+	off := at.Sub(now)
+	if off > SurpriseWindow {
+		return fmt.Sprintf("it is %s from now", timelog.FormatDuration(off, Durations))
+	}
+	if off < -SurpriseWindow {
+		return fmt.Sprintf("it is %s ago", timelog.FormatDuration(-off, Durations))
+	}
+	if !Calendar.Day(at).Equal(Calendar.Day(now)) {
+		return "it is not today"
+	}
+	return ""
+}
+
+// ConfirmSurprising asks before using a time that looks like a misreading, exiting if the answer is no. yes is --yes,
+// which skips the question for scripts that know what they are doing. Times given with --strict are never questioned.
+func ConfirmSurprising(at time.Time, yes bool) {
+	why := Surprising(at, Clock.Now())
+	Debug.Debug("surprise check", "time", at.Format(timelog.TimeFormat), "why", why, "yes", yes, "strict", Strict)
+	if why == "" || yes || Strict {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "The time was read as %s, but %s.\n", at.Format(timelog.TimeFormat), why)
+	if !Interactive {
+		fmt.Fprintln(os.Stderr, "Use --yes to use it anyway. (see the 'surprise' config key)")
+	}
+	if !Confirm("Use this time anyway") {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// heatShades go from a little time in an hour to the most time in any hour.
+var heatShades = []string{"░░", "▒▒", "▓▓", "██"}
+
+// PrintHeatMap draws the heat map for a terminal, a row for each day of the week and a column for each hour, with the
+// total for each day at the end. The shades are relative to the busiest hour, which is given in the key.
+func PrintHeatMap(w io.Writer, m *timelog.HeatMap) {
+	max := m.Max()
+
+	fmt.Fprint(w, "    ")
+	for h := 0; h < 24; h++ {
+		fmt.Fprintf(w, " %02d", h)
+	}
+	fmt.Fprintln(w, "  Total")
+
+	for i, day := range m {
+		var total time.Duration
+		fmt.Fprint(w, time.Weekday((i + 1) % 7).String()[:3], " ")
+		for _, d := range day {
+			total += d
+			fmt.Fprint(w, " ", heatShade(d, max))
+		}
+		fmt.Fprintf(w, "  %s\n", timelog.FormatDuration(total, Durations))
+	}
+
+	fmt.Fprintln(w)
+	key := []string{}
+	for i, shade := range heatShades {
+		key = append(key, fmt.Sprintf("%s up to %s", shade, timelog.FormatDuration(max*time.Duration(i+1)/time.Duration(len(heatShades)), Durations)))
+	}
+	fmt.Fprintln(w, strings.Join(key, ", "))
+}
+
+func heatShade(d, max time.Duration) string {
+	if d <= 0 || max <= 0 {
+		return "  "
+	}
+	i := int((d*time.Duration(len(heatShades)) - 1) / max)
+	if i >= len(heatShades) {
+		i = len(heatShades) - 1
+	}
+	return heatShades[i]
+}
+
+// HeatMapCSV lays out the heat map for a spreadsheet, a row for each day of the week and a column for each hour, in
+// decimal hours with two places.
+func HeatMapCSV(m *timelog.HeatMap) [][]string {
+	header := []string{"Day"}
+	for h := 0; h < 24; h++ {
+		header = append(header, fmt.Sprintf("%02d:00", h))
+	}
+	rows := [][]string{append(header, "Total")}
+
+	for i, day := range m {
+		var total time.Duration
+		row := []string{time.Weekday((i + 1) % 7).String()}
+		for _, d := range day {
+			total += d
+			row = append(row, strconv.FormatFloat(d.Hours(), 'f', 2, 64))
+		}
+		rows = append(rows, append(row, strconv.FormatFloat(total.Hours(), 'f', 2, 64)))
+	}
+	return rows
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Invoice is the record kept of a generated invoice.
+type Invoice struct {
+	Number string
+	State  string // "draft", "sent", or "paid".
+	Begin  time.Time
+	End    time.Time
+	Amount string   // The totals, as printed.
+	Codes  []string // The codes that were charged for.
+}
+
+// Invoices is the invoice ledger, in the order the invoices were generated.
+//
+// The ledger file has one invoice per line, with the fields separated by tabs. The amount and codes are quoted, since
+// codes may contain nearly anything.
+type Invoices []*Invoice
+
+// LoadInvoices reads the invoice ledger. A missing ledger is just empty.
+func LoadInvoices(path string) (Invoices, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Invoices{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := Invoices{}
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("line %v: expected at least 5 fields, found %v", i+1, len(fields))
+		}
+
+		inv := &Invoice{Number: fields[0], State: fields[1]}
+		inv.Begin, err = time.ParseInLocation(timelog.TimeFormat, fields[2], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", i+1, err)
+		}
+		inv.End, err = time.ParseInLocation(timelog.TimeFormat, fields[3], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", i+1, err)
+		}
+		inv.Amount, err = strconv.Unquote(fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("line %v: malformed amount: %w", i+1, err)
+		}
+		for _, f := range fields[5:] {
+			code, err := strconv.Unquote(f)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: malformed code: %w", i+1, err)
+			}
+			inv.Codes = append(inv.Codes, code)
+		}
+		invoices = append(invoices, inv)
+	}
+	return invoices, nil
+}
+
+// Save writes the invoice ledger.
+func (invoices Invoices) Save(path string) error {
+	b := &strings.Builder{}
+	for _, inv := range invoices {
+		fmt.Fprintf(b, "%s\t%s\t%s\t%s\t%q", inv.Number, inv.State, inv.Begin.Format(timelog.TimeFormat), inv.End.Format(timelog.TimeFormat), inv.Amount)
+		for _, code := range inv.Codes {
+			fmt.Fprintf(b, "\t%q", code)
+		}
+		b.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0666)
+}
+
+// Find returns the invoice with the given number, or nil.
+func (invoices Invoices) Find(number string) *Invoice {
+	for _, inv := range invoices {
+		if inv.Number == number {
+			return inv
+		}
+	}
+	return nil
+}
+
+// NextNumber returns the number for a new invoice. Invoices are numbered from 1 each year, eg "2026-001".
+func (invoices Invoices) NextNumber(now time.Time) string {
+	prefix := fmt.Sprintf("%04d-", now.Year())
+	next := 1
+	for _, inv := range invoices {
+		n, err := strconv.Atoi(strings.TrimPrefix(inv.Number, prefix))
+		if err == nil && strings.HasPrefix(inv.Number, prefix) && n >= next {
+			next = n + 1
+		}
+	}
+	return fmt.Sprintf("%s%03d", prefix, next)
+}
+
+// Overlapping returns the invoices that already charged for any of the given codes at some point in the given range.
+func (invoices Invoices) Overlapping(begin, end time.Time, codes []string) []*Invoice {
+	want := map[string]bool{}
+	for _, code := range codes {
+		want[code] = true
+	}
+
+	found := []*Invoice{}
+	for _, inv := range invoices {
+		if !inv.Begin.Before(end) || !begin.Before(inv.End) {
+			continue
+		}
+		for _, code := range inv.Codes {
+			if want[code] {
+				found = append(found, inv)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// String formats an invoice for listing.
+func (inv *Invoice) String() string {
+	return fmt.Sprintf("%s  %-5s  %s - %s  %s  [%s]", inv.Number, inv.State, inv.Begin.Format("2006/01/02"), inv.End.Format("2006/01/02"), inv.Amount, strings.Join(inv.Codes, ", "))
+}
+
+// formatCurrencies formats a set of per currency totals on one line, in currency order.
+func formatCurrencies(totals map[string]float64) string {
+	currencies := make([]string, 0, len(totals))
+	for c := range totals {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+
+	parts := make([]string, 0, len(currencies))
+	for _, c := range currencies {
+		parts = append(parts, report.FormatMoney(totals[c], c))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// InvoiceCommand handles the invoice ledger subcommands, returning false if the arguments aren't one of them (and so
+// are a request to generate a new invoice).
+func InvoiceCommand(path string, args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "list":
+	case "mark-sent", "mark-paid", "discard":
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "'invoice %s' needs exactly one invoice number.\n", args[0])
+			os.Exit(2)
+		}
+	default:
+		return false
+	}
+
+	invoices, err := LoadInvoices(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading invoice ledger:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(10)
+	}
+
+	if args[0] == "list" {
+		if len(invoices) == 0 {
+			fmt.Fprintln(os.Stderr, "No invoices yet.")
+			return true
+		}
+		for _, inv := range invoices {
+			fmt.Println(inv.String())
+		}
+		return true
+	}
+
+	inv := invoices.Find(args[1])
+	if inv == nil {
+		fmt.Fprintf(os.Stderr, "No invoice numbered %q.\n", args[1])
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "mark-sent":
+		if inv.State != "draft" {
+			fmt.Fprintf(os.Stderr, "Invoice %s is already %s.\n", inv.Number, inv.State)
+			os.Exit(1)
+		}
+		inv.State = "sent"
+	case "mark-paid":
+		if inv.State == "paid" {
+			fmt.Fprintf(os.Stderr, "Invoice %s is already paid.\n", inv.Number)
+			os.Exit(1)
+		}
+		inv.State = "paid"
+	case "discard":
+		if inv.State != "draft" {
+			fmt.Fprintf(os.Stderr, "Invoice %s has been %s, only drafts can be discarded.\n", inv.Number, inv.State)
+			os.Exit(1)
+		}
+		kept := Invoices{}
+		for _, other := range invoices {
+			if other != inv {
+				kept = append(kept, other)
+			}
+		}
+		invoices = kept
+	}
+
+	err = invoices.Save(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing invoice ledger:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(10)
+	}
+	if args[0] == "discard" {
+		fmt.Printf("Discarded invoice %s.\n", inv.Number)
+	} else {
+		fmt.Printf("Marked invoice %s as %s.\n", inv.Number, inv.State)
+	}
+	return true
+}
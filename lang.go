@@ -0,0 +1,79 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/markusmobius/go-dateparser"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Languages are the languages times are looked for in, from the languages config key. The first one is preferred
+// when more than one could make sense of the input.
+var Languages = []string{"en"}
+
+// SearchTimes finds every time in the text. dateparser only works in one language at a time, so the text is searched
+// in each of Languages, and the language that makes the longest first time out of it wins ("gestern um 17:00" over
+// just "17:00"). Ties go to the language listed first.
+func SearchTimes(text string) ([]dateparser.SearchResult, error) {
+	cfg := &dateparser.Configuration{
+		CurrentTime: Clock.Now().Local(),
+	}
+
+	var best []dateparser.SearchResult
+	for _, lang := range Languages {
+		times, err := DateParser.SearchWithLanguage(cfg, lang, text)
+		if err != nil {
+			return nil, err
+		}
+		if len(Languages) > 1 {
+			Debug.Debug("time language", "lang", lang, "found", len(times))
+		}
+		if len(times) > 0 && (len(best) == 0 || len(times[0].Text) > len(best[0].Text)) {
+			best = times
+		}
+	}
+	return best, nil
+}
+
+// codeFolds are letters spelled the way people type them on a keyboard that doesn't have them. Most of these don't come
+// apart into a base letter and a diacritic. The umlauts do, but "ue" is how they are written without one, and since
+// matching is fuzzy ":Munchen" still finds "Muenchen".
+var codeFolds = strings.NewReplacer("ä", "ae", "Ä", "ae", "ö", "oe", "Ö", "oe", "ü", "ue", "Ü", "ue",
+	"ß", "ss", "ẞ", "ss", "æ", "ae", "Æ", "ae", "œ", "oe", "Œ", "oe", "ø", "o", "Ø", "o",
+	"ł", "l", "Ł", "l", "đ", "d", "Đ", "d", "ı", "i", "þ", "th", "Þ", "th")
+
+// FoldCode reduces a time code (or what was typed for one) to a form for matching, so ":Munchen" finds "München" and
+// ":strasse" finds "Straße" no matter how either was typed. Compatibility characters (ligatures, full width letters)
+// are split up, diacritics are dropped, and everything is lower case.
+func FoldCode(code string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), codeFolds.Replace(norm.NFC.String(code)))
+	if err != nil {
+		return strings.ToLower(code)
+	}
+	return strings.ToLower(folded)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package locale
+
+// german is the German catalog. Tabs and padding in a message are there to line things up, so the translation keeps
+// them.
+var german = Catalog{
+	Weekdays: [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+	Days:     [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	Initials: [7]string{"M", "D", "M", "D", "F", "S", "S"},
+	Months: [12]string{
+		"Januar", "Februar", "März", "April", "Mai", "Juni",
+		"Juli", "August", "September", "Oktober", "November", "Dezember",
+	},
+	Short: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+
+	Messages: map[string]string{
+		// Table headings.
+		"Begin":  "Beginn",
+		"End":    "Ende",
+		"Length": "Dauer",
+		"Hours":  "Stunden",
+		"Code":   "Code",
+		"Desc":   "Beschreibung",
+		"Track":  "Spur",
+
+		// Reports.
+		"now":                         "jetzt",
+		"empty":                       "leer",
+		"Total":                       "Gesamt",
+		"Total:":                      "Gesamt:",
+		"Totals":                      "Summen",
+		"Combined:":                   "Zusammen:",
+		"Period: %s - ":               "Zeitraum: %s - ",
+		"Warning: %s":                 "Warnung: %s",
+		"%d week %d (%s)":             "%d Woche %d (%s)",
+		"No periods in week %d.":      "Keine Zeiten in Woche %d.",
+		"Schedule":                    "Soll",
+		"[%s] budget":                 "[%s] Budget",
+		"%s of %s":                    "%s von %s",
+		"%s to go":                    "noch %s",
+		"done":                        "erreicht",
+		"done, %s over":               "erreicht, %s darüber",
+		"Overtime: %s":                "Überstunden: %s",
+		"%s log: %s":                  "Log %s: %s",
+		"Billable:":                   "Abrechenbar:",
+		"Non-billable:":               "Nicht abrechenbar:",
+		"Utilization:":                "Auslastung:",
+		"billed %s":                   "abgerechnet %s",
+		"Rounded to %s.":              "Gerundet auf %s.",
+		"Unknown host":                "Unbekannter Rechner",
+		" (estimated %s)":             " (geschätzt %s)",
+		"Timesheet for approval":      "Stundenzettel zur Freigabe",
+		"Timesheet %s - ":             "Stundenzettel %s - ",
+		"Estimates":                   "Schätzungen",
+		"Deep work":                   "Konzentriertes Arbeiten",
+		"Plan vs actual":              "Plan und Ist",
+		"No plan to compare against.": "Kein Plan zum Vergleichen.",
+		"of %s":                       "von %s",
+		"%s left":                     "%s übrig",
+		"%s over":                     "%s darüber",
+		" (%s carried in)":            " (%s übernommen)",
+		"%s carries over":             "%s werden übertragen",
+		"Invoice %s":                  "Rechnung %s",
+		"Invoice for %s - %s":         "Rechnung für %s - %s",
+		"Time":                        "Zeit",
+		"Description":                 "Beschreibung",
+		"Attachments":                 "Anhänge",
+		"(missing)":                   "(fehlt)",
+		"Not rounded, use --round to set a billing unit.":                        "Nicht gerundet, mit --round lässt sich eine Abrechnungseinheit setzen.",
+		"No tasks with estimates, add them with --meta est=<duration>.":          "Keine Aufgaben mit Schätzung, sie werden mit --meta est=<Dauer> angegeben.",
+		"No expenses in this range, add them with 'expense'.":                    "Keine Ausgaben in diesem Zeitraum, sie werden mit 'expense' hinzugefügt.",
+		"No trips in this range, add them with 'travel'.":                        "Keine Fahrten in diesem Zeitraum, sie werden mit 'travel' hinzugefügt.",
+		"None of these periods are covered by a retainer.":                       "Keine dieser Zeiten fällt unter ein Kontingent.",
+		"Nothing to charge for, set a rate for your codes in the timecode file.": "Nichts abzurechnen, für die Codes kann in der Codedatei ein Stundensatz gesetzt werden.",
+
+		// Columns lined up with tabs.
+		"Code\tTask\tEstimate\tActual\tFactor\t":     "Code\tAufgabe\tSchätzung\tIst\tFaktor\t",
+		"Month\tTasks\tEstimate\tActual\tFactor\t":   "Monat\tAufgaben\tSchätzung\tIst\tFaktor\t",
+		"Day\tDeep\tShallow\tOther\tRatio\t\tTrend":  "Tag\tTief\tFlach\tAnderes\tAnteil\t\tTrend",
+		"Week\tDeep\tShallow\tOther\tRatio\t\tTrend": "Woche\tTief\tFlach\tAnderes\tAnteil\t\tTrend",
+		"Day\tCode\tPlanned\tActual\tSlip\t":         "Tag\tCode\tGeplant\tIst\tAbweichung\t",
+		"Total\tCode\tPlanned\tActual\tSlip\t":       "Gesamt\tCode\tGeplant\tIst\tAbweichung\t",
+		"Retainer [%s] %s:\t%s of %s used":           "Kontingent [%s] %s:\t%s von %s genutzt",
+		"[%s] overage\t%s\t@ %s/h\t%s":               "[%s] Mehrstunden\t%s\t@ %s/h\t%s",
+		"Expense [%s] %s\t%s\t\t%s":                  "Ausgabe [%s] %s\t%s\t\t%s",
+		"Travel [%s] %s\t%.1f %s\t@ %s/%s\t%s":       "Fahrt [%s] %s\t%.1f %s\t@ %s/%s\t%s",
+		"%s %g%% (included) on %s:\t\t\t%s":          "%s %g%% (enthalten) auf %s:\t\t\t%s",
+		"%s %g%% on %s:\t\t\t%s":                     "%s %g%% auf %s:\t\t\t%s",
+
+		// Padded so the lines match.
+		"Employee signature: ______________________________  Date: ____________": "Unterschrift Mitarbeiter: ______________________________  Datum: ____________",
+		"Approved by:        ______________________________  Date: ____________": "Freigegeben von:          ______________________________  Datum: ____________",
+
+		// Rest rules.
+		"Worked %s on %s, more than %s.":            "%s gearbeitet am %s, mehr als %s.",
+		"Worked %s in week %d of %d, more than %s.": "%s gearbeitet in Woche %d von %d, mehr als %s.",
+		"Only %s rest from %s to %s, less than %s.": "Nur %s Ruhezeit von %s bis %s, weniger als %s.",
+
+		// Running a report.
+		"No timecodes provided, using 'all'":                                   "Keine Codes angegeben, es wird 'all' verwendet",
+		"Timecodes: %v":                                                        "Codes: %v",
+		"Periods after: %v":                                                    "Zeiten nach: %v",
+		"Periods between: %v - %v":                                             "Zeiten zwischen: %v - %v",
+		"No periods in given time range.":                                      "Keine Zeiten im angegebenen Zeitraum.",
+		"Multiple templates found in input, using first one found.":            "Mehrere Vorlagen angegeben, es wird die erste verwendet.",
+		"Exchange rate file is missing some currencies, not combining totals.": "In der Wechselkursdatei fehlen Währungen, die Summen werden nicht zusammengefasst.",
+		"Events on the same track at the same time, fix them or set the overlappolicy config:":                                   "Ereignisse auf derselben Spur zur selben Zeit, bitte korrigieren oder overlappolicy setzen:",
+		"Warning: %d place(s) where events on the same track are at the same time, see overlappolicy. Run 'check' to list them.": "Warnung: %d Stelle(n) mit Ereignissen auf derselben Spur zur selben Zeit, siehe overlappolicy. 'check' listet sie auf.",
+		"Warning: the timelog has events in the future, %s after now was left out. Run 'check' to find them.":                    "Warnung: Das Zeitprotokoll enthält Ereignisse in der Zukunft, %s nach jetzt wurden weggelassen. 'check' findet sie.",
+		"%s has %d events at once: %s": "%s hat %d Ereignisse gleichzeitig: %s",
+
+		// Status.
+		"No events found.":     "Keine Ereignisse gefunden.",
+		"Starts in %s.":        "Beginnt in %s.",
+		"Open for %s.":         "Läuft seit %s.",
+		"Also open for %s: %s": "Läuft außerdem seit %s: %s",
+
+		// Standup and since-last.
+		"Nothing logged on %s.":                       "Nichts erfasst am %s.",
+		"Nothing logged since %s.":                    "Nichts erfasst seit %s.",
+		"Since %s, %s ago:":                           "Seit %s, vor %s:",
+		"First run, so this covers the last %d days.": "Erster Aufruf, daher die letzten %d Tage.",
+		"Total: %s":                                   "Gesamt: %s",
+
+		// Check.
+		"Event in the future: %s":          "Ereignis in der Zukunft: %s",
+		"Events at the same time: %s":      "Ereignisse zur selben Zeit: %s",
+		"No rest rules were broken.":       "Keine Ruhezeitregeln verletzt.",
+		"Missing attachment %s (%s) on %s": "Fehlender Anhang %s (%s) an %s",
+		"All %d attachment(s) are there.":  "Alle %d Anhänge sind vorhanden.",
+		"Nothing to check, set the restrules config, eg restrules=\"day=10h,rest=11h,week=48h\", or attach files to events.": "Nichts zu prüfen, restrules setzen, zB restrules=\"day=10h,rest=11h,week=48h\", oder Dateien an Ereignisse anhängen.",
+	},
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+// Package locale translates what timeclock prints. Messages are looked up by their English text, so anything without a
+// translation comes out in English, and a message only needs adding to a catalog to be translated.
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// Language is the language messages are translated into, set with Set.
+var Language = language.English
+
+// catalogs are the translations for each language other than English, by the English text.
+var catalogs = map[language.Tag]*Catalog{
+	language.German: &german,
+}
+
+// Catalog is the translation of every message for one language.
+type Catalog struct {
+	Messages map[string]string
+
+	Weekdays [7]string  // Sunday first, like time.Weekday.
+	Days     [7]string  // Short weekday names, for "Mon" in a date layout.
+	Initials [7]string  // Monday first, for the heading of a week.
+	Months   [12]string // January first.
+	Short    [12]string // Short month names, for "Jan" in a date layout.
+}
+
+// Supported are the languages there are translations for, English first.
+func Supported() []language.Tag {
+	out := []language.Tag{}
+	for tag := range catalogs {
+		out = append(out, tag)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].String() < out[j].String()
+	})
+	return append([]language.Tag{language.English}, out...)
+}
+
+// Set picks the language to use from a language tag, such as "de" or "de-AT". POSIX locale names like "de_DE.UTF-8" work
+// too, and a blank name is English. The closest supported language is used, it is an error if nothing is close.
+func Set(name string) error {
+	name, _, _ = strings.Cut(name, ".")
+	name = strings.ReplaceAll(name, "_", "-")
+	if name == "" || name == "C" || name == "POSIX" {
+		Language = language.English
+		return nil
+	}
+
+	tag, err := language.Parse(name)
+	if err != nil {
+		return fmt.Errorf("unknown language %q", name)
+	}
+	supported := Supported()
+	_, i, confidence := language.NewMatcher(supported).Match(tag)
+	if confidence == language.No {
+		names := []string{}
+		for _, s := range supported {
+			names = append(names, s.String())
+		}
+		return fmt.Errorf("no translation for %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	Language = supported[i]
+	return nil
+}
+
+// T translates a message, then formats it with the arguments like fmt.Sprintf. Without arguments the message is used
+// as is, so it may have a % in it.
+func T(message string, args ...any) string {
+	if c, ok := catalogs[Language]; ok {
+		if translated, ok := c.Messages[message]; ok {
+			message = translated
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Date formats a time like time.Format, but with the day and month names translated.
+func Date(t time.Time, layout string) string {
+	out := t.Format(layout)
+	c, ok := catalogs[Language]
+	if !ok {
+		return out
+	}
+
+	// Long names go first, or "Monday" would become the short name with "day" after it.
+	pairs := []string{}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		pairs = append(pairs, d.String(), c.Weekdays[d])
+	}
+	for m := time.January; m <= time.December; m++ {
+		pairs = append(pairs, m.String(), c.Months[m-1])
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		pairs = append(pairs, d.String()[:3], c.Days[d])
+	}
+	for m := time.January; m <= time.December; m++ {
+		pairs = append(pairs, m.String()[:3], c.Short[m-1])
+	}
+	return strings.NewReplacer(pairs...).Replace(out)
+}
+
+// Initials are the first letters of the days of the week, Monday first, for the heading of a week.
+func Initials() []string {
+	if c, ok := catalogs[Language]; ok {
+		return c.Initials[:]
+	}
+	return []string{"M", "T", "W", "T", "F", "S", "S"}
+}
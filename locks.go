@@ -0,0 +1,122 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Lock is a finalized range of the timelog. Events in it can't be changed, added, or removed.
+type Lock struct {
+	Begin time.Time
+	End   time.Time
+	At    time.Time // When it was finalized.
+}
+
+// Locks are all the finalized ranges, see LoadLocks.
+//
+// The lock file has a line for each lock, with the begin, end, and finalized time separated by tabs. Delete a line to
+// unlock that range again.
+type Locks []Lock
+
+// LoadLocks reads the lock file. A missing file has no locks.
+func LoadLocks(path string) (Locks, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Locks{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	locks := Locks{}
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %v: expected 3 fields, found %v", i+1, len(fields))
+		}
+		times := [3]time.Time{}
+		for j, f := range fields {
+			times[j], err = time.ParseInLocation(timelog.TimeFormat, f, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %w", i+1, err)
+			}
+		}
+		locks = append(locks, Lock{Begin: times[0], End: times[1], At: times[2]})
+	}
+	return locks, nil
+}
+
+// Save writes the lock file.
+func (locks Locks) Save(path string) error {
+	b := &strings.Builder{}
+	for _, l := range locks {
+		fmt.Fprintf(b, "%s\t%s\t%s\n", l.Begin.Format(timelog.TimeFormat), l.End.Format(timelog.TimeFormat), l.At.Format(timelog.TimeFormat))
+	}
+	return os.WriteFile(path, []byte(b.String()), 0666)
+}
+
+// Locked returns the events in each locked range, as timelog text. Comparing this from before and after a change shows
+// which locks the change touched, see Changed.
+func (locks Locks) Locked(log timelog.TimeLog) []string {
+	texts := make([]string, len(locks))
+	for i, l := range locks {
+		locked := timelog.TimeLog{}
+		for _, e := range log {
+			if !e.At.Before(l.Begin) && e.At.Before(l.End) {
+				locked = append(locked, e)
+			}
+		}
+		b := &strings.Builder{}
+		locked.Format(b)
+		texts[i] = b.String()
+	}
+	return texts
+}
+
+// Changed returns the locks whose events are different in the log now than they were in the texts from Locked.
+func (locks Locks) Changed(log timelog.TimeLog, before []string) Locks {
+	changed := Locks{}
+	for i, text := range locks.Locked(log) {
+		if text != before[i] {
+			changed = append(changed, locks[i])
+		}
+	}
+	return changed
+}
+
+// String formats a lock for messages.
+func (l Lock) String() string {
+	return fmt.Sprintf("%s - %s", l.Begin.Format(timelog.TimeFormat), l.End.Format(timelog.TimeFormat))
+}
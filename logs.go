@@ -0,0 +1,75 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// ParseLogs parses the logs config, a comma separated list of name=path pairs, eg
+// "client=$HOME/client.log,personal=$HOME/sctime.log". The names end up as track names, see timelog.MergeLogs, so
+// they follow the same rules.
+func ParseLogs(config string) (map[string]string, error) {
+	logs := map[string]string{}
+	for _, pair := range strings.Split(config, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, path, ok := strings.Cut(pair, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("expected name=path, not %q", pair)
+		}
+		if strings.ContainsAny(name, " \t\r\n[/") {
+			return nil, fmt.Errorf("log names may not contain white space, '[', or '/', not %q", name)
+		}
+		logs[name] = path
+	}
+	return logs, nil
+}
+
+// LoadLogs reads the logs with the given names and merges them, see timelog.MergeLogs. Unlike the main log, a log
+// asked for by name has to exist.
+func LoadLogs(logs map[string]string, names []string) (timelog.TimeLog, error) {
+	named := []timelog.NamedLog{}
+	for _, name := range names {
+		path, ok := logs[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown log %q", name)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("log %q: %w", name, err)
+		}
+		log, err := ReadTimelogFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("log %q: %w", name, err)
+		}
+		named = append(named, timelog.NamedLog{Name: name, Log: log})
+	}
+	return timelog.MergeLogs(named), nil
+}
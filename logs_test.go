@@ -0,0 +1,99 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLogs(t *testing.T) {
+	tests := []struct {
+		config string
+		want   map[string]string // nil for an error.
+	}{
+		{"", map[string]string{}},
+		{"client=/tmp/client.log", map[string]string{"client": "/tmp/client.log"}},
+		{" client = /tmp/client.log , personal=/home/me/sctime.log,", map[string]string{"client": "/tmp/client.log", "personal": "/home/me/sctime.log"}},
+		{"path=/tmp/a=b.log", map[string]string{"path": "/tmp/a=b.log"}},
+		{"client", nil},
+		{"=/tmp/client.log", nil},
+		{"client=", nil},
+		{"my client=/tmp/client.log", nil},
+		{"client/dev=/tmp/client.log", nil},
+		{"[client=/tmp/client.log", nil},
+	}
+	for _, test := range tests {
+		got, err := ParseLogs(test.config)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("ParseLogs(%q) = %v, want an error", test.config, got)
+			}
+			continue
+		}
+		if err != nil || len(got) != len(test.want) {
+			t.Errorf("ParseLogs(%q) = %v (%v), want %v", test.config, got, err, test.want)
+			continue
+		}
+		for name, path := range test.want {
+			if got[name] != path {
+				t.Errorf("ParseLogs(%q) has %s=%s, want %s", test.config, name, got[name], path)
+			}
+		}
+	}
+}
+
+func TestLoadLogs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	logs := map[string]string{
+		"client":   write("client.log", "2026/10/12 09:00AM [Acme] work\n2026/10/12 11:00AM\n"),
+		"personal": write("personal.log", "2026/10/12 10:00AM [Reading]\n2026/10/12 10:30AM\n"),
+		"missing":  filepath.Join(dir, "missing.log"),
+		"broken":   write("broken.log", "not a timelog\n"),
+	}
+
+	merged, err := LoadLogs(logs, []string{"client", "personal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 4 || merged[1].Code != "Reading" || merged[1].Meta["log"] != "personal" {
+		t.Errorf("merged log is:\n%s", merged)
+	}
+
+	for name, want := range map[string]string{"nope": "unknown log", "missing": `log "missing"`, "broken": `log "broken"`} {
+		_, err := LoadLogs(logs, []string{"client", name})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loading %s gave %v, want an error with %q", name, err, want)
+		}
+	}
+}
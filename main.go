@@ -23,13 +23,13 @@ misrepresented as being the original software.
 package main
 
 import (
-	"embed"
 	"errors"
 	"fmt"
 	"io"
-	"io/fs"
 	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -38,122 +38,268 @@ import (
 	"time"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
-	"github.com/manifoldco/promptui"
 	"github.com/markusmobius/go-dateparser"
-	"github.com/snabb/isoweek"
 
+	"github.com/milochristiansen/timeclock/locale"
+	"github.com/milochristiansen/timeclock/report"
 	"github.com/milochristiansen/timeclock/timelog"
+	"github.com/milochristiansen/timeclock/timelog/replica"
 )
 
 // Exit Codes:
 // 0: OK
 // 1: General error
 // 2: Invalid argument count
+// 3: Input required, but running non-interactively
 //
 // 5: Invalid environment
 // 6: Could not find/read config file
 // 7: Could not find/read timecode file
 // 8: Could not find/read timelog file
 // 9: Could not find/read report file
+// 10: Could not read/write invoice ledger
 
-//go:embed reports/*
-var builtinReports embed.FS
+// TemplateData is what report templates are given, the report and the invoice being generated, if any.
+type TemplateData struct {
+	*report.ReportData
+	Invoice *Invoice // The invoice being generated, nil for plain reports.
+}
 
-type ReportData struct {
-	Begin   *time.Time
-	End     *time.Time
-	Periods []*timelog.Period
-	Totals  map[string]time.Duration
+// Durations is the style used to display durations, set from the config and the --durations flag.
+var Durations = timelog.DurationDecimal
 
-	Weeks []*ReportWeek
-}
+// OverlapPolicy is what is done with events on the same track at the same time, set from the overlappolicy config.
+var OverlapPolicy = timelog.OverlapClip
 
-type ReportWeek struct {
-	Year     int // 4 digit year
-	Number   int // ISO Week number
-	FirstDay *time.Time // Date of first monday of week.
+// Calendar is when days and weeks start, set from the weekstart and daystart config.
+var Calendar = timelog.Calendar{WeekStart: time.Monday}
 
-	Periods []*timelog.Period
+// FoldCodeCase is true if codes that only differ in case are the same code, set from the foldcodecase config.
+var FoldCodeCase = true
 
-	Totals map[string][8]time.Duration // Mon-Sun, plus week total
-	Daily  [8]time.Duration            // Totals for all codes
-}
+// Clock is where the time now comes from, for reading times, working out ranges, and how long the current event has
+// been going. Things like backup file names use the real time no matter what.
+var Clock timelog.Clock = timelog.SystemClock{}
+
+// Choose is the time code candidate to use when there is more than one, set with the --choose flag. Starts from 1, 0
+// means not set.
+var Choose = 0
+
+// EventMeta is the metadata given to new events, from the meta config key and the --meta flag.
+var EventMeta = map[string]string{}
+
+// Track is the track commands work on, set with the --track flag. Blank is the main track.
+var Track = ""
 
 func main() {
+	// Global flags are pulled out before anything else looks at the arguments.
+	var durationsFlag, chooseFlag string
+	os.Args, durationsFlag = TakeFlagValue(os.Args, "--durations")
+	os.Args, chooseFlag = TakeFlagValue(os.Args, "--choose")
+	os.Args, Track = TakeFlagValue(os.Args, "--track")
+	var metaFlags []string
+	os.Args, metaFlags = TakeFlagValues(os.Args, "--meta")
+	var nonInteractive bool
+	os.Args, nonInteractive = TakeFlag(os.Args, "--non-interactive")
+	var allowBackdate bool
+	os.Args, allowBackdate = TakeFlag(os.Args, "--allow-backdate")
+	var yesFlag bool
+	os.Args, yesFlag = TakeFlag(os.Args, "--yes")
+	var logfileFlag string
+	os.Args, logfileFlag = TakeFlagValue(os.Args, "--logfile")
+	var configFlag string
+	os.Args, configFlag = TakeFlagValue(os.Args, "--config")
+	var strictFlag bool
+	os.Args, strictFlag = TakeFlag(os.Args, "--strict")
+	var debugFlag bool
+	var debugFile string
+	os.Args, debugFlag = TakeFlag(os.Args, "--debug")
+	os.Args, debugFile = TakeFlagValue(os.Args, "--debug-file")
+	var nowFlag, todayFlag string
+	os.Args, nowFlag = TakeFlagValue(os.Args, "--now")
+	os.Args, todayFlag = TakeFlagValue(os.Args, "--today")
+
+	switch {
+	case debugFile != "":
+		file, err := os.OpenFile(debugFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening debug file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		EnableDebug(file)
+	case debugFlag:
+		EnableDebug(os.Stderr)
+	}
+	Debug.Debug("starting", "args", os.Args)
+
+	// Pin the clock first, everything after this point asks it what time it is.
+	if nowFlag != "" || todayFlag != "" {
+		frozen, ok := FreezeTime(nowFlag, todayFlag, time.Now())
+		if !ok {
+			fmt.Fprintln(os.Stderr, "--now needs a date and time like 2026/10/14 09:15 or 2026-10-14T09:15:00Z, a time like 9:15AM,")
+			fmt.Fprintln(os.Stderr, "or a duration like -2h. --today needs a date like 2026/10/14 or 2026-10-14.")
+			os.Exit(2)
+		}
+		Clock = timelog.FixedClock(frozen)
+		Debug.Debug("clock frozen", "now", frozen.Format(time.RFC3339))
+	}
+
 	if len(os.Args) < 2 {
-		// Make this smarter? Write or find a formatter that can wrap text with indentation based on current terminal width.
 		fmt.Fprintln(os.Stderr, "No arguments provided. Cannot determine action.")
 		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "'time'")
-		fmt.Fprintln(os.Stderr, "   Edit last event time, provide a time as an argument.")
-		fmt.Fprintln(os.Stderr, "'code'")
-		fmt.Fprintln(os.Stderr, "    Edit last event time code, provide new code as an argument.")
-		fmt.Fprintln(os.Stderr, "    Timecodes may not contain spaces!")
-		fmt.Fprintln(os.Stderr, "'desc' or 'note'")
-		fmt.Fprintln(os.Stderr, "    Edit last event description, provide new description as an argument.")
-		fmt.Fprintln(os.Stderr, "'status'")
-		fmt.Fprintln(os.Stderr, "    Prints the current last event.")
-		fmt.Fprintln(os.Stderr, "'since'")
-		fmt.Fprintln(os.Stderr, "    Prints the time elapsed since the current last event.")
-		fmt.Fprintln(os.Stderr, "'report'")
-		fmt.Fprintln(os.Stderr, "    Print a report. You must provide a time to set the start point for the")
-		fmt.Fprintln(os.Stderr, "    report. Optionally, you may also provide a time code to limit the report")
-		fmt.Fprintln(os.Stderr, "    to only events that match the time code. If no code is provided, the 'all'")
-		fmt.Fprintln(os.Stderr, "    code is automatically added.")
-		fmt.Fprintln(os.Stderr, "    The special hardcoded timecode 'empty' may be used to output periods that")
-		fmt.Fprintln(os.Stderr, "    have a blank timecode, and the code 'all' will output all periods that")
-		fmt.Fprintln(os.Stderr, "    have a non-blank timecode.")
-		fmt.Fprintln(os.Stderr, "    To actually see all events, you must use 'empty' and 'all' together!")
-		fmt.Fprintln(os.Stderr, "'info'")
-		fmt.Fprintln(os.Stderr, "    List all known time codes.")
-		fmt.Fprintln(os.Stderr, "'test'")
-		fmt.Fprintln(os.Stderr, "    Process all following input as if you were creating an event, but don't")
-		fmt.Fprintln(os.Stderr, "    actually write anything to the timelog.")
-		fmt.Fprintln(os.Stderr, "No command word.")
-		fmt.Fprintln(os.Stderr, "    Create a new event. The entire command line is used to define the event.")
-		fmt.Fprintln(os.Stderr, "    To be valid, all that is required is a time. If the time and/or time code")
-		fmt.Fprintln(os.Stderr, "    are the first things on the command line they will be stripped and the")
-		fmt.Fprintln(os.Stderr, "    remaining text will be used as the description. If they are embedded in")
-		fmt.Fprintln(os.Stderr, "    the main body of the text, then the whole text is used unmodified. To")
-		fmt.Fprintln(os.Stderr, "    define a new code, create the event, then set the code with 'code'.")
+		PrintCommands(os.Stderr, helpWidth())
 		os.Exit(2)
 	}
 
+	// Help, docs, and the version don't need the config or the timelog, so they go before either is loaded.
+	switch {
+	case os.Args[1] == "help":
+		HelpCommand(os.Args[2:])
+		return
+	case len(os.Args) == 3 && os.Args[2] == "--help" && CommandFor(os.Args[1]) != "":
+		HelpCommand(os.Args[1:2])
+		return
+	case os.Args[1] == "completion":
+		CompletionCommand(os.Args[2:])
+		return
+	case os.Args[1] == "docs":
+		DocsCommand(os.Args[2:])
+		return
+	case os.Args[1] == "version" || os.Args[1] == "--version":
+		VersionCommand()
+		return
+	}
+	command := CommandFor(os.Args[1])
+
 	ToolMode := false
 	if os.Args[0] == "timetool" {
 		ToolMode = true
 	}
 
-	// Find/create the configuration directory.
-	configdir, ok := os.LookupEnv("XDG_CONFIG_HOME")
-	if !ok || configdir == "" {
-		home, ok := os.LookupEnv("HOME")
-		if !ok || home == "" {
-			fmt.Fprintln(os.Stderr, "Both XDG_CONFIG_HOME and HOME do not exist or are invalid.")
-			os.Exit(5)
+	if strings.ContainsAny(Track, " \t\r\n[") {
+		fmt.Fprintln(os.Stderr, "--track names may not contain white space or '['.")
+		os.Exit(2)
+	}
+
+	if chooseFlag != "" {
+		var err error
+		Choose, err = strconv.Atoi(chooseFlag)
+		if err != nil || Choose < 1 {
+			fmt.Fprintln(os.Stderr, "--choose needs a number, starting from 1.")
+			os.Exit(2)
 		}
+	}
 
-		configdir = home + "/.config"
+	// Prompting when stdin isn't a terminal just hangs or fails in confusing ways.
+	Interactive = !ToolMode && !nonInteractive && StdinIsTerminal()
+
+	// Find/create the configuration directory. A config file given with --config or SCTIME_CONFIG is used as is, and
+	// its directory is $CONFIG.
+	if configFlag == "" {
+		configFlag = os.Getenv("SCTIME_CONFIG")
 	}
-	configdir += "/sctime"
+	var configdir, configfile string
+	if configFlag != "" {
+		configfile = configFlag
+		configdir = filepath.Dir(configFlag)
+	} else {
+		var ok bool
+		configdir, ok = os.LookupEnv("XDG_CONFIG_HOME")
+		if !ok || configdir == "" {
+			home, ok := os.LookupEnv("HOME")
+			if !ok || home == "" {
+				fmt.Fprintln(os.Stderr, "Both XDG_CONFIG_HOME and HOME do not exist or are invalid.")
+				os.Exit(5)
+			}
 
-	err := os.MkdirAll(configdir, 0777)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error ensuring existence of config directory:")
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(6)
+			configdir = home + "/.config"
+		}
+		configdir += "/sctime"
+		configfile = configdir + "/config.ini"
+
+		err := os.MkdirAll(configdir, 0777)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error ensuring existence of config directory:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(6)
+		}
+	}
+
+	// And the state directory, for things that can be thrown away, like caches. This is only created when something
+	// is actually written to it.
+	statedir, ok := os.LookupEnv("XDG_STATE_HOME")
+	if !ok || statedir == "" {
+		statedir = os.Getenv("HOME") + "/.local/state"
 	}
+	statedir += "/sctime"
 
 	// Load the config file
 	config := map[string]string{
-		"logfile":    "$HOME/sctime.log",
-		"reportsdir": "$CONFIG/reports",
+		"logfile":        "$HOME/sctime.log",
+		"reportsdir":     "$CONFIG/reports",
+		"durations":      "decimal",
+		"ordering":       "warn",
+		"overlap":        "full",
+		"transforms":     "",
+		"meta":           "",
+		"stamphost":      "false",
+		"strict":         "false",
+		"surprise":       "12h",
+		"anchor":         "today",
+		"languages":      "en",
+		"codefile":       "$CONFIG/codes.ini",
+		"ratesfile":      "$CONFIG/exchange.ini",
+		"invoicefile":    "$CONFIG/invoices.log",
+		"exportfile":     "$CONFIG/exports.ini",
+		"cachefile":      "$STATE/totals.json",
+		"archives":       "",
+		"discover":       "false",
+		"gitcodes":       "",
+		"gitrepos":       "",
+		"browserhistory": "",
+		"restrules":      "",
+		"lockfile":       "$CONFIG/locks.log",
+		"stagingfile":    "$CONFIG/staging.log",
+		"syncfile":       "$CONFIG/sync.ini",
+		"syncstate":      "$STATE/sync.json",
+		"replica":        "",
+		"device":         "",
+		"planfile":       "$CONFIG/plan.log",
+		"schedule":       "",
+		"daystart":       "",
+		"expensefile":    "$CONFIG/expenses.log",
+		"travelfile":     "$CONFIG/travel.log",
+		"distanceunit":   "km",
+		"tablewidth":     "",
+		"foldcodecase":   "true",
+		"capnow":         "true",
+		"overlappolicy":  "clip",
+		"language":       "en",
+		"profile":        "",
+		"profilefile":    "$CONFIG/profiles.ini",
+		"weekstart":      "monday",
+		"overtime":       "",
+		"timesheet":      "",
+		"csvcolumns":     "begin,end,duration,code,desc",
+		"csvdelimiter":   ",",
+		"logs":           "",
+		"sincelastfile":  "$STATE/since-last.json",
+		"serveaddr":      "127.0.0.1:8737",
+		"servetoken":     "",
+		"servetokenfile": "$STATE/serve-token",
 	}
+	defaults := maps.Clone(config)
 
-	configraw, err := os.ReadFile(configdir + "/config.ini")
-	if errors.Is(err, os.ErrNotExist) {
+	configraw, err := os.ReadFile(configfile)
+	if errors.Is(err, os.ErrNotExist) && configFlag != "" {
+		// Writing defaults somewhere the user pointed us is more likely to hide a typo than help.
+		fmt.Fprintf(os.Stderr, "Config file %q does not exist.\n", configfile)
+		os.Exit(6)
+	} else if errors.Is(err, os.ErrNotExist) {
 		fmt.Fprintln(os.Stderr, "Config file does not exist, writing defaults.")
-		file, err := os.Create(configdir + "/config.ini")
+		file, err := os.Create(configfile)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Error opening config file for writing:")
 			fmt.Fprintln(os.Stderr, err)
@@ -180,293 +326,2090 @@ func main() {
 	}
 
 	ParseINI(string(configraw), config)
+	Debug.Debug("read config", "file", configfile)
 
+	// Every key can be overridden from the environment, eg SCTIME_LOGFILE, so scripts and per-project setups don't
+	// need their own config file. These are expanded just like values from the file.
+	fromenv := map[string]bool{}
 	for k := range config {
-		config[k] = os.Expand(config[k], func(s string) string {
-			if s == "CONFIG" {
-				return configdir
+		if v, ok := os.LookupEnv("SCTIME_" + strings.ToUpper(k)); ok {
+			Debug.Debug("config from environment", "key", k, "value", v)
+			config[k] = v
+			fromenv[k] = true
+		}
+	}
+
+	// With discover on, a .sctime file in the current directory or above goes between the config file and the
+	// environment.
+	discover, err := strconv.ParseBool(config["discover"])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid discover config %q, expected true or false.\n", config["discover"])
+		os.Exit(6)
+	}
+	var project *Project
+	if discover {
+		wd, err := os.Getwd()
+		if err == nil {
+			project, err = FindProject(wd)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error looking for a .sctime file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(6)
+		}
+	}
+	if project != nil {
+		Debug.Debug("read project config", "file", project.Path)
+		DefaultCode = project.Config["code"]
+		for k, v := range project.Config {
+			if _, ok := config[k]; !ok {
+				if k != "code" {
+					fmt.Fprintf(os.Stderr, "Unknown key %q in %s, ignoring it.\n", k, project.Path)
+				}
+				continue
 			}
-			return os.Getenv(s)
-		})
+			if !fromenv[k] {
+				config[k] = v
+			}
+		}
 	}
 
-	// Now on to our regularly scheduled program
+	expandvar := func(s string) string {
+		switch s {
+		case "CONFIG":
+			return configdir
+		case "STATE":
+			return statedir
+		case "PROJECT":
+			if project != nil {
+				return project.Dir()
+			}
+		}
+		return os.Getenv(s)
+	}
+
+	// An employer profile fills in the keys its timesheet rules need, but only the ones still at their defaults, so
+	// anything set in the config file, the environment, or a .sctime file wins.
+	if name := config["profile"]; name != "" {
+		profileraw, err := os.ReadFile(os.Expand(config["profilefile"], expandvar))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "Error reading profile file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(6)
+		}
+		profiles := Profiles(string(profileraw))
+		settings, ok := profiles[name]
+		if !ok {
+			names := []string{}
+			for name := range profiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Fprintf(os.Stderr, "Unknown profile %q, expected one of: %s\n", name, strings.Join(names, ", "))
+			os.Exit(6)
+		}
+		Debug.Debug("using profile", "name", name)
+		for k, v := range settings {
+			if _, ok := config[k]; !ok || k == "profile" || k == "profilefile" {
+				fmt.Fprintf(os.Stderr, "Unknown key %q in profile %q, ignoring it.\n", k, name)
+				continue
+			}
+			if !fromenv[k] && config[k] == defaults[k] {
+				config[k] = v
+			}
+		}
+	}
 
-	// Open the timesheet
-	sheetF, err := os.OpenFile(config["logfile"], os.O_RDWR|os.O_CREATE, 0644)
+	if durationsFlag != "" {
+		config["durations"] = durationsFlag
+	}
+	Durations, err = timelog.ParseDurationStyle(config["durations"])
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(8)
+		os.Exit(6)
 	}
-	defer sheetF.Close()
 
-	content, err := io.ReadAll(sheetF)
+	Strict, err = strconv.ParseBool(config["strict"])
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(8)
+		fmt.Fprintf(os.Stderr, "Invalid strict config %q, expected true or false.\n", config["strict"])
+		os.Exit(6)
 	}
+	Strict = Strict || strictFlag
 
-	log, err := timelog.ParseTimeLogString(string(content))
+	FoldCodeCase, err = strconv.ParseBool(config["foldcodecase"])
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(8)
+		fmt.Fprintf(os.Stderr, "Invalid foldcodecase config %q, expected true or false.\n", config["foldcodecase"])
+		os.Exit(6)
 	}
-	log.Sort()
 
-	// Load the timecodes from the timelog.
-	codes := log.Codes()
+	capNow, err := strconv.ParseBool(config["capnow"])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid capnow config %q, expected true or false.\n", config["capnow"])
+		os.Exit(6)
+	}
 
-	// Create a timecode tree for hierarchical filtering.
-	codetree := timelog.GenerateTimecodeTree(codes)
+	err = locale.Set(config["language"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid language config:", err)
+		os.Exit(6)
+	}
 
-	// Reporting
-	if os.Args[1] == "report" {
-		// Load the templates
-		templates := template.New("")
-		loadTemplatesFrom(builtinReports, templates)
-		loadTemplatesFrom(os.DirFS(config["reportsdir"]), templates)
+	OverlapPolicy, err = timelog.ParseOverlapPolicy(config["overlappolicy"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid overlappolicy config:", err)
+		os.Exit(6)
+	}
 
-		begin, end, fcode, template := ParseReportRequest(os.Args[2:], append(codes, "empty", "all"), templates)
+	if config["anchor"] != "today" && config["anchor"] != "last" {
+		fmt.Fprintf(os.Stderr, "Invalid anchor config %q, expected today or last.\n", config["anchor"])
+		os.Exit(6)
+	}
 
-		var all []*timelog.Period
-		if end == nil {
-			all = log.After(*begin).Periods()
-		} else {
-			all = log.Between(*begin, *end).Periods()
+	Languages = nil
+	for _, lang := range strings.Split(config["languages"], ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			Languages = append(Languages, lang)
 		}
+	}
+	if len(Languages) == 0 {
+		fmt.Fprintln(os.Stderr, "The languages config is empty, it needs at least one language, eg \"en\".")
+		os.Exit(6)
+	}
 
-		if len(fcode) == 0 {
-			fcode = append(fcode, "all")
-			fmt.Fprintln(os.Stderr, "No timecodes provided, using 'all'")
-		} else {
-			fmt.Fprintf(os.Stderr, "Timecodes: %v\n", strings.Join(fcode, ", "))
-		}
+	SurpriseWindow, err = time.ParseDuration(config["surprise"])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid surprise config %q, expected a duration like 12h, or 0 to turn it off.\n", config["surprise"])
+		os.Exit(6)
+	}
 
-		var periods []*timelog.Period
-		for _, code := range fcode {
-			if code == "empty" {
-				periods = append(periods, timelog.FilterInPeriods(all, "")...)
-				all = timelog.FilterOutPeriods(all, "")
-				continue
-			}
-			if code == "all" {
-				periods = append(periods, timelog.FilterOutPeriods(all, "")...)
-				all = timelog.FilterInPeriods(all, "")
-				continue
-			}
+	switch config["ordering"] {
+	case "error", "warn", "sort":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown ordering %q, expected 'error', 'warn', or 'sort'.\n", config["ordering"])
+		os.Exit(6)
+	}
 
-			code, hasWildcard := strings.CutSuffix(code, ":...")
+	rest, err := timelog.ParseRestRules(config["restrules"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid restrules config:", err)
+		os.Exit(6)
+	}
 
-			if hasWildcard {
-				periods = append(periods, timelog.FilterInPeriodsChildren(all, code, codetree)...)
-				continue
-			}
-			periods = append(periods, timelog.FilterInPeriods(all, code)...)
-			all = timelog.FilterOutPeriods(all, code)
-		}
+	Calendar.WeekStart, err = timelog.ParseWeekday(config["weekstart"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid weekstart config:", err)
+		os.Exit(6)
+	}
 
-		// Since the way we build the event list leaves them in whatever jumbled up order they happen to end up in, sort.
-		sort.Slice(periods, func(i, j int) bool {
-			return periods[i].Begin.Before(periods[j].Begin)
-		})
+	overtime, err := timelog.ParseOvertime(config["overtime"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid overtime config:", err)
+		os.Exit(6)
+	}
 
-		if end == nil {
-			fmt.Fprintf(os.Stderr, "Periods after: %v\n", begin.Format(timelog.TimeFormat))
-		} else {
-			fmt.Fprintf(os.Stderr, "Periods between: %v - %v\n", begin.Format(timelog.TimeFormat), end.Format(timelog.TimeFormat))
+	var schedule time.Duration
+	if config["schedule"] != "" {
+		schedule, err = time.ParseDuration(config["schedule"])
+		if err != nil || schedule <= 0 {
+			fmt.Fprintf(os.Stderr, "Invalid schedule config %q, expected the hours you work a week, like 40h.\n", config["schedule"])
+			os.Exit(6)
 		}
+	}
 
-		if len(periods) == 0 {
-			fmt.Fprintln(os.Stderr, "No periods in given time range.")
-			return
+	if config["daystart"] != "" {
+		Calendar.DayStart, err = time.ParseDuration(config["daystart"])
+		if err != nil || Calendar.DayStart < 0 || Calendar.DayStart >= 12*time.Hour || Calendar.DayStart%time.Minute != 0 {
+			fmt.Fprintf(os.Stderr, "Invalid daystart config %q, expected the time after midnight a day starts, like 4h.\n", config["daystart"])
+			os.Exit(6)
 		}
+	}
+
+	if config["distanceunit"] != "km" && config["distanceunit"] != "mi" {
+		fmt.Fprintf(os.Stderr, "Invalid distanceunit config %q, expected km or mi.\n", config["distanceunit"])
+		os.Exit(6)
+	}
 
-		running := map[string]time.Duration{}
-		for _, p := range periods {
-			running[p.Code] += p.Length()
+	tablewidth := 0
+	if config["tablewidth"] != "" {
+		tablewidth, err = strconv.Atoi(config["tablewidth"])
+		if err != nil || tablewidth < 0 {
+			fmt.Fprintf(os.Stderr, "Invalid tablewidth config %q, expected a number of columns, or 0 to not fit tables.\n", config["tablewidth"])
+			os.Exit(6)
 		}
+	}
 
-		// Now, generate the week data
-		weeks := []*ReportWeek{}
-		var cw *ReportWeek
-		for _, p := range periods {
-			cy, cwn := p.Begin.ISOWeek()
-			if cw == nil || cwn != cw.Number || cy != cw.Year {
-				fd := isoweek.StartTime(p.Begin.Year(), cwn, time.Local)
-				cw = &ReportWeek{Year: cy, Number: cwn, FirstDay: &fd, Totals: map[string][8]time.Duration{}}
-				weeks = append(weeks, cw)
-			}
+	GitCodes, err = ParseGitCodes(config["gitcodes"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid gitcodes config:", err)
+		os.Exit(6)
+	}
 
-			cw.Periods = append(cw.Periods, p)
-			d := p.Begin.Weekday() - 1
-			if d < 0 {
-				d = 6
-			}
-			v := cw.Totals[p.Code]
-			v[d] = v[d] + p.Length()
-			v[7] = v[7] + p.Length()
-			cw.Totals[p.Code] = v
-			cw.Daily[d] = cw.Daily[d] + p.Length()
-			cw.Daily[7] = cw.Daily[7] + p.Length()
+	for k := range config {
+		config[k] = os.Expand(config[k], expandvar)
+	}
+	if logfileFlag != "" {
+		config["logfile"] = logfileFlag
+	}
+
+	// Anything after 'plan' works on the plan file instead of the timelog, other than 'plan week' which compares them.
+	// A plan is all about other days and gets filled in any order, so it makes no sense to question times for not being
+	// today or to worry about them being before the last event.
+	actualfile := config["logfile"]
+	planning := command == "plan" && len(os.Args) > 2 && os.Args[2] != "week"
+	if planning {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		command = CommandFor(os.Args[1])
+		config["logfile"] = config["planfile"]
+		config["replica"] = ""
+		SurpriseWindow = 0
+		allowBackdate = true
+	}
+
+	// Metadata for new events, from the config with the flags on top. Blank values from the config are skipped, so
+	// environment variables that aren't set don't add anything, and a blank value in a flag removes the key.
+	configMeta, err := ParseMeta(strings.Split(config["meta"], ","))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid meta config:", err)
+		os.Exit(6)
+	}
+	flagMeta, err := ParseMeta(metaFlags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid --meta:", err)
+		os.Exit(2)
+	}
+	for k, v := range configMeta {
+		if v != "" {
+			EventMeta[k] = v
+		}
+	}
+	if stamp, _ := strconv.ParseBool(config["stamphost"]); stamp {
+		host, err := os.Hostname()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Can't find the hostname to stamp events with:", err)
+		} else {
+			EventMeta["host"] = host
+		}
+	}
+	for k, v := range flagMeta {
+		if v == "" {
+			delete(EventMeta, k)
+			continue
 		}
+		EventMeta[k] = v
+	}
 
-		w := tabwriter.NewWriter(os.Stdout, 2, 4, 1, ' ', 0)
-		err = template.Execute(w, ReportData{
-			Begin:   begin,
-			End:     end,
-			Periods: periods,
-			Totals:  running,
-			Weeks:   weeks,
-		})
+	// Made up logs, for testing and demos. This is left out of the usage on purpose, and doesn't touch the real log.
+	if command == "gen" {
+		GenCommand(os.Args[2:])
+		return
+	}
+
+	// Checking the setup needs to happen before the log is opened, opening it would create it.
+	if command == "doctor" {
+		DoctorCommand(config, configfile)
+		return
+	}
+
+	// Now on to our regularly scheduled program
+
+	// Open the timesheet. A logfile of "-" is read from stdin for use in pipelines, and is never written back, so it has
+	// no store. Anything else is written back through the store, which replaces the whole file at once, so a crash or a
+	// full disk halfway through a write leaves the old log rather than half of the new one.
+	var store *timelog.FileStore
+	var content []byte
+	if config["logfile"] == "-" {
+		content, err = io.ReadAll(os.Stdin)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error executing report template:")
 			fmt.Fprintln(os.Stderr, err)
-			return
+			os.Exit(8)
 		}
-		w.Flush()
+	} else {
+		store = &timelog.FileStore{Path: config["logfile"]}
+		content, err = os.ReadFile(config["logfile"])
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+	}
 
+	// Totals are cached, so they may not need the log parsed at all.
+	if command == "total" {
+		TotalCommand(os.Args[2:], content, ReadArchives(config["archives"]), config)
 		return
 	}
 
-	// Grab the last event in the sheet for later convenience.
-	var last *timelog.Event
-	if len(log) > 0 {
-		last = log[len(log)-1]
+	// Parse leniently, so a single bad hand edit doesn't lock you out of everything. Anything that would write the log
+	// back is refused later if there were problems, otherwise the bad lines would be silently dropped.
+	parseStart := time.Now()
+	log, problems := timelog.ParseTimeLogLenient(string(content))
+	for _, problem := range problems {
+		PrintParseError(problem)
 	}
+	log.Sort()
+	Debug.Debug("parsed timelog", "file", config["logfile"], "events", len(log), "problems", len(problems), "took", time.Since(parseStart))
 
-	switch {
-	// Fix times
-	case os.Args[1] == "info":
-		if last == nil {
-			fmt.Fprintln(os.Stderr, "No events found.")
-			os.Exit(1)
+	// A log from a newer version is read as well as this one can, but writing it back could lose whatever is new in it.
+	logVersion := timelog.FormatVersionOf(string(content))
+	if logVersion > timelog.FormatVersion {
+		fmt.Fprintf(os.Stderr, "The timelog is in format version %d, this version of timeclock only knows up to %d.\n", logVersion, timelog.FormatVersion)
+	}
+
+	// Older logs are upgraded as they are read, but for anything more than cosmetic it has to be 'migrate' that writes
+	// them back, so there is a backup.
+	needsMigrate := false
+	for _, m := range timelog.PendingMigrations(logVersion) {
+		needsMigrate = needsMigrate || !m.Automatic
+	}
+	if needsMigrate && command != "migrate" {
+		fmt.Fprintf(os.Stderr, "The timelog is in format version %d, run 'timeclock migrate' to upgrade it.\n", logVersion)
+	}
+
+	// With a replica, the timelog file is just the flat version of the operation log. Record anything changed in it
+	// since last time, and bring in what the other devices have done.
+	var rep *replica.Replica
+	if config["replica"] != "" && store != nil {
+		device := config["device"]
+		if device == "" {
+			device, _ = os.Hostname()
+		}
+		rep, err = replica.Open(config["replica"], device)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening replica:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+	}
+	if rep != nil && len(problems) > 0 {
+		fmt.Fprintln(os.Stderr, "Not syncing the replica until the malformed lines are fixed.")
+	} else if rep != nil && logVersion > timelog.FormatVersion {
+		fmt.Fprintln(os.Stderr, "Not syncing the replica until timeclock is upgraded.")
+	} else if rep != nil && needsMigrate {
+		fmt.Fprintln(os.Stderr, "Not syncing the replica until the timelog is migrated.")
+	} else if rep != nil {
+		merged, recorded, err := rep.Sync(log)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error syncing replica:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
 		}
+		b := &strings.Builder{}
+		err = merged.FormatFile(b)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+		Debug.Debug("synced replica", "dir", config["replica"], "device", rep.Device, "recorded", recorded, "events", len(merged))
 
-		fmt.Printf("%v\n", strings.Join(codes, "\n"))
+		if b.String() != string(content) {
+			tx := timelog.Begin(merged)
+			tx.Sort()
+			err = tx.Commit(store)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing merged timelog:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(8)
+			}
+			content = []byte(b.String())
+			log = tx.Log()
+		}
 
-	// Fix times
-	case os.Args[1] == "time":
-		if last == nil {
-			fmt.Fprintln(os.Stderr, "No events found.")
-			os.Exit(1)
+		// Only now does the flat file have the other devices' changes, see Replica.Sync.
+		err = rep.Commit()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error syncing replica:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
 		}
+	}
 
-		last.At, _, _ = ParseLine(os.Args[2:], nil, false)
-		fmt.Printf("Changed last event time to: %v\n", last.At.Format(timelog.TimeFormat))
+	// Finalized ranges can't change, so remember what is in them to check before writing.
+	locks, err := LoadLocks(config["lockfile"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading lock file:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(6)
+	}
+	locked := locks.Locked(log)
+
+	// Logs synced between machines can end up with the same thing recorded on both.
+	for _, set := range log.Conflicts() {
+		fmt.Fprintln(os.Stderr, "Events at the same time from different hosts, you probably want to keep only one:")
+		for _, e := range set {
+			host := e.Meta["host"]
+			if host == "" {
+				host = "unknown host"
+			}
+			fmt.Fprintf(os.Stderr, "    %s (%s)\n", e.String(), host)
+		}
+	}
 
-	// Fix time codes
-	case os.Args[1] == "code":
-		if last == nil {
-			fmt.Fprintln(os.Stderr, "No events found.")
-			os.Exit(1)
+	// Load the timecodes from the timelog. A plan is for the same work, so it gets the timelog's codes as well.
+	codes := log.Codes()
+	if planning {
+		actualraw, _ := os.ReadFile(actualfile)
+		actual, _ := timelog.ParseTimeLogLenient(string(actualraw))
+		codes = append(codes, actual.Codes()...)
+		sort.Strings(codes)
+		codes = slices.Compact(codes)
+	}
+
+	// Load the extra timecode information, if there is any.
+	codeinfo := timelog.CodeInfo{}
+	coderaw, err := os.ReadFile(config["codefile"])
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "Error reading timecode file:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(7)
+	}
+	ParseINISections(string(coderaw), codeinfo)
+
+	// And the currency exchange rates, also optional.
+	var exchange *timelog.ExchangeRates
+	ratesraw, err := os.ReadFile(config["ratesfile"])
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "Error reading exchange rate file:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(6)
+	}
+	if err == nil {
+		settings := map[string]string{}
+		ParseINI(string(ratesraw), settings)
+		exchange = timelog.ParseExchangeRates(settings)
+	}
+
+	// The invoice ledger commands don't need anything else.
+	if command == "invoice" && InvoiceCommand(config["invoicefile"], os.Args[2:]) {
+		return
+	}
+
+	// The API reads the log again for every request, so it only needs everything else set up.
+	if command == "serve" {
+		if store == nil {
+			fmt.Fprintln(os.Stderr, "The timelog was read from stdin, so there is nothing to serve.")
+			os.Exit(2)
 		}
+		_, filters := TakeReportFilters(nil, config)
+		ServeCommand(os.Args[2:], &Server{
+			Config:   config,
+			Info:     codeinfo,
+			Exchange: exchange,
+			Filters:  filters,
+			Store:    store,
+			Rest:     rest,
+			Schedule: schedule,
+			Overtime: overtime,
+			CapNow:   capNow,
+		})
+		return
+	}
 
-		last.Code = strings.Join(os.Args[2:], " ")
+	// Reporting, invoices are just a special kind of report.
+	if command == "report" || command == "invoice" {
+		invoicing := command == "invoice"
+		os.Args = append(os.Args[:2], project.ExpandPreset(os.Args[2:])...)
+		fallback := "default.tmpl"
+		if invoicing {
+			fallback = "invoice.tmpl"
+		}
 
-		fmt.Printf("Changed last event time code to: %v\n", last.Code)
+		// Load the templates
+		templates, err := report.LoadTemplates(config["reportsdir"], codeinfo, Durations)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading report templates:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(9)
+		}
 
-	// Fix descriptions
-	case os.Args[1] == "desc":
-		fallthrough
-	case os.Args[1] == "note":
-		if last == nil {
-			fmt.Fprintln(os.Stderr, "No events found.")
-			os.Exit(1)
+		args, topflag := TakeFlagValue(os.Args[2:], "--top")
+		args, roundflag := TakeFlagValue(args, "--round")
+		args, reconcile := TakeFlag(args, "--reconcile")
+		args, filters := TakeReportFilters(args, config)
+		args, grid := TakeFlagValue(args, "--grid")
+		args, exportflag := TakeFlagValue(args, "--export")
+		args, timesheetflag := TakeFlag(args, "--timesheet")
+		args, outputflag := TakeFlagValue(args, "--output")
+		args, formatflag := TakeFlagValue(args, "--format")
+		if outputflag == "" {
+			args, outputflag = TakeFlagValue(args, "-o")
+		}
+		args, copyflag := TakeFlag(args, "--copy")
+		args, finalizeflag := TakeFlag(args, "--finalize")
+		args, planflag := TakeFlagValue(args, "--plan")
+		args, logsflag := TakeFlagValue(args, "--logs")
+		if copyflag {
+			// Color codes are no good in a pasted report.
+			UseColor = false
+		}
+		// --timesheet is whatever the employer wants, from the timesheet config, usually set by a profile.
+		if timesheetflag {
+			switch {
+			case grid != "" || exportflag != "":
+				fmt.Fprintln(os.Stderr, "--timesheet can't be used with --grid or --export.")
+				os.Exit(2)
+			case config["timesheet"] == "":
+				fmt.Fprintln(os.Stderr, "--timesheet needs the timesheet config, eg timesheet=\"xlsx\" or the name of an export preset.")
+				os.Exit(6)
+			case config["timesheet"] == "csv" || config["timesheet"] == "xlsx":
+				grid = config["timesheet"]
+			default:
+				exportflag = config["timesheet"]
+			}
+		}
+		switch {
+		case grid == "":
+		case invoicing:
+			fmt.Fprintln(os.Stderr, "--grid can't be used with invoices.")
+			os.Exit(2)
+		case exportflag != "":
+			fmt.Fprintln(os.Stderr, "--grid and --export can't be used together.")
+			os.Exit(2)
+		case grid != "csv" && grid != "xlsx":
+			fmt.Fprintf(os.Stderr, "Unknown grid format %q, expected 'csv' or 'xlsx'.\n", grid)
+			os.Exit(2)
+		case grid == "xlsx" && copyflag:
+			fmt.Fprintln(os.Stderr, "Refusing to copy a spreadsheet to the clipboard, use '-o' to write it to a file.")
+			os.Exit(2)
+		case grid == "xlsx" && UseColor && outputflag == "":
+			// Not exactly about color, but it is the same check.
+			fmt.Fprintln(os.Stderr, "Refusing to write a spreadsheet to a terminal, redirect it to a file.")
+			os.Exit(2)
+		}
+		var rounding time.Duration
+		if roundflag != "" {
+			var err error
+			rounding, err = time.ParseDuration(roundflag)
+			if err != nil || rounding <= 0 {
+				fmt.Fprintln(os.Stderr, "--round needs a positive duration, such as 15m or 6m.")
+				os.Exit(2)
+			}
+		}
+		switch {
+		case formatflag != "" && formatflag != "json" && formatflag != "text":
+			fmt.Fprintf(os.Stderr, "Unknown report format %q, expected 'text' or 'json'.\n", formatflag)
+			os.Exit(2)
+		case formatflag == "json" && invoicing:
+			fmt.Fprintln(os.Stderr, "--format json can't be used with invoices.")
+			os.Exit(2)
+		case formatflag == "json" && (grid != "" || exportflag != ""):
+			fmt.Fprintln(os.Stderr, "--format json can't be used with --grid or --export.")
+			os.Exit(2)
+		}
+		var export *ExportPreset
+		if exportflag != "" {
+			if invoicing {
+				fmt.Fprintln(os.Stderr, "--export can't be used with invoices.")
+				os.Exit(2)
+			}
+
+			exportraw, err := os.ReadFile(config["exportfile"])
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintln(os.Stderr, "Error reading export file:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(6)
+			}
+			presets := ExportPresets(string(exportraw))
+			settings, ok := presets[exportflag]
+			if !ok {
+				names := []string{}
+				for name := range presets {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				fmt.Fprintf(os.Stderr, "Unknown export preset %q, expected one of: %s\n", exportflag, strings.Join(names, ", "))
+				os.Exit(2)
+			}
+			export, err = ParseExportPreset(exportflag, settings)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(6)
+			}
+		}
+
+		top := 0
+		if topflag != "" {
+			var err error
+			top, err = strconv.Atoi(topflag)
+			if err != nil || top < 1 {
+				fmt.Fprintln(os.Stderr, "--top needs a number, starting from 1.")
+				os.Exit(2)
+			}
+		}
+
+		// Archived events only matter for reports, so they aren't loaded until now.
+		reportlog := WithArchives(log, config["archives"])
+
+		// With --logs the report is of those logs from the logs config instead, merged together.
+		var lognames []string
+		if logsflag != "" {
+			logs, err := ParseLogs(config["logs"])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Invalid logs config:", err)
+				os.Exit(6)
+			}
+			for _, name := range strings.Split(logsflag, ",") {
+				name = strings.TrimSpace(name)
+				if _, ok := logs[name]; !ok {
+					names := []string{}
+					for name := range logs {
+						names = append(names, name)
+					}
+					sort.Strings(names)
+					fmt.Fprintf(os.Stderr, "Unknown log %q, expected one of the logs config: %s\n", name, strings.Join(names, ", "))
+					os.Exit(2)
+				}
+				if !slices.Contains(lognames, name) {
+					lognames = append(lognames, name)
+				}
+			}
+			reportlog, err = LoadLogs(logs, lognames)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error reading logs:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(8)
+			}
+		}
+
+		// The plan is optional, but one asked for by name should exist.
+		if planflag == "" {
+			planflag = config["planfile"]
+		} else if _, err := os.Stat(planflag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		plan, err := ReadTimelogFile(planflag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading plan file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+		if len(plan) == 0 {
+			plan = nil
+		}
+
+		expenses, err := LoadExpenses(config["expensefile"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading expense ledger:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(10)
+		}
+
+		trips, err := LoadTrips(config["travelfile"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading travel log:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(10)
+		}
+
+		begin, end, fcode, template := ParseReportRequest(args, append(reportlog.Codes(), "empty", "all"), templates, fallback)
+		if (invoicing || finalizeflag) && end == nil {
+			// An invoice (or a lock) covers a fixed range, no matter when it is looked at.
+			now := Clock.Now()
+			end = &now
+		}
+
+		if len(fcode) == 0 {
+			fmt.Fprintln(os.Stderr, locale.T("No timecodes provided, using 'all'"))
+		} else {
+			fmt.Fprintln(os.Stderr, locale.T("Timecodes: %v", strings.Join(fcode, ", ")))
+		}
+
+		if end == nil {
+			fmt.Fprintln(os.Stderr, locale.T("Periods after: %v", begin.Format(timelog.TimeFormat)))
+		} else {
+			fmt.Fprintln(os.Stderr, locale.T("Periods between: %v - %v", begin.Format(timelog.TimeFormat), end.Format(timelog.TimeFormat)))
+		}
+
+		// Time in the future hasn't been worked yet, however the log came to say it had.
+		var capAt *time.Time
+		if capNow {
+			now := Clock.Now()
+			capAt = &now
+		}
+
+		// Tables are fitted to the terminal, unless the config says how wide they can be or the report goes to a file.
+		fitwidth := tablewidth
+		if config["tablewidth"] == "" && outputflag == "" {
+			fitwidth = TerminalWidth()
+		}
+
+		data, err := report.Build(report.Options{
+			Log:      reportlog,
+			Info:     codeinfo,
+			Exchange: exchange,
+
+			Begin: begin,
+			End:   end,
+			CapAt: capAt,
+			Logs:  lognames,
+			Codes: fcode,
+			Where: filters.Where,
+
+			Top:       top,
+			Rounding:  rounding,
+			Reconcile: reconcile,
+			Overlap:   filters.Overlap,
+
+			OverlapPolicy: OverlapPolicy,
+			Calendar:      Calendar,
+			FoldCodeCase:  FoldCodeCase,
+
+			Transforms: filters.Transforms,
+
+			Rest:     rest,
+			Schedule: schedule,
+			Overtime: overtime,
+			Plan:     plan,
+			Expenses: expenses,
+
+			Trips:        trips,
+			DistanceUnit: config["distanceunit"],
+
+			AttachDir: AttachDir(config),
+
+			TableWidth: fitwidth,
+		})
+		if errors.Is(err, report.ErrNoPeriods) {
+			fmt.Fprintln(os.Stderr, locale.T(err.Error()))
+			return
+		}
+		var overlaps timelog.ErrOverlaps
+		if errors.As(err, &overlaps) {
+			fmt.Fprintln(os.Stderr, locale.T("Events on the same track at the same time, fix them or set the overlappolicy config:"))
+			for _, o := range overlaps {
+				fmt.Fprintf(os.Stderr, "    %s\n", report.DescribeOverlap(o))
+			}
+			os.Exit(8)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if len(data.Overlaps) > 0 {
+			fmt.Fprintln(os.Stderr, locale.T("Warning: %d place(s) where events on the same track are at the same time, see overlappolicy. Run 'check' to list them.", len(data.Overlaps)))
+		}
+		if data.Capped > 0 {
+			fmt.Fprintln(os.Stderr, locale.T("Warning: the timelog has events in the future, %s after now was left out. Run 'check' to find them.", timelog.FormatDuration(data.Capped, Durations)))
+		}
+		if exchange != nil && len(data.Currencies) > 0 && data.Combined == nil {
+			fmt.Fprintln(os.Stderr, locale.T("Exchange rate file is missing some currencies, not combining totals."))
+		}
+		templates.Funcs(data.Funcs(Durations))
+
+		// Once the report is out, --finalize locks the range so the report stays true.
+		finalize := func() {
+			if !finalizeflag {
+				return
+			}
+			locks = append(locks, Lock{Begin: *begin, End: *end, At: Clock.Now()})
+			err := locks.Save(config["lockfile"])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing lock file:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Finalized %s, the events in it can't be changed now.\n", locks[len(locks)-1])
+		}
+
+		// The file name is for the start of the report, so a weekly report always lands in the file for its week.
+		out := OpenOutput(outputflag, *begin)
+		if copyflag {
+			out.Copy()
+		}
+
+		// The timesheet grid skips the templates entirely.
+		if grid != "" {
+			write := WriteCSV
+			if grid == "xlsx" {
+				write = WriteXLSX
+			}
+			err = write(out, WeekGrid(data.Weeks))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing timesheet grid:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			FinishOutput(out)
+			finalize()
+			return
+		}
+
+		// And JSON, which is everything the templates would have had.
+		if formatflag == "json" {
+			err = data.WriteJSON(out)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing JSON report:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			FinishOutput(out)
+			finalize()
+			return
+		}
+
+		// As do exports.
+		if export != nil {
+			rows, err := export.Rows(ExportRows(data.Periods, codeinfo))
+			if err == nil {
+				err = WriteCSV(out, rows)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing export:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			FinishOutput(out)
+			finalize()
+			return
+		}
+
+		// Make sure this time hasn't already been invoiced before giving it a number.
+		var invoice *Invoice
+		var invoices Invoices
+		if invoicing {
+			if len(data.Charges) == 0 && len(data.Expenses) == 0 && !data.Reimbursed() {
+				fmt.Fprintln(os.Stderr, "Nothing to invoice, none of the periods are for codes with a rate, and there are no expenses or trips.")
+				os.Exit(1)
+			}
+
+			invoices, err = LoadInvoices(config["invoicefile"])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error reading invoice ledger:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(10)
+			}
+
+			charged := []string{}
+			for _, c := range data.Charges {
+				charged = append(charged, c.Code)
+			}
+			for _, e := range data.Expenses {
+				if !slices.Contains(charged, e.Code) {
+					charged = append(charged, e.Code)
+				}
+			}
+			for _, t := range data.Trips {
+				if t.Amount > 0 && !slices.Contains(charged, t.Trip.Code) {
+					charged = append(charged, t.Trip.Code)
+				}
+			}
+			if conflicts := invoices.Overlapping(*begin, *end, charged); len(conflicts) > 0 {
+				fmt.Fprintln(os.Stderr, "Some of this time has already been invoiced:")
+				for _, inv := range conflicts {
+					fmt.Fprintln(os.Stderr, "    "+inv.String())
+				}
+				fmt.Fprintln(os.Stderr, "Pick a range that doesn't overlap, or discard the draft with 'invoice discard <number>'.")
+				os.Exit(1)
+			}
+
+			invoice = &Invoice{
+				Number: invoices.NextNumber(Clock.Now()),
+				State:  "draft",
+				Begin:  *begin,
+				End:    *end,
+				Amount: formatCurrencies(data.Currencies),
+				Codes:  charged,
+			}
+		}
+
+		w := tabwriter.NewWriter(out, 2, 4, 1, ' ', 0)
+		err = template.Execute(w, TemplateData{ReportData: data, Invoice: invoice})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error executing report template:")
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		w.Flush()
+		FinishOutput(out)
+
+		if invoicing {
+			err = append(invoices, invoice).Save(config["invoicefile"])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing invoice ledger:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(10)
+			}
+			fmt.Fprintf(os.Stderr, "Recorded invoice %s as a draft.\n", invoice.Number)
+		}
+		finalize()
+
+		return
+	}
+
+	// Run once the new timesheet has been written, for commands with other files to update only if that worked.
+	afterWrite := func() {}
+
+	// Grab the last event in the sheet for later convenience. Everything works on one track at a time.
+	last := log.Last(Track)
+	if config["anchor"] == "last" && last != nil {
+		AnchorAt = last.At
+	}
+
+	switch command {
+	// Fix times
+	case "info":
+		if last == nil {
+			fmt.Fprintln(os.Stderr, "No events found.")
+			os.Exit(1)
+		}
+
+		args, plain := TakeFlag(os.Args[2:], "--plain")
+		args, unused := TakeFlag(args, "--unused")
+		args, prune := TakeFlag(args, "--prune")
+		args, add := TakeFlag(args, "--add")
+		args, tree := TakeFlag(args, "--tree")
+
+		if tree {
+			args, filters := TakeReportFilters(args, config)
+			periods := WithArchives(log, config["archives"]).PeriodsWith(OverlapPolicy)
+			if len(args) > 0 {
+				begin, end := ParseTimeRange(args)
+				if end == nil {
+					now := Clock.Now()
+					end = &now
+				}
+				fmt.Fprintf(os.Stderr, "Periods: %s - %s\n", begin.Format(timelog.TimeFormat), end.Format(timelog.TimeFormat))
+				periods = filters.Apply(periods, func(p *timelog.Period) bool {
+					return !p.Begin.Before(*begin) && p.Begin.Before(*end)
+				})
+			} else {
+				periods = filters.Apply(periods)
+			}
+			PrintCodeTree(os.Stdout, timelog.Totals(periods, FoldCodeCase))
+			return
+		}
+
+		if len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "Unexpected arguments to 'info':", strings.Join(args, " "))
+			os.Exit(2)
+		}
+
+		if unused || prune || add {
+			// A code only used in the archives still needs its rates and such.
+			orphans, missing := OrphanedCodes(CodeSections(string(coderaw)), WithArchives(log, config["archives"]).Codes())
+			if len(orphans) == 0 && len(missing) == 0 {
+				fmt.Println("Every code in the timecode file is used, and every code used is in it.")
+				return
+			}
+			if len(orphans) > 0 {
+				fmt.Println("In the timecode file, but never used:")
+				for _, code := range orphans {
+					fmt.Println("    " + code)
+				}
+			}
+			if len(missing) > 0 {
+				fmt.Println("Used, but not in the timecode file (or under a parent that is):")
+				for _, code := range missing {
+					fmt.Println("    " + code)
+				}
+			}
+
+			changed := string(coderaw)
+			if prune && len(orphans) > 0 {
+				if !yesFlag && !Confirm("Remove the unused sections, and all their settings") {
+					os.Exit(1)
+				}
+				changed = PruneCodeSections(changed, orphans)
+				fmt.Printf("Removed %d section(s) from %s.\n", len(orphans), config["codefile"])
+			}
+			if add && len(missing) > 0 {
+				changed = AddCodeSections(changed, missing)
+				fmt.Printf("Added %d empty section(s) to %s.\n", len(missing), config["codefile"])
+			}
+			if changed != string(coderaw) {
+				err := os.WriteFile(config["codefile"], []byte(changed), 0666)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "Error writing timecode file:")
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(7)
+				}
+			}
+			return
+		}
+
+		usage := log.Usage(Clock.Now().AddDate(0, 0, -30))
+		if plain {
+			for _, u := range usage {
+				fmt.Println(u.Code)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "Code\tLast used\tEvents\tTotal\tLast 30 days")
+		for _, u := range usage {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", u.Code, u.Last.Format("2006/01/02"), u.Events, timelog.FormatDuration(u.Total, Durations), timelog.FormatDuration(u.Recent, Durations))
+		}
+		w.Flush()
+		return
+
+	// Fix times
+	case "time":
+		if last == nil {
+			fmt.Fprintln(os.Stderr, "No events found.")
+			os.Exit(1)
+		}
+
+		// The last event is the one being changed, so anchor to the one before it.
+		if !AnchorAt.IsZero() {
+			AnchorAt = time.Time{}
+			if prev := log[:slices.Index(log, last)].Last(Track); prev != nil {
+				AnchorAt = prev.At
+			}
+		}
+		last.At, _, _ = ParseLine(os.Args[2:], nil, false)
+		ConfirmSurprising(last.At, yesFlag)
+		fmt.Printf("Changed last event time to: %v\n", last.At.Format(timelog.TimeFormat))
+		if i := slices.Index(log, last); i > 0 && last.At.Before(log[i-1].At) {
+			// Not fatal here, the ordering policy gets the final say when writing.
+			fmt.Fprintf(os.Stderr, "The new time is before the previous event (%s), so this is no longer the last event.\n", log[i-1].String())
+		}
+
+	// Fix time codes
+	case "code":
+		if last == nil {
+			fmt.Fprintln(os.Stderr, "No events found.")
+			os.Exit(1)
+		}
+
+		last.Code = strings.Join(os.Args[2:], " ")
+
+		fmt.Printf("Changed last event time code to: %v\n", last.Code)
+
+	// Fix descriptions
+	case "desc":
+		if last == nil {
+			fmt.Fprintln(os.Stderr, "No events found.")
+			os.Exit(1)
+		}
+
+		if len(os.Args) > 2 {
+			last.Desc = strings.Join(os.Args[2:], " ")
+		} else {
+			desc, err := ReadNote(last.Desc)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			last.Desc = desc
+		}
+		if err := last.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Changed last event description to: %v\n", last.Desc)
+
+	// Fill in missing descriptions with what you were committing at the time.
+	case "fill-from-git":
+		args, all := TakeFlag(os.Args[2:], "--all")
+		now := Clock.Now()
+		from, to := &now, (*time.Time)(nil)
+		if len(args) == 0 {
+			// Since the start of today.
+			*from = Calendar.Day(now)
+		} else {
+			from, to = ParseTimeRange(args)
+		}
+
+		repos, err := GitRepos(config["gitrepos"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(6)
+		}
+		if len(repos) == 0 {
+			fmt.Fprintln(os.Stderr, "No git repositories to look in, set the gitrepos config.")
+			os.Exit(6)
+		}
+
+		begin, end := SelectRange(log, *from, to)
+		filled := 0
+		for i := begin; i < end; i++ {
+			event := log[i]
+			if event.Track != Track || event.Code == "" || event.Desc != "" {
+				continue
+			}
+			until := now
+			for _, next := range log[i+1:] {
+				if next.Track == event.Track {
+					until = next.At
+					break
+				}
+			}
+
+			commits, err := AuthoredCommits(repos, event.At, until)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if len(commits) == 0 {
+				continue
+			}
+			subjects := []string{}
+			for _, c := range commits {
+				subjects = append(subjects, c.Subject)
+			}
+			desc := strings.Join(subjects, "; ")
+
+			if !all {
+				fmt.Printf("%s has no description, your commits from then:\n", strings.TrimSpace(event.String()))
+				items := []string{}
+				for _, c := range commits {
+					items = append(items, fmt.Sprintf("%s %s %s", c.Repo, c.Hash, c.Subject))
+				}
+				if len(commits) > 1 {
+					items = append(items, "All of them")
+				}
+				items = append(items, "Leave it blank")
+				n, err := ChooseOne("Use as the description", items)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				switch {
+				case n == len(items)-1:
+					continue
+				case n < len(commits):
+					desc = commits[n].Subject
+				}
+			}
+
+			event.Desc = desc
+			filled++
+			fmt.Printf("Changed description to: %v\n", event.String())
+		}
+		if filled == 0 {
+			fmt.Println("No descriptions filled in.")
+			return
+		}
+
+	// Fix anything, the hard way.
+	case "edit":
+		// The editor is the only way to edit for now, but the flag is accepted so there's room for others later.
+		args, _ := TakeFlag(os.Args[2:], "--editor")
+		begin, end, ok := SelectEvents(log, args)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "No events found.")
+			os.Exit(1)
+		}
+
+		header := fmt.Sprintf("Editing %v event(s). Save and exit to apply, lines starting with '#' are ignored.\n", end-begin)
+		header += "Removing every event aborts the edit."
+		edited, err := EditEvents(log[begin:end], header)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if edited == nil {
+			fmt.Fprintln(os.Stderr, "No events left after editing, nothing changed.")
+			os.Exit(1)
+		}
+
+		log = append(append(append(timelog.TimeLog{}, log[:begin]...), edited...), log[end:]...)
+		log.Sort()
+		fmt.Printf("Replaced %v event(s) with:\n", end-begin)
+		for _, item := range edited {
+			fmt.Println(item.String())
+		}
+
+	// Fix everything, the hard way.
+	case "edit-log":
+		begin, end := 0, len(log)
+		var edited timelog.TimeLog
+		var err error
+		if len(os.Args) > 2 {
+			from, to := ParseTimeRange(os.Args[2:])
+			begin, end = SelectRange(log, *from, to)
+
+			header := fmt.Sprintf("Editing %v event(s) in range. Save and exit to apply, lines starting with '#' are ignored.\n", end-begin)
+			header += "Removing every event aborts the edit."
+			edited, err = EditEvents(log[begin:end], header)
+		} else {
+			// Editing the raw file rather than the parsed log means this works even if the log has malformed lines,
+			// and the user gets to see (and fix) them.
+			header := "Editing the whole timelog. Save and exit to apply.\n"
+			header += "Removing every event aborts the edit."
+			edited, err = EditTimelogText(string(content), header)
+			problems = nil
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if edited == nil {
+			fmt.Fprintln(os.Stderr, "No events left after editing, nothing changed.")
+			os.Exit(1)
+		}
+
+		diff := DiffEvents(log[begin:end], edited)
+		if len(diff) == 0 {
+			fmt.Println("No changes.")
+			return
+		}
+		fmt.Println(strings.Join(diff, "\n"))
+
+		if !Confirm("Apply these changes") {
+			fmt.Fprintln(os.Stderr, "Changes discarded.")
+			os.Exit(1)
+		}
+
+		log = append(append(append(timelog.TimeLog{}, log[:begin]...), edited...), log[end:]...)
+		log.Sort()
+
+	// Rebuild a day you forgot to track, with whatever hints are available.
+	case "reconstruct":
+		args, hints := TakeFlagValue(os.Args[2:], "--hints")
+		if hints != "" && hints != "browser" {
+			fmt.Fprintf(os.Stderr, "Unknown hints %q, expected 'browser'.\n", hints)
+			os.Exit(2)
+		}
+		day := Clock.Now()
+		if len(args) > 0 {
+			at, _ := ParseTimeRange(args)
+			day = *at
+		}
+		from := Calendar.Day(day)
+		to := from.AddDate(0, 0, 1)
+		begin, end := SelectRange(log, from, &to)
+
+		header := fmt.Sprintf("Rebuilding %s, %v event(s) so far. Save and exit to apply, lines starting with '#' are ignored.\n", from.Format("Monday 2006/01/02"), end-begin)
+		header += "Removing every event aborts the edit."
+		if hints == "browser" {
+			if config["browserhistory"] == "" {
+				fmt.Fprintln(os.Stderr, "No browser history to read, set the browserhistory config to your exported history.")
+				os.Exit(6)
+			}
+			visits, err := ReadBrowserHistory(config["browserhistory"])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error reading browser history:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(6)
+			}
+			lines := BrowserHints(visits, from, 3)
+			if len(lines) == 0 {
+				lines = []string{"Nothing that day."}
+			}
+			header += "\n\nThe sites you visited most, by hour:\n" + strings.Join(lines, "\n")
+		}
+
+		edited, err := EditEvents(log[begin:end], header)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if edited == nil {
+			fmt.Fprintln(os.Stderr, "No events after editing, nothing changed.")
+			os.Exit(1)
+		}
+
+		diff := DiffEvents(log[begin:end], edited)
+		if len(diff) == 0 {
+			fmt.Println("No changes.")
+			return
+		}
+		fmt.Println(strings.Join(diff, "\n"))
+
+		if !Confirm("Apply these changes") {
+			fmt.Fprintln(os.Stderr, "Changes discarded.")
+			os.Exit(1)
+		}
+
+		log = append(append(append(timelog.TimeLog{}, log[:begin]...), edited...), log[end:]...)
+		log.Sort()
+
+	// Stage events from somewhere else, to be looked over with 'review' before they reach the timelog.
+	case "import":
+		if len(os.Args) != 3 {
+			fmt.Fprintln(os.Stderr, "Expected a file to import, or '-' to read from stdin.")
+			os.Exit(2)
+		}
+
+		var raw []byte
+		var err error
+		if os.Args[2] == "-" {
+			raw, err = io.ReadAll(os.Stdin)
+		} else {
+			raw, err = os.ReadFile(os.Args[2])
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		imported, err := timelog.ParseTimeLogString(string(raw))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error parsing imported events:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+
+		staged, err := LoadStaging(config["stagingfile"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading staging file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+		fresh := WithoutDuplicates(imported, append(append(timelog.TimeLog{}, log...), staged...))
+		staged = append(staged, fresh...)
+		staged.Sort()
+		err = SaveStaging(config["stagingfile"], staged)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing staging file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Staged %v event(s), %v were already there. Use 'review' to add them to the timelog.\n", len(fresh), len(imported)-len(fresh))
+		return
+
+	// Trade events with other places, pulled events are staged like imports.
+	case "sync":
+		SyncCommand(os.Args[2:], log, config)
+		return
+
+	// Everything there is, in one file to take elsewhere.
+	case "export":
+		if len(os.Args) >= 3 && os.Args[2] == "csv" {
+			ExportCSVCommand(os.Args[3:], log, config, codeinfo, capNow)
+			return
+		}
+		if len(os.Args) >= 3 && os.Args[2] == "ical" {
+			ExportICalCommand(os.Args[3:], log, config, capNow)
+			return
+		}
+		if len(os.Args) < 3 || len(os.Args) > 4 || os.Args[2] != "all" {
+			fmt.Fprintln(os.Stderr, "Expected 'export all', optionally followed by the file to write, 'export csv', or 'export ical'.")
+			os.Exit(2)
+		}
+		path := "sctime-export-" + time.Now().Format("2006-01-02") + ".zip"
+		if len(os.Args) == 4 {
+			path = os.Args[3]
+		}
+
+		var w io.Writer = os.Stdout
+		if path != "-" {
+			f, err := os.Create(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			w = f
+		}
+		err := ExportAll(w, content, config, codeinfo)
+		if err != nil {
+			if path != "-" {
+				os.Remove(path)
+			}
+			fmt.Fprintln(os.Stderr, "Error exporting:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Exported everything to %s.\n", path)
+		return
+
+	// How the week is shaping up, counting what is planned for the rest of it.
+	case "plan":
+		args := os.Args[2:]
+		if len(args) > 0 {
+			args = args[1:]
+		}
+		now := Clock.Now()
+		day := now
+		if len(args) > 0 {
+			at, _ := ParseTimeRange(args)
+			day = *at
+		}
+		from := Calendar.StartOfWeek(day)
+		to := from.AddDate(0, 0, 7)
+
+		plan, err := ReadTimelogFile(config["planfile"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading plan file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+		rows := timelog.Project(log, plan, from, to, now, OverlapPolicy)
+
+		week := Calendar.WeekOf(from)
+		fmt.Printf("Week %d-W%02d, %s to %s:\n", week.Year, week.Number, from.Format("Mon 2006/01/02"), to.AddDate(0, 0, -1).Format("Mon 2006/01/02"))
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "Code\tDone\tPlanned\tProjected")
+		var done, planned time.Duration
+		budgets := map[string]time.Duration{}
+		owners := []string{}
+		for _, row := range rows {
+			done += row.Actual
+			planned += row.Planned
+			if owner, _, ok := codeinfo.Budget(row.Code); ok {
+				if _, seen := budgets[owner]; !seen {
+					owners = append(owners, owner)
+				}
+				budgets[owner] += row.Projected()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row.Code, timelog.FormatDuration(row.Actual, Durations), timelog.FormatDuration(row.Planned, Durations), timelog.FormatDuration(row.Projected(), Durations))
+		}
+		fmt.Fprintf(w, "Total\t%s\t%s\t%s\n", timelog.FormatDuration(done, Durations), timelog.FormatDuration(planned, Durations), timelog.FormatDuration(done+planned, Durations))
+		w.Flush()
+
+		if schedule > 0 {
+			if projected := done + planned; projected >= schedule {
+				fmt.Printf("Projected %s over the %s schedule.\n", timelog.FormatDuration(projected-schedule, Durations), timelog.FormatDuration(schedule, Durations))
+			} else {
+				fmt.Printf("Projected %s short of the %s schedule.\n", timelog.FormatDuration(schedule-projected, Durations), timelog.FormatDuration(schedule, Durations))
+			}
+		}
+
+		// Budgets are shared with child codes, so they get a line of their own.
+		sort.Strings(owners)
+		for _, owner := range owners {
+			_, hours, _ := codeinfo.Budget(owner)
+			projected := budgets[owner]
+			if projected > hours {
+				fmt.Printf("[%s] is projected %s over its budget of %s.\n", owner, timelog.FormatDuration(projected-hours, Durations), timelog.FormatDuration(hours, Durations))
+			} else {
+				fmt.Printf("[%s] has %s of its %s budget left.\n", owner, timelog.FormatDuration(hours-projected, Durations), timelog.FormatDuration(hours, Durations))
+			}
+		}
+		return
+
+	// Throw away old history, for clients with data retention agreements.
+	case "purge":
+		args, before := TakeFlagValue(os.Args[2:], "--before")
+		args, summarize := TakeFlag(args, "--summarize")
+		if before == "" || len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "Expected '--before <date>', and optionally '--summarize'.")
+			os.Exit(2)
+		}
+		if store == nil {
+			fmt.Fprintln(os.Stderr, "The timelog was read from stdin, so there is nothing to purge.")
+			os.Exit(1)
+		}
+		at, _ := ParseTimeRange([]string{before})
+		cutoff := Calendar.Day(*at)
+
+		purged, removed := log.Purge(cutoff, summarize, OverlapPolicy, Calendar)
+		if removed == 0 {
+			fmt.Printf("Nothing before %s.\n", cutoff.Format("2006/01/02"))
+			return
+		}
+		if summarize {
+			summaries := 0
+			for _, e := range purged {
+				if e.At.Before(cutoff) {
+					summaries++
+				}
+			}
+			fmt.Printf("This removes the %v event(s) before %s, and replaces them with %v event(s) of daily totals.\n", removed, cutoff.Format("2006/01/02"), summaries)
+		} else {
+			fmt.Printf("This removes the %v event(s) before %s.\n", removed, cutoff.Format("2006/01/02"))
+		}
+
+		// No --yes here, this is the one thing that can't be fixed afterwards.
+		if !Confirm("Purge them") {
+			fmt.Fprintln(os.Stderr, "Nothing purged.")
+			os.Exit(1)
+		}
+
+		// Keep a copy of the timelog as it was, it is up to the user to get rid of it once they are happy.
+		backup := config["logfile"] + ".purge-" + time.Now().Format("20060102-150405")
+		err := os.WriteFile(backup, content, 0600)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error backing up the timelog, nothing purged:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Backed up the timelog to %s.\n", backup)
+		log = purged
+
+	// Bring the timelog up to the current format version. The log has already been upgraded as it was read, this checks
+	// it and makes a backup before it is written back.
+	case "migrate":
+		args, dryrun := TakeFlag(os.Args[2:], "--dry-run")
+		if len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "Expected 'migrate', optionally with '--dry-run'.")
+			os.Exit(2)
+		}
+		if store == nil {
+			fmt.Fprintln(os.Stderr, "The timelog was read from stdin, so there is nothing to migrate.")
+			os.Exit(1)
+		}
+		if logVersion > timelog.FormatVersion {
+			fmt.Fprintln(os.Stderr, "The timelog is from a newer version of timeclock, upgrade timeclock instead.")
+			os.Exit(8)
+		}
+		pending := timelog.PendingMigrations(logVersion)
+		if len(pending) == 0 {
+			fmt.Printf("The timelog is already in format version %d.\n", timelog.FormatVersion)
+			return
+		}
+
+		fmt.Printf("The timelog is in format version %d, upgrading it to %d:\n", logVersion, timelog.FormatVersion)
+		for _, m := range pending {
+			fmt.Printf("    %d to %d: %s\n", m.From, m.From+1, m.What)
+		}
+		if len(problems) > 0 {
+			fmt.Fprintf(os.Stderr, "%v malformed line(s) would be lost, fix them first. Nothing changed.\n", len(problems))
+			os.Exit(8)
+		}
+		err := log.VerifyFormat()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "The upgraded timelog doesn't read back the same, nothing changed:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+		fmt.Printf("Checked all %v event(s) read back the same.\n", len(log))
+		if dryrun {
+			fmt.Println("Nothing written, since this is a dry run.")
+			return
+		}
+
+		backup := fmt.Sprintf("%s.v%d-%s", config["logfile"], logVersion, time.Now().Format("20060102-150405"))
+		err = os.WriteFile(backup, content, 0600)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error backing up the timelog, nothing changed:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Backed up the timelog to %s.\n", backup)
+
+	// Show what is in the replica.
+	case "replica":
+		if rep == nil {
+			fmt.Fprintln(os.Stderr, "No replica, set the replica config to a directory shared between your devices.")
+			os.Exit(6)
+		}
+		counts := rep.Ops()
+		devices := make([]string, 0, len(counts))
+		for device := range counts {
+			devices = append(devices, device)
+		}
+		sort.Strings(devices)
+		fmt.Printf("Replica in %s, this is %s.\n", rep.Dir, rep.Device)
+		for _, device := range devices {
+			fmt.Printf("    %s: %v operation(s)\n", device, counts[device])
+		}
+		fmt.Printf("%v event(s) in the timelog.\n", len(log))
+		return
+
+	// Look over staged events, and move the ones you keep into the timelog.
+	case "review":
+		args, listflag := TakeFlag(os.Args[2:], "--list")
+		staged, err := LoadStaging(config["stagingfile"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading staging file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(8)
+		}
+		begin, end := 0, len(staged)
+		if len(args) > 0 {
+			from, to := ParseTimeRange(args)
+			begin, end = SelectRange(staged, *from, to)
+		}
+		if begin == end {
+			fmt.Println("Nothing staged.")
+			return
+		}
+
+		text, err := FormatByDay(staged[begin:end])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if listflag {
+			fmt.Print(text)
+			return
+		}
+
+		header := fmt.Sprintf("Reviewing %v staged event(s). Save and exit to add them to the timelog, lines starting with '#' are ignored.\n", end-begin)
+		header += "Fix codes and descriptions as needed, and remove the lines for events you don't want.\n"
+		header += "Removing every event aborts the review."
+		approved, err := EditTimelogText(text, header)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if approved == nil {
+			fmt.Fprintln(os.Stderr, "No events left after editing, nothing committed.")
+			os.Exit(1)
+		}
+		approved.Sort()
+
+		fresh := WithoutDuplicates(approved, log)
+		for _, e := range fresh {
+			fmt.Println("+ " + e.String())
+		}
+		if rejected := (end - begin) - len(approved); rejected > 0 {
+			fmt.Printf("%v event(s) rejected.\n", rejected)
+		}
+		if !Confirm(fmt.Sprintf("Commit these %v event(s) to the timelog", len(fresh))) {
+			fmt.Fprintln(os.Stderr, "Nothing committed, the staged events are unchanged.")
+			os.Exit(1)
+		}
+
+		log = append(log, fresh...)
+		log.Sort()
+
+		// The reviewed events only leave staging once the timelog has been written, so a failed write loses nothing.
+		remaining := append(append(timelog.TimeLog{}, staged[:begin]...), staged[end:]...)
+		afterWrite = func() {
+			err := SaveStaging(config["stagingfile"], remaining)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Timelog written, but the staging file could not be updated:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
+	// Handle the current state report.
+	case "status":
+		if last == nil {
+			fmt.Fprintln(os.Stderr, locale.T("No events found."))
+			os.Exit(1)
+		}
+
+		_, copyflag := TakeFlag(os.Args[2:], "--copy")
+		out := OpenOutput("", time.Time{})
+		if copyflag {
+			UseColor = false
+			out.Copy()
+		}
+
+		now := Clock.Now()
+		fmt.Fprintln(out, FormatEventLine(last, timelog.DisplayWidth(last.Code), "", now))
+		if last.At.After(now) {
+			fmt.Fprintln(out, locale.T("Starts in %s.", FormatElapsed(last.At.Sub(now))))
+		} else {
+			fmt.Fprintln(out, locale.T("Open for %s.", timelog.FormatDuration(now.Sub(last.At), Durations)))
+		}
+
+		// Mention anything still going on other tracks, it is easy to forget to stop them.
+		for _, track := range log.Tracks() {
+			other := log.Last(track)
+			if track == Track || other.Code == "" {
+				continue
+			}
+			fmt.Fprintln(out, locale.T("Also open for %s: %s", timelog.FormatDuration(now.Sub(other.At), Durations), other.String()))
+		}
+		FinishOutput(out)
+		return
+
+	// Show the last few events.
+	case "recent":
+		n := 10
+		if len(os.Args) > 2 {
+			var err error
+			n, err = strconv.Atoi(os.Args[2])
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, "Invalid event count:", os.Args[2])
+				os.Exit(1)
+			}
+		}
+		if n > len(log) {
+			n = len(log)
+		}
+		if n == 0 {
+			fmt.Fprintln(os.Stderr, locale.T("No events found."))
+			os.Exit(1)
+		}
+
+		now := Clock.Now()
+		recent := log[len(log)-n:]
+		for i, item := range recent {
+			// Each event's period runs until the next event on its track, or until now for the last one.
+			end := now
+			for _, next := range log[len(log)-n+i+1:] {
+				if next.Track == item.Track {
+					end = next.At
+					break
+				}
+			}
+			length := ""
+			if !end.Before(item.At) {
+				length = timelog.FormatDuration(end.Sub(item.At), Durations)
+			}
+			fmt.Println(FormatEventLine(item, recent.CodeLen(), length, now))
+		}
+		return
+
+	// Handle the current elapsed time report.
+	case "since":
+		if last == nil {
+			fmt.Fprintln(os.Stderr, "No events found.")
+			os.Exit(1)
+		}
+
+		fmt.Printf("%s\n == %s ==>\n%s\n", last.String(), timelog.FormatDuration(Clock.Now().Sub(last.At), Durations), Clock.Now().Format(timelog.TimeFormat))
+		return
+
+	// Check the working time rules.
+	case "check":
+		args, filters := TakeReportFilters(os.Args[2:], config)
+		checklog := WithArchives(log, config["archives"])
+		if len(args) == 0 {
+			// The last 30 days.
+			checklog = checklog.After(Calendar.Day(Clock.Now()).AddDate(0, 0, -30))
+		} else if begin, end := ParseTimeRange(args); end == nil {
+			checklog = checklog.After(*begin)
+		} else {
+			checklog = checklog.Between(*begin, *end)
+		}
+
+		// Events in the future are a mistake whatever range is being checked, so the whole log is looked at.
+		future := log.Future(Clock.Now())
+		for _, event := range future {
+			fmt.Println(locale.T("Event in the future: %s", event.String()))
+		}
+		failed := len(future) > 0
+
+		// Only one of a set of events at the same time can have the time after it, see overlappolicy.
+		overlaps := checklog.Overlaps()
+		for _, o := range overlaps {
+			fmt.Println(locale.T("Events at the same time: %s", report.DescribeOverlap(o)))
+		}
+		failed = failed || len(overlaps) > 0
+		if !rest.Empty() {
+			violations := timelog.CheckRest(filters.Apply(checklog.PeriodsWith(OverlapPolicy)), rest, Calendar)
+			for _, v := range violations {
+				fmt.Println(report.DescribeViolation(v, Durations))
+			}
+			if len(violations) == 0 {
+				fmt.Println(locale.T("No rest rules were broken."))
+			}
+			failed = failed || len(violations) > 0
+		}
+
+		// Deliverables are no good as evidence if they have gone missing.
+		attached, missing := 0, false
+		for _, event := range checklog {
+			for _, a := range timelog.Attachments(event.Meta, AttachDir(config)) {
+				attached++
+				if !a.Exists() {
+					fmt.Println(locale.T("Missing attachment %s (%s) on %s", a.Name, a.Path, event.String()))
+					missing = true
+				}
+			}
+		}
+		if attached > 0 && !missing {
+			fmt.Println(locale.T("All %d attachment(s) are there.", attached))
+		}
+		failed = failed || missing
+		if rest.Empty() && attached == 0 && len(future) == 0 && len(overlaps) == 0 {
+			fmt.Println(locale.T("Nothing to check, set the restrules config, eg restrules=\"day=10h,rest=11h,week=48h\", or attach files to events."))
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+
+	// Point the last event at files.
+	case "attach":
+		args, atflag := TakeFlagValue(os.Args[2:], "--at")
+		event := last
+		if atflag != "" {
+			event = nil
+			if begin, _, ok := SelectEvents(log, strings.Fields(atflag)); ok {
+				event = log[begin]
+			}
+		}
+		if event == nil {
+			fmt.Fprintln(os.Stderr, "No events found.")
+			os.Exit(1)
+		}
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "'attach' needs at least one file.")
+			os.Exit(2)
+		}
+		names := []string{}
+		for _, a := range timelog.Attachments(event.Meta, AttachDir(config)) {
+			names = append(names, a.Name)
+		}
+		for _, file := range args {
+			name, err := AttachmentName(file, AttachDir(config))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error finding attachment:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if strings.Contains(name, ",") {
+				fmt.Fprintf(os.Stderr, "Attachments can't have a ',' in their path: %s\n", name)
+				os.Exit(2)
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		if event.Meta == nil {
+			event.Meta = map[string]string{}
+		}
+		event.Meta[timelog.AttachKey] = strings.Join(names, ", ")
+		fmt.Printf("Attached to %v\n", event.String())
+
+	// Money spent, as opposed to time.
+	case "expense":
+		ExpenseCommand(os.Args[2:], log, codeinfo, config["expensefile"])
+		return
+	case "travel":
+		TravelCommand(os.Args[2:], log, config["travelfile"], config["distanceunit"])
+		return
+
+	// Statistics about how the time was spent.
+	case "stats":
+		args, switches := TakeFlag(os.Args[2:], "--switches")
+		args, heatmap := TakeFlag(args, "--heatmap")
+		args, csvflag := TakeFlag(args, "--csv")
+		if switches == heatmap {
+			fmt.Fprintln(os.Stderr, "'stats' needs one of '--switches' or '--heatmap'.")
+			os.Exit(2)
+		}
+		args, filters := TakeReportFilters(args, config)
+		statslog := WithArchives(log, config["archives"])
+		var periods []*timelog.Period
+		if len(args) == 0 {
+			// The last 30 days.
+			periods = statslog.After(Calendar.Day(Clock.Now()).AddDate(0, 0, -30)).PeriodsWith(OverlapPolicy)
+		} else if begin, end := ParseTimeRange(args); end == nil {
+			periods = statslog.After(*begin).PeriodsWith(OverlapPolicy)
+		} else {
+			periods = statslog.Between(*begin, *end).PeriodsWith(OverlapPolicy)
+		}
+		periods = filters.Apply(periods)
+
+		if heatmap {
+			m := timelog.HourlyHeatMap(timelog.FilterOutPeriods(periods, "", FoldCodeCase))
+			if csvflag {
+				if err := WriteCSV(os.Stdout, HeatMapCSV(m)); err != nil {
+					fmt.Fprintln(os.Stderr, "Error writing heat map:")
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return
+			}
+			PrintHeatMap(os.Stdout, m)
+			return
 		}
 
-		last.Desc = strings.Join(os.Args[2:], " ")
-		fmt.Printf("Changed last event description to: %v\n", last.Desc)
-
-	// Handle the current state report.
-	case os.Args[1] == "status":
-		if last == nil {
-			fmt.Fprintln(os.Stderr, "No events found.")
-			os.Exit(1)
+		stats := timelog.Switches(timelog.FilterPeriods(periods, func(p *timelog.Period) bool {
+			return p.Track == Track
+		}), Calendar)
+		if len(stats.Days) == 0 {
+			fmt.Println("No time with a code to look at.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "Day\tSwitches\tBlocks\tAverage block")
+		for _, day := range stats.Days {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", day.Date.Format("Mon 2006/01/02"), day.Switches, day.Blocks, timelog.FormatDuration(day.AverageBlock(), Durations))
 		}
+		fmt.Fprintf(w, "Total\t%d\t%d\t%s\n", stats.Switches, stats.Blocks, timelog.FormatDuration(stats.AverageBlock(), Durations))
+		w.Flush()
+		fmt.Printf("%.1f switches a day, with blocks lasting %s on average.\n", stats.SwitchesPerDay(), timelog.FormatDuration(stats.AverageBlock(), Durations))
+
+		if len(stats.Transitions) > 0 {
+			fmt.Println()
+			fmt.Println("Most common switches:")
+			w = tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			for i, t := range stats.Transitions {
+				if i == 10 {
+					break
+				}
+				fmt.Fprintf(w, "    %s -> %s\t%d\n", t.From, t.To, t.Count)
+			}
+			w.Flush()
+		}
+		return
 
-		fmt.Println(last.String())
+	// Everything since the last time you asked, for a 1:1.
+	case "since-last":
+		SinceLastCommand(os.Args[2:], WithArchives(log, config["archives"]), config, capNow)
 		return
 
-	// Handle the current elapsed time report.
-	case os.Args[1] == "since":
-		if last == nil {
-			fmt.Fprintln(os.Stderr, "No events found.")
-			os.Exit(1)
-		}
+	// What you did, for the daily standup.
+	case "standup":
+		StandupCommand(os.Args[2:], WithArchives(log, config["archives"]), config, capNow)
+		return
 
-		fmt.Printf("%s\n == %.1fh ==>\n%s\n", last.String(), time.Now().Sub(last.At).Hours(), time.Now().Format(timelog.TimeFormat))
+	// For the scripts from 'completion'.
+	case "__complete":
+		CompleteCommand(os.Args[2:], codes, TemplateNames(config["reportsdir"], codeinfo))
 		return
 
 	// Test input handling.
-	case os.Args[1] == "test":
+	case "test":
 		if len(os.Args) <= 2 {
 			fmt.Fprintln(os.Stderr, "Not enough arguments.")
 			os.Exit(1)
 		}
 
-		t, c, d := ParseLine(os.Args[2:], codes, !ToolMode)
+		args, explain := TakeFlag(os.Args[2:], "--explain")
+		if explain {
+			Explain = &Explanation{}
+		}
+
+		t, c, d := ParseLine(args, codes, Interactive)
 		last = &timelog.Event{
-			At:   t,
-			Code: c,
-			Desc: d,
+			At:    t,
+			Track: Track,
+			Code:  c,
+			Desc:  d,
+		}
+		last.Desc, err = ExpandDesc(last, log, codeinfo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(7)
 		}
 		fmt.Printf("%s\n", last.String())
 		if c == "" {
 			fmt.Fprintln(os.Stderr, "No time code found, use 'code' to specify one.")
 		}
-		if d == "" {
+		if last.Desc == "" {
 			fmt.Fprintln(os.Stderr, "No description found, use 'note' to specify one.")
 		}
+		if why := Surprising(t, Clock.Now()); why != "" && !Strict {
+			fmt.Fprintf(os.Stderr, "This time would need confirming (or --yes), %s.\n", why)
+		}
+		if explain {
+			fmt.Println()
+			Explain.Print(os.Stdout)
+		}
 		return
 
 	// Handle the default clock in/out action
 	default:
-		t, c, d := ParseLine(os.Args[1:], codes, !ToolMode)
+		t, c, d := ParseLine(os.Args[1:], codes, Interactive)
+		ConfirmSurprising(t, yesFlag)
 		old := last
 
-		if t.Before(old.At) {
-			fmt.Fprintf(os.Stderr, "Given time (%s) is before previous event time (%s).\n", t.Format(timelog.TimeFormat), old.At.Format(timelog.TimeFormat))
-			os.Exit(1)
+		last = &timelog.Event{
+			At:    t,
+			Track: Track,
+			Code:  c,
+			Desc:  d,
+		}
+		last.Desc, err = ExpandDesc(last, log, codeinfo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(7)
+		}
+		if len(EventMeta) > 0 {
+			last.Meta = maps.Clone(EventMeta)
 		}
 
-		last = &timelog.Event{
-			At:   t,
-			Code: c,
-			Desc: d,
+		// The very first event has nothing to be compared against.
+		if old != nil && t.Before(old.At) {
+			fmt.Fprintf(os.Stderr, "Given time (%s) is before previous event time (%s).\n", t.Format(timelog.TimeFormat), old.At.Format(timelog.TimeFormat))
+			if !allowBackdate && !Interactive {
+				fmt.Fprintln(os.Stderr, "Use --allow-backdate to insert it in order anyway.")
+				os.Exit(1)
+			}
+			if !allowBackdate && !Confirm("Insert it in order anyway") {
+				os.Exit(1)
+			}
+
+			// Inserting splits an existing period in two, so show both halves. The events either side
+			// may be on other tracks, it is the ones on this track the period is split between.
+			var i int
+			log, i = log.Insert(last)
+			if prev := log[:i].Last(Track); prev != nil {
+				fmt.Printf("%s\n == %s ==> \n", prev.String(), timelog.FormatDuration(last.At.Sub(prev.At), Durations))
+			}
+			next := log[i+1:].First(Track)
+			fmt.Printf("%s\n == %s ==> \n", last.String(), timelog.FormatDuration(next.At.Sub(last.At), Durations))
+			fmt.Printf("%s\n", next.String())
+			if c == "" || last.Desc == "" {
+				// 'code' and 'note' work on the last event, which this isn't.
+				fmt.Fprintln(os.Stderr, "Missing time code or description, use 'edit' with the event time to fix it.")
+			}
+			break
 		}
+
 		log = append(log, last)
 
 		if old != nil {
-			fmt.Printf("%s\n == %.1fh ==> \n", old.String(), last.At.Sub(old.At).Hours())
+			fmt.Printf("%s\n == %s ==> \n", old.String(), timelog.FormatDuration(last.At.Sub(old.At), Durations))
 		}
 		fmt.Printf("%s\n", last.String())
+		if len(log) == 1 && len(problems) == 0 {
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintln(os.Stderr, "This is the first event in your timelog, welcome!")
+			fmt.Fprintln(os.Stderr, "Each event marks the end of the previous period and the start of the next one, so")
+			fmt.Fprintln(os.Stderr, "this event opens a period that runs until your next event. To clock out, create an")
+			fmt.Fprintln(os.Stderr, "event with no time code (eg 'timeclock now done for the day'), periods without a")
+			fmt.Fprintln(os.Stderr, "code are left out of reports by default.")
+		}
 		if c == "" {
 			fmt.Fprintln(os.Stderr, "No time code found, use 'code' to specify one.")
 		}
-		if d == "" {
+		if last.Desc == "" {
 			fmt.Fprintln(os.Stderr, "No description found, use 'note' to specify one.")
 		}
 	}
 
-	// Reset the file so we can dump any output back where we got it.
-	// You can't just truncate, you can't just reset the pointer, you need to do *both*
-	err = sheetF.Truncate(0)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	if store == nil {
+		fmt.Fprintln(os.Stderr, "The timelog was read from stdin, so changes to it can't be saved.")
 		os.Exit(1)
 	}
-	_, err = sheetF.Seek(0, 0)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "Refusing to write timelog, %v malformed line(s) would be lost. Fix them and try again.\n", len(problems))
+		os.Exit(8)
+	}
+
+	if logVersion > timelog.FormatVersion {
+		fmt.Fprintln(os.Stderr, "Refusing to write timelog, it is from a newer version of timeclock. Upgrade to change it.")
+		os.Exit(8)
+	}
+
+	if needsMigrate && command != "migrate" {
+		fmt.Fprintln(os.Stderr, "Refusing to write timelog until it is upgraded with 'timeclock migrate'.")
+		os.Exit(8)
+	}
+
+	if changed := locks.Changed(log, locked); len(changed) > 0 {
+		fmt.Fprintln(os.Stderr, "Refusing to write timelog, this would change events in a finalized range:")
+		for _, l := range changed {
+			fmt.Fprintln(os.Stderr, "    "+l.String())
+		}
+		fmt.Fprintf(os.Stderr, "Remove the range from %s to change it anyway.\n", config["lockfile"])
 		os.Exit(1)
 	}
 
+	// Enforce the ordering policy. Everything is sorted on load, so anything out of order now was done by this run.
+	tx := timelog.Begin(log)
+	if moved := log.OutOfOrder(); len(moved) > 0 {
+		switch config["ordering"] {
+		case "error":
+			for _, i := range moved {
+				fmt.Fprintf(os.Stderr, "Event is before the event preceding it: %s\n", log[i].String())
+			}
+			fmt.Fprintln(os.Stderr, "Refusing to write timelog with events out of order. (see the 'ordering' config key)")
+			os.Exit(1)
+		case "warn":
+			for _, i := range moved {
+				fmt.Fprintf(os.Stderr, "Event moved to keep the timelog in order: %s\n", log[i].String())
+			}
+		}
+		tx.Sort()
+	}
+
 	// Dump the new timesheet.
-	err = log.Format(sheetF)
+	err = tx.Commit(store)
+	if errors.As(err, &timelog.ErrInvalid{}) {
+		fmt.Fprintln(os.Stderr, "Refusing to write timelog, it wouldn't read back the same:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(8)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	log = tx.Log()
+	if rep != nil {
+		// Not committed, anything the other devices did since the start of this run is merged in next time.
+		_, _, err = rep.Sync(log)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Timelog written, but recording the changes in the replica failed (they will be next time):")
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	afterWrite()
 }
 
 type FoundCode struct {
@@ -487,19 +2430,31 @@ func FindAllTimecodes(candidates []string, codes []string) (map[string][]FoundCo
 
 	total := 0
 
+	// A wildcard can be for a code that is only ever used as a parent.
+	var parents []string
+
 	// Match each candidate against the possible codes.
 	for candidate := range foundcodes {
-		for _, code := range codes {
-			c, hasWildcard := strings.CutSuffix(candidate, ":...")
+		c, _, hasWildcard := timelog.CutWildcard(candidate)
+		against := codes
+		if hasWildcard {
+			if parents == nil {
+				parents = timelog.WithParents(codes)
+			}
+			against = parents
+		}
 
-			found := fuzzy.RankMatchNormalizedFold(c, code)
+		for _, code := range against {
+
+			found := fuzzy.RankMatchNormalizedFold(FoldCode(c), FoldCode(code))
 			if found == -1 {
 				continue
 			}
 
 			total++
+			Debug.Debug("time code candidate", "input", candidate, "code", code, "distance", found)
 			if hasWildcard {
-				foundcodes[candidate] = append(foundcodes[candidate], FoundCode{Code: code + ":...", Found: candidate, Distance: found})
+				foundcodes[candidate] = append(foundcodes[candidate], FoundCode{Code: code + candidate[len(c):], Found: candidate, Distance: found})
 				continue
 			}
 			foundcodes[candidate] = append(foundcodes[candidate], FoundCode{Code: code, Found: candidate, Distance: found})
@@ -522,12 +2477,23 @@ var DateParser = dateparser.Parser{}
 
 // Returns the first time found, a time code if one is found, and the whole line with minor editing.
 func ParseLine(l []string, codes []string, canprompt bool) (time.Time, string, string) {
+	if Strict {
+		at, code, desc := ParseStrict(l)
+		if code == "" && codes != nil {
+			why := ""
+			code, why = FindDefaultCode()
+			if Explain != nil && code != "" {
+				Explain.Code = &FoundCode{Code: code}
+				Explain.CodeWhy = why
+			}
+		}
+		return at, code, desc
+	}
+
 	whole := strings.Join(l, " ")
 
 	// Try to find a time in the description
-	times, err := DateParser.SearchWithLanguage(&dateparser.Configuration{
-		CurrentTime: time.Now().Local(),
-	}, "en", whole)
+	times, err := SearchTimes(whole)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -544,6 +2510,15 @@ func ParseLine(l []string, codes []string, canprompt bool) (time.Time, string, s
 		os.Exit(1)
 	}
 
+	anchored := ""
+	times[0].Date.Time, anchored = Anchor(times[0].Text, times[0].Date.Time)
+
+	for i, t := range times {
+		Debug.Debug("time candidate", "text", t.Text, "time", t.Date.Time.Format(timelog.TimeFormat), "picked", i == 0)
+		if Explain != nil {
+			Explain.Times = append(Explain.Times, ExplainedTime{Text: t.Text, Time: t.Date.Time})
+		}
+	}
 	if len(times) > 1 {
 		fmt.Fprintln(os.Stderr, "Multiple times found in input, using first one found.")
 	}
@@ -551,70 +2526,274 @@ func ParseLine(l []string, codes []string, canprompt bool) (time.Time, string, s
 	// Try to find a time code.
 	code := FoundCode{}
 	found, total := FindAllTimecodes(l, codes)
-	if total > 1 && canprompt {
-		fmt.Fprintln(os.Stdout, "Multiple possible time codes found in input:")
 
-		foundstrings := []string{}
-		foundmap := []struct {
-			c string
-			i int
-		}{}
-		for c, l := range found {
-			for i, v := range l {
-				foundstrings = append(foundstrings, v.Code)
-				foundmap = append(foundmap, struct {
-					c string
-					i int
-				}{c, i})
-			}
+	// Flatten the candidates into a stable order, best match first, so the list (and --choose) is predictable.
+	candidates := []FoundCode{}
+	for _, items := range found {
+		candidates = append(candidates, items...)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Distance != candidates[j].Distance {
+			return candidates[i].Distance < candidates[j].Distance
+		}
+		if candidates[i].Code != candidates[j].Code {
+			return candidates[i].Code < candidates[j].Code
 		}
+		return candidates[i].Found < candidates[j].Found
+	})
+
+	why := ""
+	switch {
+	case total > 1 && Choose > 0:
+		if Choose > len(candidates) {
+			fmt.Fprintf(os.Stderr, "Cannot choose time code %v, there are only %v candidates.\n", Choose, len(candidates))
+			os.Exit(1)
+		}
+		code = candidates[Choose-1]
+		why = "picked with --choose"
+		Debug.Debug("picked time code", "code", code.Code, "why", "--choose")
+
+	case total > 1 && canprompt:
+		fmt.Fprintln(os.Stdout, "Multiple possible time codes found in input:")
 
-		prompt := promptui.Select{
-			Label: "Select Code",
-			Items: foundstrings,
+		items := []string{}
+		for _, candidate := range candidates {
+			items = append(items, candidate.Code)
 		}
-		i, _, err := prompt.Run()
+		i, err := ChooseOne("Select Code", items)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
+		code = candidates[i]
+		why = "picked from the prompt"
+		Debug.Debug("picked time code", "code", code.Code, "why", "prompt")
 
-		code = found[foundmap[i].c][foundmap[i].i]
-	} else if len(found) > 0 {
+	case len(candidates) > 0:
 		if total > 1 {
-			fmt.Fprintln(os.Stderr, "Multiple possible time codes found in input, picking best match.")
-		}
-
-		best := FoundCode{Distance: -1}
-		for _, item := range found {
-			if item[0].Distance < best.Distance || best.Distance == -1 {
-				best = item[0]
+			fmt.Fprintln(os.Stderr, "Multiple possible time codes found in input, picking best match. Use --choose <n> to pick another:")
+			for i, candidate := range candidates {
+				fmt.Fprintf(os.Stderr, "%3d: %s\n", i+1, candidate.Code)
 			}
 		}
-		code = best
+		code = candidates[0]
+		why = "best match"
+		Debug.Debug("picked time code", "code", code.Code, "why", "best match", "distance", code.Distance)
+
+	case codes != nil:
+		code.Code, why = FindDefaultCode()
+		if code.Code == "" {
+			Debug.Debug("no time code found")
+			break
+		}
+		Debug.Debug("picked time code", "code", code.Code, "why", why)
+
+	default:
+		Debug.Debug("no time code found")
 	}
 
+	edits := []ExplainedEdit{}
+
 	// If the time code and time prefix the string (in any order), strip them.
-	for _, v := range []string{":" + code.Found, times[0].Text, ":" + code.Found} {
+	for i, v := range []string{":" + code.Found, times[0].Text, ":" + code.Found} {
 		if strings.HasPrefix(whole, v) {
 			whole = strings.TrimSpace(strings.TrimPrefix(whole, v))
+			what := "the time"
+			if i != 1 {
+				what = "the time code"
+			}
+			edits = append(edits, ExplainedEdit{Text: v, Why: "was removed from the start, it is " + what})
 		}
 	}
 
+	if strings.Contains(whole, times[0].Text) {
+		edits = append(edits, ExplainedEdit{Text: times[0].Text, Why: "was left in, it is the time but is part of the sentence"})
+	}
 	// Strip the prefix colon from the first occurrence of the chosen timecode.
-	whole = strings.Replace(whole, ":"+code.Found, code.Found, 1)
+	if code.Found != "" && strings.Contains(whole, ":"+code.Found) {
+		whole = strings.Replace(whole, ":"+code.Found, code.Found, 1)
+		edits = append(edits, ExplainedEdit{Text: ":" + code.Found, Why: "lost its ':', it is the time code but is part of the sentence"})
+	}
+
+	at := times[0].Date.Time.Round(6 * time.Minute)
+	if Explain != nil {
+		Explain.Input = strings.Join(l, " ")
+		Explain.Rounded = at
+		Explain.Anchored = anchored
+		Explain.Candidates = candidates
+		if code.Code != "" {
+			Explain.Code = &code
+			Explain.CodeWhy = why
+		}
+		Explain.Edits = edits
+		Explain.Desc = whole
+	}
+
+	return at, code.Code, whole
+}
+
+// SelectEvents picks a range of events from the log based on the arguments. The arguments may be empty (the last
+// event), a count (the last N events), or a time (the event in effect at that time). Returns the range as indexes into
+// the log, and false if nothing could be selected.
+func SelectEvents(log timelog.TimeLog, args []string) (int, int, bool) {
+	if len(log) == 0 {
+		return 0, 0, false
+	}
+
+	if len(args) == 0 {
+		return len(log) - 1, len(log), true
+	}
+
+	if n, err := strconv.Atoi(strings.Join(args, " ")); err == nil {
+		if n < 1 {
+			return 0, 0, false
+		}
+		if n > len(log) {
+			n = len(log)
+		}
+		return len(log) - n, len(log), true
+	}
+
+	// This looks back at events that already happened, anchoring to the last one would only ever find the last one.
+	anchor := AnchorAt
+	AnchorAt = time.Time{}
+	at, _, _ := ParseLine(args, nil, false)
+	AnchorAt = anchor
+	for i := len(log) - 1; i >= 0; i-- {
+		if !log[i].At.After(at) {
+			return i, i + 1, true
+		}
+	}
+	return 0, 0, false
+}
 
-	return times[0].Date.Time.Round(6 * time.Minute), code.Code, whole
+// SelectRange returns the indexes of the events in the (sorted) log that fall between the given times, using the same
+// rules as [timelog.TimeLog.Between]. If end is nil everything after begin is selected.
+func SelectRange(log timelog.TimeLog, begin time.Time, end *time.Time) (int, int) {
+	first := sort.Search(len(log), func(i int) bool {
+		return log[i].At.After(begin)
+	})
+	if end == nil {
+		return first, len(log)
+	}
+	last := sort.Search(len(log), func(i int) bool {
+		return !log[i].At.Before(*end)
+	})
+	if last < first {
+		last = first
+	}
+	return first, last
 }
 
 // Returns the first two times found and a code if provided.
-func ParseReportRequest(l []string, codes []string, reports *template.Template) (*time.Time, *time.Time, []string, *template.Template) {
+func ParseReportRequest(l []string, codes []string, reports *template.Template, fallback string) (*time.Time, *time.Time, []string, *template.Template) {
+	// Patterns go first, dateparser doesn't like them much.
+	l, foundcodes := TakeCodePatterns(l, codes)
+	begin, end := ParseTimeRange(l)
+
+	// Try to find a time code.
+	found, _ := FindAllTimecodes(l, codes)
+	for _, f := range found {
+		foundcodes = append(foundcodes, f[0].Code)
+	}
+
+	// Find the template
+	foundtemplates := []*template.Template{}
+	for _, word := range l {
+		foundtmpl := reports.Lookup(word)
+		if foundtmpl != nil {
+			foundtemplates = append(foundtemplates, foundtmpl)
+		}
+	}
+
+	template := reports.Lookup(fallback)
+	for _, t := range foundtemplates {
+		Debug.Debug("template candidate", "name", t.Name())
+	}
+	if len(foundtemplates) > 1 {
+		fmt.Fprintln(os.Stderr, locale.T("Multiple templates found in input, using first one found."))
+	}
+
+	if len(foundtemplates) != 0 {
+		template = foundtemplates[0]
+	}
+	if template != nil {
+		Debug.Debug("picked template", "name", template.Name(), "fallback", len(foundtemplates) == 0)
+	}
+
+	return begin, end, foundcodes, template
+}
+
+// ReportFilters are the options shared by everything that reports on time, see TakeReportFilters.
+type ReportFilters struct {
+	Where      map[string]string
+	Overlap    timelog.OverlapMode
+	Transforms timelog.Pipeline
+
+	Key string // Sums up the options as given, for caching.
+}
+
+// Apply runs the periods through the overlap mode and transforms, then keeps those that match Where and any other
+// filters given.
+func (f *ReportFilters) Apply(periods []*timelog.Period, filters ...timelog.PeriodFilter) []*timelog.Period {
+	periods = append(timelog.Pipeline{f.Overlap}, f.Transforms...).Transform(periods)
+	for k, v := range f.Where {
+		filters = append(filters, timelog.MatchMeta(k, v))
+	}
+	return timelog.FilterPeriods(periods, filters...)
+}
+
+// TakeReportFilters pulls the --overlap, --where, and --transform flags out of the arguments, and parses them with the
+// defaults from the config.
+func TakeReportFilters(args []string, config map[string]string) ([]string, *ReportFilters) {
+	args, overlapflag := TakeFlagValue(args, "--overlap")
+	args, whereflags := TakeFlagValues(args, "--where")
+	args, transformflags := TakeFlagValues(args, "--transform")
+
+	filters := &ReportFilters{}
+	transforms, err := timelog.ParseTransformers(strings.Split(config["transforms"], ","), Calendar, FoldCodeCase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid transforms config:", err)
+		os.Exit(6)
+	}
+	extra, err := timelog.ParseTransformers(transformflags, Calendar, FoldCodeCase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid --transform:", err)
+		os.Exit(2)
+	}
+	filters.Transforms = append(transforms, extra...)
+
+	filters.Where, err = ParseMeta(whereflags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid --where:", err)
+		os.Exit(2)
+	}
+
+	if overlapflag == "" {
+		overlapflag = config["overlap"]
+	}
+	filters.Overlap, err = timelog.ParseOverlapMode(overlapflag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	where := []string{}
+	for k, v := range filters.Where {
+		where = append(where, k+"="+v)
+	}
+	sort.Strings(where)
+	filters.Key = fmt.Sprintf("overlap=%d where=%q transforms=%q,%q", filters.Overlap, where, config["transforms"], transformflags)
+
+	return args, filters
+}
+
+// ParseTimeRange returns the first two times found, in order. If only one time is found the end is nil.
+func ParseTimeRange(l []string) (*time.Time, *time.Time) {
 	whole := strings.Join(l, " ")
 
 	// Try to find a time in the description
-	times, err := DateParser.SearchWithLanguage(&dateparser.Configuration{
-		CurrentTime: time.Now().Local(),
-	}, "en", whole)
+	times, err := SearchTimes(whole)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -625,11 +2804,15 @@ func ParseReportRequest(l []string, codes []string, reports *template.Template)
 		os.Exit(1)
 	}
 
+	for i, t := range times {
+		Debug.Debug("time candidate", "text", t.Text, "time", t.Date.Time.Format(timelog.TimeFormat), "picked", i < 2)
+	}
+
 	var begin, end time.Time
 
-	begin = times[0].Date.Time
+	begin = rangeTime(times[0])
 	if len(times) > 1 {
-		end = times[1].Date.Time
+		end = rangeTime(times[1])
 
 		if begin.After(end) {
 			begin, end = end, begin
@@ -640,35 +2823,160 @@ func ParseReportRequest(l []string, codes []string, reports *template.Template)
 		fmt.Fprintln(os.Stderr, "Multiple times found in input, using first two found.")
 	}
 
-	// Try to find a time code.
-	found, _ := FindAllTimecodes(l, codes)
-	var foundcodes []string
-	for _, f := range found {
-		foundcodes = append(foundcodes, f[0].Code)
+	if len(times) > 1 {
+		return &begin, &end
 	}
+	return &begin, nil
+}
 
-	// Find the template
-	foundtemplates := []*template.Template{}
-	for _, word := range l {
-		foundtmpl := reports.Lookup(word)
-		if foundtmpl != nil {
-			foundtemplates = append(foundtemplates, foundtmpl)
+// rangeTime is where a time in a range really is. A date on its own (which comes back as midnight) is the start of that
+// day, and so are "today", "yesterday", and "tomorrow", which would otherwise be the time now on that day. With the
+// daystart config these are the day that started last at DayStart, not midnight.
+func rangeTime(found dateparser.SearchResult) time.Time {
+	switch strings.ToLower(strings.TrimSpace(found.Text)) {
+	case "today":
+		return Calendar.Day(Clock.Now())
+	case "yesterday":
+		return Calendar.Day(Clock.Now()).AddDate(0, 0, -1)
+	case "tomorrow":
+		return Calendar.Day(Clock.Now()).AddDate(0, 0, 1)
+	}
+
+	t := found.Date.Time.In(time.Local)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return Calendar.StartOfDay(t.Year(), t.Month(), t.Day())
+	}
+	return t
+}
+
+// PrintParseError prints a timelog parse error to stderr, along with a snippet showing where the problem is if the
+// error has one.
+func PrintParseError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	var ctx interface{ Snippet() string }
+	if errors.As(err, &ctx) {
+		fmt.Fprintln(os.Stderr, "    "+strings.ReplaceAll(ctx.Snippet(), "\n", "\n    "))
+	}
+}
+
+// WithArchives returns the log with the events from the archives (see ReadArchives) added, sorted. The log itself is
+// returned if there are no archives.
+func WithArchives(log timelog.TimeLog, patterns string) timelog.TimeLog {
+	archives := ReadArchives(patterns)
+	if len(archives) == 0 {
+		return log
+	}
+
+	archived, problems := timelog.ParseShards(archives, 0)
+	for _, problem := range problems {
+		PrintParseError(problem)
+	}
+	archived = append(archived, log...)
+	archived.Sort()
+	return archived
+}
+
+// ReadArchives reads the archived timelog files matching a comma separated list of glob patterns, sorted by name
+// within each pattern. Archives are only ever read, nothing writes to them.
+func ReadArchives(patterns string) []timelog.Shard {
+	shards := []timelog.Shard{}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		// Glob only fails for a bad pattern, and sorts its results.
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid archives pattern %q: %v\n", pattern, err)
+			os.Exit(6)
+		}
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error reading archived timelog:")
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(8)
+			}
+			shards = append(shards, timelog.Shard{Name: file, Content: string(content)})
 		}
 	}
+	return shards
+}
 
-	template := reports.Lookup("default.tmpl")
-	if len(foundtemplates) > 1 {
-		fmt.Fprintln(os.Stderr, "Multiple templates found in input, using first one found.")
+// TakeFlag removes any of the given flags from the argument list, and reports if any were found.
+func TakeFlag(args []string, flags ...string) ([]string, bool) {
+	out := []string{}
+	found := false
+	for _, arg := range args {
+		if slices.Contains(flags, arg) {
+			found = true
+			continue
+		}
+		out = append(out, arg)
 	}
+	return out, found
+}
 
-	if len(foundtemplates) != 0 {
-		template = foundtemplates[0]
+// TakeFlagValue removes a flag and its value from the argument list, and returns the value. Both "--flag value" and
+// "--flag=value" forms are accepted. If the flag is given more than once the last value wins.
+func TakeFlagValue(args []string, flag string) ([]string, string) {
+	out := []string{}
+	value := ""
+	for i := 0; i < len(args); i++ {
+		if v, ok := strings.CutPrefix(args[i], flag+"="); ok {
+			value = v
+			continue
+		}
+		if args[i] == flag && i+1 < len(args) {
+			value = args[i+1]
+			i++
+			continue
+		}
+		out = append(out, args[i])
 	}
+	return out, value
+}
 
-	if len(times) > 1 {
-		return &begin, &end, foundcodes, template
+// TakeFlagValues is TakeFlagValue for flags that may be given more than once, returning every value in order.
+func TakeFlagValues(args []string, flag string) ([]string, []string) {
+	out := []string{}
+	values := []string{}
+	for i := 0; i < len(args); i++ {
+		if v, ok := strings.CutPrefix(args[i], flag+"="); ok {
+			values = append(values, v)
+			continue
+		}
+		if args[i] == flag && i+1 < len(args) {
+			values = append(values, args[i+1])
+			i++
+			continue
+		}
+		out = append(out, args[i])
 	}
-	return &begin, nil, foundcodes, template
+	return out, values
+}
+
+// ParseMeta parses a list of "key=value" metadata entries, skipping blank entries. Values may be blank.
+func ParseMeta(entries []string) (map[string]string, error) {
+	meta := map[string]string{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(entry, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("expected key=value, found %q", entry)
+		}
+		if strings.ContainsAny(k, ":\r\n") || strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("metadata keys may not contain ':', and neither keys nor values may contain line breaks: %q", entry)
+		}
+		meta[k] = v
+	}
+	return meta, nil
 }
 
 // This is prehistoric code, based on stuff originally written for Rubble
@@ -700,44 +3008,20 @@ func ParseINI(input string, result map[string]string) {
 	}
 }
 
-func loadTemplatesFrom(f fs.FS, t *template.Template) {
-	err := fs.WalkDir(f, ".", func(path string, d fs.DirEntry, err error) error {
-		if d == nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return nil
-			}
-			return err
-		}
-
-		if d.IsDir() {
-			return nil
+// ParseINISections is ParseINI for files where the sections matter. Keys before the first section go in the "" section.
+func ParseINISections(input string, result map[string]map[string]string) {
+	section := ""
+	lines := strings.Split(input, "\n")
+	for i := range lines {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
 		}
 
-		if strings.HasSuffix(d.Name(), ".tmpl") {
-			file, err := f.Open(path)
-			if err != nil {
-				return err
-			}
-			content, err := io.ReadAll(file)
-			if err != nil {
-				return err
-			}
-
-			nt := t.Lookup(d.Name())
-			if nt == nil {
-				_, err = t.New(d.Name()).Parse(string(content))
-				return err
-			}
-			_, err = nt.Parse(string(content))
-			return err
+		if result[section] == nil {
+			result[section] = map[string]string{}
 		}
-
-		return nil
-	})
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error reading report templates:")
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(9)
+		ParseINI(line, result[section])
 	}
-	return
 }
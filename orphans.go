@@ -0,0 +1,130 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// CodeSections returns the codes that have a section in a timecode file, in the order they appear. Sections with
+// nothing in them count too.
+func CodeSections(raw string) []string {
+	sections := []string{}
+	for _, line := range strings.Split(raw, "\n") {
+		if code, ok := sectionHeader(line); ok && code != "" {
+			sections = append(sections, code)
+		}
+	}
+	return sections
+}
+
+// sectionHeader returns the code a line is the section header for, if it is one.
+func sectionHeader(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+		return strings.TrimSpace(line[1 : len(line)-1]), true
+	}
+	return "", false
+}
+
+// OrphanedCodes compares the sections of a timecode file with the codes used in a timelog. Since settings are inherited,
+// a section is used if the code or any of its children is, and a code is covered if it or any of its parents has a
+// section. Returns the sections nothing uses, and the codes nothing covers, both sorted.
+func OrphanedCodes(sections, used []string) (unused, missing []string) {
+	has := map[string]bool{}
+	for _, code := range sections {
+		has[code] = true
+	}
+
+	reached := map[string]bool{}
+	for _, code := range used {
+		covered := false
+		for c := code; ; {
+			if has[c] {
+				reached[c] = true
+				covered = true
+			}
+			i := strings.LastIndex(c, ":")
+			if i == -1 {
+				break
+			}
+			c = c[:i]
+		}
+		if !covered {
+			missing = append(missing, code)
+		}
+	}
+
+	for code := range has {
+		if !reached[code] {
+			unused = append(unused, code)
+		}
+	}
+	sort.Strings(unused)
+	sort.Strings(missing)
+	return unused, missing
+}
+
+// PruneCodeSections removes sections from a timecode file, along with everything in them and any comment lines directly
+// above the header. Everything else is left as it was.
+func PruneCodeSections(raw string, drop []string) string {
+	dropping := map[string]bool{}
+	for _, code := range drop {
+		dropping[code] = true
+	}
+
+	out := []string{}
+	skip := false
+	for _, line := range strings.Split(raw, "\n") {
+		if code, ok := sectionHeader(line); ok {
+			skip = dropping[code]
+			if skip {
+				// The comments above a section are about that section.
+				for len(out) > 0 && strings.HasPrefix(strings.TrimSpace(out[len(out)-1]), "#") {
+					out = out[:len(out)-1]
+				}
+			}
+		}
+		if !skip {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// AddCodeSections adds an empty section to the end of a timecode file for each code, ready to be filled in.
+func AddCodeSections(raw string, codes []string) string {
+	b := &strings.Builder{}
+	b.WriteString(raw)
+	if raw != "" && !strings.HasSuffix(raw, "\n") {
+		b.WriteString("\n")
+	}
+	for _, code := range codes {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("[" + code + "]\n")
+	}
+	return b.String()
+}
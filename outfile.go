@@ -0,0 +1,158 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Output is where a command writes its results: stdout, or a file picked with -o, and maybe the clipboard too. Output
+// for a file is held until Close, so a command that fails part way doesn't leave half a file behind.
+type Output struct {
+	io.Writer
+
+	Path string // The file being written, blank for stdout.
+	buf  *bytes.Buffer
+	clip *bytes.Buffer
+}
+
+// OpenOutput returns an Output for the file named by pattern, with strftime style tokens filled in from t (see
+// Strftime). A blank pattern is stdout.
+func OpenOutput(pattern string, t time.Time) *Output {
+	if pattern == "" {
+		return &Output{Writer: os.Stdout}
+	}
+	buf := &bytes.Buffer{}
+	return &Output{Writer: buf, Path: Strftime(pattern, t), buf: buf}
+}
+
+// Copy makes everything written to the output go on the clipboard as well, once it is closed. This is --copy.
+func (o *Output) Copy() {
+	o.clip = &bytes.Buffer{}
+	o.Writer = io.MultiWriter(o.Writer, o.clip)
+}
+
+// Close writes the output to its file, creating any missing directories, and copies it if Copy was called.
+func (o *Output) Close() error {
+	if o.Path != "" {
+		if dir := filepath.Dir(o.Path); dir != "." {
+			err := os.MkdirAll(dir, 0755)
+			if err != nil {
+				return err
+			}
+		}
+		err := os.WriteFile(o.Path, o.buf.Bytes(), 0666)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", o.Path)
+	}
+
+	if o.clip != nil {
+		err := CopyToClipboard(o.clip.Bytes())
+		if err != nil {
+			return fmt.Errorf("copying to the clipboard: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Copied to the clipboard.")
+	}
+	return nil
+}
+
+// Strftime formats t with C strftime style tokens, the common ones anyway: %Y %y %m %d %e %H %I %M %S %p %j %a %A %b
+// %B %F, the ISO week tokens %G %g %V %u, and %% for a plain '%'. Anything else is left as is.
+func Strftime(format string, t time.Time) string {
+	b := &strings.Builder{}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			b.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		year, week := t.ISOWeek()
+		switch format[i] {
+		case 'Y':
+			fmt.Fprintf(b, "%04d", t.Year())
+		case 'y':
+			fmt.Fprintf(b, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(b, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(b, "%2d", t.Day())
+		case 'H':
+			fmt.Fprintf(b, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(b, "%02d", (t.Hour()+11)%12+1)
+		case 'M':
+			fmt.Fprintf(b, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(b, "%02d", t.Second())
+		case 'p':
+			b.WriteString(t.Format("PM"))
+		case 'j':
+			fmt.Fprintf(b, "%03d", t.YearDay())
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'b':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'F':
+			b.WriteString(t.Format("2006-01-02"))
+		case 'G':
+			fmt.Fprintf(b, "%04d", year)
+		case 'g':
+			fmt.Fprintf(b, "%02d", year%100)
+		case 'V':
+			fmt.Fprintf(b, "%02d", week)
+		case 'u':
+			fmt.Fprintf(b, "%d", (int(t.Weekday())+6)%7+1)
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
+
+// FinishOutput closes the output, exiting if the file can't be written.
+func FinishOutput(out *Output) {
+	err := out.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
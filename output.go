@@ -0,0 +1,159 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// UseColor is true if output to stdout should be colorized. Color is only used when stdout is a terminal, and never if
+// NO_COLOR is set (see https://no-color.org).
+var UseColor = func() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}()
+
+// TerminalWidth is the width of the terminal on stdout in columns, from $COLUMNS or stty, or 80 if neither knows. It
+// is 0 if stdout isn't a terminal at all.
+func TerminalWidth() int {
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return 0
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdout
+	size, err := cmd.Output()
+	if err == nil {
+		fields := strings.Fields(string(size))
+		if len(fields) == 2 {
+			if n, err := strconv.Atoi(fields[1]); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 80
+}
+
+// codeColors are the ANSI foreground colors used for time codes, picked to be readable on both light and dark
+// backgrounds. Blacks and whites are left out for that reason.
+var codeColors = []int{31, 32, 33, 34, 35, 36, 91, 92, 93, 94, 95, 96}
+
+// ColorCode wraps the given text in the color for the given time code. The color is picked from a hash of the code, so
+// a code always gets the same color. Returns text unchanged if color is disabled or the code is blank.
+func ColorCode(code string, text string) string {
+	if !UseColor || strings.TrimSpace(code) == "" {
+		return text
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(code))
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", codeColors[h.Sum32()%uint32(len(codeColors))], text)
+}
+
+// Dim makes text less prominent, if color is enabled.
+func Dim(text string) string {
+	if !UseColor {
+		return text
+	}
+	return "\x1b[2m" + text + "\x1b[0m"
+}
+
+// FormatElapsed formats a duration for humans at a glance, eg "2h 13m" or "3d 4h". Precision drops as the duration
+// grows, nobody cares about the minutes on something from last week.
+func FormatElapsed(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	d = d.Truncate(time.Minute)
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// FormatRelative describes when t is relative to now, eg "2h 13m ago" or "in 5m".
+func FormatRelative(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d > -time.Minute && d < time.Minute:
+		return "just now"
+	case d < 0:
+		return "in " + FormatElapsed(d)
+	default:
+		return FormatElapsed(d) + " ago"
+	}
+}
+
+// FormatEventLine formats an event for display with its relative time, with the code padded to codeWidth so a list of
+// events lines up. If length is not empty it is shown before the description. Extra description lines are indented to
+// line up with the first.
+func FormatEventLine(e *timelog.Event, codeWidth int, length string, now time.Time) string {
+	at := e.At.Format(timelog.TimeFormat)
+	rel := fmt.Sprintf("%-14s", "("+FormatRelative(e.At, now)+")")
+	code := ColorCode(e.Code, "["+timelog.PadRight(e.Code, codeWidth)+"]")
+	if e.Track != "" {
+		code = Dim("@"+e.Track) + " " + code
+	}
+	if length != "" {
+		length = fmt.Sprintf("%7s ", length)
+	}
+
+	prefix := fmt.Sprintf("%s %s %s %s", at, Dim(rel), code, length)
+	// Widths are in terminal columns, a code too wide for codeWidth pushes the description along.
+	if w := timelog.DisplayWidth(e.Code); w > codeWidth {
+		codeWidth = w
+	}
+	indent := strings.Repeat(" ", len(at)+len(rel)+codeWidth+5+len(length))
+	if e.Track != "" {
+		indent += strings.Repeat(" ", timelog.DisplayWidth(e.Track)+2)
+	}
+	return prefix + strings.ReplaceAll(e.Desc, "\n", "\n"+indent)
+}
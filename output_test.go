@@ -0,0 +1,70 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Continuation lines of a description have to start in the same terminal column as its first line, whatever the code
+// and track are written in.
+func TestFormatEventLineIndent(t *testing.T) {
+	color := UseColor
+	defer func() { UseColor = color }()
+	UseColor = false
+
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		name  string
+		event *timelog.Event
+		width int
+	}{
+		{"ascii", &timelog.Event{Code: "Acme", Desc: "first\nsecond"}, 4},
+		{"padded", &timelog.Event{Code: "A", Desc: "first\nsecond"}, 8},
+		{"wide code", &timelog.Event{Code: "客户:开发", Desc: "first\nsecond\nthird"}, timelog.DisplayWidth("客户:开发")},
+		{"wide code in a narrow column", &timelog.Event{Code: "客户:开发", Desc: "first\nsecond"}, 4},
+		{"wide track", &timelog.Event{Track: "电脑", Code: "Acme", Desc: "first\nsecond"}, 6},
+		{"accented track", &timelog.Event{Track: "café", Code: "Ümlaut", Desc: "first\nsecond"}, 6},
+		{"emoji track", &timelog.Event{Track: "💻", Code: "Acme", Desc: "first\nsecond"}, 4},
+	}
+	for _, test := range tests {
+		test.event.At = now.Add(-time.Hour)
+		lines := strings.Split(FormatEventLine(test.event, test.width, "1.0h", now), "\n")
+		descs := strings.Split(test.event.Desc, "\n")
+		if len(lines) != len(descs) {
+			t.Errorf("%s: got %d lines, want %d", test.name, len(lines), len(descs))
+			continue
+		}
+		column := timelog.DisplayWidth(strings.TrimSuffix(lines[0], descs[0]))
+		for i, line := range lines[1:] {
+			indent := strings.TrimSuffix(line, descs[i+1])
+			if strings.TrimLeft(indent, " ") != "" || len(indent) != column {
+				t.Errorf("%s: line %d is %q, want the description at column %d:\n%s", test.name, i+2, line, column, strings.Join(lines, "\n"))
+			}
+		}
+	}
+}
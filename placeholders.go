@@ -0,0 +1,179 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// DefaultTicketPattern finds a ticket in a branch name when there is no ticketpattern code setting. It matches the
+// usual tracker keys, so feature/ABC-123-login has the ticket ABC-123.
+const DefaultTicketPattern = `[A-Z][A-Z0-9]*-[0-9]+`
+
+// ExpandPlaceholders replaces each {name} in text with what lookup returns for it. Names lookup doesn't know are left
+// alone, so braces that aren't placeholders don't need escaping, but {{ is a literal { if one does. The values aren't
+// expanded again.
+func ExpandPlaceholders(text string, lookup func(name string) (string, bool)) string {
+	b := &strings.Builder{}
+	for {
+		i := strings.IndexByte(text, '{')
+		if i == -1 {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:i])
+		text = text[i:]
+
+		if strings.HasPrefix(text, "{{") {
+			b.WriteByte('{')
+			text = text[2:]
+			continue
+		}
+		end := strings.IndexByte(text, '}')
+		if end == -1 {
+			b.WriteString(text)
+			return b.String()
+		}
+		// In "{see {ticket}}" the placeholder is the inner one.
+		if inner := strings.LastIndexByte(text[:end], '{'); inner > 0 {
+			b.WriteString(text[:inner])
+			text = text[inner:]
+			continue
+		}
+		if v, ok := lookup(text[1:end]); ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(text[:end+1])
+		}
+		text = text[end+1:]
+	}
+}
+
+// ExpandDesc fills in the placeholders in the description of a new event, then applies the description template for
+// its code, the desc code setting (which can be set for everything in the codes file defaults). {desc} in the template
+// is what was typed. A template without {desc} is only used when nothing was typed, and events without a code (clocking
+// out) never get one.
+//
+// The placeholders are {ticket} (from the current git branch, found with the ticketpattern code setting or
+// DefaultTicketPattern), {branch}, {date} (the day the event counts towards, see Calendar), {code}, and {last_desc} (the
+// last description used for the same code, or any code if the event has none). Any that can't be worked out are blank.
+func ExpandDesc(event *timelog.Event, log timelog.TimeLog, info timelog.CodeInfo) (string, error) {
+	var branch *string
+	getBranch := func() string {
+		if branch == nil {
+			b := ""
+			if wd, err := os.Getwd(); err == nil {
+				// Unlike rev-parse, this works before the first commit, and fails when no branch is checked out.
+				b, err = git(wd, "symbolic-ref", "--short", "HEAD")
+				if err != nil {
+					Debug.Debug("no git branch for {ticket} or {branch}", "dir", wd, "err", err)
+					b = ""
+				}
+			}
+			branch = &b
+		}
+		return *branch
+	}
+
+	var err error
+	lookup := func(name string) (string, bool) {
+		v := ""
+		switch name {
+		case "ticket":
+			pattern, ok := info.Get(event.Code, "ticketpattern")
+			if !ok {
+				pattern = DefaultTicketPattern
+			}
+			v, err = FindTicket(getBranch(), pattern)
+		case "branch":
+			v = getBranch()
+		case "date":
+			v = Calendar.Day(event.At).Format("2006/01/02")
+		case "code":
+			v = event.Code
+		case "last_desc":
+			v = LastDesc(log, event.Code, event.At)
+		default:
+			return "", false
+		}
+		if Explain != nil {
+			Explain.Edits = append(Explain.Edits, ExplainedEdit{Text: "{" + name + "}", Why: fmt.Sprintf("was filled in with %q", v)})
+		}
+		return v, true
+	}
+
+	desc := ExpandPlaceholders(event.Desc, lookup)
+	if tmpl, ok := info.Get(event.Code, "desc"); ok && event.Code != "" && (desc == "" || strings.Contains(tmpl, "{desc}")) {
+		typed := desc
+		desc = ExpandPlaceholders(tmpl, func(name string) (string, bool) {
+			if name == "desc" {
+				return typed, true
+			}
+			return lookup(name)
+		})
+		if Explain != nil {
+			Explain.Edits = append(Explain.Edits, ExplainedEdit{Text: tmpl, Why: "is the description template for the code"})
+		}
+	}
+	desc = strings.TrimSpace(desc)
+	if Explain != nil {
+		Explain.Desc = desc
+	}
+	return desc, err
+}
+
+// FindTicket finds the ticket in a branch name with a regular expression. If the expression has a group, the group is
+// the ticket, otherwise the whole match is.
+func FindTicket(branch, pattern string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("bad ticketpattern %q: %w", pattern, err)
+	}
+	m := re.FindStringSubmatch(branch)
+	switch {
+	case m == nil:
+		return "", nil
+	case len(m) > 1:
+		return m[1], nil
+	}
+	return m[0], nil
+}
+
+// LastDesc returns the description of the last event before at with the code, or of any event if code is blank.
+// Events without a description are skipped, and codes are compared like everywhere else (see FoldCodeCase).
+func LastDesc(log timelog.TimeLog, code string, at time.Time) string {
+	for i := len(log) - 1; i >= 0; i-- {
+		e := log[i]
+		if e.At.After(at) || e.Desc == "" || (code != "" && !timelog.SameCode(e.Code, code, FoldCodeCase)) {
+			continue
+		}
+		return e.Desc
+	}
+	return ""
+}
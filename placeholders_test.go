@@ -0,0 +1,170 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		switch name {
+		case "ticket":
+			return "ABC-123", true
+		case "code":
+			return "{ticket}", true
+		case "blank":
+			return "", true
+		}
+		return "", false
+	}
+	tests := []struct {
+		text, want string
+	}{
+		{"", ""},
+		{"no placeholders", "no placeholders"},
+		{"{ticket} review", "ABC-123 review"},
+		{"{ticket}{ticket}", "ABC-123ABC-123"},
+		{"[{blank}]", "[]"},
+		// Names that aren't placeholders, and braces that aren't names, are left as they are.
+		{"{unknown} and {ticket}", "{unknown} and ABC-123"},
+		{"{}", "{}"},
+		{"func() { return }", "func() { return }"},
+		{"a } b", "a } b"},
+		{"unclosed {ticket", "unclosed {ticket"},
+		// {{ is a literal {, so a placeholder can be written out as it is.
+		{"{{ticket}", "{ticket}"},
+		{"{{{ticket}", "{ABC-123"},
+		{"{{", "{"},
+		{"{see {ticket}}", "{see ABC-123}"},
+		// Values aren't expanded again.
+		{"{code}", "{ticket}"},
+	}
+	for _, test := range tests {
+		if got := ExpandPlaceholders(test.text, lookup); got != test.want {
+			t.Errorf("%q expanded to %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+// inDir runs f in dir, which has a git repository on branch, or none if branch is blank.
+func inDir(t *testing.T, branch string, f func()) {
+	t.Helper()
+	dir := t.TempDir()
+	if branch != "" {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("no git")
+		}
+		for _, args := range [][]string{{"init", "-q"}, {"symbolic-ref", "HEAD", "refs/heads/" + branch}} {
+			if _, err := git(dir, args...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	f()
+}
+
+func TestExpandDesc(t *testing.T) {
+	defer func(cal timelog.Calendar) { Calendar = cal }(Calendar)
+	Calendar = timelog.Calendar{WeekStart: time.Monday, DayStart: 4 * time.Hour}
+
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+	}
+	log := timelog.TimeLog{
+		{At: at(12, 9), Code: "Acme:Dev", Desc: "Login page"},
+		{At: at(12, 11), Code: "acme/dev"},
+		{At: at(12, 12), Code: "Beta", Desc: "Lunch talk"},
+		{At: at(14, 9), Code: "Acme:Dev", Desc: "From the future"},
+	}
+	info := timelog.CodeInfo{
+		"Beta":  {"ticketpattern": `issue-([0-9]+)`},
+		"Gamma": {"desc": "{ticket}: {desc}"},
+		"Bad":   {"ticketpattern": `(`},
+	}
+
+	tests := []struct {
+		branch string
+		code   string
+		desc   string
+		want   string
+	}{
+		// Without a repository, or without a ticket in the branch name, there is nothing to fill in.
+		{"", "Acme", "[{ticket}] {branch} review", "[]  review"},
+		{"main", "Acme", "[{ticket}] {branch}", "[] main"},
+		{"feature/ABC-123-login", "Acme", "{ticket} on {branch}", "ABC-123 on feature/ABC-123-login"},
+		{"feature/ABC-123-login", "Beta", "{ticket}", ""},
+		{"fix/issue-42", "Beta", "#{ticket}", "#42"},
+		{"feature/ABC-123-login", "Gamma", "typed", "ABC-123: typed"},
+		{"", "Gamma", "typed", ": typed"},
+		// Clocking in at 1am with a 4am day start is still the 13th.
+		{"", "Acme", "{date} {code} {nope}", "2026/10/13 Acme {nope}"},
+		{"", "ACME:DEV", "{last_desc}", "Login page"},
+		{"", "", "{last_desc}", "Lunch talk"},
+		{"", "Delta", "{last_desc}", ""},
+	}
+	for _, test := range tests {
+		inDir(t, test.branch, func() {
+			event := &timelog.Event{At: at(14, 1), Code: test.code, Desc: test.desc}
+			got, err := ExpandDesc(event, log, info)
+			if err != nil || got != test.want {
+				t.Errorf("%q for %s on %q gave %q, %v, want %q", test.desc, test.code, test.branch, got, err, test.want)
+			}
+		})
+	}
+
+	inDir(t, "feature/ABC-123", func() {
+		event := &timelog.Event{At: at(14, 1), Code: "Bad", Desc: "{ticket}"}
+		if _, err := ExpandDesc(event, log, info); err == nil || !strings.Contains(err.Error(), "bad ticketpattern") {
+			t.Errorf("a bad ticketpattern gave %v", err)
+		}
+	})
+}
+
+// {date} is the date of the event, which comes from Clock like every other time.
+func TestExpandDescClock(t *testing.T) {
+	now := time.Date(2026, 10, 14, 15, 0, 0, 0, time.Local)
+	var event *timelog.Event
+	runAt(t, now, func() {
+		at, code, desc := ParseLine([]string{"yesterday", "3pm", "notes", "for", "{date}"}, nil, false)
+		event = &timelog.Event{At: at, Code: code, Desc: desc}
+	})
+	desc, err := ExpandDesc(event, nil, nil)
+	if err != nil || !strings.HasSuffix(desc, "2026/10/13") {
+		t.Errorf("yesterday's description is %q, %v", desc, err)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+// builtinProfiles are the built in employer profiles. A profile is just config keys, bundled up so a whole set of
+// timesheet rules can be picked with one setting. Rounding every period to 15 minutes is the usual "7 minute rule",
+// it is the round transformer so the rounded time is what gets reported, exported, and counted towards overtime.
+const builtinProfiles = `
+[quarter-hour]
+transforms="round=15m"
+timesheet="xlsx"
+
+[us-hourly]
+transforms="round=15m"
+weekstart="sunday"
+schedule="40h"
+overtime="week=40h"
+timesheet="adp"
+
+[eu-hourly]
+transforms="round=15m"
+weekstart="monday"
+schedule="40h"
+overtime="day=8h"
+restrules="day=10h,rest=11h,week=48h"
+timesheet="workday"
+`
+
+// Profiles returns all the employer profiles, the built in ones with the user's file merged over them.
+func Profiles(user string) map[string]map[string]string {
+	profiles := map[string]map[string]string{}
+	ParseINISections(builtinProfiles, profiles)
+	ParseINISections(user, profiles)
+	delete(profiles, "")
+	return profiles
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Every built in profile has to hold up to the checks main does on the keys it sets, or picking it would just be an
+// error at startup.
+func TestBuiltinProfiles(t *testing.T) {
+	profiles := Profiles("")
+	for _, name := range []string{"quarter-hour", "us-hourly", "eu-hourly"} {
+		if profiles[name] == nil {
+			t.Errorf("missing built in profile %q", name)
+		}
+	}
+	if _, ok := profiles[""]; ok {
+		t.Error("keys outside a section made a profile")
+	}
+
+	presets := ExportPresets("")
+	for name, settings := range profiles {
+		for k, v := range settings {
+			var err error
+			switch k {
+			case "transforms":
+				_, err = timelog.ParseTransformers(strings.Split(v, ","), Calendar, FoldCodeCase)
+			case "weekstart":
+				_, err = timelog.ParseWeekday(v)
+			case "schedule":
+				_, err = time.ParseDuration(v)
+			case "overtime":
+				_, err = timelog.ParseOvertime(v)
+			case "restrules":
+				_, err = timelog.ParseRestRules(v)
+			case "timesheet":
+				if v != "csv" && v != "xlsx" && presets[v] == nil {
+					t.Errorf("profile %q: timesheet %q is neither a grid format nor an export preset", name, v)
+				}
+			default:
+				t.Errorf("profile %q: unexpected key %q", name, k)
+			}
+			if err != nil {
+				t.Errorf("profile %q: %s=%q: %v", name, k, v, err)
+			}
+		}
+	}
+
+	us, eu := profiles["us-hourly"], profiles["eu-hourly"]
+	if us["weekstart"] != "sunday" || us["overtime"] != "week=40h" {
+		t.Errorf("us-hourly is %v", us)
+	}
+	if eu["weekstart"] != "monday" || eu["overtime"] != "day=8h" {
+		t.Errorf("eu-hourly is %v", eu)
+	}
+}
+
+func TestProfilesUserFile(t *testing.T) {
+	user := `
+stray="ignored"
+
+[us-hourly]
+overtime="day=8h,week=40h"
+
+[contractor]
+transforms="round=6m"
+timesheet="csv"
+`
+	profiles := Profiles(user)
+	if _, ok := profiles[""]; ok {
+		t.Error("keys outside a section made a profile")
+	}
+
+	us := profiles["us-hourly"]
+	if us["overtime"] != "day=8h,week=40h" {
+		t.Errorf("user file didn't override us-hourly overtime: %v", us)
+	}
+	if us["weekstart"] != "sunday" || us["timesheet"] != "adp" {
+		t.Errorf("user file replaced us-hourly instead of merging over it: %v", us)
+	}
+
+	contractor := profiles["contractor"]
+	if contractor["transforms"] != "round=6m" || contractor["timesheet"] != "csv" {
+		t.Errorf("new profile read as %v", contractor)
+	}
+	if profiles["eu-hourly"]["overtime"] != "day=8h" {
+		t.Errorf("untouched built in profile changed: %v", profiles["eu-hourly"])
+	}
+}
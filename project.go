@@ -0,0 +1,89 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectFile is the name of the per-directory config file, see FindProject.
+const ProjectFile = ".sctime"
+
+// DefaultCode is the time code new events get when none is found in the input. It comes from the "code" key of a
+// .sctime file.
+var DefaultCode = ""
+
+// Project is what was read from a .sctime file.
+type Project struct {
+	Path    string            // The .sctime file itself.
+	Config  map[string]string // Config keys to override, with the default code as "code".
+	Presets map[string]string // Report presets, from the [reports] section, name to arguments.
+}
+
+// Dir is the directory the .sctime file is in, which is $PROJECT in config values.
+func (p *Project) Dir() string {
+	return filepath.Dir(p.Path)
+}
+
+// FindProject looks for a .sctime file in dir, then each of its parents in turn, and reads the first one it finds.
+// Returns nil if there isn't one all the way up.
+func FindProject(dir string) (*Project, error) {
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		raw, err := os.ReadFile(path)
+		if err == nil {
+			sections := map[string]map[string]string{}
+			ParseINISections(string(raw), sections)
+			project := &Project{Path: path, Config: sections[""], Presets: sections["reports"]}
+			if project.Config == nil {
+				project.Config = map[string]string{}
+			}
+			return project, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// ExpandPreset replaces the first argument with the arguments of the report preset by that name, if there is one.
+// Anything after it is kept, so presets can be narrowed down further on the command line.
+func (p *Project) ExpandPreset(args []string) []string {
+	if p == nil || len(args) == 0 {
+		return args
+	}
+	preset, ok := p.Presets[args[0]]
+	if !ok {
+		return args
+	}
+	return append(strings.Fields(preset), args[1:]...)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// StdinIsTerminal returns true if standard input looks like an interactive terminal rather than a pipe or file.
+func StdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+
+	// The null device is a character device too, but it sure isn't interactive.
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
+
+// Interactive is false if the user can't (or doesn't want to) be asked anything. This is the case when stdin isn't a
+// terminal, when running as timetool, and with --non-interactive.
+var Interactive = true
+
+// RequireInteractive exits if prompting isn't allowed. Rather than hanging or guessing, a machine readable line of the
+// form "non-interactive: <reason>: <detail>" is written to stderr and the exit code is 3. The reason is a short fixed
+// token a script can match on.
+func RequireInteractive(reason string, detail string) {
+	if Interactive {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "non-interactive: %s: %s\n", reason, detail)
+	os.Exit(3)
+}
+
+// simplePrompts is true if the fancy promptui prompts should be avoided in favor of plain line based ones. promptui
+// puts the terminal in raw mode and expects VT style escape sequences for the arrow keys, which a lot of Windows
+// terminals (and anything claiming to be dumb) don't deliver.
+func simplePrompts() bool {
+	return runtime.GOOS == "windows" || os.Getenv("TERM") == "dumb" || !StdinIsTerminal()
+}
+
+// ChooseOne asks the user to pick one of the items, returning its index.
+func ChooseOne(label string, items []string) (int, error) {
+	RequireInteractive("choice-required", label)
+
+	if !simplePrompts() {
+		prompt := promptui.Select{
+			Label: label,
+			Items: items,
+		}
+		i, _, err := prompt.Run()
+		return i, err
+	}
+
+	for i, item := range items {
+		fmt.Fprintf(os.Stdout, "%3d: %s\n", i+1, item)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprintf(os.Stdout, "%s [1-%d]: ", label, len(items))
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return 0, errors.New("No selection made.")
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(line))
+		if err == nil && n >= 1 && n <= len(items) {
+			return n - 1, nil
+		}
+		fmt.Fprintln(os.Stdout, "Please enter one of the numbers above.")
+	}
+}
+
+// Confirm asks the user a yes or no question, defaulting to no.
+func Confirm(label string) bool {
+	RequireInteractive("confirmation-required", label)
+
+	if !simplePrompts() {
+		prompt := promptui.Prompt{Label: label, IsConfirm: true}
+		_, err := prompt.Run()
+		return err == nil
+	}
+
+	fmt.Fprintf(os.Stdout, "%s [y/N]: ", label)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
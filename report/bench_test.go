@@ -0,0 +1,95 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+	"github.com/milochristiansen/timeclock/timelog/gen"
+)
+
+// benchYear builds the options for a report on the last year of a made up log of 100k events, like "report year" on a
+// long history.
+func benchYear(b *testing.B) Options {
+	b.Helper()
+	log := gen.Generate(gen.Options{
+		Begin: time.Date(1990, 1, 1, 0, 0, 0, 0, time.Local),
+		Days:  25000,
+		Noise: 0.5,
+		Seed:  1,
+	})
+	if len(log) < 100000 {
+		b.Fatalf("made up %d events, want at least 100000", len(log))
+	}
+	log = log[:100000]
+
+	last := log[len(log)-1].At
+	begin := time.Date(last.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	end := begin.AddDate(1, 0, 0)
+	return Options{
+		Log:          log,
+		Begin:        &begin,
+		End:          &end,
+		Calendar:     timelog.Calendar{WeekStart: time.Monday},
+		FoldCodeCase: true,
+		TableWidth:   100,
+	}
+}
+
+func BenchmarkBuildYear(b *testing.B) {
+	opts := benchYear(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := Build(opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkReportYear is BenchmarkBuildYear with the default template run on the result, which is most of what
+// "report year" does once the log is read.
+func BenchmarkReportYear(b *testing.B) {
+	opts := benchYear(b)
+	templates, err := LoadTemplates("", nil, timelog.DurationClock)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		data, err := Build(opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+		templates.Funcs(data.Funcs(timelog.DurationClock))
+		err = templates.ExecuteTemplate(io.Discard, "default.tmpl", data)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
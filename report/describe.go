@@ -0,0 +1,46 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"github.com/milochristiansen/timeclock/locale"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// DescribeViolation is timelog.RestViolation.Describe, in locale.Language.
+func DescribeViolation(v timelog.RestViolation, style timelog.DurationStyle) string {
+	actual, limit := timelog.FormatDuration(v.Actual, style), timelog.FormatDuration(v.Limit, style)
+	switch v.Rule {
+	case "day":
+		return locale.T("Worked %s on %s, more than %s.", actual, locale.Date(v.Begin, "Monday 2006/01/02"), limit)
+	case "week":
+		return locale.T("Worked %s in week %d of %d, more than %s.", actual, v.Week.Number, v.Week.Year, limit)
+	default:
+		return locale.T("Only %s rest from %s to %s, less than %s.", actual, v.Begin.Format("2006/01/02 03:04PM"), v.End.Format("2006/01/02 03:04PM"), limit)
+	}
+}
+
+// DescribeOverlap is timelog.EventOverlap.String, in locale.Language.
+func DescribeOverlap(o timelog.EventOverlap) string {
+	return locale.T("%s has %d events at once: %s", o.Where(), len(o.Events), o.What())
+}
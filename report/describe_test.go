@@ -0,0 +1,57 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/locale"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// The timelog package always describes things in English, translating them is left to this one.
+func TestDescribeTranslates(t *testing.T) {
+	err := locale.Set("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locale.Set("en")
+
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	o := timelog.EventOverlap{At: at, Events: timelog.TimeLog{{At: at, Code: "A"}, {At: at, Code: "B"}}}
+	if got, want := o.String(), "2026/10/12 09:00AM has 2 events at once: [A] , [B] "; got != want {
+		t.Errorf("EventOverlap.String() = %q, want %q", got, want)
+	}
+	if got, want := DescribeOverlap(o), "2026/10/12 09:00AM hat 2 Ereignisse gleichzeitig: [A] , [B] "; got != want {
+		t.Errorf("DescribeOverlap() = %q, want %q", got, want)
+	}
+
+	v := timelog.RestViolation{Rule: "day", Begin: at, Actual: 11 * time.Hour, Limit: 10 * time.Hour}
+	if got, want := v.Describe(timelog.DurationClock), "Worked 11:00 on Monday 2026/10/12, more than 10:00."; got != want {
+		t.Errorf("RestViolation.Describe() = %q, want %q", got, want)
+	}
+	if got, want := DescribeViolation(v, timelog.DurationClock), "11:00 gearbeitet am Montag 2026/10/12, mehr als 10:00."; got != want {
+		t.Errorf("DescribeViolation() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// The JSON form of a report, see WriteJSON. These are kept apart from ReportData so the JSON doesn't change every
+// time something is added for the templates.
+
+type jsonReport struct {
+	Begin       time.Time          `json:"begin"`
+	End         *time.Time         `json:"end"` // null for a report that runs until now.
+	Total       float64            `json:"total"`
+	FullTotal   float64            `json:"full_total"`
+	Billable    float64            `json:"billable"`
+	NonBillable float64            `json:"non_billable"`
+	Overtime    float64            `json:"overtime"`
+	Rounding    float64            `json:"rounding"`
+	BilledTotal float64            `json:"billed_total"`
+	Capped      float64            `json:"capped"`
+	Totals      map[string]float64 `json:"totals"`
+	Billed      map[string]float64 `json:"billed"`
+	Other       []string           `json:"other"`
+
+	Periods    []jsonPeriod       `json:"periods"`
+	Weeks      []jsonWeek         `json:"weeks"`
+	Days       []jsonDay          `json:"days"`
+	Tasks      []jsonTask         `json:"tasks"`
+	Charges    []jsonCharge       `json:"charges"`
+	Currencies map[string]float64 `json:"currencies"`
+	Violations []jsonViolation    `json:"violations"`
+	Logs       []jsonLog          `json:"logs"`
+}
+
+type jsonPeriod struct {
+	Begin    time.Time         `json:"begin"`
+	End      time.Time         `json:"end"`
+	Track    string            `json:"track"`
+	Code     string            `json:"code"`
+	Desc     string            `json:"desc"`
+	Meta     map[string]string `json:"meta"`
+	Length   float64           `json:"length"`
+	Excluded float64           `json:"excluded"`
+	Billed   float64           `json:"billed"`
+}
+
+type jsonWeek struct {
+	Year     int                   `json:"year"`
+	Week     int                   `json:"week"`
+	FirstDay time.Time             `json:"first_day"`
+	Totals   map[string][8]float64 `json:"totals"`
+	Daily    [8]float64            `json:"daily"`
+	Overtime float64               `json:"overtime"`
+	Targets  []jsonTarget          `json:"targets"`
+}
+
+type jsonTarget struct {
+	Code      string  `json:"code"`
+	Target    float64 `json:"target"`
+	Done      float64 `json:"done"`
+	Remaining float64 `json:"remaining"`
+}
+
+type jsonDay struct {
+	Date   time.Time          `json:"date"`
+	Total  float64            `json:"total"`
+	Totals map[string]float64 `json:"totals"`
+	Notes  []string           `json:"notes"`
+}
+
+type jsonTask struct {
+	Code     string  `json:"code"`
+	Desc     string  `json:"desc"`
+	Total    float64 `json:"total"`
+	Count    int     `json:"count"`
+	Estimate float64 `json:"estimate"`
+}
+
+type jsonCharge struct {
+	Code     string  `json:"code"`
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"`
+	Billed   float64 `json:"billed"`
+	Amount   float64 `json:"amount"`
+	Overage  bool    `json:"overage"`
+	Tax      float64 `json:"tax"`
+	Total    float64 `json:"total"`
+}
+
+type jsonLog struct {
+	Name   string             `json:"name"`
+	Total  float64            `json:"total"`
+	Totals map[string]float64 `json:"totals"`
+}
+
+type jsonViolation struct {
+	Rule   string    `json:"rule"`
+	Begin  time.Time `json:"begin"`
+	End    time.Time `json:"end"`
+	Actual float64   `json:"actual"`
+	Limit  float64   `json:"limit"`
+}
+
+// seconds is how durations are written in JSON, so scripts don't need to parse "1.5h" or "1h 30m".
+func seconds(d time.Duration) float64 {
+	return d.Seconds()
+}
+
+func secondsMap(totals map[string]time.Duration) map[string]float64 {
+	out := make(map[string]float64, len(totals))
+	for k, d := range totals {
+		out[k] = seconds(d)
+	}
+	return out
+}
+
+func secondsWeek(days [8]time.Duration) [8]float64 {
+	out := [8]float64{}
+	for i, d := range days {
+		out[i] = seconds(d)
+	}
+	return out
+}
+
+// WriteJSON writes the report as JSON, for scripts, instead of running it through a template. Durations are in
+// seconds and times are RFC 3339. Lists are never null, so they can be looped over without checking. The week arrays
+// are from the first day of the week (see Options.Calendar), and then the total for the week, the same as ReportWeek.
+func (r *ReportData) WriteJSON(w io.Writer) error {
+	out := jsonReport{
+		Begin:       *r.Begin,
+		End:         r.End,
+		Total:       seconds(r.Total),
+		FullTotal:   seconds(r.FullTotal),
+		Billable:    seconds(r.Billable),
+		NonBillable: seconds(r.NonBillable),
+		Overtime:    seconds(r.Overtime),
+		Rounding:    seconds(r.Rounding),
+		BilledTotal: seconds(r.BilledTotal),
+		Capped:      seconds(r.Capped),
+		Totals:      secondsMap(r.Totals),
+		Billed:      secondsMap(r.BilledTotals),
+		Other:       r.Other,
+		Currencies:  r.Currencies,
+
+		Periods:    []jsonPeriod{},
+		Weeks:      []jsonWeek{},
+		Days:       []jsonDay{},
+		Tasks:      []jsonTask{},
+		Charges:    []jsonCharge{},
+		Violations: []jsonViolation{},
+		Logs:       []jsonLog{},
+	}
+	if out.Currencies == nil {
+		out.Currencies = map[string]float64{}
+	}
+
+	for _, p := range r.Periods {
+		meta := p.Meta
+		if meta == nil {
+			meta = map[string]string{}
+		}
+		out.Periods = append(out.Periods, jsonPeriod{
+			Begin:    p.Begin,
+			End:      p.End,
+			Track:    p.Track,
+			Code:     p.Code,
+			Desc:     p.Desc,
+			Meta:     meta,
+			Length:   seconds(p.Length()),
+			Excluded: seconds(p.Excluded),
+			Billed:   seconds(r.Billed(p)),
+		})
+	}
+
+	for _, wk := range r.Weeks {
+		week := jsonWeek{
+			Year:     wk.Year,
+			Week:     wk.Number,
+			FirstDay: *wk.FirstDay,
+			Totals:   map[string][8]float64{},
+			Daily:    secondsWeek(wk.Daily),
+			Overtime: seconds(wk.Overtime),
+			Targets:  []jsonTarget{},
+		}
+		for code, days := range wk.Totals {
+			week.Totals[code] = secondsWeek(days)
+		}
+		for _, t := range wk.Targets {
+			week.Targets = append(week.Targets, jsonTarget{
+				Code:      t.Code,
+				Target:    seconds(t.Target),
+				Done:      seconds(t.Done),
+				Remaining: seconds(t.Remaining()),
+			})
+		}
+		out.Weeks = append(out.Weeks, week)
+	}
+
+	for _, d := range r.Days {
+		notes := d.Notes
+		if notes == nil {
+			notes = []string{}
+		}
+		out.Days = append(out.Days, jsonDay{Date: d.Date, Total: seconds(d.Total), Totals: secondsMap(d.Totals), Notes: notes})
+	}
+
+	for _, t := range r.Tasks {
+		out.Tasks = append(out.Tasks, jsonTask{Code: t.Code, Desc: t.Desc, Total: seconds(t.Total), Count: t.Count, Estimate: seconds(t.Estimate)})
+	}
+
+	for _, c := range r.Charges {
+		out.Charges = append(out.Charges, jsonCharge{
+			Code:     c.Code,
+			Currency: c.Currency,
+			Rate:     c.Rate,
+			Billed:   seconds(c.Billed),
+			Amount:   c.Amount,
+			Overage:  c.Overage,
+			Tax:      c.Tax,
+			Total:    c.Total,
+		})
+	}
+
+	for _, v := range r.Violations {
+		out.Violations = append(out.Violations, jsonViolation{
+			Rule:   v.Rule,
+			Begin:  v.Begin,
+			End:    v.End,
+			Actual: seconds(v.Actual),
+			Limit:  seconds(v.Limit),
+		})
+	}
+
+	for _, l := range r.Logs {
+		out.Logs = append(out.Logs, jsonLog{Name: l.Value, Total: seconds(l.Total), Totals: secondsMap(l.Totals)})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(out)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestSeconds(t *testing.T) {
+	if got := seconds(90 * time.Minute); got != 5400 {
+		t.Errorf("seconds(90m) = %v", got)
+	}
+	if got := seconds(1500 * time.Millisecond); got != 1.5 {
+		t.Errorf("seconds(1.5s) = %v", got)
+	}
+	got := secondsMap(map[string]time.Duration{"A": time.Hour, "B": 0})
+	if len(got) != 2 || got["A"] != 3600 || got["B"] != 0 {
+		t.Errorf("secondsMap = %v", got)
+	}
+	if m := secondsMap(nil); m == nil || len(m) != 0 {
+		t.Errorf("secondsMap(nil) = %#v, want an empty map", m)
+	}
+	week := secondsWeek([8]time.Duration{time.Hour, 0, 0, 0, 0, 0, 30 * time.Minute, 90 * time.Minute})
+	if week != [8]float64{3600, 0, 0, 0, 0, 0, 1800, 5400} {
+		t.Errorf("secondsWeek = %v", week)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+	// 2026/10/12 is a Monday. The Tuesday night period runs over midnight into Wednesday.
+	log := timelog.TimeLog{
+		{At: at(12, 9, 0), Code: "Acme:Dev", Desc: "review"},
+		{At: at(12, 10, 30), Code: ""},
+		{At: at(13, 23, 0), Code: "Acme:Ops", Desc: "deploy", Meta: map[string]string{"ticket": "OPS-1"}},
+		{At: at(14, 1, 0), Code: ""},
+	}
+	begin, end := at(12, 0, 0), at(19, 0, 0)
+	data, err := Build(Options{
+		Log:          log,
+		Begin:        &begin,
+		End:          &end,
+		Calendar:     timelog.Calendar{WeekStart: time.Monday},
+		FoldCodeCase: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	err = data.WriteJSON(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Field names are what scripts depend on, so check them on the raw document rather than through jsonReport.
+	var doc map[string]any
+	err = json.Unmarshal(b.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("output doesn't parse: %v\n%s", err, b.String())
+	}
+	for _, key := range []string{"begin", "end", "total", "full_total", "billable", "non_billable", "overtime", "rounding",
+		"billed_total", "capped", "totals", "billed", "other", "periods", "weeks", "days", "tasks", "charges", "currencies",
+		"violations", "logs"} {
+		if _, ok := doc[key]; !ok {
+			t.Errorf("missing %q", key)
+		}
+	}
+	for _, key := range []string{"other", "periods", "weeks", "days", "tasks", "charges", "violations", "logs"} {
+		if _, ok := doc[key].([]any); !ok {
+			t.Errorf("%q is %#v, want a list", key, doc[key])
+		}
+	}
+	if doc["total"] != 12600.0 {
+		t.Errorf("total is %v, want 12600 seconds", doc["total"])
+	}
+	if doc["begin"] != begin.Format(time.RFC3339) {
+		t.Errorf("begin is %v, want RFC 3339", doc["begin"])
+	}
+
+	var out jsonReport
+	err = json.Unmarshal(b.Bytes(), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Totals["Acme:Dev"] != 5400 || out.Totals["Acme:Ops"] != 7200 {
+		t.Errorf("totals are %v", out.Totals)
+	}
+
+	if len(out.Periods) != 2 {
+		t.Fatalf("got %d periods", len(out.Periods))
+	}
+	ops := out.Periods[1]
+	if ops.Code != "Acme:Ops" || ops.Desc != "deploy" || ops.Length != 7200 || ops.Meta["ticket"] != "OPS-1" {
+		t.Errorf("period read back as %+v", ops)
+	}
+	if !ops.Begin.Equal(at(13, 23, 0)) || !ops.End.Equal(at(14, 1, 0)) {
+		t.Errorf("period runs %v to %v", ops.Begin, ops.End)
+	}
+	if out.Periods[0].Meta == nil {
+		t.Error("period without metadata has null meta")
+	}
+
+	// Periods aren't split at midnight, so all of the Tuesday night period is Tuesday's.
+	if len(out.Weeks) != 1 {
+		t.Fatalf("got %d weeks", len(out.Weeks))
+	}
+	week := out.Weeks[0]
+	if week.Year != 2026 || week.Week != 42 || !week.FirstDay.Equal(at(12, 0, 0)) {
+		t.Errorf("week is %d-%d from %v", week.Year, week.Week, week.FirstDay)
+	}
+	if want := [8]float64{5400, 7200, 0, 0, 0, 0, 0, 12600}; week.Daily != want {
+		t.Errorf("daily is %v, want %v", week.Daily, want)
+	}
+	if want := [8]float64{0, 7200, 0, 0, 0, 0, 0, 7200}; week.Totals["Acme:Ops"] != want {
+		t.Errorf("Acme:Ops by day is %v, want %v", week.Totals["Acme:Ops"], want)
+	}
+}
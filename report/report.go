@@ -0,0 +1,1001 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+// Package report builds the data for timelog reports, and loads the templates that present it.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// ErrNoPeriods is returned by Build when nothing in the time range matches the filters.
+var ErrNoPeriods = errors.New("No periods in given time range.")
+
+// Options describes a report.
+type Options struct {
+	Log      timelog.TimeLog        // The whole log, sorted. Retainers need the history before the report begins.
+	Info     timelog.CodeInfo       // Extra timecode information, may be empty.
+	Exchange *timelog.ExchangeRates // Used to combine the charges into one currency, may be nil.
+
+	Begin *time.Time
+	End   *time.Time // nil for no end.
+
+	CapAt *time.Time // If set, periods are cut off at this time, see timelog.CapPeriods. Usually now.
+
+	Logs []string // The names of the logs Log was merged from, if it was, see timelog.MergeLogs and ReportData.Logs.
+
+	// Timecodes to include. "empty" is periods without a code, "all" is periods with one, and a code ending in ":..."
+	// includes its children, or with ":*" only its direct children (see timelog.CutWildcard). Nothing means "all".
+	Codes []string
+	Where map[string]string // Only periods with all of these metadata values are included.
+
+	Top       int                 // If more than 0, only this many codes get their own totals, the rest are "other".
+	Rounding  time.Duration       // The unit billed time is rounded to, 0 for no rounding.
+	Reconcile bool                // Make the rounded periods add up to the rounded totals.
+	Overlap   timelog.OverlapMode // How time overlapping between tracks is counted.
+
+	// What to do with events on the same track at the same time, see timelog.TimeLog.CheckedPeriods.
+	OverlapPolicy timelog.OverlapPolicy
+
+	Calendar     timelog.Calendar // When days and weeks start.
+	FoldCodeCase bool             // Codes that only differ in case are the same code, see timelog.CodeKey.
+
+	// Transformers to run on the periods after working out the overlap, before filtering.
+	Transforms []timelog.PeriodTransformer
+
+	Rest timelog.RestRules // Working time limits to check, may be empty.
+
+	Schedule time.Duration // The time worked in a week, from the schedule config, 0 for none. See ReportWeek.Targets.
+
+	Overtime timelog.Overtime // When reported time counts as overtime, may be empty. See ReportWeek.Overtime.
+
+	Plan timelog.TimeLog // Planned work to compare against, may be nil. Only the code filter applies to it.
+
+	Expenses []*timelog.Expense // Every expense, the ones in the range that pass the code filter are included.
+
+	Trips        []*timelog.Trip // Every trip, picked out like Expenses.
+	DistanceUnit string          // "km" or "mi", what trips are reported in and mileage rates are per.
+
+	AttachDir string // What relative attachment paths are relative to, see the attachments template function.
+
+	// The width the table template function fits tables to, usually the width of the terminal. 0 means don't fit them
+	// at all, which is what you want when the output is going to a file.
+	TableWidth int
+}
+
+// capped cuts the periods off at CapAt, if there is one, returning how much time was cut.
+func (opts Options) capped(periods []*timelog.Period) ([]*timelog.Period, time.Duration) {
+	if opts.CapAt == nil {
+		return periods, 0
+	}
+	return timelog.CapPeriods(periods, *opts.CapAt)
+}
+
+// transform runs the overlap and transformers from the options.
+func (opts Options) transform(periods []*timelog.Period) []*timelog.Period {
+	return append(timelog.Pipeline{opts.Overlap}, opts.Transforms...).Transform(periods)
+}
+
+type ReportData struct {
+	Begin   *time.Time
+	End     *time.Time
+	Periods []*timelog.Period
+	Totals  map[string]time.Duration
+
+	Weeks []*ReportWeek
+	Days  []*ReportDay
+	Tasks []*ReportTask // Sorted by code, then by total time with the biggest first.
+
+	Other []string // Codes that were rolled up into "other" in the totals by --top, sorted.
+
+	// Subtotals for each of the logs the report was merged from, in the order they were given, by the timelog.LogKey
+	// metadata. Value is the name of the log. Empty unless the report was made from several logs.
+	Logs []*ReportGroup
+
+	Rounding     time.Duration            // The unit billed time is rounded to, or 0 if it isn't.
+	BilledTotals map[string]time.Duration // Like Totals, but with billed time. Use the billed function for periods.
+	BilledTotal  time.Duration            // Total billed time.
+
+	Retainers  []*timelog.RetainerMonth // Retainers used by the periods, for each month in the range.
+	Charges    []*timelog.Charge        // What is owed for each code with a rate, sorted by code.
+	Currencies map[string]float64       // Total owed in each currency, including tax. These are never converted.
+	Taxes      []*timelog.TaxLine       // The tax on the charges, by tax and currency.
+	Combined   *ReportMoney             // Everything converted into one currency, nil without a full exchange rate table.
+
+	// Expenses in the range, in order, and their totals for each client (the top level code), sorted by client. The
+	// expenses are included in Currencies and Combined, but not Taxes.
+	Expenses       []*timelog.Expense
+	ClientExpenses []*ReportExpenses
+
+	// Trips in the range and their totals for each client, like Expenses. Trips on codes with a mileage rate are
+	// included in Currencies and Combined.
+	Trips        []*ReportTrip
+	ClientTrips  []*ReportTrips
+	DistanceUnit string
+
+	Billable    time.Duration // Total of the periods with billable codes.
+	NonBillable time.Duration // Total of the periods without billable codes.
+	Overtime    time.Duration // Total overtime of the weeks, 0 without overtime rules.
+
+	// Time after CapAt that was left out, because the log has events in the future. Worth a warning if it isn't 0.
+	Capped time.Duration
+
+	// Events on the same track at the same time in the range, see Options.OverlapPolicy. Also worth a warning.
+	Overlaps []timelog.EventOverlap
+
+	Total     time.Duration    // Total of all the periods in the report.
+	FullTotal time.Duration    // Total of all the periods in the time range, before filtering by code.
+	Shares    map[string]Share // Share of the time for each code, keyed the same as Totals.
+
+	// Where the rest rules were broken in the time range, or running into it, whatever the code. Use the violation
+	// function to describe them.
+	Violations []timelog.RestViolation
+
+	// The targets for the last week in the report, which for most reports is this week. See ReportWeek.Targets.
+	Targets []*ReportTarget
+
+	// The time each day and week split by focus, see CodeInfo.Focus. A "focus" metadata value on an event overrides
+	// its code.
+	FocusDays  []*ReportFocus
+	FocusWeeks []*ReportFocus
+
+	// Every estimated task with time in the report, sorted like Tasks, and the estimation accuracy for each month up to
+	// the end of the report, counting every estimated task in the log (that passes the code filter).
+	Estimates []*ReportEstimate
+	Accuracy  []*ReportAccuracy
+
+	// The plan compared with what actually happened, for each code on each day with either, sorted by day then code.
+	// PlanTotals are the same for the whole report, sorted by code. Both are nil without a plan.
+	Plan       []*PlanLine
+	PlanTotals []*PlanLine
+
+	billed     map[*timelog.Period]time.Duration
+	label      func(string) string
+	calendar   timelog.Calendar
+	attachDir  string
+	tableWidth int
+}
+
+// Share is a duration expressed as percentages (0-100) of the report totals.
+type Share struct {
+	OfTotal float64 // Percentage of the time in the report.
+	OfFull  float64 // Percentage of all the time in the range, whatever the code.
+}
+
+// ReportExpenses totals the expenses for one client.
+type ReportExpenses struct {
+	Client     string
+	Expenses   []*timelog.Expense
+	Currencies map[string]float64
+}
+
+// ReportTrip is a trip and what it is reimbursed for.
+type ReportTrip struct {
+	Trip     *timelog.Trip
+	Distance float64 // In the report's DistanceUnit.
+	Rate     float64 // Per unit of distance, 0 if the code has no mileage rate.
+	Currency string
+	Amount   float64
+}
+
+// Reimbursed is true if any of the trips in the report are reimbursed.
+func (r *ReportData) Reimbursed() bool {
+	for _, t := range r.Trips {
+		if t.Amount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportTrips totals the trips for one client.
+type ReportTrips struct {
+	Client     string
+	Trips      []*ReportTrip
+	Distance   float64
+	Currencies map[string]float64 // Only trips with a mileage rate.
+}
+
+// ReportMoney is an amount in a specific currency.
+type ReportMoney struct {
+	Amount   float64
+	Currency string
+}
+
+// FormatMoney formats an amount of money for display, with the currency after it if there is one.
+func FormatMoney(amount float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// percentOf returns d as a percentage of total, or 0 if there is no total.
+func percentOf(d, total time.Duration) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(d) / float64(total) * 100
+}
+
+// ReportGroup totals the periods with the same value for a metadata key, see the bymeta template function.
+type ReportGroup struct {
+	Value  string // Blank for periods without the key.
+	Total  time.Duration
+	Totals map[string]time.Duration // Keyed the same as ReportData.Totals.
+}
+
+// ReportTask totals the periods for a single task, a set of periods with the same code and (normalized) description.
+type ReportTask struct {
+	Code  string
+	Desc  string // The description as it was first seen.
+	Total time.Duration
+	Count int // Number of periods.
+
+	Estimate time.Duration // From the task's "est" metadata, 0 if it has none. See ReportData.Estimates.
+
+	key [2]string
+}
+
+// ReportEstimate is how long an estimated task took, over the whole log rather than just the report.
+type ReportEstimate struct {
+	Code     string
+	Desc     string
+	Estimate time.Duration // The last "est" the task was given, re-estimating replaces it.
+	Actual   time.Duration
+	Last     time.Time // The end of the task's last period.
+}
+
+// Factor is how many times longer than estimated the task took, eg 1.5 for half again as long.
+func (e *ReportEstimate) Factor() float64 {
+	return float64(e.Actual) / float64(e.Estimate)
+}
+
+// ReportAccuracy is the estimation accuracy for the tasks last worked on in one month.
+type ReportAccuracy struct {
+	Month    time.Time // Midnight on the first day of the month.
+	Tasks    int
+	Estimate time.Duration
+	Actual   time.Duration
+}
+
+// Factor is the total actual time over the total estimate, so big tasks count for more than small ones.
+func (a *ReportAccuracy) Factor() float64 {
+	return float64(a.Actual) / float64(a.Estimate)
+}
+
+// ReportDay totals the periods that count for a single day, normally the ones that begin on it.
+type ReportDay struct {
+	Date   time.Time // The start of the day, midnight unless daystart is set.
+	Total  time.Duration
+	Totals map[string]time.Duration // Keyed the same as ReportData.Totals.
+	Notes  []string                 // The first line of each different description, in the order they were first seen.
+}
+
+// ReportFocus splits the time in a day or week into deep and shallow work.
+type ReportFocus struct {
+	Date    time.Time // Midnight at the start of the day, or of the first day for a week.
+	Deep    time.Duration
+	Shallow time.Duration
+	Other   time.Duration // Time that isn't classified either way.
+
+	// How much Ratio went up since the previous day or week in the report, in percentage points. Zero for the first.
+	Trend float64
+}
+
+// Ratio is the deep work as a percentage (0-100) of the classified time.
+func (f *ReportFocus) Ratio() float64 {
+	return percentOf(f.Deep, f.Deep+f.Shallow)
+}
+
+// SlipTolerance is how far the actual time can be from the plan before it counts as slipping. Event times are rounded to
+// 6 minutes, so a little either way is just noise.
+const SlipTolerance = 15 * time.Minute
+
+// PlanLine is the planned and actual time for a code, on one day or over the whole report.
+type PlanLine struct {
+	Date    time.Time // Midnight at the start of the day, zero in ReportData.PlanTotals.
+	Code    string
+	Planned time.Duration
+	Actual  time.Duration
+}
+
+// Slip is how much more time went to the code than was planned, negative if less did.
+func (l *PlanLine) Slip() time.Duration {
+	return l.Actual - l.Planned
+}
+
+// Slipped is true if the actual time is off the plan by more than SlipTolerance, either way.
+func (l *PlanLine) Slipped() bool {
+	return l.Slip() > SlipTolerance || l.Slip() < -SlipTolerance
+}
+
+type ReportWeek struct {
+	Year     int        // 4 digit year
+	Number   int        // Week number, see timelog.WeekOf
+	FirstDay *time.Time // Midnight on the first day of the week, see Options.Calendar.
+
+	Periods []*timelog.Period
+
+	Totals map[string][8]time.Duration // Each day from the first, plus week total
+	Daily  [8]time.Duration            // Totals for all codes
+
+	Billable    [8]time.Duration // Each day, plus week total, for periods with billable codes.
+	NonBillable [8]time.Duration // Each day, plus week total, for periods without billable codes.
+
+	Overtime time.Duration // How much of Daily is overtime by Options.Overtime.
+
+	FullTotal time.Duration    // Total of all the periods in the week, before filtering by code.
+	Share     Share            // Share of the report time that falls in this week.
+	Shares    map[string]Share // Share of the week for each code, keyed the same as Totals.
+
+	// How the week is going against the schedule (if there is one) and the budgets of the codes in it, in that order,
+	// budgets sorted by code. These are for the whole week in the log, whatever the report's range and codes.
+	Targets []*ReportTarget
+}
+
+// Billed returns the billed time for one of the report periods, after rounding.
+func (r *ReportData) Billed(p *timelog.Period) time.Duration {
+	return r.billed[p]
+}
+
+// ByMeta groups the report periods by the value of a metadata key, sorted by value.
+func (r *ReportData) ByMeta(key string) []*ReportGroup {
+	groups := []*ReportGroup{}
+	found := map[string]*ReportGroup{}
+	for _, p := range r.Periods {
+		v := p.Meta[key]
+		g, ok := found[v]
+		if !ok {
+			g = &ReportGroup{Value: v, Totals: map[string]time.Duration{}}
+			found[v] = g
+			groups = append(groups, g)
+		}
+		g.Total += p.Length()
+		g.Totals[r.label(p.Code)] += p.Length()
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Value < groups[j].Value
+	})
+	return groups
+}
+
+// Build works out everything a report needs from the options. If nothing matches, ErrNoPeriods is returned.
+func Build(opts Options) (*ReportData, error) {
+	if opts.Begin == nil {
+		return nil, errors.New("report options need a begin time")
+	}
+	begin, end := opts.Begin, opts.End
+	info := opts.Info
+
+	var events timelog.TimeLog
+	if end == nil {
+		events = opts.Log.After(*begin)
+	} else {
+		events = opts.Log.Between(*begin, *end)
+	}
+	all, err := events.CheckedPeriods(opts.OverlapPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hang on to everything for working out percentages later. Overlap has to be worked out before filtering too,
+	// since the other side of an overlap may well be filtered out.
+	all, capped := opts.capped(all)
+	timelog.CanonicalCodes(all, opts.FoldCodeCase)
+	all = opts.transform(all)
+	full := all
+
+	// Everything is filtered in one go, which keeps the periods in order.
+	filters := []timelog.PeriodFilter{CodeFilter(opts.Codes, opts.FoldCodeCase)}
+	for k, v := range opts.Where {
+		filters = append(filters, timelog.MatchMeta(k, v))
+	}
+	periods := timelog.FilterPeriods(all, filters...)
+
+	// Periods that are part of a night shift go with the day it started, which keeps each day together.
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].Day(opts.Calendar).Before(periods[j].Day(opts.Calendar))
+	})
+
+	if len(periods) == 0 {
+		return nil, ErrNoPeriods
+	}
+
+	r := &ReportData{
+		Begin:    begin,
+		End:      end,
+		Periods:  periods,
+		Rounding: opts.Rounding,
+		Capped:   capped,
+		Overlaps: events.Overlaps(),
+
+		label:      func(code string) string { return code },
+		calendar:   opts.Calendar,
+		attachDir:  opts.AttachDir,
+		tableWidth: opts.TableWidth,
+	}
+
+	running := timelog.Totals(periods, opts.FoldCodeCase)
+
+	// With Top, only the biggest codes get their own totals and everything else is lumped together.
+	r.Other = []string{}
+	if opts.Top > 0 && len(running) > opts.Top {
+		ranked := []string{}
+		for code := range running {
+			ranked = append(ranked, code)
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			if running[ranked[i]] != running[ranked[j]] {
+				return running[ranked[i]] > running[ranked[j]]
+			}
+			return ranked[i] < ranked[j]
+		})
+
+		keep := map[string]bool{}
+		for _, code := range ranked[:opts.Top] {
+			keep[code] = true
+		}
+		r.Other = ranked[opts.Top:]
+		sort.Strings(r.Other)
+
+		r.label = func(code string) string {
+			if keep[code] {
+				return code
+			}
+			return "other"
+		}
+
+		rolledup := map[string]time.Duration{}
+		for code, d := range running {
+			rolledup[r.label(code)] += d
+		}
+		running = rolledup
+	}
+	r.Totals = running
+
+	buildRest(r, opts)
+	buildMoney(r, opts, full)
+	buildTasks(r)
+	buildWeeks(r, info, opts.Overtime)
+	buildTargets(r, opts)
+	buildDays(r)
+	buildPlan(r, opts)
+	buildEstimates(r, opts)
+	buildFocus(r, info)
+	buildLogs(r, opts)
+
+	// Work out the percentages.
+	for _, d := range running {
+		r.Total += d
+	}
+	weekfull := map[timelog.Week]time.Duration{}
+	for week, codes := range timelog.TotalsByWeek(full, opts.Calendar, opts.FoldCodeCase) {
+		for _, d := range codes {
+			weekfull[week] += d
+			r.FullTotal += d
+		}
+	}
+
+	r.Shares = map[string]Share{}
+	for code, d := range running {
+		r.Shares[code] = Share{OfTotal: percentOf(d, r.Total), OfFull: percentOf(d, r.FullTotal)}
+	}
+	for _, w := range r.Weeks {
+		w.FullTotal = weekfull[timelog.Week{Year: w.Year, Number: w.Number}]
+		w.Share = Share{OfTotal: percentOf(w.Daily[7], r.Total), OfFull: percentOf(w.Daily[7], r.FullTotal)}
+		w.Shares = map[string]Share{}
+		for code, days := range w.Totals {
+			w.Shares[code] = Share{OfTotal: percentOf(days[7], w.Daily[7]), OfFull: percentOf(days[7], w.FullTotal)}
+		}
+	}
+
+	return r, nil
+}
+
+// CodeFilter matches the periods for the report codes, see Options.Codes. With fold, case doesn't matter (see
+// timelog.CodeKey).
+func CodeFilter(codes []string, fold bool) timelog.PeriodFilter {
+	if len(codes) == 0 {
+		codes = []string{"all"}
+	}
+
+	filters := []timelog.PeriodFilter{}
+	for _, code := range codes {
+		switch code {
+		case "empty":
+			filters = append(filters, timelog.MatchCode("", fold))
+		case "all":
+			filters = append(filters, timelog.Not(timelog.MatchCode("", fold)))
+		default:
+			if parent, depth, ok := timelog.CutWildcard(code); ok {
+				filters = append(filters, timelog.MatchCodeDepth(parent, depth, fold))
+				continue
+			}
+			filters = append(filters, timelog.MatchCode(code, fold))
+		}
+	}
+	return timelog.AnyOf(filters...)
+}
+
+// buildMoney works out the billed time, retainers, and what is owed.
+func buildMoney(r *ReportData, opts Options, full []*timelog.Period) {
+	info := opts.Info
+	begin, end := opts.Begin, opts.End
+
+	// Work out what actually gets billed.
+	r.billed = timelog.RoundPeriods(r.Periods, opts.Rounding, opts.Reconcile)
+	r.BilledTotals = map[string]time.Duration{}
+	for _, p := range r.Periods {
+		r.BilledTotals[r.label(p.Code)] += r.billed[p]
+		r.BilledTotal += r.billed[p]
+	}
+
+	r.Retainers = []*timelog.RetainerMonth{}
+	reported := map[string]bool{}
+	for _, p := range r.Periods {
+		if ret, ok := info.Retainer(p.Code); ok {
+			reported[ret.Code] = true
+		}
+	}
+
+	// Retainers need everything for their codes from the start of the log, not just what is in the report, or
+	// the rollover and overage would be wrong. That means assembling the whole log again, so skip it if there are
+	// no retainers involved.
+	var retainermonths []*timelog.RetainerMonth
+	var overage map[*timelog.Period]time.Duration
+	if len(reported) > 0 {
+		history := []*timelog.Period{}
+		periods, _ := opts.capped(opts.Log.PeriodsWith(opts.OverlapPolicy))
+		for _, p := range opts.transform(periods) {
+			if !p.Begin.After(*begin) {
+				history = append(history, p)
+			}
+		}
+		retained := timelog.RoundPeriods(history, opts.Rounding, opts.Reconcile)
+		maps.Copy(retained, timelog.RoundPeriods(full, opts.Rounding, opts.Reconcile))
+		retainermonths, overage = timelog.Retainers(info, append(history, full...), retained)
+	}
+	for _, m := range retainermonths {
+		if !reported[m.Code] || !m.Month.AddDate(0, 1, 0).After(*begin) || (end != nil && !m.Month.Before(*end)) {
+			continue
+		}
+		r.Retainers = append(r.Retainers, m)
+	}
+
+	// And what it costs. Rates are per code, so this ignores Top. Time covered by a retainer is already paid
+	// for, so only the overage is charged.
+	billedcodes := map[string]time.Duration{}
+	for _, p := range r.Periods {
+		if _, ok := info.Retainer(p.Code); ok {
+			billedcodes[p.Code] += overage[p]
+			continue
+		}
+		billedcodes[p.Code] += r.billed[p]
+	}
+	r.Charges = info.Charges(billedcodes)
+	for _, c := range r.Charges {
+		_, c.Overage = info.Retainer(c.Code)
+	}
+	r.Currencies = timelog.CurrencyTotals(r.Charges)
+	r.Taxes = timelog.TaxLines(r.Charges)
+	buildExpenses(r, opts)
+	for currency, amount := range timelog.ExpenseTotals(r.Expenses) {
+		r.Currencies[currency] = math.Round((r.Currencies[currency]+amount)*100) / 100
+	}
+	buildTrips(r, opts)
+	for _, t := range r.Trips {
+		if t.Amount > 0 {
+			r.Currencies[t.Currency] = math.Round((r.Currencies[t.Currency]+t.Amount)*100) / 100
+		}
+	}
+	if opts.Exchange != nil && len(r.Currencies) > 0 {
+		if v, ok := opts.Exchange.Combine(r.Currencies); ok {
+			r.Combined = &ReportMoney{Amount: v, Currency: opts.Exchange.Base}
+		}
+	}
+}
+
+// buildExpenses picks out the expenses in the range, and totals them for each client.
+func buildExpenses(r *ReportData, opts Options) {
+	r.Expenses = []*timelog.Expense{}
+	r.ClientExpenses = []*ReportExpenses{}
+	filter := CodeFilter(opts.Codes, opts.FoldCodeCase)
+	clients := map[string]*ReportExpenses{}
+	for _, e := range opts.Expenses {
+		if e.At.Before(*opts.Begin) || (opts.End != nil && !e.At.Before(*opts.End)) || !filter(&timelog.Period{Code: e.Code}) {
+			continue
+		}
+		r.Expenses = append(r.Expenses, e)
+
+		c := clients[e.Client()]
+		if c == nil {
+			c = &ReportExpenses{Client: e.Client(), Expenses: []*timelog.Expense{}}
+			clients[c.Client] = c
+			r.ClientExpenses = append(r.ClientExpenses, c)
+		}
+		c.Expenses = append(c.Expenses, e)
+	}
+	for _, c := range r.ClientExpenses {
+		c.Currencies = timelog.ExpenseTotals(c.Expenses)
+	}
+	sort.Slice(r.ClientExpenses, func(i, j int) bool {
+		return r.ClientExpenses[i].Client < r.ClientExpenses[j].Client
+	})
+}
+
+// buildTrips picks out the trips in the range, works out what each is reimbursed for, and totals them for each client.
+func buildTrips(r *ReportData, opts Options) {
+	r.DistanceUnit = opts.DistanceUnit
+	if r.DistanceUnit == "" {
+		r.DistanceUnit = "km"
+	}
+	r.Trips = []*ReportTrip{}
+	r.ClientTrips = []*ReportTrips{}
+	filter := CodeFilter(opts.Codes, opts.FoldCodeCase)
+	clients := map[string]*ReportTrips{}
+	for _, t := range opts.Trips {
+		if t.At.Before(*opts.Begin) || (opts.End != nil && !t.At.Before(*opts.End)) || !filter(&timelog.Period{Code: t.Code}) {
+			continue
+		}
+		rt := &ReportTrip{Trip: t, Distance: t.In(r.DistanceUnit)}
+		if rate, currency, ok := opts.Info.Mileage(t.Code); ok {
+			rt.Rate, rt.Currency = rate, currency
+			rt.Amount = math.Round(rt.Distance*rate*100) / 100
+		}
+		r.Trips = append(r.Trips, rt)
+
+		c := clients[t.Client()]
+		if c == nil {
+			c = &ReportTrips{Client: t.Client(), Trips: []*ReportTrip{}, Currencies: map[string]float64{}}
+			clients[c.Client] = c
+			r.ClientTrips = append(r.ClientTrips, c)
+		}
+		c.Trips = append(c.Trips, rt)
+		c.Distance += rt.Distance
+		if rt.Amount > 0 {
+			c.Currencies[rt.Currency] = math.Round((c.Currencies[rt.Currency]+rt.Amount)*100) / 100
+		}
+	}
+	sort.Slice(r.ClientTrips, func(i, j int) bool {
+		return r.ClientTrips[i].Client < r.ClientTrips[j].Client
+	})
+}
+
+// buildTasks groups the report periods into tasks.
+func buildTasks(r *ReportData) {
+	r.Tasks = []*ReportTask{}
+	taskmap := map[[2]string]*ReportTask{}
+	for _, p := range r.Periods {
+		key := [2]string{p.Code, timelog.NormalizeDesc(p.Desc)}
+		task, ok := taskmap[key]
+		if !ok {
+			first, _, _ := strings.Cut(p.Desc, "\n")
+			task = &ReportTask{Code: p.Code, Desc: first, key: key}
+			taskmap[key] = task
+			r.Tasks = append(r.Tasks, task)
+		}
+		task.Total += p.Length()
+		task.Count++
+	}
+	sort.SliceStable(r.Tasks, func(i, j int) bool {
+		if r.Tasks[i].Code != r.Tasks[j].Code {
+			return r.Tasks[i].Code < r.Tasks[j].Code
+		}
+		return r.Tasks[i].Total > r.Tasks[j].Total
+	})
+}
+
+// buildRest checks the rest rules. What happened just before the report matters too, the night before its first day
+// may have been too short and the start of its first week counts towards the week, so the log is checked from far
+// enough back to see it. Only the violations that reach into the report are kept.
+func buildRest(r *ReportData, opts Options) {
+	r.Violations = []timelog.RestViolation{}
+	if opts.Rest.Empty() {
+		return
+	}
+
+	from := opts.Calendar.StartOfWeek(*r.Begin)
+	if rest := r.Begin.Add(-opts.Rest.MinRest); rest.Before(from) {
+		from = rest
+	}
+	var events timelog.TimeLog
+	if r.End == nil {
+		events = opts.Log.After(from)
+	} else {
+		events = opts.Log.Between(from, *r.End)
+	}
+	// Overlaps before the report aren't its problem, so they don't stop it here.
+	periods, _ := opts.capped(events.PeriodsWith(opts.OverlapPolicy))
+	timelog.CanonicalCodes(periods, opts.FoldCodeCase)
+	periods = opts.transform(periods)
+
+	for _, v := range timelog.CheckRest(periods, opts.Rest, opts.Calendar) {
+		if v.End.After(*r.Begin) && (r.End == nil || v.Begin.Before(*r.End)) {
+			r.Violations = append(r.Violations, v)
+		}
+	}
+}
+
+// buildLogs works out the subtotals for each log in opts.Logs.
+func buildLogs(r *ReportData, opts Options) {
+	r.Logs = []*ReportGroup{}
+	found := map[string]*ReportGroup{}
+	for _, name := range opts.Logs {
+		g := &ReportGroup{Value: name, Totals: map[string]time.Duration{}}
+		found[name] = g
+		r.Logs = append(r.Logs, g)
+	}
+	for _, p := range r.Periods {
+		if g, ok := found[p.Meta[timelog.LogKey]]; ok {
+			g.Total += p.Length()
+			g.Totals[r.label(p.Code)] += p.Length()
+		}
+	}
+}
+
+// buildFocus splits the report periods by focus, for each day and week.
+func buildFocus(r *ReportData, info timelog.CodeInfo) {
+	r.FocusDays = []*ReportFocus{}
+	r.FocusWeeks = []*ReportFocus{}
+	var day, week *ReportFocus
+	for _, p := range r.Periods {
+		date := p.Day(r.calendar)
+		if day == nil || !day.Date.Equal(date) {
+			day = &ReportFocus{Date: date}
+			r.FocusDays = append(r.FocusDays, day)
+		}
+		if start := date.AddDate(0, 0, -r.calendar.WeekdayIndex(date.Weekday())); week == nil || !week.Date.Equal(start) {
+			week = &ReportFocus{Date: start}
+			r.FocusWeeks = append(r.FocusWeeks, week)
+		}
+
+		focus, ok := p.Meta["focus"]
+		if !ok {
+			focus = info.Focus(p.Code)
+		}
+		for _, f := range []*ReportFocus{day, week} {
+			switch strings.ToLower(focus) {
+			case "deep":
+				f.Deep += p.Length()
+			case "shallow":
+				f.Shallow += p.Length()
+			default:
+				f.Other += p.Length()
+			}
+		}
+	}
+
+	for _, list := range [][]*ReportFocus{r.FocusDays, r.FocusWeeks} {
+		for i := 1; i < len(list); i++ {
+			list[i].Trend = list[i].Ratio() - list[i-1].Ratio()
+		}
+	}
+}
+
+// buildEstimates works out how long each task with an "est" took. Tasks are matched the same way as in buildTasks,
+// but are totalled over the whole log, since a task can easily run past either end of a report.
+func buildEstimates(r *ReportData, opts Options) {
+	r.Estimates = []*ReportEstimate{}
+	r.Accuracy = []*ReportAccuracy{}
+
+	// Going over the whole log is most of the time a report takes on a long one, so it is skipped if nothing in it was
+	// ever estimated.
+	estimated := false
+	for _, e := range opts.Log {
+		if e.Meta["est"] != "" {
+			estimated = true
+			break
+		}
+	}
+	if !estimated {
+		return
+	}
+
+	periods, _ := opts.capped(opts.Log.PeriodsWith(opts.OverlapPolicy))
+	all := timelog.FilterPeriods(opts.transform(periods), CodeFilter(opts.Codes, opts.FoldCodeCase))
+	tasks := map[[2]string]*ReportEstimate{}
+	order := []*ReportEstimate{}
+	for _, p := range all {
+		if opts.End != nil && p.Begin.After(*opts.End) {
+			break
+		}
+		key := [2]string{p.Code, timelog.NormalizeDesc(p.Desc)}
+		task, ok := tasks[key]
+		if !ok {
+			first, _, _ := strings.Cut(p.Desc, "\n")
+			task = &ReportEstimate{Code: p.Code, Desc: first}
+			tasks[key] = task
+			order = append(order, task)
+		}
+		if est, err := time.ParseDuration(p.Meta["est"]); err == nil && est > 0 {
+			task.Estimate = est
+		}
+		task.Actual += p.Length()
+		task.Last = p.End
+	}
+
+	months := map[time.Time]*ReportAccuracy{}
+	for _, task := range order {
+		if task.Estimate == 0 {
+			continue
+		}
+		last := task.Last.In(time.Local)
+		month := time.Date(last.Year(), last.Month(), 1, 0, 0, 0, 0, time.Local)
+		if months[month] == nil {
+			months[month] = &ReportAccuracy{Month: month}
+			r.Accuracy = append(r.Accuracy, months[month])
+		}
+		months[month].Tasks++
+		months[month].Estimate += task.Estimate
+		months[month].Actual += task.Actual
+	}
+	sort.Slice(r.Accuracy, func(i, j int) bool {
+		return r.Accuracy[i].Month.Before(r.Accuracy[j].Month)
+	})
+
+	for _, t := range r.Tasks {
+		if task := tasks[t.key]; task != nil && task.Estimate > 0 {
+			t.Estimate = task.Estimate
+			r.Estimates = append(r.Estimates, task)
+		}
+	}
+}
+
+// buildDays buckets the report periods into days.
+func buildDays(r *ReportData) {
+	r.Days = []*ReportDay{}
+	var cd *ReportDay
+	seen := map[string]bool{}
+	for _, p := range r.Periods {
+		if day := p.Day(r.calendar); cd == nil || !cd.Date.Equal(day) {
+			cd = &ReportDay{Date: day, Totals: map[string]time.Duration{}, Notes: []string{}}
+			r.Days = append(r.Days, cd)
+			seen = map[string]bool{}
+		}
+		cd.Total += p.Length()
+		cd.Totals[r.label(p.Code)] += p.Length()
+
+		first, _, _ := strings.Cut(p.Desc, "\n")
+		if norm := timelog.NormalizeDesc(first); norm != "" && !seen[norm] {
+			seen[norm] = true
+			cd.Notes = append(cd.Notes, strings.TrimSpace(first))
+		}
+	}
+}
+
+// buildPlan compares the planned periods in the range with the report periods, by day and code.
+func buildPlan(r *ReportData, opts Options) {
+	if opts.Plan == nil {
+		return
+	}
+	var planned []*timelog.Period
+	if opts.End == nil {
+		planned = opts.Plan.After(*opts.Begin).PeriodsWith(opts.OverlapPolicy)
+	} else {
+		planned = opts.Plan.Between(*opts.Begin, *opts.End).PeriodsWith(opts.OverlapPolicy)
+	}
+	planned = timelog.FilterPeriods(opts.transform(planned), CodeFilter(opts.Codes, opts.FoldCodeCase))
+
+	type key struct {
+		day  time.Time
+		code string
+	}
+	lines := map[key]*PlanLine{}
+	totals := map[string]*PlanLine{}
+
+	// The plan may spell codes differently, the report's spelling wins.
+	names := timelog.CodeNames{Fold: opts.FoldCodeCase}
+	for _, p := range r.Periods {
+		names.Name(p.Code)
+	}
+	add := func(p *timelog.Period, plan bool) {
+		if p.Code == "" {
+			return
+		}
+		k := key{p.Day(r.calendar), names.Name(p.Code)}
+		if lines[k] == nil {
+			lines[k] = &PlanLine{Date: k.day, Code: k.code}
+		}
+		if totals[k.code] == nil {
+			totals[k.code] = &PlanLine{Code: k.code}
+		}
+		if plan {
+			lines[k].Planned += p.Length()
+			totals[k.code].Planned += p.Length()
+		} else {
+			lines[k].Actual += p.Length()
+			totals[k.code].Actual += p.Length()
+		}
+	}
+	for _, p := range planned {
+		add(p, true)
+	}
+	for _, p := range r.Periods {
+		add(p, false)
+	}
+
+	r.Plan = make([]*PlanLine, 0, len(lines))
+	for _, l := range lines {
+		r.Plan = append(r.Plan, l)
+	}
+	sort.Slice(r.Plan, func(i, j int) bool {
+		if !r.Plan[i].Date.Equal(r.Plan[j].Date) {
+			return r.Plan[i].Date.Before(r.Plan[j].Date)
+		}
+		return r.Plan[i].Code < r.Plan[j].Code
+	})
+	r.PlanTotals = make([]*PlanLine, 0, len(totals))
+	for _, l := range totals {
+		r.PlanTotals = append(r.PlanTotals, l)
+	}
+	sort.Slice(r.PlanTotals, func(i, j int) bool {
+		return r.PlanTotals[i].Code < r.PlanTotals[j].Code
+	})
+}
+
+// buildWeeks buckets the report periods into weeks, see timelog.Calendar.WeekOf.
+func buildWeeks(r *ReportData, info timelog.CodeInfo, overtime timelog.Overtime) {
+	r.Weeks = []*ReportWeek{}
+	var cw *ReportWeek
+	for _, p := range r.Periods {
+		day := p.Day(r.calendar)
+		week := r.calendar.WeekOf(day)
+		if cw == nil || week.Number != cw.Number || week.Year != cw.Year {
+			start := r.calendar.StartOfWeek(day)
+			fd := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+			cw = &ReportWeek{Year: week.Year, Number: week.Number, FirstDay: &fd, Totals: map[string][8]time.Duration{}}
+			r.Weeks = append(r.Weeks, cw)
+		}
+
+		cw.Periods = append(cw.Periods, p)
+		d := r.calendar.WeekdayIndex(day.Weekday())
+		v := cw.Totals[r.label(p.Code)]
+		v[d] = v[d] + p.Length()
+		v[7] = v[7] + p.Length()
+		cw.Totals[r.label(p.Code)] = v
+		cw.Daily[d] = cw.Daily[d] + p.Length()
+		cw.Daily[7] = cw.Daily[7] + p.Length()
+
+		split := &cw.NonBillable
+		if info.Billable(p.Code) {
+			split = &cw.Billable
+		}
+		split[d] += p.Length()
+		split[7] += p.Length()
+	}
+
+	for _, w := range r.Weeks {
+		w.Overtime = overtime.Of(w.Daily[:7])
+		r.Billable += w.Billable[7]
+		r.NonBillable += w.NonBillable[7]
+		r.Overtime += w.Overtime
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestBuildLogs(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 12, hour, minute, 0, 0, time.Local)
+	}
+	log := timelog.MergeLogs([]timelog.NamedLog{
+		{Name: "client", Log: timelog.TimeLog{{At: at(9, 0), Code: "Acme:Dev"}, {At: at(11, 0), Code: "Acme:Ops"}, {At: at(11, 30)}}},
+		{Name: "personal", Log: timelog.TimeLog{{At: at(10, 0), Code: "Reading"}, {At: at(10, 45)}}},
+		{Name: "unused", Log: timelog.TimeLog{{At: at(13, 0), Code: "Other"}, {At: at(14, 0)}}},
+	})
+	begin, end := at(0, 0), at(23, 59)
+	data, err := Build(Options{
+		Log:          log,
+		Begin:        &begin,
+		End:          &end,
+		Logs:         []string{"client", "personal", "empty"},
+		Calendar:     timelog.Calendar{WeekStart: time.Monday},
+		FoldCodeCase: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every log asked for gets a subtotal, in the order given, even with nothing in it. Logs that weren't named don't.
+	want := []struct {
+		name   string
+		total  time.Duration
+		totals map[string]time.Duration
+	}{
+		{"client", 150 * time.Minute, map[string]time.Duration{"Acme:Dev": 2 * time.Hour, "Acme:Ops": 30 * time.Minute}},
+		{"personal", 45 * time.Minute, map[string]time.Duration{"Reading": 45 * time.Minute}},
+		{"empty", 0, map[string]time.Duration{}},
+	}
+	if len(data.Logs) != len(want) {
+		t.Fatalf("got %d log subtotals, want %d", len(data.Logs), len(want))
+	}
+	for i, w := range want {
+		g := data.Logs[i]
+		if g.Value != w.name || g.Total != w.total || len(g.Totals) != len(w.totals) {
+			t.Errorf("log %d is %s with %v %v, want %s with %v %v", i, g.Value, g.Total, g.Totals, w.name, w.total, w.totals)
+			continue
+		}
+		for code, d := range w.totals {
+			if g.Totals[code] != d {
+				t.Errorf("%s has %v on %s, want %v", w.name, g.Totals[code], code, d)
+			}
+		}
+	}
+}
+
+// The rest rules look back past the start of the report, for the night before its first day and the rest of its first
+// week, but only report what reaches into it.
+func TestBuildRest(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+	}
+	// 2026/10/12 is a Monday. Monday is a long day that ends late, and Tuesday starts 8h after.
+	log := timelog.TimeLog{
+		{At: at(12, 8), Code: "A"},
+		{At: at(12, 23), Code: ""},
+		{At: at(13, 7), Code: "A"},
+		{At: at(13, 17), Code: ""},
+		{At: at(14, 7), Code: "A"},
+		{At: at(14, 17), Code: ""},
+		{At: at(15, 7), Code: "A"},
+		{At: at(15, 17), Code: ""},
+		{At: at(16, 7), Code: "A"},
+		{At: at(16, 12), Code: ""},
+	}
+	rules := timelog.RestRules{MaxDay: 10 * time.Hour, MinRest: 11 * time.Hour, MaxWeek: 48 * time.Hour}
+
+	tests := []struct {
+		name       string
+		begin, end time.Time
+		want       []string
+	}{
+		{"the report starts after the short night", at(13, 0), at(14, 0), []string{"rest"}},
+		{"the week is too long by Friday", at(16, 0), at(17, 0), []string{"week"}},
+		{"the whole week", at(12, 0), at(19, 0), []string{"day", "week", "rest"}},
+	}
+	for _, test := range tests {
+		data, err := Build(Options{
+			Log:      log,
+			Begin:    &test.begin,
+			End:      &test.end,
+			Rest:     rules,
+			Calendar: timelog.Calendar{WeekStart: time.Monday},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, v := range data.Violations {
+			got = append(got, v.Rule)
+		}
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: got %v, want %v", test.name, data.Violations, test.want)
+		}
+	}
+}
+
+func TestBuildExpensesAndTrips(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+	}
+	log := timelog.TimeLog{{At: at(13, 9), Code: "Acme:Dev"}, {At: at(13, 11), Code: ""}}
+	info := timelog.CodeInfo{"Acme": {"rate": "50", "currency": "EUR", "mileage": "0.3"}}
+	expenses := []*timelog.Expense{
+		{At: at(12, 0), Day: true, Code: "Acme:Dev", Amount: 12.5, Currency: "EUR"},
+		{At: at(13, 10), Code: "Acme:Ops", Amount: 7.25, Currency: "EUR"},
+		{At: at(13, 12), Code: "Bank", Amount: 20, Currency: "USD"},
+		{At: at(20, 0), Day: true, Code: "Acme", Amount: 999, Currency: "EUR"},
+	}
+	trips := []*timelog.Trip{
+		{At: at(13, 9), Code: "Acme:Dev", Distance: 10, Unit: "mi"},
+		{At: at(14, 0), Day: true, Code: "Acme", Distance: 5, Unit: "km"},
+		{At: at(14, 0), Day: true, Code: "Bank", Distance: 3, Unit: "km"},
+		{At: at(11, 0), Day: true, Code: "Acme", Distance: 500, Unit: "km"},
+	}
+
+	tests := []struct {
+		unit       string
+		distances  []float64 // Acme's trips.
+		amounts    []float64
+		acme, bank float64 // Client distances.
+		currencies map[string]float64
+	}{
+		// 10mi is 16.09344km, which at 0.30 is 4.828032 and rounds up to 4.83.
+		{"", []float64{16.09344, 5}, []float64{4.83, 1.5}, 21.09344, 3, map[string]float64{"EUR": 126.08, "USD": 20}},
+		// 5km is 3.10686mi, which at 0.30 is 0.932057 and rounds down to 0.93.
+		{"mi", []float64{10, 5 / timelog.KilometersPerMile}, []float64{3, 0.93}, 10 + 5/timelog.KilometersPerMile, 3 / timelog.KilometersPerMile, map[string]float64{"EUR": 123.68, "USD": 20}},
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, test := range tests {
+		begin, end := at(12, 0), at(19, 0)
+		data, err := Build(Options{
+			Log:          log,
+			Begin:        &begin,
+			End:          &end,
+			Info:         info,
+			Expenses:     expenses,
+			Trips:        trips,
+			DistanceUnit: test.unit,
+			Calendar:     timelog.Calendar{WeekStart: time.Monday},
+			FoldCodeCase: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(data.Expenses) != 3 || len(data.ClientExpenses) != 2 {
+			t.Fatalf("%q: got %d expenses for %d clients, want 3 for 2", test.unit, len(data.Expenses), len(data.ClientExpenses))
+		}
+		acme, bank := data.ClientExpenses[0], data.ClientExpenses[1]
+		if acme.Client != "Acme" || len(acme.Expenses) != 2 || len(acme.Currencies) != 1 || acme.Currencies["EUR"] != 19.75 {
+			t.Errorf("%q: Acme's expenses are %+v", test.unit, acme)
+		}
+		if bank.Client != "Bank" || len(bank.Expenses) != 1 || len(bank.Currencies) != 1 || bank.Currencies["USD"] != 20 {
+			t.Errorf("%q: Bank's expenses are %+v", test.unit, bank)
+		}
+
+		if len(data.Trips) != 3 || len(data.ClientTrips) != 2 {
+			t.Fatalf("%q: got %d trips for %d clients, want 3 for 2", test.unit, len(data.Trips), len(data.ClientTrips))
+		}
+		for i, rt := range data.Trips[:2] {
+			if !near(rt.Distance, test.distances[i]) || rt.Amount != test.amounts[i] || rt.Rate != 0.3 || rt.Currency != "EUR" {
+				t.Errorf("%q: trip %d is %+v, want %v for %v", test.unit, i, rt, test.distances[i], test.amounts[i])
+			}
+		}
+		if rt := data.Trips[2]; rt.Amount != 0 || rt.Currency != "" {
+			t.Errorf("%q: Bank has no mileage rate, but its trip is %+v", test.unit, rt)
+		}
+		ta, tb := data.ClientTrips[0], data.ClientTrips[1]
+		if ta.Client != "Acme" || !near(ta.Distance, test.acme) || len(ta.Currencies) != 1 || ta.Currencies["EUR"] != test.amounts[0]+test.amounts[1] {
+			t.Errorf("%q: Acme's trips are %+v", test.unit, ta)
+		}
+		if tb.Client != "Bank" || !near(tb.Distance, test.bank) || len(tb.Currencies) != 0 {
+			t.Errorf("%q: Bank's trips are %+v", test.unit, tb)
+		}
+
+		// The 100 EUR of time, the expenses, and the mileage, each counted once.
+		if len(data.Currencies) != len(test.currencies) {
+			t.Errorf("%q: currencies are %v, want %v", test.unit, data.Currencies, test.currencies)
+		}
+		for currency, amount := range test.currencies {
+			if data.Currencies[currency] != amount {
+				t.Errorf("%q: %s totals %v, want %v", test.unit, currency, data.Currencies[currency], amount)
+			}
+		}
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/milochristiansen/timeclock/locale"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// tableMinWrap is the narrowest the wrapped column will get, no matter how little room the others leave it.
+const tableMinWrap = 12
+
+// Table lays out items (a list of structs, or pointers to them) in columns, with a row for each item under a header
+// row of the column names, translated (see the locale package).
+//
+// Columns are named after fields or methods that take no arguments, eg "Begin", "Code", or "Length". "Hours" is Length
+// in decimal hours. Lower case names are looked up in the item's Meta, if it has one, so "ticket" is the ticket
+// metadata. Times can be given a layout after a colon, eg "End:03:04PM".
+//
+// If the table is wider than width, the Desc column (or the last column, if there isn't one) is wrapped to fit.
+func Table(items any, width int, style timelog.DurationStyle, columns ...string) (string, error) {
+	list := reflect.ValueOf(items)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return "", fmt.Errorf("table needs a list, not %T", items)
+	}
+	if len(columns) == 0 {
+		return "", errors.New("table needs at least one column")
+	}
+
+	names := make([]string, len(columns))
+	layouts := make([]string, len(columns))
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		names[i], layouts[i], _ = strings.Cut(column, ":")
+		header[i] = locale.T(names[i])
+	}
+
+	rows := [][]string{header}
+	right := make([]bool, len(columns))
+	for i := 0; i < list.Len(); i++ {
+		row := make([]string, len(columns))
+		for j, name := range names {
+			cell, numeric, err := tableCell(list.Index(i), name, layouts[j], style)
+			if err != nil {
+				return "", err
+			}
+			row[j] = cell
+			right[j] = right[j] || numeric
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for j, cell := range row {
+			if n := textWidth(cell); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+
+	wrap := len(columns) - 1
+	for j, name := range names {
+		if name == "Desc" {
+			wrap = j
+		}
+	}
+	if width > 0 {
+		total := 2 * (len(columns) - 1)
+		for _, w := range widths {
+			total += w
+		}
+		if total > width {
+			w := widths[wrap] - (total - width)
+			if w < tableMinWrap {
+				w = tableMinWrap
+			}
+			if w < widths[wrap] {
+				widths[wrap] = w
+			}
+		}
+	}
+
+	out := &strings.Builder{}
+	for _, row := range rows {
+		cells := make([][]string, len(row))
+		height := 1
+		for j, cell := range row {
+			cells[j] = []string{cell}
+			if j == wrap {
+				cells[j] = WrapText(cell, widths[j])
+			}
+			if len(cells[j]) > height {
+				height = len(cells[j])
+			}
+		}
+
+		for l := 0; l < height; l++ {
+			line := &strings.Builder{}
+			for j := range row {
+				cell := ""
+				if l < len(cells[j]) {
+					cell = cells[j][l]
+				}
+				if j > 0 {
+					line.WriteString("  ")
+				}
+				pad := strings.Repeat(" ", widths[j]-textWidth(cell))
+				if right[j] {
+					line.WriteString(pad + cell)
+				} else {
+					line.WriteString(cell + pad)
+				}
+			}
+			out.WriteString(strings.TrimRight(line.String(), " "))
+			out.WriteString("\n")
+		}
+	}
+	return out.String(), nil
+}
+
+// tableCell formats a single cell for Table. numeric is true if the cell should be aligned to the right.
+func tableCell(item reflect.Value, name, layout string, style timelog.DurationStyle) (cell string, numeric bool, err error) {
+	field := name
+	if name == "Hours" {
+		field = "Length"
+	}
+	value, ok := tableValue(item, field)
+	if !ok {
+		return "", false, fmt.Errorf("table: there is no %q column for %s", name, item.Type())
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", false, nil
+	case time.Time:
+		if v.IsZero() {
+			return "", false, nil
+		}
+		if layout == "" {
+			layout = "2006/01/02 03:04PM"
+		}
+		return locale.Date(v, layout), false, nil
+	case *time.Time:
+		if v == nil {
+			return "", false, nil
+		}
+		return tableCell(reflect.ValueOf(*v), "", layout, style)
+	case time.Duration:
+		if name == "Hours" {
+			return fmt.Sprintf("%.2f", v.Hours()), true, nil
+		}
+		return timelog.FormatDuration(v, style), true, nil
+	case float64:
+		return fmt.Sprintf("%.2f", v), true, nil
+	case int:
+		return strconv.Itoa(v), true, nil
+	case string:
+		return v, false, nil
+	}
+	return fmt.Sprint(value), false, nil
+}
+
+// tableValue finds the value of a column for an item, from a method, a field, or its metadata in that order.
+func tableValue(item reflect.Value, name string) (any, bool) {
+	if name == "" {
+		return item.Interface(), true
+	}
+
+	method := item.MethodByName(name)
+	if method.IsValid() && method.Type().NumIn() == 0 && method.Type().NumOut() == 1 {
+		return method.Call(nil)[0].Interface(), true
+	}
+
+	for item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+		if item.IsNil() {
+			return nil, true
+		}
+		item = item.Elem()
+	}
+	if item.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	method = item.MethodByName(name)
+	if method.IsValid() && method.Type().NumIn() == 0 && method.Type().NumOut() == 1 {
+		return method.Call(nil)[0].Interface(), true
+	}
+	if field := item.FieldByName(name); field.IsValid() && field.CanInterface() {
+		return field.Interface(), true
+	}
+
+	first, _ := utf8.DecodeRuneInString(name)
+	if string(first) != strings.ToLower(string(first)) {
+		return nil, false
+	}
+	if field := item.FieldByName("Meta"); field.IsValid() && field.CanInterface() {
+		if meta, ok := field.Interface().(map[string]string); ok {
+			return meta[name], true
+		}
+	}
+	return nil, false
+}
+
+// WrapText breaks text into lines no wider than width, between words where it can.
+func WrapText(text string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for textWidth(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			// At least one character is taken, or a wide one would never fit in a single column. Combining marks and
+			// joined emoji add no width, so they stay with the character before them.
+			cut := 0
+			for i, r := range word {
+				end := i + utf8.RuneLen(r)
+				if textWidth(word[:end]) > width && i > 0 {
+					break
+				}
+				cut = end
+			}
+			lines = append(lines, word[:cut])
+			word = word[cut:]
+		}
+
+		switch {
+		case line == "":
+			line = word
+		case textWidth(line)+1+textWidth(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// textWidth is how many columns text takes up on the terminal.
+func textWidth(text string) int {
+	return timelog.DisplayWidth(text)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestTableAlignsWideText(t *testing.T) {
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	periods := []*timelog.Period{
+		{Begin: at, End: at.Add(90 * time.Minute), Code: "Dev", Desc: "plain"},
+		{Begin: at, End: at.Add(30 * time.Minute), Code: "项目:开发", Desc: "会议记录"},
+		{Begin: at, End: at.Add(2 * time.Hour), Code: "Cafe\u0301", Desc: "👩\u200d💻 review"},
+	}
+	out, err := Table(periods, 0, timelog.DurationClock, "Code", "Length", "Desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every row should have its duration end, and its description start, in the same column as the header's.
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != len(periods)+1 {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(periods)+1, out)
+	}
+	descAt := strings.Index(lines[0], "Desc")
+	for i, line := range lines[1:] {
+		desc := periods[i].Desc
+		before := line[:strings.Index(line, desc)]
+		if got, want := timelog.DisplayWidth(before), descAt; got != want {
+			t.Errorf("row %d description starts in column %d, want %d:\n%s", i, got, want, out)
+		}
+		length := timelog.FormatDuration(periods[i].Length(), timelog.DurationClock)
+		if got, want := timelog.DisplayWidth(strings.TrimRight(before, " ")), descAt-2; got != want || !strings.HasSuffix(strings.TrimRight(before, " "), length) {
+			t.Errorf("row %d duration ends in column %d, want %d:\n%s", i, got, want, out)
+		}
+	}
+}
+
+func TestWrapTextWide(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"one two three", 7, []string{"one two", "three"}},
+		{"会议记录 开发", 8, []string{"会议记录", "开发"}},
+		{"会议记录", 5, []string{"会议", "记录"}},
+
+		// A wide character is taken even when the width is too narrow for it.
+		{"会议", 1, []string{"会", "议"}},
+
+		// Accents and joined emoji stay with the character they're on.
+		{"cafe\u0301cafe\u0301", 4, []string{"cafe\u0301", "cafe\u0301"}},
+		{"👩\u200d💻👩\u200d💻", 2, []string{"👩\u200d💻", "👩\u200d💻"}},
+		{"👍🏽👍🏽 ok", 4, []string{"👍🏽👍🏽", "ok"}},
+	}
+	for _, test := range tests {
+		got := WrapText(test.text, test.width)
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("WrapText(%q, %d) = %q, want %q", test.text, test.width, got, test.want)
+		}
+	}
+}
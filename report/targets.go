@@ -0,0 +1,115 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// ReportTarget is the time towards a weekly target, the schedule or a budget, see ReportWeek.Targets.
+type ReportTarget struct {
+	Code   string // The code the budget is set on, or blank for the schedule.
+	Target time.Duration
+	Done   time.Duration
+}
+
+// Remaining is how much is left before the target is reached, 0 once it has been.
+func (t *ReportTarget) Remaining() time.Duration {
+	if t.Done >= t.Target {
+		return 0
+	}
+	return t.Target - t.Done
+}
+
+// Over is how much more time there is than the target, 0 until it has been reached.
+func (t *ReportTarget) Over() time.Duration {
+	if t.Done <= t.Target {
+		return 0
+	}
+	return t.Done - t.Target
+}
+
+// Percent is how far along the target is, which goes over 100 once there is more time than the target.
+func (t *ReportTarget) Percent() float64 {
+	return percentOf(t.Done, t.Target)
+}
+
+// buildTargets works out the targets for each week in the report. A target is for the whole week, so the time for it
+// comes from the log rather than the report, whatever range and codes the report has. That way a report for today
+// still says how the week is going.
+func buildTargets(r *ReportData, opts Options) {
+	r.Targets = []*ReportTarget{}
+	if len(r.Weeks) == 0 {
+		return
+	}
+
+	// Between checks the whole log is sorted each time, so the weeks are cut out of just the part they cover.
+	first, last := *r.Weeks[0].FirstDay, *r.Weeks[0].FirstDay
+	for _, w := range r.Weeks {
+		if w.FirstDay.Before(first) {
+			first = *w.FirstDay
+		}
+		if w.FirstDay.After(last) {
+			last = *w.FirstDay
+		}
+	}
+	log := opts.Log.Between(first, last.AddDate(0, 0, 7))
+	for _, w := range r.Weeks {
+		w.Targets = []*ReportTarget{}
+		begin := *w.FirstDay
+		periods, _ := opts.capped(log.Between(begin, begin.AddDate(0, 0, 7)).PeriodsWith(opts.OverlapPolicy))
+		periods = opts.transform(periods)
+
+		// Budgets are for the whole code, so they only need the report to have some time on it to show up.
+		owners := []string{}
+		for _, p := range w.Periods {
+			if owner, _, ok := opts.Info.Budget(p.Code); ok && !slices.Contains(owners, owner) {
+				owners = append(owners, owner)
+			}
+		}
+		sort.Strings(owners)
+
+		var worked time.Duration
+		budgets := map[string]time.Duration{}
+		for _, p := range periods {
+			if p.Code == "" {
+				continue
+			}
+			worked += p.Length()
+			if owner, _, ok := opts.Info.Budget(p.Code); ok {
+				budgets[owner] += p.Length()
+			}
+		}
+
+		if opts.Schedule > 0 {
+			w.Targets = append(w.Targets, &ReportTarget{Target: opts.Schedule, Done: worked})
+		}
+		for _, owner := range owners {
+			_, hours, _ := opts.Info.Budget(owner)
+			w.Targets = append(w.Targets, &ReportTarget{Code: owner, Target: hours, Done: budgets[owner]})
+		}
+	}
+	r.Targets = r.Weeks[len(r.Weeks)-1].Targets
+}
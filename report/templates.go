@@ -0,0 +1,162 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package report
+
+import (
+	"embed"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/milochristiansen/timeclock/locale"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+//go:embed reports/*
+var builtinReports embed.FS
+
+// LoadTemplates loads the built in report templates, then any in dir on top of them. User templates with the same
+// name as a built in one replace it. A blank dir only loads the built in templates.
+//
+// tr, date, and initials translate text, dates, and the heading of a week into locale.Language.
+//
+// The billed, bymeta, attachments, table, and initials functions don't do anything useful until Funcs is used to point
+// them at a report. Until then tables aren't fitted to a width, and weeks start on Monday.
+func LoadTemplates(dir string, info timelog.CodeInfo, style timelog.DurationStyle) (*template.Template, error) {
+	templates := template.New("").Funcs(template.FuncMap{
+		"duration": func(d time.Duration) string {
+			return timelog.FormatDuration(d, style)
+		},
+		"billable": info.Billable,
+		"billed":   func(p *timelog.Period) time.Duration { return p.Length() },
+		"bymeta":   func(key string) []*ReportGroup { return nil },
+		"percent":  percentOf,
+		"money":    FormatMoney,
+		"violation": func(v timelog.RestViolation) string {
+			return DescribeViolation(v, style)
+		},
+		"bar": func(percent float64, width int) string {
+			n := int(percent/100*float64(width) + 0.5)
+			if n < 0 {
+				n = 0
+			} else if n > width {
+				n = width
+			}
+			return strings.Repeat("#", n) + strings.Repeat(".", width-n)
+		},
+		"signed": func(d time.Duration) string {
+			if d < 0 {
+				return "-" + timelog.FormatDuration(-d, style)
+			}
+			return "+" + timelog.FormatDuration(d, style)
+		},
+		"attachments": func(p *timelog.Period) []*timelog.Attachment {
+			return timelog.Attachments(p.Meta, "")
+		},
+		"table": func(items any, columns ...string) (string, error) {
+			return Table(items, 0, style, columns...)
+		},
+		"tr":   locale.T,
+		"date": locale.Date,
+		"initials": func() []string {
+			return weekInitials(time.Monday)
+		},
+	})
+
+	err := loadTemplatesFrom(builtinReports, templates)
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		err = loadTemplatesFrom(os.DirFS(dir), templates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+// Funcs returns the template functions that need the report data, for use with template.Funcs before executing a
+// template loaded by LoadTemplates. The style should be the same one given to LoadTemplates.
+func (r *ReportData) Funcs(style timelog.DurationStyle) template.FuncMap {
+	return template.FuncMap{
+		"table": func(items any, columns ...string) (string, error) {
+			return Table(items, r.tableWidth, style, columns...)
+		},
+		"initials": func() []string {
+			// The days in a week are in order from the calendar's WeekStart, which isn't always Monday.
+			return weekInitials(r.calendar.WeekStart)
+		},
+		"billed": r.Billed,
+		"bymeta": r.ByMeta,
+		"attachments": func(p *timelog.Period) []*timelog.Attachment {
+			return timelog.Attachments(p.Meta, r.attachDir)
+		},
+	}
+}
+
+// weekInitials is the initials of the days of the week, translated, in order from start.
+func weekInitials(start time.Weekday) []string {
+	initials, i := locale.Initials(), (int(start)+6)%7
+	return append(append([]string{}, initials[i:]...), initials[:i]...)
+}
+
+func loadTemplatesFrom(f fs.FS, t *template.Template) error {
+	return fs.WalkDir(f, ".", func(path string, d fs.DirEntry, err error) error {
+		if d == nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		if strings.HasSuffix(d.Name(), ".tmpl") {
+			file, err := f.Open(path)
+			if err != nil {
+				return err
+			}
+			content, err := io.ReadAll(file)
+			if err != nil {
+				return err
+			}
+
+			nt := t.Lookup(d.Name())
+			if nt == nil {
+				_, err = t.New(d.Name()).Parse(string(content))
+				return err
+			}
+			_, err = nt.Parse(string(content))
+			return err
+		}
+
+		return nil
+	})
+}
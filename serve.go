@@ -0,0 +1,649 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// serveLimit is the most a request body can be, far more than any event needs.
+const serveLimit = 1 << 20
+
+// Server is the REST API behind 'serve', for clocking in and out from other tools and devices without running the CLI.
+// Every request reads the timelog again and writes any change straight back, so the CLI can be used alongside it, and
+// requests are handled one at a time. Changes are checked the same way the CLI checks them before writing.
+//
+// Every request needs the token, and has to be addressed to this machine or Addr by name, so a web page can't use the
+// API from a browser: it can't send the token without being let in by CORS, which the API never does, and a domain of
+// its own pointed at this machine (DNS rebinding) is refused. Bodies have to be sent as JSON for the same reason, since
+// a page can post a form anywhere.
+type Server struct {
+	Config   map[string]string
+	Info     timelog.CodeInfo
+	Exchange *timelog.ExchangeRates
+	Filters  *ReportFilters     // The defaults from the config, for reports.
+	Store    *timelog.FileStore // Where changes are saved, the logfile.
+	Rest     timelog.RestRules
+	Schedule time.Duration
+	Overtime timelog.Overtime
+	CapNow   bool
+	Token    string // Every request needs it as a bearer token, nothing is allowed without one.
+	Addr     string // The address being served on, see allowedHost.
+
+	mu sync.Mutex
+}
+
+// ServeEvent is an event as it is sent to the server. Anything left out is the usual default when adding an event, and
+// is left alone when editing one. A meta value that is empty removes that key, like --meta.
+type ServeEvent struct {
+	At    *time.Time        `json:"at"`
+	Track *string           `json:"track"`
+	Code  *string           `json:"code"`
+	Desc  *string           `json:"desc"`
+	Meta  map[string]string `json:"meta"`
+}
+
+// apiError is an error with the status to answer it with.
+type apiError struct {
+	Status int
+	Msg    string
+}
+
+func (e *apiError) Error() string {
+	return e.Msg
+}
+
+func fail(status int, format string, args ...any) error {
+	return &apiError{Status: status, Msg: fmt.Sprintf(format, args...)}
+}
+
+// jsonWriter is for answers that know how to write themselves, like a report.
+type jsonWriter interface {
+	WriteJSON(w io.Writer) error
+}
+
+type serveHandler func(r *http.Request) (int, any, error)
+
+// Handler returns the routes of the API:
+//
+//	GET   /events       events in the timelog, see listEvents
+//	POST  /events       add an event, see appendEvent
+//	GET   /events/last  the last event
+//	PATCH /events/last  change the last event, see editLast
+//	GET   /report       a report as JSON, see runReport
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/events", s.route(map[string]serveHandler{"GET": s.listEvents, "POST": s.appendEvent}))
+	mux.Handle("/events/last", s.route(map[string]serveHandler{"GET": s.lastEvent, "PATCH": s.editLast}))
+	mux.Handle("/report", s.route(map[string]serveHandler{"GET": s.runReport}))
+	return mux
+}
+
+// route checks the host, token, method, and content type, runs the handler for the method, and writes what it returns as JSON. Errors are
+// written as {"error": "..."}.
+func (s *Server) route(methods map[string]serveHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		status, body, err := s.serve(methods, w, r)
+
+		var aerr *apiError
+		switch {
+		case errors.As(err, &aerr):
+			status, body = aerr.Status, map[string]string{"error": aerr.Msg}
+		case err != nil:
+			status, body = http.StatusInternalServerError, map[string]string{"error": err.Error()}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if jw, ok := body.(jsonWriter); ok {
+			err = jw.WriteJSON(w)
+		} else {
+			err = json.NewEncoder(w).Encode(body)
+		}
+		Debug.Debug("served", "method", r.Method, "path", r.URL.Path, "status", status, "error", err, "took", time.Since(start))
+	})
+}
+
+func (s *Server) serve(methods map[string]serveHandler, w http.ResponseWriter, r *http.Request) (int, any, error) {
+	if !s.allowedHost(r.Host) {
+		return 0, nil, fail(http.StatusMisdirectedRequest, "%q isn't this server, use localhost or the address it is serving on", r.Host)
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.Token == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		return 0, nil, fail(http.StatusUnauthorized, "missing or wrong token")
+	}
+
+	handler, ok := methods[r.Method]
+	if !ok {
+		allowed := []string{}
+		for m := range methods {
+			allowed = append(allowed, m)
+		}
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		return 0, nil, fail(http.StatusMethodNotAllowed, "%s isn't allowed here", r.Method)
+	}
+
+	if r.Method == http.MethodPost || r.Method == http.MethodPatch {
+		kind, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || kind != "application/json" {
+			return 0, nil, fail(http.StatusUnsupportedMediaType, "the body has to be JSON, sent with 'Content-Type: application/json'")
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, serveLimit)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return handler(r)
+}
+
+// allowedHost is true if the Host header of a request names this machine (localhost or a loopback address) or the
+// host being served on. Serving on every address (0.0.0.0 or [::]) allows any IP address too, but still no other
+// names, since it is names that rebinding needs.
+func (s *Server) allowedHost(host string) bool {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), "80")
+	}
+	if IsLoopback(host) {
+		return true
+	}
+	name, _, _ := net.SplitHostPort(host)
+	bind, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(name, bind) {
+		return true
+	}
+	everywhere := bind == "" || net.ParseIP(bind) != nil && net.ParseIP(bind).IsUnspecified()
+	return everywhere && net.ParseIP(name) != nil
+}
+
+// load reads the timelog as it is now, and returns it along with a function to commit a transaction begun on it. The
+// commit refuses anything the CLI would refuse to write.
+func (s *Server) load() (timelog.TimeLog, func(*timelog.Tx) error, error) {
+	content, err := os.ReadFile(s.Config["logfile"])
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	log, problems := timelog.ParseTimeLogLenient(string(content))
+	log.Sort()
+
+	version := timelog.FormatVersionOf(string(content))
+	needsMigrate := false
+	for _, m := range timelog.PendingMigrations(version) {
+		needsMigrate = needsMigrate || !m.Automatic
+	}
+
+	locks, err := LoadLocks(s.Config["lockfile"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading lock file: %w", err)
+	}
+	locked := locks.Locked(log)
+
+	save := func(tx *timelog.Tx) error {
+		switch {
+		case len(problems) > 0:
+			return fail(http.StatusConflict, "the timelog has %d malformed line(s), fix them before changing it", len(problems))
+		case version > timelog.FormatVersion:
+			return fail(http.StatusConflict, "the timelog is from a newer version of timeclock, upgrade to change it")
+		case needsMigrate:
+			return fail(http.StatusConflict, "the timelog needs upgrading with 'timeclock migrate' first")
+		}
+		log := tx.Log()
+		if changed := locks.Changed(log, locked); len(changed) > 0 {
+			return fail(http.StatusConflict, "this would change events in the finalized range %s", changed[0])
+		}
+		if moved := log.OutOfOrder(); len(moved) > 0 {
+			if s.Config["ordering"] == "error" {
+				i := moved[0]
+				return fail(http.StatusConflict, "%s is before the event preceding it, %s", log[i].String(), log[i-1].String())
+			}
+			tx.Sort()
+		}
+		err := tx.Commit(s.Store)
+		var invalid timelog.ErrInvalid
+		if errors.As(err, &invalid) {
+			return fail(http.StatusBadRequest, "the timelog can't be written like this: %v", invalid.Err)
+		}
+		return err
+	}
+	return log, save, nil
+}
+
+// track is the track a request is for, from ?track=, or the one given to 'serve' (see --track) without it.
+func track(r *http.Request) string {
+	if r.URL.Query().Has("track") {
+		return r.URL.Query().Get("track")
+	}
+	return Track
+}
+
+// exported is an event as the API sends it back, the same as in 'export all'.
+func exported(e *timelog.Event) *ExportEvent {
+	return &ExportEvent{At: e.At, Track: e.Track, Code: e.Code, Desc: e.Desc, Meta: e.Meta}
+}
+
+// decode reads a ServeEvent from the request body. Unknown fields are an error, so a typo doesn't go unnoticed.
+func decode(r *http.Request) (*ServeEvent, error) {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	event := &ServeEvent{}
+	err := dec.Decode(event)
+	if err != nil {
+		return nil, fail(http.StatusBadRequest, "invalid event: %v", err)
+	}
+	if event.At != nil {
+		// The timelog only keeps minutes, so don't answer with seconds that won't be there next time.
+		at := event.At.In(time.Local).Truncate(time.Minute)
+		event.At = &at
+	}
+	return event, nil
+}
+
+// listEvents is every event in the timelog (not the archives), oldest first. ?from= and ?to= limit it to events in a
+// range (see parseServeTime), ?track= to one track, and ?limit= to the last so many.
+func (s *Server) listEvents(r *http.Request) (int, any, error) {
+	q := r.URL.Query()
+	from, err := parseServeTime(q.Get("from"))
+	if err != nil {
+		return 0, nil, err
+	}
+	to, err := parseServeTime(q.Get("to"))
+	if err != nil {
+		return 0, nil, err
+	}
+	limit := 0
+	if q.Has("limit") {
+		limit, err = strconv.Atoi(q.Get("limit"))
+		if err != nil || limit < 1 {
+			return 0, nil, fail(http.StatusBadRequest, "limit needs a number, starting from 1")
+		}
+	}
+
+	log, _, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	events := []*ExportEvent{}
+	for _, e := range log {
+		switch {
+		case q.Has("track") && e.Track != q.Get("track"):
+		case from != nil && e.At.Before(*from):
+		case to != nil && !e.At.Before(*to):
+		default:
+			events = append(events, exported(e))
+		}
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return http.StatusOK, events, nil
+}
+
+// appendEvent adds an event. It is at the current time on the track from --track unless the body says otherwise, and gets
+// the meta and description placeholders an event from the CLI would. An event before the last one on its track is
+// refused unless ?backdate=true is given, like --allow-backdate.
+func (s *Server) appendEvent(r *http.Request) (int, any, error) {
+	body, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	backdate := false
+	if v := r.URL.Query().Get("backdate"); v != "" {
+		backdate, err = strconv.ParseBool(v)
+		if err != nil {
+			return 0, nil, fail(http.StatusBadRequest, "backdate needs to be true or false")
+		}
+	}
+
+	log, save, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	e := &timelog.Event{At: Clock.Now().Truncate(time.Minute), Track: Track}
+	if body.At != nil {
+		e.At = *body.At
+	}
+	if body.Track != nil {
+		e.Track = *body.Track
+	}
+	if body.Code != nil {
+		e.Code = *body.Code
+	}
+	if body.Desc != nil {
+		e.Desc = *body.Desc
+	}
+	e.Desc, err = ExpandDesc(e, log, s.Info)
+	if err != nil {
+		return 0, nil, fail(http.StatusBadRequest, "%v", err)
+	}
+	e.Meta = withMeta(maps.Clone(EventMeta), body.Meta)
+
+	tx := timelog.Begin(log)
+	if last := log.Last(e.Track); last != nil && e.At.Before(last.At) {
+		if !backdate {
+			return 0, nil, fail(http.StatusConflict, "%s is before the last event, %s (use ?backdate=true to insert it in order anyway)", e.At.Format(timelog.TimeFormat), last.String())
+		}
+		tx.Insert(e)
+	} else {
+		tx.Append(e)
+	}
+
+	err = save(tx)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, exported(e), nil
+}
+
+// lastEvent is the last event on the track.
+func (s *Server) lastEvent(r *http.Request) (int, any, error) {
+	log, _, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	last := log.Last(track(r))
+	if last == nil {
+		return 0, nil, fail(http.StatusNotFound, "no events found")
+	}
+	return http.StatusOK, exported(last), nil
+}
+
+// editLast changes the last event on the track, like 'time', 'code', and 'desc' do. Only what is in the body changes.
+func (s *Server) editLast(r *http.Request) (int, any, error) {
+	body, err := decode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body.Track != nil {
+		return 0, nil, fail(http.StatusBadRequest, "the track can't be changed, pick the event's track with ?track=")
+	}
+
+	log, save, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	tx := timelog.Begin(log)
+	last := tx.Log().Last(track(r))
+	if last == nil {
+		return 0, nil, fail(http.StatusNotFound, "no events found")
+	}
+
+	err = tx.Edit(slices.Index(tx.Log(), last), func(e *timelog.Event) {
+		if body.At != nil {
+			e.At = *body.At
+		}
+		if body.Code != nil {
+			e.Code = *body.Code
+		}
+		if body.Desc != nil {
+			e.Desc = *body.Desc
+		}
+		e.Meta = withMeta(e.Meta, body.Meta)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = save(tx)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, exported(last), nil
+}
+
+// withMeta sets the values in changes on meta, removing the keys with empty values. It returns nil rather than an
+// empty map, so an event without metadata stays that way.
+func withMeta(meta, changes map[string]string) map[string]string {
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	for k, v := range changes {
+		if v == "" {
+			delete(meta, k)
+			continue
+		}
+		meta[k] = v
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// runReport is the same report 'report --format json' makes, archives included. ?from= is needed, and ?to= is
+// optional (see parseServeTime). ?code= picks codes like the report arguments do (it can be given more than once, or be
+// a comma separated list), and ?round= is like --round. Everything else comes from the config.
+func (s *Server) runReport(r *http.Request) (int, any, error) {
+	q := r.URL.Query()
+	begin, err := parseServeTime(q.Get("from"))
+	if err != nil {
+		return 0, nil, err
+	}
+	if begin == nil {
+		return 0, nil, fail(http.StatusBadRequest, "a report needs a from time")
+	}
+	end, err := parseServeTime(q.Get("to"))
+	if err != nil {
+		return 0, nil, err
+	}
+	codes := []string{}
+	for _, c := range q["code"] {
+		for _, code := range strings.Split(c, ",") {
+			if code = strings.TrimPrefix(strings.TrimSpace(code), ":"); code != "" {
+				codes = append(codes, code)
+			}
+		}
+	}
+	var rounding time.Duration
+	if q.Has("round") {
+		rounding, err = time.ParseDuration(q.Get("round"))
+		if err != nil || rounding <= 0 {
+			return 0, nil, fail(http.StatusBadRequest, "round needs a positive duration, such as 15m or 6m")
+		}
+	}
+	var capAt *time.Time
+	if s.CapNow {
+		now := Clock.Now()
+		capAt = &now
+	}
+
+	log, _, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := report.Build(report.Options{
+		Log:      WithArchives(log, s.Config["archives"]),
+		Info:     s.Info,
+		Exchange: s.Exchange,
+
+		Begin: begin,
+		End:   end,
+		CapAt: capAt,
+		Codes: codes,
+		Where: s.Filters.Where,
+
+		Rounding: rounding,
+		Overlap:  s.Filters.Overlap,
+
+		OverlapPolicy: OverlapPolicy,
+		Calendar:      Calendar,
+		FoldCodeCase:  FoldCodeCase,
+
+		Transforms: s.Filters.Transforms,
+
+		Rest:     s.Rest,
+		Schedule: s.Schedule,
+		Overtime: s.Overtime,
+
+		DistanceUnit: s.Config["distanceunit"],
+
+		AttachDir: AttachDir(s.Config),
+	})
+	if errors.Is(err, report.ErrNoPeriods) {
+		return 0, nil, fail(http.StatusNotFound, "%v", err)
+	}
+	var overlaps timelog.ErrOverlaps
+	if errors.As(err, &overlaps) {
+		return 0, nil, fail(http.StatusConflict, "events on the same track at the same time, fix them or set the overlappolicy config: %v", overlaps[0])
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, data, nil
+}
+
+// parseServeTime reads a time from a query parameter: RFC 3339, a date (2006-01-02, the start of that day, see
+// daystart), or the timelog's own format. Empty is nil. The natural language the CLI takes is left to the CLI, so a
+// script always gets the time it meant.
+func parseServeTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.In(time.Local)
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		t = Calendar.StartOfDay(t.Year(), t.Month(), t.Day())
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation(timelog.TimeFormat, value, time.Local); err == nil {
+		return &t, nil
+	}
+	return nil, fail(http.StatusBadRequest, "%q isn't a time, expected RFC 3339 (2006-01-02T15:04:05Z07:00) or a date (2006-01-02)", value)
+}
+
+// IsLoopback is true for an address that only this machine can reach.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ServeToken returns the token requests to 'serve' need: the servetoken if it is set, otherwise the one in the
+// servetokenfile. The first time there isn't one, a random token is made up and saved there, readable only by you, for
+// scripts to read it from.
+func ServeToken(config map[string]string) (token string, path string, err error) {
+	if config["servetoken"] != "" {
+		return config["servetoken"], "", nil
+	}
+	path = config["servetokenfile"]
+	if path == "" {
+		return "", "", errors.New("set the servetoken or servetokenfile config, requests need a token")
+	}
+	raw, err := os.ReadFile(path)
+	if token = strings.TrimSpace(string(raw)); err == nil && token != "" {
+		return token, path, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", path, err
+	}
+
+	buf := make([]byte, 24)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return "", path, err
+	}
+	token = hex.EncodeToString(buf)
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return "", path, err
+	}
+	return token, path, os.WriteFile(path, []byte(token+"\n"), 0600)
+}
+
+// ServeCommand handles 'serve', which runs the REST API (see Server) until it is stopped. It listens on the serveaddr,
+// or --addr, which is only this machine by default. Every request needs the token from ServeToken, wherever it
+// listens, since a web page open in a browser can reach this machine too.
+func ServeCommand(args []string, srv *Server) {
+	args, addrflag := TakeFlagValue(args, "--addr")
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "'serve' doesn't take %q.\n", strings.Join(args, " "))
+		os.Exit(2)
+	}
+	addr, code := srv.Config["serveaddr"], 6
+	if addrflag != "" {
+		addr, code = addrflag, 2
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid address to serve on %q, expected a host and port like 127.0.0.1:8737.\n", addr)
+		os.Exit(code)
+	}
+	token, tokenfile, err := ServeToken(srv.Config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading or saving the token for serve:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(6)
+	}
+	srv.Token, srv.Addr = token, addr
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Serving %s on http://%s\n", srv.Config["logfile"], listener.Addr())
+	if tokenfile != "" {
+		fmt.Fprintf(os.Stderr, "Requests need the token in %s, as 'Authorization: Bearer <token>'.\n", tokenfile)
+	} else {
+		fmt.Fprintln(os.Stderr, "Requests need the servetoken, as 'Authorization: Bearer <token>'.")
+	}
+
+	server := &http.Server{Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	err = server.Serve(listener)
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
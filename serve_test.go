@@ -0,0 +1,248 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+const testToken = "secret"
+
+// testServer is a Server on an empty timelog in a temporary directory, serving on the default address.
+func testServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	config := map[string]string{
+		"logfile":  filepath.Join(dir, "sctime.log"),
+		"lockfile": filepath.Join(dir, "locks.log"),
+		"ordering": "warn",
+	}
+	return &Server{
+		Config:  config,
+		Filters: &ReportFilters{},
+		Store:   &timelog.FileStore{Path: config["logfile"]},
+		Token:   testToken,
+		Addr:    "127.0.0.1:8737",
+	}
+}
+
+// request makes a request to the server's handler the way curl would, with the token and a JSON body if there is one.
+func request(s *Server, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "http://127.0.0.1:8737"+target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+testToken)
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	return w
+}
+
+func TestServeSecurity(t *testing.T) {
+	tests := []struct {
+		name   string
+		addr   string // Where the server is listening, 127.0.0.1:8737 if empty.
+		host   string
+		token  string
+		kind   string
+		method string
+		want   int
+	}{
+		{"allowed", "", "127.0.0.1:8737", testToken, "application/json", "POST", http.StatusCreated},
+		{"localhost", "", "localhost:8737", testToken, "application/json", "POST", http.StatusCreated},
+		{"localhost without a port", "", "LOCALHOST", testToken, "application/json", "POST", http.StatusCreated},
+		{"IPv6 loopback", "", "[::1]:8737", testToken, "application/json", "POST", http.StatusCreated},
+		{"charset", "", "127.0.0.1:8737", testToken, "application/json; charset=utf-8", "POST", http.StatusCreated},
+
+		{"rebound domain", "", "attacker.example:8737", testToken, "application/json", "POST", http.StatusMisdirectedRequest},
+		{"rebound domain GET", "", "attacker.example", testToken, "", "GET", http.StatusMisdirectedRequest},
+		{"LAN address, not listening on it", "", "192.168.1.10:8737", testToken, "", "GET", http.StatusMisdirectedRequest},
+		{"bind address", "192.168.1.10:8737", "192.168.1.10:8737", testToken, "", "GET", http.StatusOK},
+		{"bind name", "timeclock.lan:8737", "TimeClock.lan:8737", testToken, "", "GET", http.StatusOK},
+		{"every address", "0.0.0.0:8737", "192.168.1.10:8737", testToken, "", "GET", http.StatusOK},
+		{"every address, by name", "0.0.0.0:8737", "attacker.example:8737", testToken, "", "GET", http.StatusMisdirectedRequest},
+		{"every IPv6 address", "[::]:8737", "[fd00::1]:8737", testToken, "", "GET", http.StatusOK},
+
+		{"no token", "", "127.0.0.1:8737", "", "", "GET", http.StatusUnauthorized},
+		{"wrong token", "", "127.0.0.1:8737", "guess", "", "GET", http.StatusUnauthorized},
+
+		{"form post", "", "127.0.0.1:8737", testToken, "application/x-www-form-urlencoded", "POST", http.StatusUnsupportedMediaType},
+		{"text post", "", "127.0.0.1:8737", testToken, "text/plain", "POST", http.StatusUnsupportedMediaType},
+		{"no content type", "", "127.0.0.1:8737", testToken, "", "POST", http.StatusUnsupportedMediaType},
+		{"text patch", "", "127.0.0.1:8737", testToken, "text/plain", "PATCH", http.StatusUnsupportedMediaType},
+		{"wrong method", "", "127.0.0.1:8737", testToken, "", "DELETE", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := testServer(t)
+			if test.addr != "" {
+				s.Addr = test.addr
+			}
+			target := "/events"
+			if test.method == "PATCH" {
+				target = "/events/last"
+			}
+			r := httptest.NewRequest(test.method, target, strings.NewReader(`{"at": "2026-10-12T09:00:00Z", "code": "Acme"}`))
+			r.Host = test.host
+			if test.token != "" {
+				r.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			if test.kind != "" {
+				r.Header.Set("Content-Type", test.kind)
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, r)
+			if w.Code != test.want {
+				t.Errorf("got %d, want %d: %s", w.Code, test.want, w.Body)
+			}
+			if w.Code != http.StatusCreated {
+				if _, err := os.Stat(s.Config["logfile"]); err == nil {
+					t.Errorf("a refused request wrote the timelog")
+				}
+			}
+		})
+	}
+
+	// Without a token nothing is allowed, rather than everything.
+	s := testServer(t)
+	s.Token = ""
+	r := httptest.NewRequest("GET", "/events", nil)
+	r.Host = "127.0.0.1:8737"
+	r.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("a server without a token answered %d", w.Code)
+	}
+}
+
+func TestServeEvents(t *testing.T) {
+	s := testServer(t)
+	day := time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)
+	at := func(hour int) string {
+		return `"` + day.Add(time.Duration(hour)*time.Hour).Format(time.RFC3339) + `"`
+	}
+
+	steps := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{"GET", "/events/last", "", http.StatusNotFound},
+		{"POST", "/events", `{"at": ` + at(9) + `, "code": "Acme:Dev", "desc": "fixing bug", "meta": {"ticket": "42"}}`, http.StatusCreated},
+		{"POST", "/events", `{"at": ` + at(12) + `}`, http.StatusCreated},
+		{"POST", "/events", `{"at": ` + at(10) + `, "code": "Ops"}`, http.StatusConflict},
+		{"POST", "/events?backdate=true", `{"at": ` + at(10) + `, "code": "Ops"}`, http.StatusCreated},
+		{"POST", "/events", `{"at": ` + at(13) + `, "cod": "typo"}`, http.StatusBadRequest},
+		{"PATCH", "/events/last", `{"code": "Acme:Meetings", "desc": "standup"}`, http.StatusOK},
+		{"PATCH", "/events/last", `{"track": "other"}`, http.StatusBadRequest},
+		{"GET", "/report", "", http.StatusBadRequest},
+		{"GET", "/report?from=2026-10-12&to=2026-10-13", "", http.StatusOK},
+		{"GET", "/report?from=soon", "", http.StatusBadRequest},
+	}
+	for _, step := range steps {
+		w := request(s, step.method, step.target, step.body)
+		if w.Code != step.want {
+			t.Fatalf("%s %s %s: got %d, want %d: %s", step.method, step.target, step.body, w.Code, step.want, w.Body)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s answered with a Content-Type of %q", step.method, step.target, ct)
+		}
+	}
+
+	w := request(s, "GET", "/events", "")
+	events := []*ExportEvent{}
+	err := json.NewDecoder(w.Body).Decode(&events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, e := range events {
+		got = append(got, e.At.In(time.Local).Format("15:04")+" "+e.Code+" "+e.Desc)
+	}
+	want := []string{"09:00 Acme:Dev fixing bug", "10:00 Ops ", "12:00 Acme:Meetings standup"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("events are %q, want %q", got, want)
+	}
+	if events[0].Meta["ticket"] != "42" {
+		t.Errorf("metadata wasn't kept: %v", events[0].Meta)
+	}
+
+	// What was served is what is in the file, for the CLI to read.
+	content, err := os.ReadFile(s.Config["logfile"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := timelog.ParseTimeLogString(string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 3 || log[2].Code != "Acme:Meetings" {
+		t.Errorf("the timelog has:\n%s", content)
+	}
+
+	w = request(s, "GET", "/events?limit=1&from=2026-10-12", "")
+	events = []*ExportEvent{}
+	_ = json.NewDecoder(w.Body).Decode(&events)
+	if len(events) != 1 || events[0].Code != "Acme:Meetings" {
+		t.Errorf("?limit=1 gave %d event(s)", len(events))
+	}
+}
+
+func TestServeToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "serve-token")
+	config := map[string]string{"servetokenfile": path}
+
+	token, from, err := ServeToken(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) < 32 || from != path {
+		t.Fatalf("got token %q from %q", token, from)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("the token file can be read by others, its mode is %v", info.Mode())
+	}
+
+	again, _, err := ServeToken(config)
+	if err != nil || again != token {
+		t.Errorf("the second run got token %q (%v), want the saved %q", again, err, token)
+	}
+
+	config["servetoken"] = "configured"
+	if set, from, _ := ServeToken(config); set != "configured" || from != "" {
+		t.Errorf("the servetoken config wasn't used, got %q from %q", set, from)
+	}
+}
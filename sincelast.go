@@ -0,0 +1,150 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/locale"
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// SinceLastFirst is how many days back 'since-last' goes the first time, when there is no last time yet.
+const SinceLastFirst = 7
+
+// SinceLastState is the sincelastfile, when each digest was last asked for. The digest without a name is "".
+type SinceLastState struct {
+	Runs map[string]time.Time `json:"runs"`
+}
+
+// LoadSinceLast reads the state file. A missing file is the same as an empty one, and like the totals cache, a broken
+// one isn't worth stopping for, it just means starting over.
+func LoadSinceLast(path string) *SinceLastState {
+	state := &SinceLastState{}
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(raw, state)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "Ignoring unreadable since-last state:", err)
+		state = &SinceLastState{}
+	}
+	if state.Runs == nil {
+		state.Runs = map[string]time.Time{}
+	}
+	return state
+}
+
+// Save writes the state file, creating the directory it goes in if needed.
+func (state *SinceLastState) Save(path string) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, raw, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// SinceLastCommand handles 'since-last', which sums up everything logged since it was last run: the time on each code,
+// with what was done on it like 'standup', and the total. Giving a name keeps a separate digest, eg one for each
+// person you have a 1:1 with. --peek leaves the last run where it was.
+func SinceLastCommand(args []string, log timelog.TimeLog, config map[string]string, capNow bool) {
+	args, peek := TakeFlag(args, "--peek")
+	args, filters := TakeReportFilters(args, config)
+	args, codes := TakeCodePatterns(args, append(log.Codes(), "empty", "all"))
+	found, _ := FindAllTimecodes(args, append(log.Codes(), "empty", "all"))
+	for _, f := range found {
+		codes = append(codes, f[0].Code)
+	}
+	name := ""
+	for _, arg := range args {
+		if strings.HasPrefix(arg, ":") {
+			continue
+		}
+		if name != "" {
+			fmt.Fprintln(os.Stderr, "'since-last' takes one name for the digest, and codes.")
+			os.Exit(2)
+		}
+		name = arg
+	}
+
+	path := config["sincelastfile"]
+	state := LoadSinceLast(path)
+	now := Clock.Now()
+	last, ok := state.Runs[name]
+	if !ok {
+		last = now.AddDate(0, 0, -SinceLastFirst)
+		fmt.Fprintln(os.Stderr, locale.T("First run, so this covers the last %d days.", SinceLastFirst))
+	}
+
+	periods := log.After(last).PeriodsWith(OverlapPolicy)
+	if capNow {
+		periods, _ = timelog.CapPeriods(periods, now)
+	}
+	periods = filters.Apply(periods, report.CodeFilter(codes, FoldCodeCase))
+	timelog.CanonicalCodes(periods, FoldCodeCase)
+
+	when := locale.Date(last, "Mon 2006/01/02 03:04PM")
+	if len(periods) == 0 {
+		fmt.Println(locale.T("Nothing logged since %s.", when))
+	} else {
+		fmt.Println(locale.T("Since %s, %s ago:", when, timelog.FormatDuration(now.Sub(last), Durations)))
+		totals := timelog.Totals(periods, FoldCodeCase)
+		var total time.Duration
+		for _, line := range Standup(periods) {
+			total += totals[line.Code]
+			out := fmt.Sprintf("- %s (%s)", line.Code, timelog.FormatDuration(totals[line.Code], Durations))
+			if len(line.Notes) > 0 {
+				out += " — " + strings.Join(line.Notes, "; ")
+			}
+			fmt.Println(out)
+		}
+		fmt.Println(locale.T("Total: %s", timelog.FormatDuration(total, Durations)))
+	}
+
+	if peek {
+		return
+	}
+	state.Runs[name] = now
+	err := state.Save(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error saving since-last state:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
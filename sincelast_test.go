@@ -0,0 +1,125 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestSinceLastState(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state", "since-last.json")
+
+	state := LoadSinceLast(path)
+	if state.Runs == nil || len(state.Runs) != 0 {
+		t.Fatalf("missing file loaded as %v", state.Runs)
+	}
+
+	at := time.Date(2026, 10, 12, 17, 30, 0, 0, time.UTC)
+	state.Runs[""] = at
+	state.Runs["alex"] = at.AddDate(0, 0, -3)
+	err := state.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	read := LoadSinceLast(path)
+	if len(read.Runs) != 2 || !read.Runs[""].Equal(at) || !read.Runs["alex"].Equal(at.AddDate(0, 0, -3)) {
+		t.Errorf("state read back as %v", read.Runs)
+	}
+
+	for _, broken := range []string{"", "{", `{"runs": 7}`, "null"} {
+		err := os.WriteFile(path, []byte(broken), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state := LoadSinceLast(path)
+		if state.Runs == nil || len(state.Runs) != 0 {
+			t.Errorf("broken file %q loaded as %v", broken, state.Runs)
+		}
+	}
+}
+
+// sinceLast runs the command at the given time and returns what it printed.
+func sinceLast(t *testing.T, log timelog.TimeLog, config map[string]string, now time.Time, args ...string) string {
+	t.Helper()
+	return runAt(t, now, func() {
+		SinceLastCommand(args, log, config, true)
+	})
+}
+
+func TestSinceLastCommand(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+	}
+	log := timelog.TimeLog{
+		{At: at(1, 9), Code: "Old", Desc: "before the first window"},
+		{At: at(1, 10), Code: ""},
+		{At: at(10, 9), Code: "Acme", Desc: "review"},
+		{At: at(10, 11), Code: ""},
+		{At: at(12, 9), Code: "Beta", Desc: "release"},
+		{At: at(12, 10), Code: ""},
+	}
+	config := map[string]string{"sincelastfile": filepath.Join(t.TempDir(), "since-last.json")}
+
+	steps := []struct {
+		name string
+		now  time.Time
+		args []string
+		has  []string
+		not  []string
+	}{
+		{"first run covers a week", at(11, 12), []string{"--peek"}, []string{"Acme", "review", "Total: 2.0h"}, []string{"Old", "Beta"}},
+		{"peek didn't move the last run", at(11, 12), nil, []string{"Acme", "Total: 2.0h"}, []string{"Old", "Beta"}},
+		{"second run starts at the first", at(12, 12), nil, []string{"Beta", "release", "Total: 1.0h"}, []string{"Acme"}},
+		{"nothing new", at(12, 13), nil, []string{"Nothing logged since"}, []string{"Beta"}},
+		{"names are kept apart", at(12, 13), []string{"alex"}, []string{"Acme", "Beta", "Total: 3.0h"}, []string{"Old"}},
+	}
+	for _, step := range steps {
+		out := sinceLast(t, log, config, step.now, step.args...)
+		for _, s := range step.has {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s: %q missing from:\n%s", step.name, s, out)
+			}
+		}
+		for _, s := range step.not {
+			if strings.Contains(out, s) {
+				t.Errorf("%s: %q in:\n%s", step.name, s, out)
+			}
+		}
+	}
+
+	state := LoadSinceLast(config["sincelastfile"])
+	if !state.Runs[""].Equal(at(12, 13)) || !state.Runs["alex"].Equal(at(12, 13)) {
+		t.Errorf("last runs saved as %v", state.Runs)
+	}
+}
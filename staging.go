@@ -0,0 +1,120 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// LoadStaging reads the staged events waiting for review.
+func LoadStaging(path string) (timelog.TimeLog, error) {
+	return ReadTimelogFile(path)
+}
+
+// ReadTimelogFile reads and sorts a timelog file other than the main one, such as the staging area or the plan. A
+// missing file has nothing in it.
+func ReadTimelogFile(path string) (timelog.TimeLog, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return timelog.TimeLog{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	log, err := timelog.ParseTimeLogString(string(content))
+	if err != nil {
+		return nil, err
+	}
+	log.Sort()
+	return log, nil
+}
+
+// SaveStaging writes the staged events back, removing the file once there are none left.
+func SaveStaging(path string, staged timelog.TimeLog) error {
+	if len(staged) == 0 {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	b := &strings.Builder{}
+	err := staged.FormatFile(b)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(b.String()), 0666)
+}
+
+// FormatByDay formats events as timelog text, with a comment before each day's events naming the day. The columns line
+// up across the days. Like [timelog.TimeLog.FormatFile], it starts with the format version.
+func FormatByDay(events timelog.TimeLog) (string, error) {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s%d\n", timelog.HeaderPrefix, timelog.FormatVersion)
+	for begin := 0; begin < len(events); {
+		day := Calendar.Day(events[begin].At)
+		end := begin
+		for end < len(events) && Calendar.Day(events[end].At).Equal(day) {
+			end++
+		}
+
+		if begin > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("# " + day.Format("Monday 2006/01/02") + "\n")
+		err := events.FormatRange(b, begin, end)
+		if err != nil {
+			return "", err
+		}
+		begin = end
+	}
+	return b.String(), nil
+}
+
+// WithoutDuplicates returns the events that aren't already in log, with the same time, track, code, and description.
+func WithoutDuplicates(events, log timelog.TimeLog) timelog.TimeLog {
+	type key struct {
+		at                int64
+		track, code, desc string
+	}
+	have := map[key]bool{}
+	for _, e := range log {
+		have[key{e.At.Unix(), e.Track, e.Code, e.Desc}] = true
+	}
+
+	out := timelog.TimeLog{}
+	for _, e := range events {
+		k := key{e.At.Unix(), e.Track, e.Code, e.Desc}
+		if !have[k] {
+			have[k] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// A description line that looks like an event is only that in a log without a format header, so staged events have to
+// be written with one.
+func TestStagingKeepsDescriptions(t *testing.T) {
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	staged := timelog.TimeLog{{At: at, Code: "Proj", Desc: "notes\n2026/10/12 10:00AM [Ops] from the call"}}
+
+	path := filepath.Join(t.TempDir(), "staging.log")
+	err := SaveStaging(path, staged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadTimelogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 1 || read[0].Desc != staged[0].Desc {
+		t.Fatalf("staged event read back as:\n%s", read.String())
+	}
+
+	text, err := FormatByDay(staged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byday, err := timelog.ParseTimeLogString(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byday) != 1 || byday[0].Desc != staged[0].Desc {
+		t.Fatalf("review text read back as:\n%s", byday.String())
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/locale"
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// StandupLine is what was done on one code, for 'standup'.
+type StandupLine struct {
+	Code  string
+	Notes []string // The distinct descriptions, first lines only, in the order they were first logged.
+}
+
+func (l *StandupLine) String() string {
+	if len(l.Notes) == 0 {
+		return "- " + l.Code
+	}
+	return "- " + l.Code + " — " + strings.Join(l.Notes, "; ")
+}
+
+// Standup collects the descriptions of the periods for each code, sorted by code. Descriptions that are the same task
+// (see timelog.NormalizeDesc) are only listed once, and empty ones not at all.
+func Standup(periods []*timelog.Period) []*StandupLine {
+	lines := []*StandupLine{}
+	found := map[string]*StandupLine{}
+	seen := map[[2]string]bool{}
+	for _, p := range periods {
+		line, ok := found[p.Code]
+		if !ok {
+			line = &StandupLine{Code: p.Code}
+			found[p.Code] = line
+			lines = append(lines, line)
+		}
+
+		key := [2]string{p.Code, timelog.NormalizeDesc(p.Desc)}
+		if key[1] == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		first, _, _ := strings.Cut(p.Desc, "\n")
+		line.Notes = append(line.Notes, strings.TrimSpace(first))
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Code < lines[j].Code
+	})
+	return lines
+}
+
+// periodsBeginning returns the periods that begin from begin up to end. Unlike the periods of TimeLog.Between, this
+// keeps the last one even when it runs past end, so a task that went on past midnight is still listed for its day.
+func periodsBeginning(log timelog.TimeLog, begin, end time.Time) []*timelog.Period {
+	periods := log.After(begin.Add(-time.Nanosecond)).PeriodsWith(OverlapPolicy)
+	return timelog.FilterPeriods(periods, func(p *timelog.Period) bool {
+		return !p.Begin.Before(begin) && p.Begin.Before(end)
+	})
+}
+
+// StandupCommand handles 'standup', which lists what was done on each code on a day, or in a range. Without one it is
+// the last day before today with anything logged, so on a Monday it is Friday.
+func StandupCommand(args []string, log timelog.TimeLog, config map[string]string, capNow bool) {
+	args, filters := TakeReportFilters(args, config)
+	args, codes := TakeCodePatterns(args, append(log.Codes(), "empty", "all"))
+	found, _ := FindAllTimecodes(args, append(log.Codes(), "empty", "all"))
+	for _, f := range found {
+		codes = append(codes, f[0].Code)
+	}
+	filter := report.CodeFilter(codes, FoldCodeCase)
+
+	now := Clock.Now()
+	today := Calendar.Day(now)
+
+	// Time codes were picked out above, what's left is the range.
+	rangeArgs := []string{}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, ":") {
+			rangeArgs = append(rangeArgs, arg)
+		}
+	}
+
+	var begin, end time.Time
+	if len(rangeArgs) == 0 {
+		begin = today.AddDate(0, 0, -1)
+		recent := timelog.FilterPeriods(periodsBeginning(log, today.AddDate(0, 0, -30), today), filter)
+		for i := len(recent) - 1; i >= 0; i-- {
+			if recent[i].Length() > 0 {
+				begin = recent[i].Day(Calendar)
+				break
+			}
+		}
+		end = begin.AddDate(0, 0, 1)
+	} else if b, e := ParseTimeRange(rangeArgs); e == nil {
+		begin = Calendar.Day(*b)
+		end = begin.AddDate(0, 0, 1)
+	} else {
+		begin, end = *b, *e
+	}
+
+	periods := periodsBeginning(log, begin, end)
+	if capNow {
+		periods, _ = timelog.CapPeriods(periods, now)
+	}
+	periods = filters.Apply(periods, filter)
+	timelog.CanonicalCodes(periods, FoldCodeCase)
+
+	when := locale.Date(begin, "Mon 2006/01/02")
+	if !end.Equal(begin.AddDate(0, 0, 1)) {
+		when += " - " + locale.Date(end, "Mon 2006/01/02")
+	}
+	lines := Standup(periods)
+	if len(lines) == 0 {
+		fmt.Fprintln(os.Stderr, locale.T("Nothing logged on %s.", when))
+		return
+	}
+	fmt.Println(when + ":")
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
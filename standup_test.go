@@ -0,0 +1,137 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// runAt runs a command with the clock stopped at now, and returns what it printed to stdout. Stderr is thrown away.
+func runAt(t *testing.T, now time.Time, run func()) string {
+	t.Helper()
+	clock, stdout, stderr := Clock, os.Stdout, os.Stderr
+	defer func() {
+		Clock, os.Stdout, os.Stderr = clock, stdout, stderr
+	}()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	Clock, os.Stdout = timelog.FixedClock(now), w
+	os.Stderr, err = os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	run()
+	w.Close()
+	os.Stderr.Close()
+	return <-done
+}
+
+func TestStandup(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 12, hour, minute, 0, 0, time.Local)
+	}
+	periods := []*timelog.Period{
+		{Begin: at(9, 0), End: at(10, 0), Code: "Beta", Desc: "Fix the build\nIt was the cache."},
+		{Begin: at(10, 0), End: at(11, 0), Code: "Acme", Desc: "review"},
+		{Begin: at(11, 0), End: at(12, 0), Code: "Beta", Desc: "  fix the   build. "},
+		{Begin: at(12, 0), End: at(13, 0), Code: "Acme", Desc: ""},
+		{Begin: at(13, 0), End: at(14, 0), Code: "Acme", Desc: "Deploy"},
+		{Begin: at(14, 0), End: at(15, 0), Code: "Acme", Desc: "Review"},
+		{Begin: at(15, 0), End: at(16, 0), Code: "Gamma"},
+	}
+	lines := Standup(periods)
+
+	want := []string{
+		"- Acme — review; Deploy",
+		"- Beta — Fix the build",
+		"- Gamma",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %v", len(lines), len(want), lines)
+	}
+	for i, line := range lines {
+		if line.String() != want[i] {
+			t.Errorf("line %d is %q, want %q", i, line.String(), want[i])
+		}
+	}
+}
+
+func TestStandupCommand(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+	}
+	// 2026/10/09 is a Friday, and 2026/10/12 the Monday after. The late Friday period runs past midnight, and the
+	// Thursday one begins right at the start of the day.
+	log := timelog.TimeLog{
+		{At: at(8, 0), Code: "Acme", Desc: "thursday"},
+		{At: at(8, 10), Code: ""},
+		{At: at(9, 9), Code: "Acme", Desc: "friday"},
+		{At: at(9, 23), Code: "Beta", Desc: "late friday"},
+		{At: at(10, 1), Code: ""},
+		{At: at(12, 9), Code: "Acme", Desc: "monday"},
+		{At: at(12, 10), Code: ""},
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		args []string
+		has  []string
+		not  []string
+	}{
+		{"monday is friday", at(12, 12), nil, []string{"Fri 2026/10/09:", "friday", "late friday"}, []string{"thursday", "monday"}},
+		{"the day before", at(9, 12), nil, []string{"Thu 2026/10/08:", "thursday"}, []string{"friday"}},
+		{"a day", at(14, 12), []string{"2026/10/12"}, []string{"Mon 2026/10/12:", "monday"}, []string{"friday"}},
+		{"codes", at(12, 12), []string{":Beta"}, []string{"late friday"}, []string{"- Acme"}},
+		{"nothing", at(1, 12), nil, nil, []string{"Acme", "Beta"}},
+	}
+	for _, test := range tests {
+		out := runAt(t, test.now, func() {
+			StandupCommand(test.args, log, map[string]string{}, true)
+		})
+		for _, s := range test.has {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s: %q missing from:\n%s", test.name, s, out)
+			}
+		}
+		for _, s := range test.not {
+			if strings.Contains(out, s) {
+				t.Errorf("%s: %q in:\n%s", test.name, s, out)
+			}
+		}
+	}
+}
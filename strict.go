@@ -0,0 +1,139 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Strict turns off natural language parsing for new events, see ParseStrict. Set from the strict config key and the
+// --strict flag.
+var Strict = false
+
+// strictFormats are the time formats accepted by ParseStrict, with and without a date.
+var strictFormats = []struct {
+	layout string
+	dated  bool
+}{
+	{timelog.TimeFormat, true},
+	{"2006/01/02 15:04", true},
+	{"2006-01-02 15:04", true},
+	{"2006/01/02T15:04", true},
+	{"2006-01-02T15:04", true},
+	{"3:04PM", false},
+	{"15:04", false},
+}
+
+// ParseStrict is the alternative to ParseLine for --strict. The input is positional: an optional "@time", then an
+// optional ":code", then the description, which is used exactly as given. Without a time the event is for now, and the
+// code is taken as is, it doesn't have to exist already.
+//
+// The time may be "now", a duration relative to now ("-15m", "+1h"), a time of day ("9:15", "9:15AM", which is today
+// unless AnchorAt says otherwise), or a date and time ("2026/10/14 09:15", "2026-10-14T09:15"). A date and time with a
+// space may be one argument or two, so it works without quotes. "now" and relative times are rounded like any other
+// time, everything else is used exactly.
+func ParseStrict(l []string) (time.Time, string, string) {
+	input := strings.Join(l, " ")
+	now := Clock.Now().Local()
+	at := now.Round(6 * time.Minute)
+	timetext, codetext, anchored := "now", "", ""
+	if len(l) > 0 && strings.HasPrefix(l[0], "@") {
+		text, n := strings.TrimPrefix(l[0], "@"), 1
+		var ok bool
+		at, ok = parseStrictTime(text, now)
+		if !ok && len(l) > 1 {
+			// Unquoted, "@2026/10/14 09:15" reaches us as two arguments.
+			if t, dated := parseStrictTime(text+" "+l[1], now); dated {
+				at, ok, text, n = t, true, text+" "+l[1], 2
+			}
+		}
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Can't understand the time %q. Use now, a duration like -15m, a time like 9:15 or 9:15AM,\n", l[0])
+			fmt.Fprintln(os.Stderr, "or a date and time like 2026/10/14 09:15 or 2026-10-14T09:15.")
+			os.Exit(2)
+		}
+		at, anchored = Anchor(text, at)
+		timetext = "@" + text
+		l = l[n:]
+	}
+
+	code := ""
+	if len(l) > 0 && strings.HasPrefix(l[0], ":") {
+		code = strings.TrimPrefix(l[0], ":")
+		codetext = l[0]
+		l = l[1:]
+	}
+
+	desc := strings.Join(l, " ")
+	Debug.Debug("strict entry", "time", at.Format(timelog.TimeFormat), "code", code, "desc", desc)
+	if Explain != nil {
+		Explain.Input = input
+		Explain.Times = []ExplainedTime{{Text: timetext, Time: at}}
+		Explain.Rounded = at
+		Explain.Anchored = anchored
+		Explain.Desc = desc
+		if timetext != "now" {
+			Explain.Edits = append(Explain.Edits, ExplainedEdit{Text: timetext, Why: "was removed from the start, it is the time"})
+		}
+		if codetext != "" {
+			Explain.Edits = append(Explain.Edits, ExplainedEdit{Text: codetext, Why: "was removed from the start, it is the time code"})
+		}
+		if code != "" {
+			Explain.Code = &FoundCode{Code: code, Found: code}
+			Explain.Candidates = []FoundCode{*Explain.Code}
+			Explain.CodeWhy = "given with --strict"
+		}
+	}
+	return at, code, desc
+}
+
+// parseStrictTime parses the time part of a strict entry, see ParseStrict.
+func parseStrictTime(s string, now time.Time) (time.Time, bool) {
+	if s == "now" {
+		return now.Round(6 * time.Minute), true
+	}
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return now.Add(d).Round(6 * time.Minute), true
+	}
+
+	for _, f := range strictFormats {
+		t, err := time.ParseInLocation(f.layout, strings.ToUpper(s), time.Local)
+		if err != nil {
+			continue
+		}
+		if !f.dated {
+			t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
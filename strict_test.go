@@ -0,0 +1,80 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestParseStrict(t *testing.T) {
+	clock := Clock
+	defer func() { Clock = clock }()
+	now := time.Date(2026, 10, 14, 10, 2, 30, 0, time.Local)
+	Clock = timelog.FixedClock(now)
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name string
+		args []string
+		at   time.Time
+		code string
+		desc string
+	}{
+		{"nothing", nil, at(14, 10, 0), "", ""},
+		{"only a description", []string{"fixed", "the", "2031", "build"}, at(14, 10, 0), "", "fixed the 2031 build"},
+		{"now", []string{"@now", "lunch"}, at(14, 10, 0), "", "lunch"},
+		{"minus a duration", []string{"@-15m", ":Acme", "standup"}, at(14, 9, 48), "Acme", "standup"},
+		{"plus a duration", []string{"@+1h30m", ":Acme"}, at(14, 11, 30), "Acme", ""},
+		{"24 hour time", []string{"@9:15", ":Acme:Dev", "review"}, at(14, 9, 15), "Acme:Dev", "review"},
+		{"12 hour time", []string{"@1:05pm", "call"}, at(14, 13, 5), "", "call"},
+		{"date and time, quoted", []string{"@2026/10/12 09:15", ":Acme"}, at(12, 9, 15), "Acme", ""},
+		{"date and time, unquoted", []string{"@2026/10/12", "09:15", ":Acme", "late", "entry"}, at(12, 9, 15), "Acme", "late entry"},
+		{"date and 12 hour time, unquoted", []string{"@2026/10/12", "09:15PM", "evening"}, at(12, 21, 15), "", "evening"},
+		{"dashes and a T", []string{"@2026-10-12T17:45", "wrap", "up"}, at(12, 17, 45), "", "wrap up"},
+		{"a code is used as typed", []string{":new client:thing"}, at(14, 10, 0), "new client:thing", ""},
+		{"a code later is description", []string{"talked", "to", ":Acme"}, at(14, 10, 0), "", "talked to :Acme"},
+		{"a time later is description", []string{":Acme", "@9:15", "meeting"}, at(14, 10, 0), "Acme", "@9:15 meeting"},
+		{"a time of day then a time in the description", []string{"@9:15", "10:00", "sync"}, at(14, 9, 15), "", "10:00 sync"},
+	}
+	for _, test := range tests {
+		gotAt, code, desc := ParseStrict(test.args)
+		if !gotAt.Equal(test.at) || code != test.code || desc != test.desc {
+			t.Errorf("%s: ParseStrict(%q) = %v, %q, %q, want %v, %q, %q", test.name, test.args,
+				gotAt.Format(timelog.TimeFormat), code, desc, test.at.Format(timelog.TimeFormat), test.code, test.desc)
+		}
+	}
+}
+
+func TestParseStrictTime(t *testing.T) {
+	now := time.Date(2026, 10, 14, 10, 2, 30, 0, time.Local)
+	for _, bad := range []string{"", "soon", "-15", "+1x", "25:00", "2026/10/14", "2026/13/14 09:15", "9:15 AM", "yesterday 9:15"} {
+		if got, ok := parseStrictTime(bad, now); ok {
+			t.Errorf("parseStrictTime(%q) = %v, want an error", bad, got)
+		}
+	}
+}
@@ -0,0 +1,483 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// SyncSource is somewhere outside the timelog that events are pulled from and pushed to, one section of the syncfile.
+//
+// The tool itself only knows how to read and write timelog text. A "timelog" source is a timelog file somewhere else,
+// such as a folder shared with another machine. A "command" source runs a program that does the talking, so anything
+// with a script that can turn its entries into timelog text (and back) can be synced: Jira, Toggl, a calendar, etc.
+type SyncSource struct {
+	Name string
+	Kind string // "timelog" or "command"
+
+	Path string // For timelog sources, the file.
+	Pull string // For command sources, the shell command that prints events.
+	Push string // For command sources, the shell command that takes events on stdin.
+
+	Direction string // "both", "pull", or "push"
+}
+
+// ParseSyncSources reads the sources from the syncfile, one section per source.
+func ParseSyncSources(raw string) ([]*SyncSource, error) {
+	sections := map[string]map[string]string{}
+	ParseINISections(raw, sections)
+
+	sources := []*SyncSource{}
+	for name, settings := range sections {
+		if name == "" {
+			if len(settings) > 0 {
+				return nil, errors.New("settings before the first source section")
+			}
+			continue
+		}
+
+		src := &SyncSource{Name: name, Direction: "both"}
+		for k, v := range settings {
+			switch k {
+			case "kind":
+				src.Kind = v
+			case "path":
+				src.Path = os.ExpandEnv(v)
+			case "pull":
+				src.Pull = v
+			case "push":
+				src.Push = v
+			case "direction":
+				src.Direction = v
+			default:
+				return nil, fmt.Errorf("source %q: unknown setting %q", name, k)
+			}
+		}
+
+		switch src.Direction {
+		case "both", "pull", "push":
+		default:
+			return nil, fmt.Errorf("source %q: direction must be 'both', 'pull', or 'push', not %q", name, src.Direction)
+		}
+		switch src.Kind {
+		case "timelog":
+			if src.Path == "" {
+				return nil, fmt.Errorf("source %q: timelog sources need a path", name)
+			}
+		case "command":
+			if src.CanPull() && src.Pull == "" {
+				return nil, fmt.Errorf("source %q: no pull command, set one or make the direction 'push'", name)
+			}
+			if src.CanPush() && src.Push == "" {
+				return nil, fmt.Errorf("source %q: no push command, set one or make the direction 'pull'", name)
+			}
+		default:
+			return nil, fmt.Errorf("source %q: kind must be 'timelog' or 'command', not %q", name, src.Kind)
+		}
+		sources = append(sources, src)
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Name < sources[j].Name
+	})
+	return sources, nil
+}
+
+func (src *SyncSource) CanPull() bool {
+	return src.Direction != "push"
+}
+
+func (src *SyncSource) CanPush() bool {
+	return src.Direction != "pull"
+}
+
+// run runs one of a command source's commands with the shell. The cursor is passed in $SCTIME_CURSOR, so the command
+// can ask for only what changed since.
+func (src *SyncSource) run(command string, cursor time.Time, stdin []byte) ([]byte, error) {
+	Debug.Debug("running sync command", "source", src.Name, "command", command)
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(), "SCTIME_SOURCE="+src.Name, "SCTIME_CURSOR="+cursor.Format(time.RFC3339))
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("source %q: %w", src.Name, err)
+	}
+	return out, nil
+}
+
+// Fetch returns the source's events after the cursor. Command sources are trusted to only print events after the
+// cursor, but anything older they do print is still checked against the mappings, so it does no harm.
+func (src *SyncSource) Fetch(cursor time.Time) (timelog.TimeLog, error) {
+	var raw []byte
+	var err error
+	switch src.Kind {
+	case "timelog":
+		raw, err = os.ReadFile(src.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return timelog.TimeLog{}, nil
+		}
+	case "command":
+		raw, err = src.run(src.Pull, cursor, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := timelog.ParseTimeLogString(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("source %q: %w", src.Name, err)
+	}
+	events.Sort()
+	if src.Kind == "timelog" {
+		first := sort.Search(len(events), func(i int) bool {
+			return events[i].At.After(cursor)
+		})
+		events = events[first:]
+	}
+	return events, nil
+}
+
+// Send pushes events to the source, and returns the external ID for each. Push commands may print one ID per line,
+// in the same order as the events they were given. If they print nothing, the event IDs are used.
+func (src *SyncSource) Send(events timelog.TimeLog, cursor time.Time) ([]string, error) {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = EventID(e)
+	}
+
+	switch src.Kind {
+	case "timelog":
+		existing, err := src.Fetch(time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		tx := timelog.Begin(existing)
+		tx.Append(events...)
+		tx.Sort()
+		return ids, tx.Commit(&timelog.FileStore{Path: src.Path})
+
+	case "command":
+		b := &strings.Builder{}
+		err := events.FormatFile(b)
+		if err != nil {
+			return nil, err
+		}
+		out, err := src.run(src.Push, cursor, []byte(b.String()))
+		if err != nil {
+			return nil, err
+		}
+		lines := strings.Fields(string(out))
+		switch len(lines) {
+		case 0:
+		case len(events):
+			ids = lines
+		default:
+			return nil, fmt.Errorf("source %q: push printed %v ID(s) for %v event(s)", src.Name, len(lines), len(events))
+		}
+	}
+	return ids, nil
+}
+
+// EventID identifies an event, for mapping it to what it is called somewhere else. Events don't have IDs of their own,
+// so this is the time and track, which are unique enough in practice.
+func EventID(e *timelog.Event) string {
+	id := e.At.UTC().Format("20060102T150405Z")
+	if e.Track != "" {
+		id += "@" + e.Track
+	}
+	return id
+}
+
+// ExternalID is what a source calls an event, from its "id" metadata if it has any, or the event ID otherwise.
+func ExternalID(e *timelog.Event) string {
+	if id, ok := e.Meta["id"]; ok && id != "" {
+		return id
+	}
+	return EventID(e)
+}
+
+// SyncCursor is where syncing with a source is up to.
+type SyncCursor struct {
+	Pulled time.Time // The newest event pulled.
+	Pushed time.Time // The newest event pushed.
+
+	LastPull time.Time // When the last pull was.
+	LastPush time.Time // And the last push.
+
+	// External IDs mapped to the events they are in the timelog, both for what was pulled and what was pushed.
+	IDs map[string]string
+}
+
+// Incoming returns the fetched events that haven't been pulled before.
+func (cur *SyncCursor) Incoming(fetched timelog.TimeLog) timelog.TimeLog {
+	out := timelog.TimeLog{}
+	for _, e := range fetched {
+		if _, ok := cur.IDs[ExternalID(e)]; !ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Outgoing returns the events in the log that the source doesn't have yet, the ones without a mapping. Anything pulled
+// from the source is already there, so it isn't sent back. This goes by the mappings rather than the push cursor, so
+// an event backdated to before the last push still goes out.
+func (cur *SyncCursor) Outgoing(log timelog.TimeLog) timelog.TimeLog {
+	mapped := map[string]bool{}
+	for _, id := range cur.IDs {
+		mapped[id] = true
+	}
+
+	out := timelog.TimeLog{}
+	for _, e := range log {
+		if !mapped[EventID(e)] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// SyncState is the cursor for each source, by name.
+type SyncState map[string]*SyncCursor
+
+// LoadSyncState reads the sync state file. A missing file means nothing has been synced yet.
+func LoadSyncState(path string) (SyncState, error) {
+	state := SyncState{}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &state)
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Cursor returns the cursor for a source, starting a new one if the source hasn't been synced before.
+func (state SyncState) Cursor(name string) *SyncCursor {
+	cur, ok := state[name]
+	if !ok || cur == nil {
+		cur = &SyncCursor{}
+		state[name] = cur
+	}
+	if cur.IDs == nil {
+		cur.IDs = map[string]string{}
+	}
+	return cur
+}
+
+// Save writes the sync state file, creating the directory it goes in if needed.
+func (state SyncState) Save(path string) error {
+	raw, err := json.MarshalIndent(state, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, raw, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// SyncCommand handles 'sync status', 'sync pull', and 'sync push', each for every source or only the ones named.
+// Pulled events go to the staging area for 'review', so syncing never writes the timelog itself.
+func SyncCommand(args []string, log timelog.TimeLog, config map[string]string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Expected 'status', 'pull', or 'push'.")
+		os.Exit(2)
+	}
+	action, names := args[0], args[1:]
+	if action != "status" && action != "pull" && action != "push" {
+		fmt.Fprintf(os.Stderr, "Unknown sync action %q, expected 'status', 'pull', or 'push'.\n", action)
+		os.Exit(2)
+	}
+
+	raw, err := os.ReadFile(config["syncfile"])
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "No sync sources, add some to %s.\n", config["syncfile"])
+		os.Exit(6)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading sync sources:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(6)
+	}
+	sources, err := ParseSyncSources(string(raw))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error in sync sources:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(6)
+	}
+	if len(names) > 0 {
+		picked := []*SyncSource{}
+		for _, name := range names {
+			found := false
+			for _, src := range sources {
+				if src.Name == name {
+					picked = append(picked, src)
+					found = true
+				}
+			}
+			if !found {
+				fmt.Fprintf(os.Stderr, "No sync source named %q.\n", name)
+				os.Exit(2)
+			}
+		}
+		sources = picked
+	}
+
+	state, err := LoadSyncState(config["syncstate"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading sync state:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	staged, err := LoadStaging(config["stagingfile"])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading staging file:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(8)
+	}
+
+	when := func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.Local().Format(timelog.TimeFormat)
+	}
+
+	failed := false
+	for _, src := range sources {
+		cur := state.Cursor(src.Name)
+
+		switch action {
+		case "status":
+			fmt.Printf("%s (%s, %s)\n", src.Name, src.Kind, src.Direction)
+			if src.CanPull() {
+				waiting := 0
+				for _, e := range staged {
+					if e.Meta["source"] == src.Name {
+						waiting++
+					}
+				}
+				fmt.Printf("    pull: last %s, %v event(s) staged for review\n", when(cur.LastPull), waiting)
+			}
+			if src.CanPush() {
+				fmt.Printf("    push: last %s, %v event(s) waiting\n", when(cur.LastPush), len(cur.Outgoing(log)))
+			}
+
+		case "pull":
+			if !src.CanPull() {
+				continue
+			}
+			fetched, err := src.Fetch(cur.Pulled)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+				continue
+			}
+			incoming := cur.Incoming(fetched)
+			for _, e := range incoming {
+				cur.IDs[ExternalID(e)] = EventID(e)
+				if e.At.After(cur.Pulled) {
+					cur.Pulled = e.At
+				}
+				if e.Meta == nil {
+					e.Meta = map[string]string{}
+				}
+				e.Meta["source"] = src.Name
+			}
+			fresh := WithoutDuplicates(incoming, append(append(timelog.TimeLog{}, log...), staged...))
+			staged = append(staged, fresh...)
+			cur.LastPull = time.Now()
+			fmt.Printf("%s: staged %v event(s).\n", src.Name, len(fresh))
+
+		case "push":
+			if !src.CanPush() {
+				continue
+			}
+			outgoing := cur.Outgoing(log)
+			if len(outgoing) == 0 {
+				fmt.Printf("%s: nothing to push.\n", src.Name)
+				continue
+			}
+			ids, err := src.Send(outgoing, cur.Pushed)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+				continue
+			}
+			for i, e := range outgoing {
+				cur.IDs[ids[i]] = EventID(e)
+				if e.At.After(cur.Pushed) {
+					cur.Pushed = e.At
+				}
+			}
+			cur.LastPush = time.Now()
+			fmt.Printf("%s: pushed %v event(s).\n", src.Name, len(outgoing))
+		}
+	}
+
+	if action != "status" {
+		// Staging first, so a failure between the two pulls the same events again rather than losing them.
+		staged.Sort()
+		err = SaveStaging(config["stagingfile"], staged)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing staging file:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		err = state.Save(config["syncstate"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing sync state:")
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
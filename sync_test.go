@@ -0,0 +1,87 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func TestSyncPushBackdated(t *testing.T) {
+	day := time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)
+	src := &SyncSource{Name: "other", Kind: "timelog", Path: filepath.Join(t.TempDir(), "other.log"), Direction: "both"}
+	cur := (SyncState{}).Cursor(src.Name)
+
+	push := func(log timelog.TimeLog) int {
+		t.Helper()
+		outgoing := cur.Outgoing(log)
+		ids, err := src.Send(outgoing, cur.Pushed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, e := range outgoing {
+			cur.IDs[ids[i]] = EventID(e)
+			if e.At.After(cur.Pushed) {
+				cur.Pushed = e.At
+			}
+		}
+		return len(outgoing)
+	}
+
+	log := timelog.TimeLog{
+		{At: day.Add(9 * time.Hour), Code: "Proj", Desc: "morning"},
+		{At: day.Add(12 * time.Hour)},
+	}
+	if n := push(log); n != 2 {
+		t.Fatalf("first push sent %d event(s), want 2", n)
+	}
+
+	// Backdated to before the last push, it still hasn't been sent.
+	log, _ = log.Insert(&timelog.Event{At: day.Add(10 * time.Hour), Code: "Ops", Desc: "backdated"})
+	if n := push(log); n != 1 {
+		t.Fatalf("second push sent %d event(s), want the backdated one", n)
+	}
+	if n := push(log); n != 0 {
+		t.Fatalf("third push sent %d event(s), want none", n)
+	}
+
+	raw, err := os.ReadFile(src.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timelog.FormatVersionOf(string(raw)) != timelog.FormatVersion {
+		t.Errorf("pushed timelog has no format header:\n%s", raw)
+	}
+	pushed, err := timelog.ParseTimeLogString(string(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pushed) != 3 || !strings.Contains(pushed.String(), "backdated") {
+		t.Errorf("pushed timelog is missing events:\n%s", raw)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AttachKey is the metadata key for the files an event refers to, like screenshots or deliverables. The value is a
+// comma separated list of paths.
+const AttachKey = "attach"
+
+// Attachment is a file an event refers to.
+type Attachment struct {
+	Name string // As written in the metadata.
+	Path string // Where it actually is.
+}
+
+// Attachments returns the files in the attach metadata. Paths may start with ~ for the home directory and have
+// environment variables in them, and relative paths are relative to dir (normally the timelog's directory).
+func Attachments(meta map[string]string, dir string) []*Attachment {
+	out := []*Attachment{}
+	for _, name := range strings.Split(meta[AttachKey], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		out = append(out, &Attachment{Name: name, Path: ResolveAttachment(name, dir)})
+	}
+	return out
+}
+
+// ResolveAttachment works out where an attached file is, see [Attachments].
+func ResolveAttachment(name, dir string) string {
+	path := os.ExpandEnv(name)
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// Exists is true if the file is there.
+func (a *Attachment) Exists() bool {
+	_, err := os.Stat(a.Path)
+	return err == nil
+}
+
+// URL is a file:// URL for the attachment, for linking to it.
+func (a *Attachment) URL() string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(a.Path)}).String()
+}
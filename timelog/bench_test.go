@@ -0,0 +1,99 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+	"github.com/milochristiansen/timeclock/timelog/gen"
+)
+
+// benchEvents is how long the made up logs the benchmarks run on are, about 40 years of work.
+const benchEvents = 100000
+
+// benchLog makes up a log of benchEvents events, the same one every time.
+func benchLog(b *testing.B) timelog.TimeLog {
+	b.Helper()
+	log := gen.Generate(gen.Options{
+		Begin: time.Date(1990, 1, 1, 0, 0, 0, 0, time.Local),
+		Days:  benchEvents / 4,
+		Noise: 0.5,
+		Seed:  1,
+	})
+	if len(log) < benchEvents {
+		b.Fatalf("made up %d events, want at least %d", len(log), benchEvents)
+	}
+	return log[:benchEvents]
+}
+
+func BenchmarkParseTimeLog(b *testing.B) {
+	buf := &strings.Builder{}
+	err := benchLog(b).FormatFile(buf)
+	if err != nil {
+		b.Fatal(err)
+	}
+	text := buf.String()
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := timelog.ParseTimeLogString(text)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	log := benchLog(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := log.Format(&strings.Builder{})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPeriods(b *testing.B) {
+	log := benchLog(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		log.Periods()
+	}
+}
+
+func BenchmarkTotalsByWeek(b *testing.B) {
+	periods := benchLog(b).Periods()
+	cal := timelog.Calendar{WeekStart: time.Monday, DayStart: 4 * time.Hour}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		timelog.TotalsByWeek(periods, cal, true)
+	}
+}
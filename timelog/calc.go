@@ -32,6 +32,13 @@ import (
 // After returns a TimeLog with only the [Event] items that happen after the given [time.Time].
 // Editing [Event] items in the new TimeLog will also edit events in the original!
 func (log TimeLog) After(t time.Time) TimeLog {
+	if log.sorted() {
+		i := sort.Search(len(log), func(i int) bool {
+			return log[i].At.After(t)
+		})
+		return log[i:len(log):len(log)]
+	}
+
 	out := []*Event{}
 
 	for _, item := range log {
@@ -51,6 +58,19 @@ func (log TimeLog) Between(t1, t2 time.Time) TimeLog {
 		t2, t1 = t1, t2
 	}
 
+	if log.sorted() {
+		i := sort.Search(len(log), func(i int) bool {
+			return log[i].At.After(t1)
+		})
+		j := sort.Search(len(log), func(j int) bool {
+			return !log[j].At.Before(t2)
+		})
+		if j < i {
+			j = i
+		}
+		return log[i:j:j]
+	}
+
 	out := []*Event{}
 
 	for _, item := range log {
@@ -62,88 +82,338 @@ func (log TimeLog) Between(t1, t2 time.Time) TimeLog {
 	return out
 }
 
-// Sort makes sure that all Event items are nicely in order.
+// Sort makes sure that all Event items are nicely in order. Events at the same time keep their relative order.
 func (log TimeLog) Sort() {
-	sort.Slice(log, func(i, j int) bool {
+	if log.sorted() {
+		return
+	}
+	sort.SliceStable(log, func(i, j int) bool {
 		return log[i].At.Before(log[j].At)
 	})
 }
 
+// sorted checks if the log is already in order. This is much cheaper than sorting it, and if it is sorted After and
+// Between can search it instead of checking every event.
+func (log TimeLog) sorted() bool {
+	for i := 1; i < len(log); i++ {
+		if log[i].At.Before(log[i-1].At) {
+			return false
+		}
+	}
+	return true
+}
+
+// OutOfOrder returns the indexes of any [Event] items that happen before the event preceding them.
+func (log TimeLog) OutOfOrder() []int {
+	out := []int{}
+	for i := 1; i < len(log); i++ {
+		if log[i].At.Before(log[i-1].At) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Insert adds an [Event] to a sorted TimeLog in the right place to keep it sorted, returning the new TimeLog and the
+// index the event ended up at. If there are already events at the same time the new event goes after them.
+func (log TimeLog) Insert(e *Event) (TimeLog, int) {
+	i := sort.Search(len(log), func(i int) bool {
+		return log[i].At.After(e.At)
+	})
+
+	log = append(log, nil)
+	copy(log[i+1:], log[i:])
+	log[i] = e
+	return log, i
+}
+
 // Period describes a time period bracketed by two events. By convention the description and time code are take from
 // the event that marks the beginning of the period.
 type Period struct {
 	Begin time.Time
 	End   time.Time
+	Track string
 	Desc  string
 	Code  string
+	Meta  map[string]string // Shared with the event, so don't modify it.
+
+	// Time between Begin and End that isn't counted for this period, because it overlaps with periods on other tracks.
+	// See [AttributeOverlap].
+	Excluded time.Duration
+
+	// The day the shift this period is part of started, if it counts for that day rather than the day it begins on.
+	// See [NightShift].
+	Shift time.Time
 }
 
+// Day is the day the period counts for. This is the day it begins on, unless it is part of a shift that started the
+// day before.
+func (p *Period) Day(cal Calendar) time.Time {
+	if !p.Shift.IsZero() {
+		return p.Shift
+	}
+	return cal.Day(p.Begin)
+}
+
+// Length is the time counted for the period. This is the time from Begin to End, less any excluded time.
 func (p *Period) Length() time.Duration {
-	return p.End.Sub(p.Begin)
+	return p.End.Sub(p.Begin) - p.Excluded
 }
 
 func (p *Period) String() string {
 	return fmt.Sprintf("%s - %s %5.1fh [%s] %s", p.Begin.Format(TimeFormat), p.End.Format(TimeShortFormat), p.Length().Hours(), p.Code, p.Desc)
 }
 
-// FilterOutPeriods removes all [Period] items that match the given time code.
-func FilterOutPeriods(p []*Period, code string) []*Period {
-	out := []*Period{}
+// RoundPeriods works out the billed length of each [Period], rounded to the nearest multiple of unit.
+//
+// Rounding each period on its own lets the rounding errors pile up, so a day of 7 minute periods billed in 15 minute
+// units comes out wildly wrong. If reconcile is set the error is carried forward from each period to the next instead
+// (separately for each code), so the billed lengths for a code always add up to its rounded total. The catch is that
+// individual periods may be billed a unit more or less than they would be rounded on their own.
+//
+// If unit is zero or less, the billed lengths are the raw lengths.
+func RoundPeriods(periods []*Period, unit time.Duration, reconcile bool) map[*Period]time.Duration {
+	out := map[*Period]time.Duration{}
+	raw := map[string]time.Duration{}
+	billed := map[string]time.Duration{}
+
+	for _, p := range periods {
+		switch {
+		case unit <= 0:
+			out[p] = p.Length()
+		case !reconcile:
+			out[p] = p.Length().Round(unit)
+		default:
+			raw[p.Code] += p.Length()
+			b := raw[p.Code].Round(unit) - billed[p.Code]
+			billed[p.Code] += b
+			out[p] = b
+		}
+	}
+	return out
+}
+
+// NormalizeDesc reduces a description to a form suitable for grouping periods by task. Only the first line is used,
+// case and runs of whitespace are ignored, as is trailing punctuation. "Fixed  the bug." and "fixed the bug" are the
+// same task.
+func NormalizeDesc(desc string) string {
+	first, _, _ := strings.Cut(desc, "\n")
+	first = strings.Join(strings.Fields(strings.ToLower(first)), " ")
+	return strings.TrimRight(first, ".,;:!? ")
+}
 
+// PeriodFilter decides if a [Period] should be kept, see [FilterPeriods].
+type PeriodFilter func(p *Period) bool
+
+// FilterPeriods returns the [Period] items that pass every filter, in the same order. This only goes through the
+// periods once no matter how many filters there are, so combine filters rather than filtering over and over.
+func FilterPeriods(p []*Period, filters ...PeriodFilter) []*Period {
+	out := make([]*Period, 0, len(p))
+
+outer:
 	for _, item := range p {
-		if item.Code != code {
-			out = append(out, item)
+		for _, f := range filters {
+			if !f(item) {
+				continue outer
+			}
 		}
+		out = append(out, item)
 	}
 
 	return out
 }
 
-// FilterInPeriods removes all [Period] items that *do not* match the given time code.
-func FilterInPeriods(p []*Period, code string) []*Period {
-	out := []*Period{}
+// MatchCode matches periods with exactly the given time code, or another spelling of it (see CodeKey).
+func MatchCode(code string, fold bool) PeriodFilter {
+	key := CodeKey(code, fold)
+	return func(p *Period) bool {
+		return p.Code == code || CodeKey(p.Code, fold) == key
+	}
+}
 
-	for _, item := range p {
-		if item.Code == code {
-			out = append(out, item)
+// MatchCodeChildren matches periods with the given time code or any of its children.
+func MatchCodeChildren(code string, fold bool) PeriodFilter {
+	return MatchCodeDepth(code, -1, fold)
+}
+
+// MatchCodeDepth matches periods with the given time code or its children, down to depth levels below it. A depth of 1
+// is the code and its direct children, 0 is just the code, and less than 0 is every child no matter how deep.
+func MatchCodeDepth(code string, depth int, fold bool) PeriodFilter {
+	key := CodeKey(code, fold)
+	prefix := key + ":"
+	return func(p *Period) bool {
+		if p.Code == code {
+			return true
 		}
+		pkey := CodeKey(p.Code, fold)
+		if pkey == key {
+			return true
+		}
+		rest, ok := strings.CutPrefix(pkey, prefix)
+		return ok && (depth < 0 || strings.Count(rest, ":") < depth)
 	}
+}
 
-	return out
+// CutWildcard splits a wildcard off the end of a time code. "code:..." is every child of code, and each "code:*" is one
+// more level of children, so "code:*" is the direct children and "code:*:*" goes down to the grandchildren. The depth
+// is as for MatchCodeDepth.
+func CutWildcard(code string) (parent string, depth int, ok bool) {
+	if parent, ok := strings.CutSuffix(code, ":..."); ok {
+		return parent, -1, true
+	}
+	for {
+		cut, ok := strings.CutSuffix(code, ":*")
+		if !ok {
+			break
+		}
+		code = cut
+		depth++
+	}
+	return code, depth, depth > 0
+}
+
+// MatchMeta matches periods with the given metadata value. A blank value matches periods without the key at all.
+func MatchMeta(key, value string) PeriodFilter {
+	return func(p *Period) bool {
+		return p.Meta[key] == value
+	}
+}
+
+// Not matches periods that don't match f.
+func Not(f PeriodFilter) PeriodFilter {
+	return func(p *Period) bool {
+		return !f(p)
+	}
+}
+
+// AnyOf matches periods that match at least one of the filters. With no filters it matches nothing.
+func AnyOf(filters ...PeriodFilter) PeriodFilter {
+	return func(p *Period) bool {
+		for _, f := range filters {
+			if f(p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterOutPeriods removes all [Period] items that match the given time code.
+func FilterOutPeriods(p []*Period, code string, fold bool) []*Period {
+	return FilterPeriods(p, Not(MatchCode(code, fold)))
+}
+
+// FilterInPeriods removes all [Period] items that *do not* match the given time code.
+func FilterInPeriods(p []*Period, code string, fold bool) []*Period {
+	return FilterPeriods(p, MatchCode(code, fold))
 }
 
 // Periods takes a TimeLog and assembles the [Event] items into a set of [Period] items. The description and time code
 // for each Period is taken from the Event that marks its beginning. If it is not already, the TimeLog will be sorted!
+//
+// Each track is assembled on its own, so a period only ends at the next event on the same track. Events on the same
+// track at the same time clip each other, see OverlapClip. The result is sorted by the beginning of each period.
 func (log TimeLog) Periods() []*Period {
-	out := []*Period{}
+	return log.PeriodsWith(OverlapClip)
+}
+
+// PeriodsWith is [TimeLog.Periods], with events on the same track at the same time handled by the given policy.
+func (log TimeLog) PeriodsWith(policy OverlapPolicy) []*Period {
+	out := make([]*Period, 0, len(log))
 
 	log.Sort()
 
-	var last *Event
+	// With only one track the periods come out in order already, so there is no need to sort them again.
+	last := map[string]*Event{}
+	tracks := 0
 	for _, item := range log {
-		if last != nil {
+		prev, ok := last[item.Track]
+		if !ok {
+			tracks++
+		}
+		if prev != nil && policy == OverlapFirstWins && prev.At.Equal(item.At) {
+			continue
+		}
+		if prev != nil {
 			out = append(out, &Period{
-				Begin: last.At,
+				Begin: prev.At,
 				End:   item.At,
-				Desc:  last.Desc,
-				Code:  last.Code,
+				Track: prev.Track,
+				Desc:  prev.Desc,
+				Code:  prev.Code,
+				Meta:  prev.Meta,
 			})
 		}
-		last = item
+		last[item.Track] = item
 	}
 
+	if tracks > 1 {
+		sort.SliceStable(out, func(i, j int) bool {
+			return out[i].Begin.Before(out[j].Begin)
+		})
+	}
 	return out
 }
 
 type TimecodeTreeNode struct {
 	Kids map[string]*TimecodeTreeNode
 	Self string
+
+	fold bool // See CodeKey, from GenerateTimecodeTree.
 }
 
-func GenerateTimecodeTree(codes []string) *TimecodeTreeNode {
-	codetree := &TimecodeTreeNode{Kids: map[string]*TimecodeTreeNode{}, Self: "-"}
+// Has returns true if the code is in the tree, in any spelling (see CodeKey).
+func (n *TimecodeTreeNode) Has(code string) bool {
+	for _, part := range strings.Split(NormalizeCode(code), ":") {
+		kid, ok := n.kid(part)
+		if !ok {
+			return false
+		}
+		n = kid
+	}
+	return true
+}
+
+// kid finds the child for one part of a code. Kids is keyed by the first spelling of each part that was added, so any
+// other spelling has to be looked for.
+func (n *TimecodeTreeNode) kid(part string) (*TimecodeTreeNode, bool) {
+	if kid, ok := n.Kids[part]; ok {
+		return kid, true
+	}
+	key := CodeKey(part, n.fold)
+	for name, kid := range n.Kids {
+		if CodeKey(name, n.fold) == key {
+			return kid, true
+		}
+	}
+	return nil, false
+}
+
+// WithParents returns the codes along with all of their parents, each once. "a:b:c" gives "a:b:c", "a:b", and "a".
+// Any of the separators NormalizeCode knows about split a code, so "a/b" has the parent "a" too.
+func WithParents(codes []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(codes))
 	for _, code := range codes {
-		parts := strings.Split(code, ":")
+		for c := code; !seen[c]; {
+			seen[c] = true
+			out = append(out, c)
+			i := strings.LastIndexAny(c, ":/.")
+			if i == -1 {
+				break
+			}
+			c = c[:i]
+		}
+	}
+	return out
+}
+
+// GenerateTimecodeTree builds a tree of the codes and their parents. With fold, spellings of a code that only differ in
+// case are the same code (see CodeKey).
+func GenerateTimecodeTree(codes []string, fold bool) *TimecodeTreeNode {
+	codetree := &TimecodeTreeNode{Kids: map[string]*TimecodeTreeNode{}, Self: "-", fold: fold}
+	for _, code := range codes {
+		parts := strings.Split(NormalizeCode(code), ":")
 		n := codetree
 		sofar := ""
 		for i, part := range parts {
@@ -151,11 +421,13 @@ func GenerateTimecodeTree(codes []string) *TimecodeTreeNode {
 				sofar += ":"
 			}
 			sofar += part
-			kid, ok := n.Kids[part]
+			// Another spelling of a part that is already there goes with the first one.
+			kid, ok := n.kid(part)
 			if !ok {
-				kid = &TimecodeTreeNode{Kids: map[string]*TimecodeTreeNode{}, Self: sofar}
+				kid = &TimecodeTreeNode{Kids: map[string]*TimecodeTreeNode{}, Self: sofar, fold: fold}
 				n.Kids[part] = kid
 			}
+			sofar = kid.Self
 			n = kid
 		}
 	}
@@ -164,51 +436,32 @@ func GenerateTimecodeTree(codes []string) *TimecodeTreeNode {
 
 // FilterOutPeriods removes all [Period] items that match the given time code and its children.
 func FilterOutPeriodsChildren(p []*Period, code string, codetree *TimecodeTreeNode) []*Period {
-	// Find the node for the given code
-	parts := strings.Split(code, ":")
-	n := codetree
-	for _, part := range parts {
-		kid, ok := n.Kids[part]
-		if !ok {
-			// Code not found in tree.
-			return nil
-		}
-		n = kid
-	}
+	return FilterOutPeriodsDepth(p, code, -1, codetree)
+}
 
-	// n is the node for the current code. Now walk down the remaining children, filtering them in.
-	return filterOutPeriodsChildren(p, n)
+// FilterInPeriods removes all [Period] items that *do not* match the given time code and its children.
+func FilterInPeriodsChildren(p []*Period, code string, codetree *TimecodeTreeNode) []*Period {
+	return FilterInPeriodsDepth(p, code, -1, codetree)
 }
 
-func filterOutPeriodsChildren(p []*Period, n *TimecodeTreeNode) []*Period {
-	for _, kid := range n.Kids {
-		p = filterOutPeriodsChildren(p, kid)
+// FilterOutPeriodsDepth is FilterOutPeriodsChildren, but only goes depth levels down, see MatchCodeDepth.
+func FilterOutPeriodsDepth(p []*Period, code string, depth int, codetree *TimecodeTreeNode) []*Period {
+	if !codetree.Has(code) {
+		return nil
 	}
-
-	return FilterOutPeriods(p, n.Self)
+	return FilterPeriods(p, Not(MatchCodeDepth(code, depth, codetree.fold)))
 }
 
-// FilterInPeriods removes all [Period] items that *do not* match the given time code and its children.
-func FilterInPeriodsChildren(p []*Period, code string, codetree *TimecodeTreeNode) []*Period {
-	// Find the node for the given code
-	parts := strings.Split(code, ":")
-	n := codetree
-	for _, part := range parts {
-		kid, ok := n.Kids[part]
-		if !ok {
-			// Code not found in tree.
-			return nil
-		}
-		n = kid
+// FilterInPeriodsDepth is FilterInPeriodsChildren, but only goes depth levels down, see MatchCodeDepth.
+func FilterInPeriodsDepth(p []*Period, code string, depth int, codetree *TimecodeTreeNode) []*Period {
+	if !codetree.Has(code) {
+		return nil
 	}
-
-	return filterInPeriodsChildren(p, n)
+	return FilterPeriods(p, MatchCodeDepth(code, depth, codetree.fold))
 }
 
-func filterInPeriodsChildren(p []*Period, n *TimecodeTreeNode) []*Period {
-	out := []*Period{}
-	for _, kid := range n.Kids {
-		out = append(out, filterInPeriodsChildren(p, kid)...)
-	}
-	return append(out, FilterInPeriods(p, n.Self)...)
+// FilterPeriodsMeta removes all [Period] items that don't have the given metadata value. A blank value matches periods
+// without the key at all.
+func FilterPeriodsMeta(p []*Period, key, value string) []*Period {
+	return FilterPeriods(p, MatchMeta(key, value))
 }
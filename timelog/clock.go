@@ -0,0 +1,146 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The classic timeclock dialect, as used by Emacs timeclock and read by Ledger, records explicit clock in and clock out
+// pairs instead of a sequence of events:
+//
+//	i 2026/10/14 09:00:00 Customer:Dev  Fixing the thing
+//	o 2026/10/14 10:30:00
+//
+// These lines can be mixed into a time log freely. Each session is put on a track of its own ("clock" if it is free,
+// otherwise "clock2" and so on), so sessions may overlap each other and the main track. A clock out line can name the
+// code to clock out of, otherwise it ends the last session clocked in and anything after the time is its description.
+
+// clockFormats are the timestamp formats accepted in clock lines. Seconds are optional, since the native format
+// doesn't have them.
+var clockFormats = []string{
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// isClockLine reports if a line is in the classic timeclock dialect.
+func isClockLine(line string) bool {
+	return len(line) > 1 && (line[0] == 'i' || line[0] == 'o') && (line[1] == ' ' || line[1] == '\t')
+}
+
+// clockSessions tracks open sessions while parsing clock lines.
+type clockSessions struct {
+	open []*Event
+}
+
+// parse turns a clock line into an event, opening or closing a session.
+func (s *clockSessions) parse(line string, n int) (*Event, error) {
+	context := func(rest string, expected string) ErrorContext {
+		column := utf8.RuneCountInString(line[:len(line)-len(rest)]) + 1
+		return ErrorContext{Line: n, Column: column, Text: line, Expected: expected}
+	}
+
+	rest := strings.TrimLeft(line[1:], " \t")
+	fields := strings.Fields(rest)
+	if len(fields) < 2 {
+		return nil, ErrUnexpectedEnd{context(strings.TrimRight(rest, " \t"), "a date and 24 hour time, eg '2026/10/14 09:00:00'")}
+	}
+
+	stamp := fields[0] + " " + fields[1]
+	var at time.Time
+	var err error
+	for _, format := range clockFormats {
+		at, err = time.ParseInLocation(format, stamp, time.Local)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, ErrBadDate{context(rest, "a date and 24 hour time, eg '2026/10/14 09:00:00'")}
+	}
+
+	// The code and description are separated by two spaces or a tab, since codes may contain single spaces.
+	rest = strings.TrimLeft(rest, " \t")
+	rest = strings.TrimLeft(rest[len(fields[0]):], " \t")
+	rest = strings.Trim(rest[len(fields[1]):], " \t")
+	code, desc := rest, ""
+	if i := strings.IndexAny(rest, "\t"); i != -1 {
+		code, desc = rest[:i], rest[i+1:]
+	}
+	if i := strings.Index(code, "  "); i != -1 {
+		code, desc = code[:i], code[i:]+desc
+	}
+	code, desc = strings.Trim(code, " \t"), strings.Trim(desc, " \t")
+	if strings.Contains(code, "]") {
+		return nil, ErrMalformed{context(rest, "a time code without ']'")}
+	}
+
+	if line[0] == 'i' {
+		e := &Event{At: at, Track: s.freeTrack(), Code: code, Desc: desc}
+		s.open = append(s.open, e)
+		return e, nil
+	}
+
+	// Clocking out ends the named session, or the last one if there isn't one named.
+	if len(s.open) == 0 {
+		return nil, ErrMalformed{context(line[1:], "an open session to clock out of")}
+	}
+	found := -1
+	for i := len(s.open) - 1; i >= 0 && code != ""; i-- {
+		if s.open[i].Code == code {
+			found = i
+			break
+		}
+	}
+	if found == -1 {
+		found = len(s.open) - 1
+		desc = rest
+	}
+	session := s.open[found]
+	s.open = append(s.open[:found], s.open[found+1:]...)
+
+	return &Event{At: at, Track: session.Track, Desc: desc}, nil
+}
+
+// freeTrack returns the first clock track without an open session.
+func (s *clockSessions) freeTrack() string {
+	for n := 1; ; n++ {
+		track := "clock"
+		if n > 1 {
+			track += strconv.Itoa(n)
+		}
+
+		used := false
+		for _, e := range s.open {
+			used = used || e.Track == track
+		}
+		if !used {
+			return track
+		}
+	}
+}
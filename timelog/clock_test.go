@@ -0,0 +1,142 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// clockEvents is the events as "15:04 @track [code] desc" lines, so what each clock line turned into is easy to see.
+func clockEvents(log TimeLog) string {
+	lines := []string{}
+	for _, e := range log {
+		lines = append(lines, fmt.Sprintf("%s @%s [%s] %s", e.At.Format("01/02 15:04"), e.Track, e.Code, e.Desc))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestClockLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			"a session",
+			"i 2026/10/14 09:00:00 Customer:Dev  Fixing the thing\no 2026/10/14 10:30:00\n",
+			"10/14 09:00 @clock [Customer:Dev] Fixing the thing\n10/14 10:30 @clock [] ",
+		},
+		{
+			"without seconds, with dashes, and a tab before the description",
+			"i 2026-10-14 09:00 Customer:Dev\tFixing\no\t2026-10-14 10:30\n",
+			"10/14 09:00 @clock [Customer:Dev] Fixing\n10/14 10:30 @clock [] ",
+		},
+		{
+			"codes may have single spaces",
+			"i 2026/10/14 09:00 Big Client:Dev\no 2026/10/14 10:00\n",
+			"10/14 09:00 @clock [Big Client:Dev] \n10/14 10:00 @clock [] ",
+		},
+		{
+			"two open sessions get their own tracks",
+			"i 2026/10/14 09:00 A\ni 2026/10/14 09:30 B\no 2026/10/14 10:00\ni 2026/10/14 10:15 C\no 2026/10/14 11:00\no 2026/10/14 11:30\n",
+			"10/14 09:00 @clock [A] \n10/14 09:30 @clock2 [B] \n10/14 10:00 @clock2 [] \n" +
+				"10/14 10:15 @clock2 [C] \n10/14 11:00 @clock2 [] \n10/14 11:30 @clock [] ",
+		},
+		{
+			"clocking out of a named session",
+			"i 2026/10/14 09:00 A\ni 2026/10/14 09:30 B\no 2026/10/14 10:00 A  handed over\no 2026/10/14 11:00\n",
+			"10/14 09:00 @clock [A] \n10/14 09:30 @clock2 [B] \n10/14 10:00 @clock [] handed over\n10/14 11:00 @clock2 [] ",
+		},
+		{
+			"a clock out description",
+			"i 2026/10/14 09:00 A\no 2026/10/14 10:00 done for the day\n",
+			"10/14 09:00 @clock [A] \n10/14 10:00 @clock [] done for the day",
+		},
+		{
+			"CRLF",
+			"i 2026/10/14 09:00:00 A  first\r\no 2026/10/14 10:00:00 left\r\n",
+			"10/14 09:00 @clock [A] first\n10/14 10:00 @clock [] left",
+		},
+		{
+			"mixed with events",
+			"2026/10/14 08:00AM [Mail] inbox\ni 2026/10/14 09:00 Call  standup\n2026/10/14 09:30AM [Dev] \no 2026/10/14 09:45\n2026/10/14 12:00PM []\n",
+			"10/14 08:00 @ [Mail] inbox\n10/14 09:00 @clock [Call] standup\n10/14 09:30 @ [Dev] \n10/14 09:45 @clock [] \n10/14 12:00 @ [] ",
+		},
+	}
+	for _, test := range tests {
+		log, err := ParseTimeLogString(test.input)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := clockEvents(log); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestClockLineErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  any
+		line  int
+	}{
+		{"clock out with nothing open", "o 2026/10/14 10:00\n", ErrMalformed{}, 1},
+		{"clock out after the session ended", "i 2026/10/14 09:00 A\no 2026/10/14 10:00\no 2026/10/14 11:00\n", ErrMalformed{}, 3},
+		{"no time", "i 2026/10/14\n", ErrUnexpectedEnd{}, 1},
+		{"bad date", "i 2026/13/14 09:00 A\n", ErrBadDate{}, 1},
+		{"12 hour time", "i 2026/10/14 09:00AM A\n", ErrBadDate{}, 1},
+		{"bracket in the code", "i 2026/10/14 09:00 A]B\n", ErrMalformed{}, 1},
+	}
+	for _, test := range tests {
+		_, err := ParseTimeLogString(test.input)
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+			continue
+		}
+		var ctx ErrorContext
+		switch e := err.(type) {
+		case ErrMalformed:
+			ctx = e.ErrorContext
+		case ErrBadDate:
+			ctx = e.ErrorContext
+		case ErrUnexpectedEnd:
+			ctx = e.ErrorContext
+		}
+		if fmt.Sprintf("%T", err) != fmt.Sprintf("%T", test.want) || ctx.Line != test.line {
+			t.Errorf("%s: got %T on line %d (%v), want %T on line %d", test.name, err, ctx.Line, err, test.want, test.line)
+		}
+
+		// Read leniently, the bad line is a problem and the rest is kept.
+		log, problems := ParseTimeLogLenient(test.input)
+		if len(problems) != 1 || problems[0].Line != test.line {
+			t.Errorf("%s: lenient read gave problems %v", test.name, problems)
+		}
+		if strings.Count(test.input, "\n")-1 != len(log) {
+			t.Errorf("%s: lenient read kept %d events", test.name, len(log))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import "strings"
+
+// codeSeparators turns the other separators people type by habit into ':'.
+var codeSeparators = strings.NewReplacer("/", ":", ".", ":")
+
+// NormalizeCode returns a code with '/' and '.' turned into ':', the way a code is written once it is canonical.
+func NormalizeCode(code string) string {
+	return codeSeparators.Replace(code)
+}
+
+// CodeKey returns the form of a code used to compare it with others: the separators are normalized (see
+// NormalizeCode) and, with fold, it is lower case. Two codes are the same code if they have the same key.
+//
+// Folding case makes codes that only differ in case the same code, so Client:Dev and client:dev are filtered, totaled,
+// and put in the tree together. Every function taking a fold argument means this.
+func CodeKey(code string, fold bool) string {
+	code = NormalizeCode(code)
+	if fold {
+		code = strings.ToLower(code)
+	}
+	return code
+}
+
+// SameCode reports if two codes are the same code, see CodeKey.
+func SameCode(a, b string, fold bool) bool {
+	return a == b || CodeKey(a, fold) == CodeKey(b, fold)
+}
+
+// CodeNames picks one spelling for each code, the first one it is asked about, so codes that are the same (see
+// CodeKey) can be grouped under one name. Make one with CodeNames{Fold: fold}.
+type CodeNames struct {
+	Fold bool // See CodeKey.
+
+	names map[string]string
+}
+
+// Name returns the spelling to use for code, with its separators normalized.
+func (names *CodeNames) Name(code string) string {
+	if names.names == nil {
+		names.names = map[string]string{}
+	}
+	key := CodeKey(code, names.Fold)
+	if name, ok := names.names[key]; ok {
+		return name
+	}
+	name := NormalizeCode(code)
+	names.names[key] = name
+	return name
+}
+
+// CanonicalCodes gives every period with the same code (see CodeKey) the same spelling of it, the first one in the
+// list. Anything that groups periods by code, like Totals, then groups them together.
+func CanonicalCodes(periods []*Period, fold bool) {
+	names := CodeNames{Fold: fold}
+	for _, p := range periods {
+		p.Code = names.Name(p.Code)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CodeInfo holds extra information about time codes, keyed by code and then by the name of the setting. Settings are
+// inherited by child codes, so setting something for "client" also sets it for "client:dev" unless "client:dev"
+// overrides it.
+type CodeInfo map[string]map[string]string
+
+// Get looks up a setting for a code, checking the code's parents if the code itself doesn't have it. Settings in the
+// "" section (before the first code in the file) are defaults for every code.
+func (info CodeInfo) Get(code, key string) (string, bool) {
+	for {
+		if v, ok := info[code][key]; ok {
+			return v, true
+		}
+
+		i := strings.LastIndex(code, ":")
+		if i == -1 {
+			v, ok := info[""][key]
+			return v, ok
+		}
+		code = code[:i]
+	}
+}
+
+// Bool looks up a setting for a code and interprets it as a boolean. Missing or malformed settings are false.
+func (info CodeInfo) Bool(code, key string) bool {
+	v, ok := info.Get(code, key)
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	return err == nil && b
+}
+
+// Billable reports if time spent on a code can be billed to someone, which is set with "billable=true".
+func (info CodeInfo) Billable(code string) bool {
+	return info.Bool(code, "billable")
+}
+
+// Focus returns how time on a code is classified for focus, "deep" for deep work or "shallow" for shallow or admin
+// work, which is set with "focus=deep" or "focus=shallow". Codes that aren't classified (or have some other value)
+// return "".
+func (info CodeInfo) Focus(code string) string {
+	v, _ := info.Get(code, "focus")
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "deep", "shallow":
+		return v
+	}
+	return ""
+}
+
+// Rate returns the hourly rate for a code and the currency it is in, which are set with "rate=120" and "currency=EUR".
+// The currency may be blank if it was never set. Codes without a valid rate are not charged for.
+func (info CodeInfo) Rate(code string) (float64, string, bool) {
+	v, ok := info.Get(code, "rate")
+	if !ok {
+		return 0, "", false
+	}
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil || rate < 0 {
+		return 0, "", false
+	}
+	currency, _ := info.Get(code, "currency")
+	return rate, currency, true
+}
+
+// Mileage returns the rate travel on a code is reimbursed at, per unit of distance (km or miles, whichever is used),
+// and the currency it is in. These are set with "mileage=0.30" and the same "currency" as Rate. Codes without a valid
+// mileage rate aren't reimbursed.
+func (info CodeInfo) Mileage(code string) (float64, string, bool) {
+	v, ok := info.Get(code, "mileage")
+	if !ok {
+		return 0, "", false
+	}
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil || rate < 0 {
+		return 0, "", false
+	}
+	currency, _ := info.Get(code, "currency")
+	return rate, currency, true
+}
+
+// Tax returns the tax rule for a code: the name of the tax, its rate as a percentage, and if the code's rate already
+// includes it. These are set with "tax=20", "taxname=VAT", and "taxinclusive=true". Usually the tax is set once for
+// everything, so setting "taxexempt=true" for a code turns it off again. Codes without a tax (or with a zero rate) are
+// not taxed.
+func (info CodeInfo) Tax(code string) (name string, rate float64, inclusive bool, ok bool) {
+	if info.Bool(code, "taxexempt") {
+		return "", 0, false, false
+	}
+	v, ok := info.Get(code, "tax")
+	if !ok {
+		return "", 0, false, false
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	if err != nil || rate <= 0 {
+		return "", 0, false, false
+	}
+	name, ok = info.Get(code, "taxname")
+	if !ok {
+		name = "Tax"
+	}
+	return name, rate, info.Bool(code, "taxinclusive"), true
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DurationStyle selects how [FormatDuration] writes durations.
+type DurationStyle int
+
+const (
+	DurationDecimal DurationStyle = iota // Decimal hours, eg "1.5h"
+	DurationClock                        // Hours and minutes like a clock, eg "1:30"
+	DurationWords                        // Hours and minutes spelled out, eg "1h 30m"
+)
+
+// ParseDurationStyle converts the name of a style ("decimal", "clock", or "words") into a DurationStyle.
+func ParseDurationStyle(name string) (DurationStyle, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "decimal", "":
+		return DurationDecimal, nil
+	case "clock", "h:mm":
+		return DurationClock, nil
+	case "words":
+		return DurationWords, nil
+	}
+	return DurationDecimal, fmt.Errorf("Unknown duration style %q, expected 'decimal', 'clock', or 'words'.", name)
+}
+
+// FormatDuration formats a duration in the given style. Durations are rounded to the nearest minute, or the nearest
+// tenth of an hour for the decimal style.
+func FormatDuration(d time.Duration, style DurationStyle) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	switch style {
+	case DurationClock:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%s%d:%02d", sign, int(d/time.Hour), int(d%time.Hour/time.Minute))
+	case DurationWords:
+		d = d.Round(time.Minute)
+		h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+		switch {
+		case h == 0:
+			return fmt.Sprintf("%s%dm", sign, m)
+		case m == 0:
+			return fmt.Sprintf("%s%dh", sign, h)
+		}
+		return fmt.Sprintf("%s%dh %dm", sign, h, m)
+	default:
+		// Round first so we never print "-0.0h".
+		hours := math.Round(d.Hours()*10) / 10
+		if hours == 0 {
+			sign = ""
+		}
+		return fmt.Sprintf("%s%.1fh", sign, hours)
+	}
+}
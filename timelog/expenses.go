@@ -0,0 +1,148 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expense is money spent on a code, like a train ticket to a client. It is attached to an event, or to a whole day.
+type Expense struct {
+	At       time.Time // The event it is attached to, or the start of the day.
+	Day      bool      // Attached to the day rather than an event.
+	Code     string
+	Amount   float64
+	Currency string
+	Note     string
+	Receipt  string // The path to a scan or photo of the receipt, may be blank.
+}
+
+// Client is the top level of the expense's code, which is who pays for it.
+func (e *Expense) Client() string {
+	client, _, _ := strings.Cut(e.Code, ":")
+	return client
+}
+
+// String formats an expense for listing.
+func (e *Expense) String() string {
+	at := e.At.Format(TimeFormat)
+	if e.Day {
+		at = e.At.Format("2006/01/02")
+	}
+	s := fmt.Sprintf("%s [%s] %.2f", at, e.Code, e.Amount)
+	if e.Currency != "" {
+		s += " " + e.Currency
+	}
+	if e.Note != "" {
+		s += " " + e.Note
+	}
+	if e.Receipt != "" {
+		s += " (" + e.Receipt + ")"
+	}
+	return s
+}
+
+// ParseExpenses reads an expense ledger, with one expense per line and the fields separated by tabs: the time, "event"
+// or "day", the code, the amount, the currency, the note, and the receipt. The code, note, and receipt are quoted,
+// the currency may be blank but can't contain spaces. Blank lines and lines starting with '#' are skipped. The result is sorted by time.
+func ParseExpenses(content string) ([]*Expense, error) {
+	expenses := []*Expense{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %v: expected 7 fields, found %v", i+1, len(fields))
+		}
+
+		e := &Expense{Currency: fields[4]}
+		var err error
+		e.At, err = time.ParseInLocation(TimeFormat, fields[0], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", i+1, err)
+		}
+		switch fields[1] {
+		case "event":
+		case "day":
+			e.Day = true
+		default:
+			return nil, fmt.Errorf("line %v: expected 'event' or 'day', found %q", i+1, fields[1])
+		}
+		e.Amount, err = strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: malformed amount: %w", i+1, err)
+		}
+		if math.IsNaN(e.Amount) || math.IsInf(e.Amount, 0) {
+			return nil, fmt.Errorf("line %v: malformed amount: %q", i+1, fields[3])
+		}
+		if strings.IndexFunc(e.Currency, unicode.IsSpace) >= 0 || strings.ContainsRune(e.Currency, '"') {
+			return nil, fmt.Errorf("line %v: malformed currency: %q", i+1, e.Currency)
+		}
+		for _, f := range []struct {
+			to   *string
+			from string
+			name string
+		}{{&e.Code, fields[2], "code"}, {&e.Note, fields[5], "note"}, {&e.Receipt, fields[6], "receipt"}} {
+			*f.to, err = strconv.Unquote(f.from)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: malformed %s: %w", i+1, f.name, err)
+			}
+		}
+		expenses = append(expenses, e)
+	}
+	sort.SliceStable(expenses, func(i, j int) bool {
+		return expenses[i].At.Before(expenses[j].At)
+	})
+	return expenses, nil
+}
+
+// FormatExpenses writes expenses in the ledger format read by ParseExpenses.
+func FormatExpenses(expenses []*Expense) string {
+	b := &strings.Builder{}
+	for _, e := range expenses {
+		kind := "event"
+		if e.Day {
+			kind = "day"
+		}
+		fmt.Fprintf(b, "%s\t%s\t%q\t%s\t%s\t%q\t%q\n", e.At.Format(TimeFormat), kind, e.Code, strconv.FormatFloat(e.Amount, 'f', -1, 64), e.Currency, e.Note, e.Receipt)
+	}
+	return b.String()
+}
+
+// ExpenseTotals adds up expenses for each currency, like [CurrencyTotals].
+func ExpenseTotals(expenses []*Expense) map[string]float64 {
+	totals := map[string]float64{}
+	for _, e := range expenses {
+		totals[e.Currency] = roundCents(totals[e.Currency] + e.Amount)
+	}
+	return totals
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseExpenses(t *testing.T) {
+	content := "# Expenses\n" +
+		"2026/10/13 02:30PM\tevent\t\"Acme:Dev\"\t12.5\tEUR\t\"Train ticket\"\t\"receipts/train.jpg\"\n" +
+		"\n" +
+		"2026/10/12 12:00AM\tday\t\"Bank\"\t-3\t\t\"Refund\"\t\"\"\r\n"
+	expenses, err := ParseExpenses(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Expense{
+		{At: time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local), Day: true, Code: "Bank", Amount: -3, Note: "Refund"},
+		{At: time.Date(2026, 10, 13, 14, 30, 0, 0, time.Local), Code: "Acme:Dev", Amount: 12.5, Currency: "EUR", Note: "Train ticket", Receipt: "receipts/train.jpg"},
+	}
+	if len(expenses) != len(want) {
+		t.Fatalf("got %d expenses, want %d", len(expenses), len(want))
+	}
+	for i, w := range want {
+		if g := *expenses[i]; !g.At.Equal(w.At) || g.Day != w.Day || g.Code != w.Code || g.Amount != w.Amount || g.Currency != w.Currency || g.Note != w.Note || g.Receipt != w.Receipt {
+			t.Errorf("expense %d is %+v, want %+v", i, g, w)
+		}
+	}
+
+	// Writing them out and reading them back changes nothing.
+	again, err := ParseExpenses(FormatExpenses(expenses))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if FormatExpenses(again) != FormatExpenses(expenses) {
+		t.Errorf("round trip gave\n%s\nwant\n%s", FormatExpenses(again), FormatExpenses(expenses))
+	}
+}
+
+func TestParseExpensesErrors(t *testing.T) {
+	good := []string{"2026/10/13 02:30PM", "event", `"Acme"`, "12.50", "EUR", `"Train"`, `""`}
+	tests := []struct {
+		field int
+		value string
+		err   string
+	}{
+		{0, "2026-10-13 14:30", "line 2: parsing time"},
+		{1, "week", "line 2: expected 'event' or 'day'"},
+		{2, "Acme", "line 2: malformed code"},
+		{3, "12,50", "line 2: malformed amount"},
+		{3, "", "line 2: malformed amount"},
+		{3, "NaN", "line 2: malformed amount"},
+		{3, "Inf", "line 2: malformed amount"},
+		{4, "E UR", "line 2: malformed currency"},
+		{4, `"EUR"`, "line 2: malformed currency"},
+		{5, `"Train`, "line 2: malformed note"},
+		{6, "receipt.jpg", "line 2: malformed receipt"},
+	}
+	for _, test := range tests {
+		fields := append([]string{}, good...)
+		fields[test.field] = test.value
+		_, err := ParseExpenses("\n" + strings.Join(fields, "\t"))
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("field %d as %q gave %v, want %q", test.field, test.value, err, test.err)
+		}
+	}
+
+	for _, n := range []int{6, 8} {
+		fields := append([]string{}, good...)
+		for len(fields) < n {
+			fields = append(fields, `""`)
+		}
+		_, err := ParseExpenses(strings.Join(fields[:n], "\t"))
+		if err == nil || !strings.Contains(err.Error(), "expected 7 fields") {
+			t.Errorf("%d fields gave %v", n, err)
+		}
+	}
+}
+
+func TestExpenseTotals(t *testing.T) {
+	totals := ExpenseTotals([]*Expense{
+		{Amount: 0.1, Currency: "EUR"},
+		{Amount: 0.2, Currency: "EUR"},
+		{Amount: 5, Currency: "USD"},
+		{Amount: 1.25},
+	})
+	want := map[string]float64{"EUR": 0.3, "USD": 5, "": 1.25}
+	if len(totals) != len(want) {
+		t.Fatalf("got %v, want %v", totals, want)
+	}
+	for currency, amount := range want {
+		if totals[currency] != amount {
+			t.Errorf("%q totals %v, want %v", currency, totals[currency], amount)
+		}
+	}
+}
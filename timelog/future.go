@@ -0,0 +1,61 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import "time"
+
+// FutureGrace is how far ahead of the clock an event can be and still not be in the future. Times are rounded to the
+// nearest 6 minutes, so an event for "now" is often a few minutes ahead.
+const FutureGrace = 6 * time.Minute
+
+// Future returns the events that are in the future, more than FutureGrace after now. These are almost always a typo in
+// the date, or a time that was read the wrong way.
+func (log TimeLog) Future(now time.Time) TimeLog {
+	return log.After(now.Add(FutureGrace))
+}
+
+// CapPeriods cuts periods off at now, so an event in the future by mistake doesn't add hours that haven't happened
+// yet. A period that ends more than FutureGrace after now is cut back to end at now, or dropped if it doesn't begin
+// until after now. The periods are modified, and the returned duration is how much time was cut.
+func CapPeriods(periods []*Period, now time.Time) ([]*Period, time.Duration) {
+	limit := now.Add(FutureGrace)
+	out := make([]*Period, 0, len(periods))
+	cut := time.Duration(0)
+	for _, p := range periods {
+		if !p.End.After(limit) {
+			out = append(out, p)
+			continue
+		}
+		if !p.Begin.Before(now) {
+			cut += p.End.Sub(p.Begin)
+			continue
+		}
+		cut += p.End.Sub(now)
+		p.End = now
+		if span := p.End.Sub(p.Begin); p.Excluded > span {
+			p.Excluded = span
+		}
+		out = append(out, p)
+	}
+	return out, cut
+}
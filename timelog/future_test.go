@@ -0,0 +1,107 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFuture(t *testing.T) {
+	now := at(14, 12, 0)
+	log := TimeLog{
+		{At: at(14, 9, 0), Code: "A"},
+		{At: now, Code: ""},
+		{At: now.Add(FutureGrace), Code: "B"},
+		{At: now.Add(FutureGrace + time.Minute), Code: ""},
+		{At: at(15, 9, 0), Code: "C"},
+	}
+	future := log.Future(now)
+	if len(future) != 2 || future[0] != log[3] || future[1] != log[4] {
+		t.Errorf("Future(%v) = %v, want the last two events", now, future)
+	}
+
+	// Out of order logs go through the slow path.
+	shuffled := TimeLog{log[4], log[0], log[2], log[3], log[1]}
+	if future := shuffled.Future(now); len(future) != 2 || future[0] != log[4] || future[1] != log[3] {
+		t.Errorf("Future on an unsorted log = %v, want the two future events in log order", future)
+	}
+
+	if future := log.Future(at(16, 0, 0)); len(future) != 0 {
+		t.Errorf("Future after the last event = %v", future)
+	}
+}
+
+func TestCapPeriods(t *testing.T) {
+	now := at(14, 12, 0)
+	tests := []struct {
+		name     string
+		in       *Period
+		kept     bool
+		end      time.Time
+		excluded time.Duration
+		cut      time.Duration
+	}{
+		{"past", period("A", at(14, 9, 0), at(14, 10, 0)), true, at(14, 10, 0), 0, 0},
+		{"ends now", period("A", at(14, 11, 0), now), true, now, 0, 0},
+		{"ends within the grace", period("A", at(14, 11, 0), now.Add(FutureGrace)), true, now.Add(FutureGrace), 0, 0},
+		{"ends past the grace", period("A", at(14, 11, 0), at(14, 13, 0)), true, now, 0, time.Hour},
+		{"begins now", period("A", now, at(14, 13, 0)), false, time.Time{}, 0, time.Hour},
+		{"begins later", period("A", at(15, 9, 0), at(15, 17, 0)), false, time.Time{}, 0, 8 * time.Hour},
+		{"breaks cut down", &Period{Code: "A", Begin: at(14, 11, 30), End: at(14, 14, 0), Excluded: time.Hour}, true, now, 30 * time.Minute, 2 * time.Hour},
+		{"breaks kept", &Period{Code: "A", Begin: at(14, 10, 0), End: at(14, 14, 0), Excluded: time.Hour}, true, now, time.Hour, 2 * time.Hour},
+	}
+	for _, test := range tests {
+		out, cut := CapPeriods([]*Period{test.in}, now)
+		if cut != test.cut {
+			t.Errorf("%s: cut %v, want %v", test.name, cut, test.cut)
+		}
+		if !test.kept {
+			if len(out) != 0 {
+				t.Errorf("%s: kept %v-%v", test.name, out[0].Begin, out[0].End)
+			}
+			continue
+		}
+		if len(out) != 1 {
+			t.Errorf("%s: dropped", test.name)
+			continue
+		}
+		if !out[0].End.Equal(test.end) || out[0].Excluded != test.excluded {
+			t.Errorf("%s: capped to end %v with %v excluded, want %v with %v", test.name, out[0].End, out[0].Excluded, test.end, test.excluded)
+		}
+	}
+
+	// The cuts add up across periods, and the order of what is kept doesn't change.
+	periods := []*Period{
+		period("A", at(14, 9, 0), at(14, 10, 0)),
+		period("B", at(14, 11, 0), at(14, 13, 0)),
+		period("C", at(14, 13, 0), at(14, 15, 0)),
+	}
+	out, cut := CapPeriods(periods, now)
+	if cut != 3*time.Hour || len(out) != 2 || out[0].Code != "A" || out[1].Code != "B" {
+		t.Errorf("CapPeriods cut %v and kept %v", cut, out)
+	}
+	if total := Totals(out, false)["A"] + Totals(out, false)["B"]; total != 2*time.Hour {
+		t.Errorf("capped periods total %v, want 2h", total)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+// Package gen makes up realistic looking timelogs, for benchmarks, working on report templates, and reproducing bugs
+// without having to hand over a real log.
+package gen
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// DefaultCodes are used when Options.Codes is empty.
+var DefaultCodes = []string{"Acme:Dev", "Acme:Meetings", "Internal", "Globex:Support", "Internal:Admin"}
+
+// DefaultDescs are used when Options.Descs is empty.
+var DefaultDescs = []string{"fixing bug", "code review", "standup", "planning", "emails", "deploy", "writing docs", ""}
+
+// Options describe the log to make up. Zero values get sensible defaults, except Noise, where zero is a perfectly
+// regular log.
+type Options struct {
+	Begin time.Time // The first day, the time of day is ignored.
+	Days  int       // How many days to make up.
+
+	Codes []string // The codes to pick from, earlier ones are picked more often.
+	Descs []string // The descriptions to pick from, a blank one means some periods have none.
+
+	Start    time.Duration // When a day usually starts, as time after midnight, 9AM by default.
+	Length   time.Duration // How long a day usually is, 8 hours by default.
+	Switches int           // How many periods a day usually has, not counting lunch, 4 by default.
+	Weekends bool          // Work on weekends too.
+
+	// How much everything wanders from the usual, from 0 to 1. At 1 days start and end up to an hour and a half
+	// either side of the usual, have anything from none to twice the usual switches, and one in ten is skipped.
+	Noise float64
+
+	Seed int64 // The same seed and options always make the same log.
+}
+
+// Generate makes up a timelog. Each day has a run of coded periods with a lunch break (a period without a code) in the
+// middle, unless it is under 5 hours, and ends with an event without a code. Times are rounded to 6 minutes like events typed in by hand.
+func Generate(opts Options) timelog.TimeLog {
+	if len(opts.Codes) == 0 {
+		opts.Codes = DefaultCodes
+	}
+	if len(opts.Descs) == 0 {
+		opts.Descs = DefaultDescs
+	}
+	if opts.Start == 0 {
+		opts.Start = 9 * time.Hour
+	}
+	if opts.Length == 0 {
+		opts.Length = 8 * time.Hour
+	}
+	if opts.Switches == 0 {
+		opts.Switches = 4
+	}
+
+	r := rand.New(rand.NewSource(opts.Seed))
+	// jitter is a random duration within scale either side of zero, scaled by the noise.
+	jitter := func(scale time.Duration) time.Duration {
+		return time.Duration((r.Float64()*2 - 1) * opts.Noise * float64(scale))
+	}
+	// Earlier codes get picked more, with weights of 1, 1/2, 1/3, and so on.
+	weights := make([]float64, len(opts.Codes))
+	total := 0.0
+	for i := range weights {
+		total += 1 / float64(i+1)
+		weights[i] = total
+	}
+	pick := func() string {
+		n := r.Float64() * total
+		return opts.Codes[sort.SearchFloat64s(weights, n)]
+	}
+
+	log := timelog.TimeLog{}
+	add := func(at time.Time, code, desc string) {
+		at = at.Round(6 * time.Minute)
+		if n := len(log); n > 0 && !at.After(log[n-1].At) {
+			return
+		}
+		log = append(log, &timelog.Event{At: at, Code: code, Desc: desc})
+	}
+
+	first := time.Date(opts.Begin.Year(), opts.Begin.Month(), opts.Begin.Day(), 0, 0, 0, 0, time.Local)
+	for i := 0; i < opts.Days; i++ {
+		day := first.AddDate(0, 0, i)
+		if wd := day.Weekday(); !opts.Weekends && (wd == time.Saturday || wd == time.Sunday) {
+			continue
+		}
+		if r.Float64() < opts.Noise/10 {
+			continue
+		}
+
+		start := day.Add(opts.Start + jitter(90*time.Minute))
+		end := start.Add(opts.Length + jitter(90*time.Minute))
+		lunch := start.Add(end.Sub(start)/2 + jitter(time.Hour))
+		lunchEnd := lunch.Add(30*time.Minute + time.Duration(r.Float64()*opts.Noise*float64(30*time.Minute)))
+
+		switches := opts.Switches + int(jitter(time.Duration(opts.Switches)))
+		if switches < 1 {
+			switches = 1
+		}
+		points := []time.Time{start}
+		for j := 1; j < switches; j++ {
+			points = append(points, start.Add(time.Duration(r.Int63n(int64(end.Sub(start))))))
+		}
+		sort.Slice(points, func(a, b int) bool { return points[a].Before(points[b]) })
+
+		// Short days don't get a lunch break.
+		lunched := end.Sub(start) < 5*time.Hour
+		for _, at := range points {
+			if !lunched && !at.Before(lunch) {
+				add(lunch, "", "lunch")
+				add(lunchEnd, pick(), opts.Descs[r.Intn(len(opts.Descs))])
+				lunched = true
+			}
+			if !lunched || at.After(lunchEnd) {
+				add(at, pick(), opts.Descs[r.Intn(len(opts.Descs))])
+			}
+		}
+		if !lunched {
+			add(lunch, "", "lunch")
+			add(lunchEnd, pick(), opts.Descs[r.Intn(len(opts.Descs))])
+		}
+		// Whatever happens the day has to end, even if that means the last period never really started.
+		if n := len(log); n > 0 && !end.Round(6*time.Minute).After(log[n-1].At) {
+			log[n-1].Code, log[n-1].Desc = "", ""
+		} else {
+			add(end, "", "")
+		}
+	}
+	return log
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"time"
+)
+
+// HeatMap is the time tracked in each hour of each day of the week, with Monday first.
+type HeatMap [7][24]time.Duration
+
+// Max is the most time in any one hour.
+func (m *HeatMap) Max() time.Duration {
+	var max time.Duration
+	for _, day := range m {
+		for _, d := range day {
+			if d > max {
+				max = d
+			}
+		}
+	}
+	return max
+}
+
+// Weekday is the row for the given day.
+func (m *HeatMap) Weekday(day time.Weekday) *[24]time.Duration {
+	return &m[(int(day)+6)%7]
+}
+
+// HourlyHeatMap adds up the periods by the hour of the day and day of the week they happened in, in local time. Periods
+// are split at each hour, and excluded time is taken out of each piece evenly, since there is no telling when it was.
+func HourlyHeatMap(periods []*Period) *HeatMap {
+	m := &HeatMap{}
+	for _, p := range periods {
+		whole := p.End.Sub(p.Begin)
+		if whole <= 0 {
+			continue
+		}
+		counted := float64(p.Length()) / float64(whole)
+
+		begin := p.Begin.Local()
+		end := p.End.Local()
+		for begin.Before(end) {
+			next := time.Date(begin.Year(), begin.Month(), begin.Day(), begin.Hour()+1, 0, 0, 0, begin.Location())
+			if next.After(end) {
+				next = end
+			}
+			m.Weekday(begin.Weekday())[begin.Hour()] += time.Duration(float64(next.Sub(begin)) * counted)
+			begin = next
+		}
+	}
+	return m
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+// LogKey is the metadata key for the name of the log an event came from, when several are merged, see MergeLogs.
+const LogKey = "log"
+
+// NamedLog is a timelog with a name, eg one of the logs in the logs config.
+type NamedLog struct {
+	Name string
+	Log  TimeLog
+}
+
+// MergeLogs merges named logs into one, sorted. Each event gets its log's name in LogKey, and is moved onto a track of
+// the log's own (the main track becomes the log's name, and any other track becomes name/track), so the events in one
+// log never cut the periods in another short. The events are changed in place, so don't write the logs back after.
+func MergeLogs(logs []NamedLog) TimeLog {
+	merged := TimeLog{}
+	for _, l := range logs {
+		for _, e := range l.Log {
+			meta := make(map[string]string, len(e.Meta)+1)
+			for k, v := range e.Meta {
+				meta[k] = v
+			}
+			meta[LogKey] = l.Name
+			e.Meta = meta
+
+			if e.Track == "" {
+				e.Track = l.Name
+			} else {
+				e.Track = l.Name + "/" + e.Track
+			}
+			merged = append(merged, e)
+		}
+	}
+	merged.Sort()
+	return merged
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeLogs(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 12, hour, minute, 0, 0, time.Local)
+	}
+	client := TimeLog{
+		{At: at(9, 0), Code: "Acme:Dev", Meta: map[string]string{"ticket": "42"}},
+		{At: at(11, 0)},
+		{At: at(9, 30), Track: "build", Code: "CI"},
+		{At: at(10, 0), Track: "build"},
+	}
+	personal := TimeLog{
+		{At: at(10, 0), Code: "Reading"},
+		{At: at(10, 30)},
+	}
+	shared := client[0].Meta
+
+	merged := MergeLogs([]NamedLog{{Name: "client", Log: client}, {Name: "personal", Log: personal}})
+	if len(merged) != 6 || len(merged.OutOfOrder()) != 0 {
+		t.Fatalf("merged log isn't the 6 events in order:\n%s", merged)
+	}
+	for _, e := range merged {
+		log := e.Meta[LogKey]
+		if log != "client" && log != "personal" {
+			t.Errorf("%s has no log name", e)
+		}
+		if e.Code == "CI" && e.Track != "client/build" {
+			t.Errorf("a track of the client log became %q, want client/build", e.Track)
+		}
+		if e.Code == "Reading" && e.Track != "personal" {
+			t.Errorf("the main track of the personal log became %q, want personal", e.Track)
+		}
+	}
+	if shared["ticket"] != "42" || shared[LogKey] != "" {
+		t.Errorf("the original metadata map was changed: %v", shared)
+	}
+	if merged[0].Meta["ticket"] != "42" {
+		t.Errorf("metadata was lost: %v", merged[0].Meta)
+	}
+
+	// The personal log's events at 10 and 10:30 don't cut the client's period from 9 to 11 short.
+	lengths := map[string]time.Duration{}
+	for _, p := range merged.Periods() {
+		lengths[p.Code] += p.Length()
+	}
+	want := map[string]time.Duration{"Acme:Dev": 2 * time.Hour, "CI": 30 * time.Minute, "Reading": 30 * time.Minute}
+	for code, d := range want {
+		if lengths[code] != d {
+			t.Errorf("%s has %v, want %v", code, lengths[code], d)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Migration upgrades the text of a timelog from one format version to the next.
+//
+// Migrations must keep every line where it is, so line numbers in parse errors still point at the right place in the
+// file. Put a changed line where the old one was, and leave a blank line or comment for one that was removed.
+type Migration struct {
+	From int    // The version it upgrades from, to From+1.
+	What string // What changes, for the migrate command.
+
+	// Automatic migrations don't change anything an older version would misread, so a log is upgraded just by writing
+	// it back. Anything else has to be done with the migrate command, which backs up and verifies the log first.
+	Automatic bool
+
+	Upgrade func(input string) (string, error)
+}
+
+// Migrations upgrade each version of the format to the next, in order. Every version back to 0 must have one, that is
+// how the parser can still read logs from all of them.
+var Migrations = []Migration{
+	{
+		// Version 0 is everything before there was a header. Logs written by timeclock from before then may have
+		// metadata and multi-line descriptions, but the oldest versions ignored indentation, so an indented event or
+		// comment has to stay one rather than becoming part of the description before it.
+		From:      0,
+		What:      "add the format version header, and unindent indented events and comments so they stay events and comments",
+		Automatic: true,
+		Upgrade:   unindentEvents,
+	},
+}
+
+// unindentEvents takes the indentation off lines that the oldest versions read as events or comments, which ignored
+// any leading white space. Anything else indented is left to be a description or metadata line, those versions
+// couldn't read it anyway.
+func unindentEvents(input string) (string, error) {
+	lines := strings.Split(input, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == line || strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			lines[i] = trimmed
+			continue
+		}
+		if _, err := parseEvent(newLineReader(strings.TrimSuffix(trimmed, "\r"), i+1)); err == nil {
+			lines[i] = trimmed
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// PendingMigrations returns the migrations a log in the given format version needs to be brought up to date.
+func PendingMigrations(version int) []Migration {
+	pending := []Migration{}
+	for _, m := range Migrations {
+		if m.From >= version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// UpgradeText runs the migrations the text of a timelog needs to be read in the current format version. The header is
+// left as it is, [TimeLog.FormatFile] writes the new one. Text in the current version, or a newer one, is returned as
+// it is.
+func UpgradeText(input string) (string, error) {
+	var err error
+	for _, m := range PendingMigrations(FormatVersionOf(input)) {
+		input, err = m.Upgrade(input)
+		if err != nil {
+			return "", fmt.Errorf("upgrading the timelog from format version %d: %w", m.From, err)
+		}
+	}
+	return input, nil
+}
+
+// VerifyFormat checks that the log reads back exactly the same once it is written, so nothing is lost by writing it.
+func (log TimeLog) VerifyFormat() error {
+	before := &bytes.Buffer{}
+	err := log.FormatFile(before)
+	if err != nil {
+		return err
+	}
+	reread, err := ParseTimeLogString(before.String())
+	if err != nil {
+		return fmt.Errorf("the log doesn't read back: %w", err)
+	}
+	if len(reread) != len(log) {
+		return fmt.Errorf("the log reads back with %d events instead of %d", len(reread), len(log))
+	}
+	after := &bytes.Buffer{}
+	err = reread.FormatFile(after)
+	if err != nil {
+		return err
+	}
+	if before.String() != after.String() {
+		for i := range log {
+			if log[i].String() != reread[i].String() {
+				return fmt.Errorf("%s reads back as %s", log[i].String(), reread[i].String())
+			}
+		}
+		return fmt.Errorf("the log doesn't read back the same")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"testing"
+)
+
+func TestUnindentVersion0(t *testing.T) {
+	body := "2026/10/12 09:00AM [Proj] first\n" +
+		"\tsecond line\n" +
+		"\t2026/10/12 10:00AM [Ops] indented\n" +
+		"  # a comment\n" +
+		"2026/10/12 11:00AM []\n"
+
+	tests := []struct {
+		name  string
+		input string
+		descs []string
+	}{
+		// The oldest versions ignored indentation, so the indented event and comment stay what they were.
+		{"version 0", body, []string{"first\nsecond line", "indented", ""}},
+		{"version 1", HeaderPrefix + "1\n" + body, []string{"first\nsecond line\n2026/10/12 10:00AM [Ops] indented\n# a comment", ""}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log, err := ParseTimeLogString(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(log) != len(test.descs) {
+				t.Fatalf("got %d events, want %d:\n%s", len(log), len(test.descs), log.String())
+			}
+			for i, e := range log {
+				if e.Desc != test.descs[i] {
+					t.Errorf("event %d: got description %q, want %q", i, e.Desc, test.descs[i])
+				}
+			}
+		})
+	}
+}
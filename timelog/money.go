@@ -0,0 +1,212 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Charge is the amount owed for some billed time on a single code.
+type Charge struct {
+	Code     string
+	Currency string
+	Rate     float64 // Per hour.
+	Billed   time.Duration
+	Amount   float64 // Billed time at the rate.
+	Overage  bool    // The code has a retainer, so only the time over the retainer is charged for.
+
+	TaxName      string
+	TaxRate      float64 // As a percentage, 0 if the code isn't taxed.
+	TaxInclusive bool    // If the rate (and so Amount) already includes the tax.
+	Tax          float64 // The tax part of Total.
+	Total        float64 // Everything owed, including tax.
+}
+
+// Net is the amount owed before tax.
+func (c *Charge) Net() float64 {
+	return c.Total - c.Tax
+}
+
+// applyTax works out the tax and total for a charge.
+func (c *Charge) applyTax(name string, rate float64, inclusive bool) {
+	c.TaxName, c.TaxRate, c.TaxInclusive = name, rate, inclusive
+	switch {
+	case rate <= 0:
+		c.Tax = 0
+		c.Total = c.Amount
+	case inclusive:
+		c.Tax = roundCents(c.Amount - c.Amount/(1+rate/100))
+		c.Total = c.Amount
+	default:
+		c.Tax = roundCents(c.Amount * rate / 100)
+		c.Total = c.Amount + c.Tax
+	}
+}
+
+// TaxLine totals the tax on a set of charges with the same tax rule and currency.
+type TaxLine struct {
+	Name      string
+	Rate      float64 // As a percentage.
+	Inclusive bool
+	Currency  string
+	Net       float64 // The amount the tax is charged on.
+	Tax       float64
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ChargeFor works out what d costs at the given hourly rate, rounded to the nearest cent (or whatever the smallest
+// unit in the currency happens to be, close enough).
+func ChargeFor(d time.Duration, rate float64) float64 {
+	return roundCents(d.Hours() * rate)
+}
+
+// Charges works out what is owed for each code given how much of each was billed, including any tax. Codes without a
+// rate are not charged for. The result is sorted by code.
+func (info CodeInfo) Charges(billed map[string]time.Duration) []*Charge {
+	charges := []*Charge{}
+	for code, d := range billed {
+		rate, currency, ok := info.Rate(code)
+		if !ok || d == 0 {
+			continue
+		}
+		c := &Charge{
+			Code:     code,
+			Currency: currency,
+			Rate:     rate,
+			Billed:   d,
+			Amount:   ChargeFor(d, rate),
+		}
+		if name, rate, inclusive, ok := info.Tax(code); ok {
+			c.applyTax(name, rate, inclusive)
+		} else {
+			c.applyTax("", 0, false)
+		}
+		charges = append(charges, c)
+	}
+	sort.Slice(charges, func(i, j int) bool {
+		return charges[i].Code < charges[j].Code
+	})
+	return charges
+}
+
+// CurrencyTotals adds up a set of charges (including tax) for each currency. Different currencies are never added
+// together here, use [ExchangeRates] for that.
+func CurrencyTotals(charges []*Charge) map[string]float64 {
+	totals := map[string]float64{}
+	for _, c := range charges {
+		totals[c.Currency] = roundCents(totals[c.Currency] + c.Total)
+	}
+	return totals
+}
+
+// TaxLines totals the tax on a set of charges, with a line for each combination of tax rule and currency. Untaxed
+// charges are left out. The result is sorted by currency, then name, then rate.
+func TaxLines(charges []*Charge) []*TaxLine {
+	type key struct {
+		name, currency string
+		rate           float64
+		inclusive      bool
+	}
+	lines := []*TaxLine{}
+	found := map[key]*TaxLine{}
+	for _, c := range charges {
+		if c.TaxRate <= 0 {
+			continue
+		}
+		k := key{c.TaxName, c.Currency, c.TaxRate, c.TaxInclusive}
+		l, ok := found[k]
+		if !ok {
+			l = &TaxLine{Name: c.TaxName, Rate: c.TaxRate, Inclusive: c.TaxInclusive, Currency: c.Currency}
+			found[k] = l
+			lines = append(lines, l)
+		}
+		l.Net = roundCents(l.Net + c.Net())
+		l.Tax = roundCents(l.Tax + c.Tax)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Currency != lines[j].Currency {
+			return lines[i].Currency < lines[j].Currency
+		}
+		if lines[i].Name != lines[j].Name {
+			return lines[i].Name < lines[j].Name
+		}
+		return lines[i].Rate < lines[j].Rate
+	})
+	return lines
+}
+
+// ExchangeRates is a user supplied table for converting between currencies. Rates holds how much one unit of each
+// currency is worth in the Base currency.
+type ExchangeRates struct {
+	Base  string
+	Rates map[string]float64
+}
+
+// ParseExchangeRates builds an exchange rate table from a set of settings, where "base" names the base currency and
+// every other key is a currency with its value in the base currency. Malformed rates are skipped.
+func ParseExchangeRates(settings map[string]string) *ExchangeRates {
+	rates := &ExchangeRates{Base: settings["base"], Rates: map[string]float64{}}
+	for k, v := range settings {
+		if k == "base" {
+			continue
+		}
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r <= 0 {
+			continue
+		}
+		rates.Rates[k] = r
+	}
+	return rates
+}
+
+// Convert converts an amount into the base currency. It fails if the table doesn't know the currency.
+func (rates *ExchangeRates) Convert(amount float64, currency string) (float64, bool) {
+	if currency == rates.Base {
+		return amount, true
+	}
+	r, ok := rates.Rates[currency]
+	if !ok {
+		return 0, false
+	}
+	return amount * r, true
+}
+
+// Combine converts a set of per currency totals into a single total in the base currency. It fails if any of the
+// currencies can't be converted.
+func (rates *ExchangeRates) Combine(totals map[string]float64) (float64, bool) {
+	sum := 0.0
+	for currency, amount := range totals {
+		v, ok := rates.Convert(amount, currency)
+		if !ok {
+			return 0, false
+		}
+		sum += v
+	}
+	return roundCents(sum), true
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"sort"
+	"sync"
+)
+
+// Change is a log being saved to a [Store], passed to the functions given to OnChange.
+type Change struct {
+	Before TimeLog // The log as the store last had it, nil if it hadn't been loaded or saved before.
+	After  TimeLog // The log that was saved.
+
+	Added   []*Event // Events in After that weren't in Before, in order.
+	Removed []*Event // Events in Before that aren't in After, in order. An edited event is removed and added.
+}
+
+// NewChange works out what changed between two versions of a log. Events are the same if they would be written the
+// same, see [TimeLog.Format].
+func NewChange(before, after TimeLog) Change {
+	c := Change{Before: before, After: after}
+	count := map[string]int{}
+	for _, e := range before {
+		count[e.format(0, 0)]++
+	}
+	for _, e := range after {
+		k := e.format(0, 0)
+		if count[k] > 0 {
+			count[k]--
+			continue
+		}
+		c.Added = append(c.Added, e)
+	}
+	for _, e := range before {
+		k := e.format(0, 0)
+		if count[k] > 0 {
+			count[k]--
+			c.Removed = append(c.Removed, e)
+		}
+	}
+	return c
+}
+
+// Empty is true if nothing was added or removed.
+func (c Change) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// Notifier keeps track of the functions to call when a store changes. Stores embed one to get OnChange.
+type Notifier struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]func(Change)
+}
+
+// OnChange calls f after every save, with what changed. The returned function stops the calls. Only saves made through
+// this store are seen, not changes made to the file by something else.
+func (n *Notifier) OnChange(f func(Change)) (cancel func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.subs == nil {
+		n.subs = map[int]func(Change){}
+	}
+	id := n.next
+	n.next++
+	n.subs[id] = f
+	return func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.subs, id)
+	}
+}
+
+// Notify calls every function given to OnChange, in the order they were added. It is called by stores after a save.
+func (n *Notifier) Notify(c Change) {
+	n.mu.Lock()
+	ids := make([]int, 0, len(n.subs))
+	for id := range n.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	subs := make([]func(Change), len(ids))
+	for i, id := range ids {
+		subs[i] = n.subs[id]
+	}
+	n.mu.Unlock()
+
+	for _, f := range subs {
+		f(c)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OverlapPolicy is what [TimeLog.PeriodsWith] does with events on the same track at the same time. Each event's period
+// runs until the next event, so events at the same time overlap completely, and only one of them can have the time.
+// This never happens with a log kept by hand, but merging in events from somewhere else can easily do it.
+type OverlapPolicy int
+
+const (
+	// OverlapClip lets each event clip the one before, so the last one in the log gets the time and the others get
+	// empty periods.
+	OverlapClip OverlapPolicy = iota
+
+	// OverlapFirstWins gives the time to the first event in the log, the others are left out.
+	OverlapFirstWins
+
+	// OverlapFail refuses to report at all until the overlaps are fixed, see [TimeLog.CheckedPeriods]. PeriodsWith
+	// itself can't fail, so it clips.
+	OverlapFail
+)
+
+// ParseOverlapPolicy converts the name of an overlap policy ("clip", "first-wins", or "error") to an OverlapPolicy.
+func ParseOverlapPolicy(name string) (OverlapPolicy, error) {
+	switch name {
+	case "clip", "":
+		return OverlapClip, nil
+	case "first-wins":
+		return OverlapFirstWins, nil
+	case "error":
+		return OverlapFail, nil
+	}
+	return OverlapClip, fmt.Errorf("unknown overlap policy %q, expected 'clip', 'first-wins', or 'error'", name)
+}
+
+// EventOverlap is a set of events on the same track at the same time, in log order.
+type EventOverlap struct {
+	Track  string
+	At     time.Time
+	Events TimeLog
+}
+
+func (o EventOverlap) String() string {
+	return fmt.Sprintf("%s has %d events at once: %s", o.Where(), len(o.Events), o.What())
+}
+
+// Where is the time of the overlap, and the track if it isn't the default one.
+func (o EventOverlap) Where() string {
+	where := o.At.Format(TimeFormat)
+	if o.Track != "" {
+		where += " @" + o.Track
+	}
+	return where
+}
+
+// What lists the code and description of each event.
+func (o EventOverlap) What() string {
+	what := make([]string, 0, len(o.Events))
+	for _, e := range o.Events {
+		what = append(what, fmt.Sprintf("[%s] %s", e.Code, e.Desc))
+	}
+	return strings.Join(what, ", ")
+}
+
+// Overlaps finds every place where events on the same track are at the same time, sorted by time. If it is not
+// already, the TimeLog will be sorted!
+func (log TimeLog) Overlaps() []EventOverlap {
+	log.Sort()
+
+	out := []EventOverlap{}
+	open := map[string]int{} // Where the last overlap for each track is in out, while it might still grow.
+	last := map[string]*Event{}
+	for _, item := range log {
+		prev := last[item.Track]
+		last[item.Track] = item
+		if prev == nil || !prev.At.Equal(item.At) {
+			delete(open, item.Track)
+			continue
+		}
+		if i, ok := open[item.Track]; ok {
+			out[i].Events = append(out[i].Events, item)
+			continue
+		}
+		open[item.Track] = len(out)
+		out = append(out, EventOverlap{Track: item.Track, At: item.At, Events: TimeLog{prev, item}})
+	}
+	return out
+}
+
+// ErrOverlaps is returned by [TimeLog.CheckedPeriods] when the policy is OverlapFail and there are overlaps.
+type ErrOverlaps []EventOverlap
+
+func (err ErrOverlaps) Error() string {
+	return fmt.Sprintf("%d place(s) where events on the same track are at the same time, first at %s", len(err), err[0].At.Format(TimeFormat))
+}
+
+// CheckedPeriods is [TimeLog.PeriodsWith], but fails with ErrOverlaps if the policy is OverlapFail and there are any
+// overlaps.
+func (log TimeLog) CheckedPeriods(policy OverlapPolicy) ([]*Period, error) {
+	if policy == OverlapFail {
+		if overlaps := log.Overlaps(); len(overlaps) > 0 {
+			return nil, ErrOverlaps(overlaps)
+		}
+	}
+	return log.PeriodsWith(policy), nil
+}
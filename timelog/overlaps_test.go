@@ -0,0 +1,121 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// overlapLog has two events at once on the default track, three at once on "side", and one on "other" at the same
+// time as the first two, which isn't an overlap.
+func overlapLog() TimeLog {
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	return TimeLog{
+		{At: at, Code: "A", Desc: "a"},
+		{At: at, Code: "B", Desc: "b"},
+		{At: at, Track: "other", Code: "C"},
+		{At: at.Add(time.Hour)},
+		{At: at.Add(time.Hour), Track: "other"},
+		{At: at.Add(2 * time.Hour), Track: "side", Code: "D"},
+		{At: at.Add(2 * time.Hour), Track: "side", Code: "E"},
+		{At: at.Add(2 * time.Hour), Track: "side", Code: "F"},
+		{At: at.Add(3 * time.Hour), Track: "side"},
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	overlaps := overlapLog().Overlaps()
+	if len(overlaps) != 2 {
+		t.Fatalf("found %d overlaps, want 2: %v", len(overlaps), overlaps)
+	}
+	for i, want := range []struct {
+		track string
+		codes string
+	}{{"", "A B"}, {"side", "D E F"}} {
+		codes := []string{}
+		for _, e := range overlaps[i].Events {
+			codes = append(codes, e.Code)
+		}
+		if overlaps[i].Track != want.track || strings.Join(codes, " ") != want.codes {
+			t.Errorf("overlap %d is %q on @%s, want %q on @%s", i, codes, overlaps[i].Track, want.codes, want.track)
+		}
+	}
+}
+
+func TestPeriodsWith(t *testing.T) {
+	tests := []struct {
+		policy OverlapPolicy
+		want   string // The code and length of each period that isn't empty, in order.
+	}{
+		{OverlapClip, "B 1h0m0s, C 1h0m0s, F 1h0m0s"},
+		{OverlapFirstWins, "A 1h0m0s, C 1h0m0s, D 1h0m0s"},
+		{OverlapFail, "B 1h0m0s, C 1h0m0s, F 1h0m0s"},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, p := range overlapLog().PeriodsWith(test.policy) {
+			if d := p.End.Sub(p.Begin); d > 0 {
+				got = append(got, p.Code+" "+d.String())
+			}
+		}
+		if strings.Join(got, ", ") != test.want {
+			t.Errorf("policy %d gives %q, want %q", test.policy, strings.Join(got, ", "), test.want)
+		}
+	}
+
+	if overlapLog().Periods()[0].Code != "A" || overlapLog().Periods()[0].End != overlapLog()[0].At {
+		t.Error("Periods doesn't clip")
+	}
+}
+
+func TestCheckedPeriods(t *testing.T) {
+	_, err := overlapLog().CheckedPeriods(OverlapFail)
+	var overlaps ErrOverlaps
+	if !errors.As(err, &overlaps) || len(overlaps) != 2 {
+		t.Errorf("got %v, want ErrOverlaps with 2 overlaps", err)
+	}
+
+	for _, policy := range []OverlapPolicy{OverlapClip, OverlapFirstWins} {
+		if _, err := overlapLog().CheckedPeriods(policy); err != nil {
+			t.Errorf("policy %d: %v", policy, err)
+		}
+	}
+	if _, err := overlapLog()[3:5].CheckedPeriods(OverlapFail); err != nil {
+		t.Errorf("a log without overlaps: %v", err)
+	}
+}
+
+func TestParseOverlapPolicy(t *testing.T) {
+	for name, want := range map[string]OverlapPolicy{"": OverlapClip, "clip": OverlapClip, "first-wins": OverlapFirstWins, "error": OverlapFail} {
+		got, err := ParseOverlapPolicy(name)
+		if err != nil || got != want {
+			t.Errorf("ParseOverlapPolicy(%q) = %d, %v, want %d", name, got, err, want)
+		}
+	}
+	if _, err := ParseOverlapPolicy("last-wins"); err == nil {
+		t.Error("an unknown policy parsed")
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Overtime is when worked time starts counting as overtime, for employers that pay it. A zero limit isn't used.
+type Overtime struct {
+	Day  time.Duration // Time past this in a day is overtime.
+	Week time.Duration // Time past this in a week is overtime, not counting what was already overtime for its day.
+}
+
+// ParseOvertime parses the overtime config, a comma separated list of day=<duration> and week=<duration>, eg
+// "day=8h,week=40h".
+func ParseOvertime(config string) (Overtime, error) {
+	ot := Overtime{}
+	for _, rule := range strings.Split(config, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(rule, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(arg))
+		if err != nil || d <= 0 {
+			return ot, fmt.Errorf("overtime rule %q needs a positive duration, such as %s=8h", rule, strings.TrimSpace(name))
+		}
+		switch strings.TrimSpace(name) {
+		case "day":
+			ot.Day = d
+		case "week":
+			ot.Week = d
+		default:
+			return ot, fmt.Errorf("unknown overtime rule %q, expected 'day' or 'week'", name)
+		}
+	}
+	return ot, nil
+}
+
+// Empty is true if neither limit is set.
+func (o Overtime) Empty() bool {
+	return o == Overtime{}
+}
+
+// Of returns how much of a week is overtime, given the time worked on each day of it. Daily overtime is taken out
+// first, so the same hour is never counted twice.
+func (o Overtime) Of(days []time.Duration) time.Duration {
+	var daily, total time.Duration
+	for _, d := range days {
+		total += d
+		if o.Day > 0 && d > o.Day {
+			daily += d - o.Day
+		}
+	}
+	if o.Week > 0 && total-daily > o.Week {
+		return total - o.Week
+	}
+	return daily
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOvertime(t *testing.T) {
+	tests := []struct {
+		config string
+		want   Overtime
+		err    bool
+	}{
+		{"", Overtime{}, false},
+		{"day=8h", Overtime{Day: 8 * time.Hour}, false},
+		{"week=40h", Overtime{Week: 40 * time.Hour}, false},
+		{" day = 7h30m , week=37.5h ", Overtime{Day: 7*time.Hour + 30*time.Minute, Week: 37*time.Hour + 30*time.Minute}, false},
+		{"day=8h,,", Overtime{Day: 8 * time.Hour}, false},
+		{"day=8h,day=9h", Overtime{Day: 9 * time.Hour}, false},
+		{"day", Overtime{}, true},
+		{"day=", Overtime{}, true},
+		{"day=0h", Overtime{}, true},
+		{"week=-1h", Overtime{}, true},
+		{"day=eight", Overtime{}, true},
+		{"month=160h", Overtime{}, true},
+		{"Day=8h", Overtime{}, true},
+	}
+	for _, test := range tests {
+		got, err := ParseOvertime(test.config)
+		if (err != nil) != test.err {
+			t.Errorf("ParseOvertime(%q) error = %v, want error %v", test.config, err, test.err)
+			continue
+		}
+		if err == nil && got != test.want {
+			t.Errorf("ParseOvertime(%q) = %+v, want %+v", test.config, got, test.want)
+		}
+	}
+}
+
+func TestOvertimeOf(t *testing.T) {
+	h := func(hours ...float64) []time.Duration {
+		days := []time.Duration{}
+		for _, n := range hours {
+			days = append(days, time.Duration(n*float64(time.Hour)))
+		}
+		return days
+	}
+	daily := Overtime{Day: 8 * time.Hour}
+	weekly := Overtime{Week: 40 * time.Hour}
+	both := Overtime{Day: 8 * time.Hour, Week: 40 * time.Hour}
+
+	tests := []struct {
+		name string
+		ot   Overtime
+		days []time.Duration
+		want float64 // Hours.
+	}{
+		{"no limits", Overtime{}, h(12, 12, 12, 12, 12), 0},
+		{"empty week", both, nil, 0},
+		{"daily under", daily, h(8, 8, 8, 8, 8), 0},
+		{"daily over", daily, h(10, 8, 9.5, 0, 0), 3.5},
+		{"weekly under", weekly, h(12, 12, 12), 0},
+		{"weekly over", weekly, h(10, 10, 10, 10, 5), 5},
+		{"weekly on the limit", weekly, h(8, 8, 8, 8, 8), 0},
+		// 2h of the Monday is daily overtime, which leaves 38h towards the week, so nothing more.
+		{"both, daily only", both, h(10, 8, 8, 8, 6), 2},
+		// 2h daily, then the other 44h is 4h past the week, 6h in all, never the same hour twice.
+		{"both, daily and weekly", both, h(10, 8, 8, 8, 8, 4), 6},
+		{"both, weekly from a sixth day", both, h(8, 8, 8, 8, 8, 6), 6},
+	}
+	for _, test := range tests {
+		got := test.ot.Of(test.days)
+		if want := time.Duration(test.want * float64(time.Hour)); got != want {
+			t.Errorf("%s: Of(%v) = %v, want %v", test.name, test.days, got, want)
+		}
+	}
+}
+
+func TestOvertimeEmpty(t *testing.T) {
+	if !(Overtime{}).Empty() {
+		t.Error("zero Overtime isn't empty")
+	}
+	if (Overtime{Week: time.Hour}).Empty() || (Overtime{Day: time.Hour}).Empty() {
+		t.Error("Overtime with a limit is empty")
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Budget finds the weekly budget that covers a code, set with "budget=10h", and the code it was set on. Like
+// retainers, children share their parent's budget rather than each getting one of their own.
+func (info CodeInfo) Budget(code string) (string, time.Duration, bool) {
+	for {
+		if v, ok := info[code]["budget"]; ok {
+			hours, err := time.ParseDuration(v)
+			if err != nil || hours <= 0 {
+				return "", 0, false
+			}
+			return code, hours, true
+		}
+
+		i := strings.LastIndex(code, ":")
+		if i == -1 {
+			return "", 0, false
+		}
+		code = code[:i]
+	}
+}
+
+// Projection is the time for a code in a range, part of it already done and the rest still planned.
+type Projection struct {
+	Code    string
+	Actual  time.Duration // Time in the timelog, up to now.
+	Planned time.Duration // Time in the plan, from now on.
+}
+
+// Projected is all the time expected by the end of the range.
+func (p *Projection) Projected() time.Duration {
+	return p.Actual + p.Planned
+}
+
+// Project works out the time for each code between from and to, taking what actually happened up to now from the
+// timelog and what is planned after now from the plan. Anything still running in the timelog counts up to now, and
+// planned time before now is ignored, the timelog has what really happened instead. The result is sorted by code, and
+// leaves out time with no code. Events on the same track at the same time are handled by policy.
+func Project(log, plan TimeLog, from, to, now time.Time, policy OverlapPolicy) []*Projection {
+	rows := map[string]*Projection{}
+	row := func(code string) *Projection {
+		if rows[code] == nil {
+			rows[code] = &Projection{Code: code}
+		}
+		return rows[code]
+	}
+
+	// Close off anything still running, so it counts.
+	open := TimeLog{}
+	for track, e := range log.lastOnEachTrack() {
+		if e.Code != "" && e.At.Before(now) {
+			open = append(open, &Event{At: now, Track: track})
+		}
+	}
+	for _, p := range append(append(TimeLog{}, log...), open...).PeriodsWith(policy) {
+		if p.Code != "" {
+			row(p.Code).Actual += clip(p, from, earliest(now, to))
+		}
+	}
+	for _, p := range plan.PeriodsWith(policy) {
+		if p.Code != "" {
+			row(p.Code).Planned += clip(p, latest(now, from), to)
+		}
+	}
+
+	out := []*Projection{}
+	for _, r := range rows {
+		if r.Actual > 0 || r.Planned > 0 {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Code < out[j].Code
+	})
+	return out
+}
+
+// lastOnEachTrack returns the last event on each track.
+func (log TimeLog) lastOnEachTrack() map[string]*Event {
+	last := map[string]*Event{}
+	for _, e := range log {
+		if prev, ok := last[e.Track]; !ok || !e.At.Before(prev.At) {
+			last[e.Track] = e
+		}
+	}
+	return last
+}
+
+// clip is how much of the period falls between from and to.
+func clip(p *Period, from, to time.Time) time.Duration {
+	begin, end := latest(p.Begin, from), earliest(p.End, to)
+	if !end.After(begin) {
+		return 0
+	}
+	return end.Sub(begin)
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"sort"
+	"time"
+)
+
+// SummaryDesc is the description given to the events Purge makes to stand in for a day's work.
+const SummaryDesc = "Daily total"
+
+// Purge returns the log without anything before the cutoff, along with how many events were removed.
+//
+// With summarize set, each day before the cutoff is replaced with one period for each code (on each track) as long as
+// all the time for that code on that day, so totals still come out the same. The periods start at midnight and run
+// back to back, and keep nothing else, no descriptions and no metadata. Events at the same time are counted by policy,
+// and days are the days of cal, the same as in a report.
+//
+// Whatever was running at the cutoff carries on from a new event at the cutoff, so nothing after it is lost.
+func (log TimeLog) Purge(cutoff time.Time, summarize bool, policy OverlapPolicy, cal Calendar) (TimeLog, int) {
+	log.Sort()
+	first := sort.Search(len(log), func(i int) bool {
+		return !log[i].At.Before(cutoff)
+	})
+	out := TimeLog{}
+
+	if summarize {
+		type bucket struct {
+			day   time.Time
+			track string
+		}
+		totals := map[bucket]map[string]time.Duration{}
+		buckets := []bucket{}
+		for _, p := range (SplitMidnight{Calendar: cal}).Transform(log.PeriodsWith(policy)) {
+			if p.Code == "" || !p.Begin.Before(cutoff) {
+				continue
+			}
+			part := *p
+			if part.End.After(cutoff) {
+				part.End = cutoff
+			}
+			b := bucket{cal.Day(part.Begin), part.Track}
+			if totals[b] == nil {
+				totals[b] = map[string]time.Duration{}
+				buckets = append(buckets, b)
+			}
+			totals[b][part.Code] += part.Length()
+		}
+
+		for _, b := range buckets {
+			codes := make([]string, 0, len(totals[b]))
+			for code := range totals[b] {
+				codes = append(codes, code)
+			}
+			sort.Strings(codes)
+
+			at := b.day
+			for _, code := range codes {
+				out = append(out, &Event{At: at, Track: b.track, Code: code, Desc: SummaryDesc})
+				at = at.Add(totals[b][code])
+			}
+			if at.Before(cutoff) {
+				out = append(out, &Event{At: at, Track: b.track})
+			}
+		}
+	}
+
+	// Carry on anything still running at the cutoff.
+	running := map[string]*Event{}
+	for _, e := range log[:first] {
+		running[e.Track] = e
+	}
+	for _, e := range log[first:] {
+		if e.At.Equal(cutoff) {
+			delete(running, e.Track)
+		}
+	}
+	tracks := make([]string, 0, len(running))
+	for track := range running {
+		tracks = append(tracks, track)
+	}
+	sort.Strings(tracks)
+	for _, track := range tracks {
+		e := running[track]
+		if e.Code == "" {
+			continue
+		}
+		carried := *e
+		carried.At = cutoff
+		out = append(out, &carried)
+	}
+
+	out = append(out, log[first:]...)
+	out.Sort()
+	return out, first
+}
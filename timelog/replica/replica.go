@@ -0,0 +1,354 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+// Package replica keeps a timelog as an operation log that can be edited on several machines without them talking to
+// each other, and merged afterwards without conflicts.
+//
+// Each device only ever appends to its own file in a shared directory (<device>.ops), so a file syncing tool never
+// has two versions of the same file to choose between. An operation either adds an event or removes one added earlier,
+// and every operation is stamped with its device and a Lamport clock, which together identify it. The timelog is every
+// added event that hasn't been removed since. Since adds and removes never conflict, reading every file gives the same
+// timelog in any order and on any device.
+//
+// Changing an event is removing it and adding the new version, so two devices changing the same event at once end up
+// with both versions, which is the safest outcome when there is no way to know which one was meant.
+package replica
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Op is a single change to the timelog.
+type Op struct {
+	Clock  uint64
+	Device string
+
+	Add    *timelog.Event `json:",omitempty"` // An event added,
+	Remove string         `json:",omitempty"` // or the ID of the op that added an event that is now gone.
+}
+
+// ID identifies the op, and the event it added.
+func (op *Op) ID() string {
+	return fmt.Sprintf("%s:%d", op.Device, op.Clock)
+}
+
+// Entry is an event in the timelog, along with the ID of the op that added it.
+type Entry struct {
+	ID    string
+	Event *timelog.Event
+}
+
+// Replica is the operation log in a directory, as seen from one device.
+type Replica struct {
+	Dir    string
+	Device string
+
+	ops   []*Op
+	clock uint64
+}
+
+// Open reads every device's operations from the directory, creating it if it doesn't exist yet.
+func Open(dir, device string) (*Replica, error) {
+	if device == "" || strings.ContainsAny(device, `/\:`) || strings.HasPrefix(device, ".") {
+		return nil, fmt.Errorf("invalid device name %q", device)
+	}
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replica{Dir: dir, Device: device}
+	files, err := filepath.Glob(filepath.Join(dir, "*.ops"))
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, file := range files {
+		ops, err := readOps(file)
+		if err != nil {
+			return nil, err
+		}
+
+		// Copies of the same file (conflict copies from a sync tool, for example) only contribute each op once.
+		for _, op := range ops {
+			if seen[op.ID()] {
+				continue
+			}
+			seen[op.ID()] = true
+			r.ops = append(r.ops, op)
+			if op.Clock > r.clock {
+				r.clock = op.Clock
+			}
+		}
+	}
+	return r, nil
+}
+
+// readOps reads one op file, one JSON op per line. A broken last line is a write that was cut short (or hasn't
+// finished syncing yet), so it is left for later rather than being an error.
+func readOps(file string) ([]*Op, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ops := []*Op{}
+	var broken error
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if broken != nil {
+			return nil, broken
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		op := &Op{}
+		err := json.Unmarshal(scanner.Bytes(), op)
+		if err == nil && op.Device == "" {
+			err = errors.New("no device")
+		}
+		if err != nil {
+			broken = fmt.Errorf("%s:%d: %w", file, line, err)
+			continue
+		}
+		if op.Add != nil {
+			op.Add.At = op.Add.At.Local()
+		}
+		ops = append(ops, op)
+	}
+	return ops, scanner.Err()
+}
+
+// Ops returns the number of operations from each device.
+func (r *Replica) Ops() map[string]int {
+	counts := map[string]int{}
+	for _, op := range r.ops {
+		counts[op.Device]++
+	}
+	return counts
+}
+
+// Entries works out the timelog from the operations, in order.
+func (r *Replica) Entries() []*Entry {
+	removed := map[string]bool{}
+	for _, op := range r.ops {
+		if op.Remove != "" {
+			removed[op.Remove] = true
+		}
+	}
+
+	entries := []*Entry{}
+	for _, op := range r.ops {
+		if op.Add != nil && !removed[op.ID()] {
+			entries = append(entries, &Entry{ID: op.ID(), Event: op.Add})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Event.At.Equal(entries[j].Event.At) {
+			return entries[i].Event.At.Before(entries[j].Event.At)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+// TimeLog is the timelog from the operations, the flat file version.
+func (r *Replica) TimeLog() timelog.TimeLog {
+	log := timelog.TimeLog{}
+	for _, entry := range r.Entries() {
+		log = append(log, entry.Event)
+	}
+	return log
+}
+
+// Sync records the changes made to the flat file version of the timelog since the last sync from this device, and
+// returns the merged timelog with everyone's changes, along with how many operations were recorded.
+//
+// The first sync from a device only adds, it has no way to tell an event it never had from one it removed. Events it
+// has that are already in the replica are left alone, so a copy of the same timelog on each device is only added once.
+//
+// Once the merged timelog has been written to the flat file, call Commit. Until then the next Sync compares against
+// log, so if writing the merged timelog fails the changes aren't recorded twice and the other devices' events aren't
+// mistaken for ones removed here.
+func (r *Replica) Sync(log timelog.TimeLog) (timelog.TimeLog, int, error) {
+	base, err := r.loadBase()
+	first := errors.Is(err, os.ErrNotExist)
+	if err != nil && !first {
+		return nil, 0, err
+	}
+	if first {
+		base = r.Entries()
+	}
+
+	ops := r.diff(base, log, !first)
+	if len(ops) > 0 {
+		err = r.record(ops)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	err = r.saveBase(r.matching(log))
+	if err != nil {
+		return nil, len(ops), err
+	}
+	return r.TimeLog(), len(ops), nil
+}
+
+// Commit records that the flat file now has the merged timelog from the last Sync, so the next Sync compares against
+// that.
+func (r *Replica) Commit() error {
+	return r.saveBase(r.Entries())
+}
+
+// matching returns the entries for the events in log, which are all in the replica once Sync has recorded them.
+func (r *Replica) matching(log timelog.TimeLog) []*Entry {
+	want := map[string]int{}
+	for _, e := range log {
+		want[key(e)]++
+	}
+	out := []*Entry{}
+	for _, entry := range r.Entries() {
+		if k := key(entry.Event); want[k] > 0 {
+			want[k]--
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// diff works out the operations to turn base into log. Events are matched by their contents, they have no IDs of their
+// own in the flat file.
+func (r *Replica) diff(base []*Entry, log timelog.TimeLog, removes bool) []*Op {
+	unmatched := map[string][]*Entry{}
+	for _, entry := range base {
+		k := key(entry.Event)
+		unmatched[k] = append(unmatched[k], entry)
+	}
+
+	ops := []*Op{}
+	for _, e := range log {
+		k := key(e)
+		if len(unmatched[k]) > 0 {
+			unmatched[k] = unmatched[k][1:]
+			continue
+		}
+		r.clock++
+		ops = append(ops, &Op{Clock: r.clock, Device: r.Device, Add: e})
+	}
+	if !removes {
+		return ops
+	}
+
+	gone := []*Entry{}
+	for _, entries := range unmatched {
+		gone = append(gone, entries...)
+	}
+	sort.Slice(gone, func(i, j int) bool {
+		return gone[i].ID < gone[j].ID
+	})
+	for _, entry := range gone {
+		r.clock++
+		ops = append(ops, &Op{Clock: r.clock, Device: r.Device, Remove: entry.ID})
+	}
+	return ops
+}
+
+// record appends ops to this device's file.
+func (r *Replica) record(ops []*Op) error {
+	f, err := os.OpenFile(filepath.Join(r.Dir, r.Device+".ops"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, op := range ops {
+		raw, err := json.Marshal(op)
+		if err != nil {
+			return err
+		}
+		w.Write(raw)
+		w.WriteString("\n")
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	r.ops = append(r.ops, ops...)
+	return nil
+}
+
+// The base is the timelog as of this device's last sync, for telling what changed in the flat file since. It is only
+// ever read by the device that wrote it.
+func (r *Replica) basePath() string {
+	return filepath.Join(r.Dir, r.Device+".base")
+}
+
+func (r *Replica) loadBase() ([]*Entry, error) {
+	raw, err := os.ReadFile(r.basePath())
+	if err != nil {
+		return nil, err
+	}
+	base := []*Entry{}
+	err = json.Unmarshal(raw, &base)
+	return base, err
+}
+
+func (r *Replica) saveBase(entries []*Entry) error {
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	tmp := r.basePath() + ".tmp"
+	err = os.WriteFile(tmp, raw, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.basePath())
+}
+
+// key is everything about an event, for matching it with the same event elsewhere.
+func key(e *timelog.Event) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%d\x00%s\x00%s\x00%s", e.At.Unix(), e.Track, e.Code, e.Desc)
+	keys := make([]string, 0, len(e.Meta))
+	for k := range e.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "\x00%s=%s", k, e.Meta[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package replica
+
+import (
+	"testing"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+func open(t *testing.T, dir, device string) *Replica {
+	t.Helper()
+	r, err := Open(dir, device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func sync(t *testing.T, r *Replica, log timelog.TimeLog) timelog.TimeLog {
+	t.Helper()
+	merged, _, err := r.Sync(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return merged
+}
+
+// If the merged timelog never makes it to the flat file, the next sync has to start from the flat file as it was.
+func TestSyncWithoutCommit(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+
+	desktop := open(t, dir, "desktop")
+	sync(t, desktop, timelog.TimeLog{{At: at, Code: "Desktop", Desc: "theirs"}})
+	if err := desktop.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The laptop merges it in and writes the flat file, which works.
+	laptop := open(t, dir, "laptop")
+	flat := sync(t, laptop, timelog.TimeLog{{At: at.Add(time.Hour), Code: "Laptop", Desc: "mine"}})
+	if err := laptop.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The desktop picks that up, then adds another event.
+	desktop = open(t, dir, "desktop")
+	other := sync(t, desktop, timelog.TimeLog{{At: at, Code: "Desktop", Desc: "theirs"}})
+	if err := desktop.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	sync(t, desktop, append(other, &timelog.Event{At: at.Add(2 * time.Hour), Code: "Desktop", Desc: "more"}))
+	if err := desktop.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The laptop syncs a change of its own, but writing the merged log fails, so there is no Commit and the flat file
+	// doesn't have the desktop's new event.
+	flat = append(flat, &timelog.Event{At: at.Add(3 * time.Hour), Desc: "done"})
+	laptop = open(t, dir, "laptop")
+	if merged := sync(t, laptop, flat); len(merged) != 4 {
+		t.Fatalf("merged log has %d event(s), want 4:\n%s", len(merged), merged.String())
+	}
+
+	// Trying again with the same flat file mustn't remove the desktop's event, or record the laptop's twice.
+	laptop = open(t, dir, "laptop")
+	if merged := sync(t, laptop, flat); len(merged) != 4 {
+		t.Fatalf("merged log has %d event(s), want 4:\n%s", len(merged), merged.String())
+	}
+	desktop = open(t, dir, "desktop")
+	if got := desktop.TimeLog(); len(got) != 4 {
+		t.Fatalf("the desktop sees %d event(s), want 4:\n%s", len(got), got.String())
+	}
+}
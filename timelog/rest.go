@@ -0,0 +1,180 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RestRules are limits on working time, for places that have working time regulations. A zero limit isn't checked.
+type RestRules struct {
+	MaxDay  time.Duration // Most time worked in a day.
+	MinRest time.Duration // Least time off between the end of one day's work and the start of the next.
+	MaxWeek time.Duration // Most time worked in a week, see Calendar.
+}
+
+// ParseRestRules parses the restrules config, a comma separated list of day=<duration>, rest=<duration>, and
+// week=<duration>, eg "day=10h,rest=11h,week=48h".
+func ParseRestRules(config string) (RestRules, error) {
+	rules := RestRules{}
+	for _, rule := range strings.Split(config, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(rule, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(arg))
+		if err != nil || d <= 0 {
+			return rules, fmt.Errorf("rest rule %q needs a positive duration, such as %s=10h", rule, strings.TrimSpace(name))
+		}
+		switch strings.TrimSpace(name) {
+		case "day":
+			rules.MaxDay = d
+		case "rest":
+			rules.MinRest = d
+		case "week":
+			rules.MaxWeek = d
+		default:
+			return rules, fmt.Errorf("unknown rest rule %q, expected 'day', 'rest', or 'week'", name)
+		}
+	}
+	return rules, nil
+}
+
+// Empty is true if none of the rules are set.
+func (r RestRules) Empty() bool {
+	return r == RestRules{}
+}
+
+// RestViolation is a place where the rest rules were broken.
+type RestViolation struct {
+	Rule string // "day", "rest", or "week"
+
+	// The day or week that was worked too long, or the rest that was too short.
+	Begin time.Time
+	End   time.Time
+
+	Actual time.Duration // What was worked, or how long the rest was.
+	Limit  time.Duration
+
+	Week Week // The week that was worked too long, for the "week" rule.
+}
+
+// Describe says what the violation was, with durations in the given style. It is always in English, the report package
+// has a translated version.
+func (v RestViolation) Describe(style DurationStyle) string {
+	actual, limit := FormatDuration(v.Actual, style), FormatDuration(v.Limit, style)
+	switch v.Rule {
+	case "day":
+		return fmt.Sprintf("Worked %s on %s, more than %s.", actual, v.Begin.Format("Monday 2006/01/02"), limit)
+	case "week":
+		return fmt.Sprintf("Worked %s in week %d of %d, more than %s.", actual, v.Week.Number, v.Week.Year, limit)
+	default:
+		return fmt.Sprintf("Only %s rest from %s to %s, less than %s.", actual, v.Begin.Format("2006/01/02 03:04PM"), v.End.Format("2006/01/02 03:04PM"), limit)
+	}
+}
+
+// CheckRest checks the periods against the rules. Only periods with a code count as work, and time on different tracks
+// at the same time only counts once. Work that runs past midnight counts for each day it covers, days and weeks are
+// those of cal. The violations are sorted by when they begin.
+func CheckRest(periods []*Period, rules RestRules, cal Calendar) []RestViolation {
+	// Merge all the work into blocks, so overlapping tracks don't count twice and rest is the time between blocks.
+	work := []*Period{}
+	for _, p := range periods {
+		if p.Code != "" && p.End.After(p.Begin) {
+			work = append(work, p)
+		}
+	}
+	sort.SliceStable(work, func(i, j int) bool {
+		return work[i].Begin.Before(work[j].Begin)
+	})
+	type block struct{ begin, end time.Time }
+	blocks := []block{}
+	for _, p := range work {
+		if n := len(blocks); n > 0 && !p.Begin.After(blocks[n-1].end) {
+			if p.End.After(blocks[n-1].end) {
+				blocks[n-1].end = p.End
+			}
+			continue
+		}
+		blocks = append(blocks, block{p.Begin, p.End})
+	}
+
+	violations := []RestViolation{}
+	days := map[time.Time]time.Duration{}
+	weeks := map[Week]time.Duration{}
+	weekstart := map[Week]time.Time{}
+	for i, b := range blocks {
+		for begin := b.begin; begin.Before(b.end); {
+			day := cal.Day(begin)
+			end := day.AddDate(0, 0, 1)
+			if b.end.Before(end) {
+				end = b.end
+			}
+			days[day] += end.Sub(begin)
+			week := cal.WeekOf(begin)
+			weeks[week] += end.Sub(begin)
+			if _, ok := weekstart[week]; !ok {
+				weekstart[week] = cal.StartOfWeek(day)
+			}
+			begin = end
+		}
+
+		// Only the gap between one day's work and the next is rest, breaks during the day aren't. Days are told apart by
+		// when their work began, so a late night that runs past midnight still needs the rest after it.
+		if i > 0 && rules.MinRest > 0 {
+			prev := blocks[i-1]
+			rest := b.begin.Sub(prev.end)
+			if !cal.Day(prev.begin).Equal(cal.Day(b.begin)) && rest < rules.MinRest {
+				violations = append(violations, RestViolation{Rule: "rest", Begin: prev.end, End: b.begin, Actual: rest, Limit: rules.MinRest})
+			}
+		}
+	}
+
+	if rules.MaxDay > 0 {
+		for day, d := range days {
+			if d > rules.MaxDay {
+				violations = append(violations, RestViolation{Rule: "day", Begin: day, End: day.AddDate(0, 0, 1), Actual: d, Limit: rules.MaxDay})
+			}
+		}
+	}
+	if rules.MaxWeek > 0 {
+		for week, d := range weeks {
+			if d > rules.MaxWeek {
+				begin := weekstart[week]
+				violations = append(violations, RestViolation{Rule: "week", Begin: begin, End: begin.AddDate(0, 0, 7), Actual: d, Limit: rules.MaxWeek, Week: week})
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if !violations[i].Begin.Equal(violations[j].Begin) {
+			return violations[i].Begin.Before(violations[j].Begin)
+		}
+		return violations[i].Rule < violations[j].Rule
+	})
+	return violations
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRestRules(t *testing.T) {
+	tests := []struct {
+		config string
+		want   RestRules
+		err    bool
+	}{
+		{"", RestRules{}, false},
+		{"day=10h", RestRules{MaxDay: 10 * time.Hour}, false},
+		{"rest=11h", RestRules{MinRest: 11 * time.Hour}, false},
+		{"week=48h", RestRules{MaxWeek: 48 * time.Hour}, false},
+		{" day = 10h , rest=11h,week=48h ", RestRules{MaxDay: 10 * time.Hour, MinRest: 11 * time.Hour, MaxWeek: 48 * time.Hour}, false},
+		{"rest=11h,", RestRules{MinRest: 11 * time.Hour}, false},
+		{"day", RestRules{}, true},
+		{"day=", RestRules{}, true},
+		{"rest=0s", RestRules{}, true},
+		{"week=-48h", RestRules{}, true},
+		{"day=ten hours", RestRules{}, true},
+		{"night=8h", RestRules{}, true},
+	}
+	for _, test := range tests {
+		got, err := ParseRestRules(test.config)
+		if (err != nil) != test.err {
+			t.Errorf("ParseRestRules(%q) error = %v, want error %v", test.config, err, test.err)
+			continue
+		}
+		if err == nil && got != test.want {
+			t.Errorf("ParseRestRules(%q) = %+v, want %+v", test.config, got, test.want)
+		}
+	}
+	if !(RestRules{}).Empty() || (RestRules{MinRest: time.Hour}).Empty() {
+		t.Error("Empty is wrong")
+	}
+}
+
+func TestCheckRest(t *testing.T) {
+	rules := RestRules{MaxDay: 10 * time.Hour, MinRest: 11 * time.Hour, MaxWeek: 48 * time.Hour}
+	monday := Calendar{WeekStart: time.Monday}
+
+	type want struct {
+		rule       string
+		begin, end time.Time
+		actual     time.Duration
+	}
+	tests := []struct {
+		name    string
+		cal     Calendar
+		periods []*Period
+		want    []want
+	}{
+		{"within the rules", monday, []*Period{
+			period("A", at(12, 9, 0), at(12, 17, 0)),
+			period("A", at(13, 9, 0), at(13, 17, 0)),
+		}, nil},
+		{"a long day", monday, []*Period{
+			period("A", at(12, 8, 0), at(12, 12, 0)),
+			period("", at(12, 12, 0), at(12, 13, 0)),
+			period("B", at(12, 13, 0), at(12, 20, 0)),
+		}, []want{{"day", at(12, 0, 0), at(13, 0, 0), 11 * time.Hour}}},
+		{"overlapping tracks count once", monday, []*Period{
+			period("A", at(12, 8, 0), at(12, 17, 0)),
+			{Begin: at(12, 9, 0), End: at(12, 17, 30), Code: "B", Track: "call"},
+		}, nil},
+		{"short rest over midnight", monday, []*Period{
+			period("A", at(12, 14, 0), at(12, 23, 0)),
+			period("A", at(13, 7, 0), at(13, 12, 0)),
+		}, []want{{"rest", at(12, 23, 0), at(13, 7, 0), 8 * time.Hour}}},
+		{"a break during the day isn't rest", monday, []*Period{
+			period("A", at(12, 9, 0), at(12, 11, 0)),
+			period("A", at(12, 15, 0), at(12, 17, 0)),
+		}, nil},
+		// Going past midnight, the 2h after it count for Tuesday, and Tuesday's work starts 6h after.
+		{"work over midnight", monday, []*Period{
+			period("A", at(12, 16, 0), at(13, 2, 0)),
+			period("A", at(13, 8, 0), at(13, 17, 0)),
+		}, []want{{"day", at(13, 0, 0), at(14, 0, 0), 11 * time.Hour}, {"rest", at(13, 2, 0), at(13, 8, 0), 6 * time.Hour}}},
+		// With the day starting at 4AM, the 2h after midnight are still Monday's, so this is a short night instead.
+		{"work over midnight, day starts at 4AM", Calendar{WeekStart: time.Monday, DayStart: 4 * time.Hour}, []*Period{
+			period("A", at(12, 16, 0), at(13, 2, 0)),
+			period("A", at(13, 8, 0), at(13, 17, 0)),
+		}, []want{{"rest", at(13, 2, 0), at(13, 8, 0), 6 * time.Hour}}},
+		// 2026/10/18 is a Sunday, ending the week from Monday the 12th.
+		{"a long week", monday, []*Period{
+			period("A", at(12, 8, 0), at(12, 18, 0)),
+			period("A", at(13, 8, 0), at(13, 18, 0)),
+			period("A", at(14, 8, 0), at(14, 18, 0)),
+			period("A", at(15, 8, 0), at(15, 18, 0)),
+			period("A", at(17, 8, 0), at(17, 13, 0)),
+			period("A", at(18, 8, 0), at(18, 13, 0)),
+			period("A", at(19, 8, 0), at(19, 18, 0)),
+		}, []want{{"week", at(12, 0, 0), at(19, 0, 0), 50 * time.Hour}}},
+		// Weeks from Sunday put the 18th in the next week, so neither week is too long.
+		{"the week boundary", Calendar{WeekStart: time.Sunday}, []*Period{
+			period("A", at(12, 8, 0), at(12, 18, 0)),
+			period("A", at(13, 8, 0), at(13, 18, 0)),
+			period("A", at(14, 8, 0), at(14, 18, 0)),
+			period("A", at(15, 8, 0), at(15, 18, 0)),
+			period("A", at(17, 8, 0), at(17, 13, 0)),
+			period("A", at(18, 8, 0), at(18, 13, 0)),
+		}, nil},
+	}
+	for _, test := range tests {
+		got := CheckRest(test.periods, rules, test.cal)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i, w := range test.want {
+			g := got[i]
+			if g.Rule != w.rule || !g.Begin.Equal(w.begin) || !g.End.Equal(w.end) || g.Actual != w.actual {
+				t.Errorf("%s: violation %d is %s %v-%v %v, want %s %v-%v %v", test.name, i, g.Rule, g.Begin, g.End, g.Actual,
+					w.rule, w.begin, w.end, w.actual)
+			}
+		}
+	}
+}
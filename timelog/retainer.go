@@ -0,0 +1,144 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Retainer is a number of prepaid hours each month for a code and all its children.
+type Retainer struct {
+	Code     string // The code the retainer was set on.
+	Hours    time.Duration
+	Rollover bool // Unused hours can be used in the next month (but not after that).
+}
+
+// Retainer finds the retainer that covers a code, set with "retainer=20h" and optionally "rollover=true". Unlike other
+// settings, children share their parent's retainer rather than each getting one of their own.
+func (info CodeInfo) Retainer(code string) (*Retainer, bool) {
+	for {
+		if v, ok := info[code]["retainer"]; ok {
+			hours, err := time.ParseDuration(v)
+			if err != nil || hours <= 0 {
+				return nil, false
+			}
+			return &Retainer{Code: code, Hours: hours, Rollover: info.Bool(code, "rollover")}, true
+		}
+
+		i := strings.LastIndex(code, ":")
+		if i == -1 {
+			return nil, false
+		}
+		code = code[:i]
+	}
+}
+
+// RetainerMonth is how a retainer was used in a single calendar month.
+type RetainerMonth struct {
+	Code  string    // The code the retainer was set on.
+	Month time.Time // Midnight on the first day of the month.
+
+	Hours     time.Duration // The hours this month.
+	CarriedIn time.Duration // Unused hours from last month. These are used first.
+	Used      time.Duration // All the time billed this month, including overage.
+	Overage   time.Duration // Time over the retainer, which is charged for normally.
+
+	CarriedOut time.Duration // Unused hours available next month.
+}
+
+// Allowance is the total prepaid time for the month.
+func (m *RetainerMonth) Allowance() time.Duration {
+	return m.Hours + m.CarriedIn
+}
+
+// Remaining is the prepaid time left this month.
+func (m *RetainerMonth) Remaining() time.Duration {
+	if m.Used >= m.Allowance() {
+		return 0
+	}
+	return m.Allowance() - m.Used
+}
+
+// Retainers works through a set of periods in order, working out how each retainer was used month by month and how
+// much of each period was over its retainer. Periods are counted by the month they begin in, using the billed lengths.
+//
+// The periods should start far enough back for any rollover to be right, the start of the log is best. The months
+// are sorted by code then month, and include months with nothing used if they fall between months that do.
+func Retainers(info CodeInfo, periods []*Period, billed map[*Period]time.Duration) ([]*RetainerMonth, map[*Period]time.Duration) {
+	overage := map[*Period]time.Duration{}
+	current := map[string]*RetainerMonth{}
+	months := []*RetainerMonth{}
+
+	for _, p := range periods {
+		r, ok := info.Retainer(p.Code)
+		if !ok {
+			continue
+		}
+
+		begin := p.Begin.In(time.Local)
+		month := time.Date(begin.Year(), begin.Month(), 1, 0, 0, 0, 0, time.Local)
+
+		m := current[r.Code]
+		for m == nil || m.Month.Before(month) {
+			next := &RetainerMonth{Code: r.Code, Month: month, Hours: r.Hours}
+			if m != nil {
+				next.Month = m.Month.AddDate(0, 1, 0)
+				next.CarriedIn = m.CarriedOut
+			}
+			if r.Rollover {
+				next.CarriedOut = r.Hours
+			}
+			m = next
+			current[r.Code] = m
+			months = append(months, m)
+		}
+
+		before := m.Used
+		m.Used += billed[p]
+		overage[p] = excess(m.Used, m.Allowance()) - excess(before, m.Allowance())
+		m.Overage = excess(m.Used, m.Allowance())
+
+		// Carried hours are used up first, and only this month's hours can carry on.
+		m.CarriedOut = 0
+		if r.Rollover && excess(m.Used, m.CarriedIn) < m.Hours {
+			m.CarriedOut = m.Hours - excess(m.Used, m.CarriedIn)
+		}
+	}
+
+	sort.SliceStable(months, func(i, j int) bool {
+		if months[i].Code != months[j].Code {
+			return months[i].Code < months[j].Code
+		}
+		return months[i].Month.Before(months[j].Month)
+	})
+	return months, overage
+}
+
+func excess(d, limit time.Duration) time.Duration {
+	if d <= limit {
+		return 0
+	}
+	return d - limit
+}
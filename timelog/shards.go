@@ -0,0 +1,76 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Shard is a piece of a timelog kept in a file of its own, such as an archive of an old year.
+type Shard struct {
+	Name    string // Where the shard came from, usually a file name. Problems are marked with it.
+	Content string
+}
+
+// ParseShards parses shards leniently (see [ParseTimeLogLenient]) and merges them into a single sorted TimeLog. The
+// shards are parsed concurrently, no more than workers at once, or one per CPU if workers is 0 or less. Events at the
+// same time stay in the order the shards were given in, and problems are in shard order.
+func ParseShards(shards []Shard, workers int) (TimeLog, []*Problem) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	logs := make([]TimeLog, len(shards))
+	problems := make([][]*Problem, len(shards))
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, workers)
+	for i, shard := range shards {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, shard Shard) {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			logs[i], problems[i] = ParseTimeLogLenient(shard.Content)
+			for _, p := range problems[i] {
+				p.Source = shard.Name
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+
+	size := 0
+	for _, log := range logs {
+		size += len(log)
+	}
+	out := make(TimeLog, 0, size)
+	outProblems := []*Problem{}
+	for i := range shards {
+		out = append(out, logs[i]...)
+		outProblems = append(outProblems, problems[i]...)
+	}
+	out.Sort()
+	return out, outProblems
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store is somewhere a timelog is kept. Save replaces the whole log at once, so afterwards either all of the new log is
+// there or (if it failed) all of the old one still is. After each save the functions given to OnChange are told what
+// changed, so something embedding the library can keep up without watching the file.
+type Store interface {
+	Load() (TimeLog, error)
+	Save(log TimeLog) error
+	OnChange(f func(Change)) (cancel func())
+}
+
+// FileStore keeps a timelog in a file, the usual way.
+type FileStore struct {
+	Notifier
+	Path string
+
+	mu   sync.Mutex
+	last TimeLog // What was last loaded or saved, for working out what changed.
+}
+
+// Load reads and parses the file. A file that doesn't exist yet is an empty log.
+func (s *FileStore) Load() (TimeLog, error) {
+	content, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return TimeLog{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	log, err := ParseTimeLogString(string(content))
+	if err != nil {
+		return nil, err
+	}
+	log.Sort()
+	s.remember(log)
+	return log, nil
+}
+
+// remember keeps a copy of the log as it is in the file, and returns the one it replaced along with it.
+func (s *FileStore) remember(log TimeLog) (before, after TimeLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before = s.last
+	s.last = log.Clone()
+	return before, s.last
+}
+
+// Save writes the log (with [TimeLog.FormatFile]) to a new file next to the old one, and then renames it over the top,
+// so nothing reading the file ever sees half a log. The new file keeps the old one's permissions. If Path is a
+// symlink, the file it points to is replaced.
+func (s *FileStore) Save(log TimeLog) error {
+	path := s.Path
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // Does nothing once it has been renamed.
+
+	b := bufio.NewWriter(f)
+	err = log.FormatFile(b)
+	if err == nil {
+		err = b.Flush()
+	}
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return err
+	}
+
+	s.Notify(NewChange(s.remember(log)))
+	return nil
+}
+
+// MemoryStore keeps a timelog in memory, for embedding the library somewhere there is no file, and for testing.
+type MemoryStore struct {
+	Notifier
+
+	mu  sync.Mutex
+	log TimeLog
+}
+
+// Load returns a copy of the log.
+func (s *MemoryStore) Load() (TimeLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Clone(), nil
+}
+
+// Save keeps a copy of the log, after checking it could be written to a file (see [Event.Validate]).
+func (s *MemoryStore) Save(log TimeLog) error {
+	for _, e := range log {
+		err := e.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	before := s.log
+	s.log = log.Clone()
+	after := s.log
+	s.mu.Unlock()
+
+	s.Notify(NewChange(before, after))
+	return nil
+}
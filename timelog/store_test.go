@@ -0,0 +1,82 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStoreSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sctime.log")
+	link := filepath.Join(dir, "link.log")
+	err := os.WriteFile(path, []byte("2026/10/12 09:00AM [Old] event\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink(path, link)
+	if err != nil {
+		t.Skip("no symlinks here:", err)
+	}
+
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	store := &FileStore{Path: link}
+	log := TimeLog{{At: at, Code: "Proj", Desc: "new"}, {At: at.Add(time.Hour)}}
+	err = store.Save(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file the link points to is replaced, keeping its permissions, and nothing is left lying around.
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("the symlink was replaced: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("permissions weren't kept: %v %v", info.Mode(), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("left %d files in the directory, want the log and the link", len(entries))
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.String() != log.String() {
+		t.Errorf("saved\n%s\nloaded\n%s", log.String(), loaded.String())
+	}
+
+	// A log that can't be written leaves the old one alone.
+	err = store.Save(TimeLog{{At: at, Code: "bad]code"}})
+	if err == nil {
+		t.Fatal("saved an event that can't be written")
+	}
+	loaded, _ = store.Load()
+	if loaded.String() != log.String() {
+		t.Errorf("a failed save changed the log:\n%s", loaded.String())
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"sort"
+	"time"
+)
+
+// Transition is one code following another, and how often it happened, see [Switches].
+type Transition struct {
+	From  string
+	To    string
+	Count int
+}
+
+// SwitchDay is the context switching on one day.
+type SwitchDay struct {
+	Date     time.Time
+	Switches int           // Times the code changed.
+	Blocks   int           // Uninterrupted runs of the same code.
+	Worked   time.Duration // The time in those blocks.
+}
+
+// AverageBlock is how long a block lasted on average.
+func (d *SwitchDay) AverageBlock() time.Duration {
+	if d.Blocks == 0 {
+		return 0
+	}
+	return d.Worked / time.Duration(d.Blocks)
+}
+
+// SwitchStats is the result of [Switches].
+type SwitchStats struct {
+	Days        []*SwitchDay  // In order, only days with something on them.
+	Transitions []*Transition // Most common first.
+
+	Switches int
+	Blocks   int
+	Worked   time.Duration
+}
+
+// AverageBlock is how long a block lasted on average, over every day.
+func (s *SwitchStats) AverageBlock() time.Duration {
+	if s.Blocks == 0 {
+		return 0
+	}
+	return s.Worked / time.Duration(s.Blocks)
+}
+
+// SwitchesPerDay is the average number of switches on a day with something on it.
+func (s *SwitchStats) SwitchesPerDay() float64 {
+	if len(s.Days) == 0 {
+		return 0
+	}
+	return float64(s.Switches) / float64(len(s.Days))
+}
+
+// Switches works out how often the code changes in the periods, each track on its own. A block is a run of periods
+// with the same code and no gap between them, so a break (or any period without a code) ends a block even if the
+// same code comes after it. Going from one code to another is a switch, breaks or not, but the first block of a day
+// is not, so coming back to work the next morning doesn't count. Blocks belong to the day their first period counts for,
+// by cal.
+func Switches(periods []*Period, cal Calendar) *SwitchStats {
+	periods = FilterOutPeriods(periods, "", false)
+	sorted := make([]*Period, len(periods))
+	copy(sorted, periods)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Track != sorted[j].Track {
+			return sorted[i].Track < sorted[j].Track
+		}
+		return sorted[i].Begin.Before(sorted[j].Begin)
+	})
+
+	stats := &SwitchStats{}
+	days := map[time.Time]*SwitchDay{}
+	transitions := map[[2]string]*Transition{}
+
+	var prev *Period
+	var day *SwitchDay
+	for _, p := range sorted {
+		if prev != nil && prev.Track == p.Track && prev.Code == p.Code && !p.Begin.After(prev.End) {
+			// Still the same block.
+			day.Worked += p.Length()
+			stats.Worked += p.Length()
+			prev = p
+			continue
+		}
+
+		date := p.Day(cal)
+		day = days[date]
+		if day == nil {
+			day = &SwitchDay{Date: date}
+			days[date] = day
+		}
+		day.Blocks++
+		day.Worked += p.Length()
+		stats.Blocks++
+		stats.Worked += p.Length()
+
+		if prev != nil && prev.Track == p.Track && prev.Code != p.Code && prev.Day(cal).Equal(date) {
+			day.Switches++
+			stats.Switches++
+			key := [2]string{prev.Code, p.Code}
+			t := transitions[key]
+			if t == nil {
+				t = &Transition{From: prev.Code, To: p.Code}
+				transitions[key] = t
+			}
+			t.Count++
+		}
+		prev = p
+	}
+
+	for _, d := range days {
+		stats.Days = append(stats.Days, d)
+	}
+	sort.Slice(stats.Days, func(i, j int) bool {
+		return stats.Days[i].Date.Before(stats.Days[j].Date)
+	})
+	for _, t := range transitions {
+		stats.Transitions = append(stats.Transitions, t)
+	}
+	sort.Slice(stats.Transitions, func(i, j int) bool {
+		a, b := stats.Transitions[i], stats.Transitions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return stats
+}
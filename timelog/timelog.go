@@ -23,11 +23,15 @@ misrepresented as being the original software.
 package timelog
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/milochristiansen/ledger/parse/lex"
 )
@@ -40,13 +44,24 @@ const TimeShortFormat = "03:04PM"
 type TimeLog []*Event
 
 // Event marks the end of one time period and the start of another.
+//
+// Events on different tracks don't affect each other, each track is its own sequence of periods. This allows tracking
+// things that happen at the same time, a build running for one client while you are in a meeting for another. Most
+// events are on the main track, which has a blank name.
 type Event struct {
-	At   time.Time
-	Code string
-	Desc string
+	At    time.Time
+	Track string
+	Code  string
+	Desc  string
+
+	// Extra information about the event, such as where you were. Nil if there is none.
+	Meta map[string]string
 }
 
 func (e *Event) String() string {
+	if e.Track != "" {
+		return fmt.Sprintf("%s @%s [%s] %s", e.At.Format(TimeFormat), e.Track, e.Code, e.Desc)
+	}
 	return fmt.Sprintf("%s [%s] %s", e.At.Format(TimeFormat), e.Code, e.Desc)
 }
 
@@ -67,34 +82,142 @@ func (log TimeLog) Codes() []string {
 	return out
 }
 
-// CodeLen returns the length of the longest time code in the log.
+// CodeLen returns how wide the longest time code in the log is, in terminal columns (see DisplayWidth).
 func (log TimeLog) CodeLen() int {
 	codes := log.Codes()
 	max := 0
 	for _, code := range codes {
-		if len(code) > max {
-			max = len(code)
+		if l := DisplayWidth(strings.Trim(code, " \t")); l > max {
+			max = l
 		}
 	}
 	return max
 }
 
 // Format dumps a TimeLog to an [io.Writer], one [Event] per line.
+//
+// Descriptions with more than one line have each following line written indented on its own line.
+//
+// The output is normalized: times are written in local time to the minute, leading and trailing spaces and tabs are
+// trimmed from codes and each line of the description, and trailing blank description lines are dropped. Events that
+// can't be written in a way that reads back the same (see [Event.Validate]) cause an error before anything is written.
+// Writes are buffered, if one fails the error is an [ErrWrite] saying which event it was.
 func (log TimeLog) Format(w io.Writer) error {
-	cl := log.CodeLen()
+	return log.FormatRange(w, 0, len(log))
+}
 
-	for _, item := range log {
-		_, err := fmt.Fprintf(w, "%s [%*s] %s\n", item.At.Format(TimeFormat), cl, item.Code, item.Desc)
+// FormatRange is [TimeLog.Format] for only the events log[begin:end]. The columns are lined up for the whole log, so
+// the range can be appended to a file with the rest of the log in it, or written somewhere else in pieces that all
+// line up.
+func (log TimeLog) FormatRange(w io.Writer, begin, end int) error {
+	events := log[begin:end]
+	for _, item := range events {
+		err := item.Validate()
 		if err != nil {
 			return err
 		}
 	}
+
+	cl := log.CodeLen()
+	tl := 0
+	for _, item := range log {
+		if w := DisplayWidth(item.Track) + 2; item.Track != "" && w > tl {
+			tl = w
+		}
+	}
+
+	b := bufio.NewWriter(w)
+	for i, item := range events {
+		_, err := b.WriteString(item.format(cl, tl))
+		if err != nil {
+			return ErrWrite{Index: begin + i, Event: item, Err: err}
+		}
+	}
+	if err := b.Flush(); err != nil && len(events) > 0 {
+		return ErrWrite{Index: end - 1, Event: events[len(events)-1], Err: err}
+	}
+	return nil
+}
+
+// format writes the event as a line (or more) of a timelog, with the code right aligned in cl columns and the track
+// in tl, see FormatRange.
+func (e *Event) format(cl, tl int) string {
+	track := ""
+	if e.Track != "" {
+		track = "@" + e.Track + " "
+	}
+	line := &strings.Builder{}
+	line.WriteString(e.At.In(time.Local).Format(TimeFormat) + " " + PadRight(track, tl) + "[" + PadLeft(strings.Trim(e.Code, " \t"), cl) + "]")
+	desc := descLines(e.Desc)
+	if len(desc) > 0 && desc[0] != "" {
+		line.WriteString(" " + desc[0])
+	}
+	for i := 1; i < len(desc); i++ {
+		line.WriteString("\n\t" + desc[i])
+	}
+	keys := make([]string, 0, len(e.Meta))
+	for k := range e.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		line.WriteString("\n\t; " + strings.Trim(k, " \t") + ": " + strings.Trim(e.Meta[k], " \t"))
+	}
+	line.WriteString("\n")
+	return line.String()
+}
+
+// Validate checks that an Event can be written by [TimeLog.Format] and parsed back unchanged, save for the
+// normalization Format does.
+func (e *Event) Validate() error {
+	if !utf8.ValidString(e.Code) || !utf8.ValidString(e.Desc) {
+		return ErrUnrepresentable{Event: e, Reason: "time codes and descriptions must be valid UTF-8"}
+	}
+	if !utf8.ValidString(e.Track) || strings.ContainsAny(e.Track, " \t\r\n[") {
+		return ErrUnrepresentable{Event: e, Reason: "track names may not contain white space or '['"}
+	}
+	if strings.ContainsAny(e.Code, "]\r\n") {
+		return ErrUnrepresentable{Event: e, Reason: "time codes may not contain ']' or line breaks"}
+	}
+	if strings.ContainsAny(e.Desc, "\r") {
+		return ErrUnrepresentable{Event: e, Reason: "descriptions may not contain carriage returns"}
+	}
+	for i, line := range descLines(e.Desc) {
+		// The first line is on the event line, the rest are indented, where ';' starts metadata.
+		if i > 0 && strings.HasPrefix(line, ";") {
+			return ErrUnrepresentable{Event: e, Reason: "description lines after the first may not start with ';', it would be read as metadata"}
+		}
+	}
+	for k, v := range e.Meta {
+		if !utf8.ValidString(k) || !utf8.ValidString(v) {
+			return ErrUnrepresentable{Event: e, Reason: "metadata must be valid UTF-8"}
+		}
+		if strings.Trim(k, " \t") == "" || strings.ContainsAny(k, ":\r\n") {
+			return ErrUnrepresentable{Event: e, Reason: "metadata keys must not be blank or contain ':' or line breaks"}
+		}
+		if strings.ContainsAny(v, "\r\n") {
+			return ErrUnrepresentable{Event: e, Reason: "metadata values may not contain line breaks"}
+		}
+	}
 	return nil
 }
 
+// descLines splits a description into trimmed lines, dropping any trailing blank lines. The first line may be blank
+// if the description starts with a line break.
+func descLines(desc string) []string {
+	lines := strings.Split(desc, "\n")
+	for i := range lines {
+		lines[i] = strings.Trim(lines[i], " \t")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
 // String dumps the TimeLog to a string. It should not be possible for this to fail, but outside of testing please
 // use [TimeLog.Format] and handle your errors!
-func (log TimeLog) String(w io.Writer) string {
+func (log TimeLog) String() string {
 	out := new(bytes.Buffer)
 	_ = log.Format(out) // No error *should* be possible here, simple writes to a Buffer are pretty robust.
 	return out.String()
@@ -102,89 +225,362 @@ func (log TimeLog) String(w io.Writer) string {
 
 // ParseTimeLogString parses a TimeLog from the given string.
 func ParseTimeLogString(input string) (TimeLog, error) {
-	return parseTimeLog(lex.NewCharReader(input, 1))
+	log, _, err := parseTimeLog(input, false)
+	return log, err
 }
 
 // ParseTimeLog parses a TimeLog from the given [io.RuneReader].
 func ParseTimeLog(input io.RuneReader) (TimeLog, error) {
-	return parseTimeLog(lex.NewRawCharReader(input, 1))
+	return ParseTimeLogString(readAllRunes(input))
 }
 
-// A lot of this code comes from my Ledger parser.
-func parseTimeLog(cr *lex.CharReader) (TimeLog, error) {
-	log := []*Event{}
-	for !cr.EOF {
-		// Eat any leading white space, also lines that are blank.
-		cr.Eat(" \t")
-		if cr.C == '\n' {
-			cr.Next()
-			continue
-		}
+// ParseTimeLogLenient parses a TimeLog from the given string, but rather than failing on the first malformed line it
+// skips the line and records a [Problem] for it. The returned TimeLog contains every event that parsed cleanly.
+//
+// Be careful writing a TimeLog obtained this way back to where it came from, any skipped lines will be lost!
+func ParseTimeLogLenient(input string) (TimeLog, []*Problem) {
+	log, problems, _ := parseTimeLog(input, true)
+	return log, problems
+}
 
-		// Consume comments.
-		if cr.C == '#' {
-			cr.EatUntil("\n")
-			cr.Next()
-			continue
-		}
+// Problem describes a single line that [ParseTimeLogLenient] could not parse.
+type Problem struct {
+	Line   int    // Line number, starting from 1.
+	Text   string // The raw text of the offending line.
+	Err    error  // The parse error, one of ErrBadDate, ErrUnexpectedEnd, or ErrMalformed.
+	Source string // The shard the line is from, if the log was parsed with [ParseShards].
+}
+
+func (p *Problem) Error() string {
+	if p.Source != "" {
+		return p.Source + ": " + p.Err.Error()
+	}
+	return p.Err.Error()
+}
 
-		current := &Event{}
+// Snippet returns the offending line with a caret marking where the problem is.
+func (p *Problem) Snippet() string {
+	var ctx interface{ Snippet() string }
+	if errors.As(p.Err, &ctx) {
+		return ctx.Snippet()
+	}
+	return p.Text
+}
+
+func (p *Problem) Unwrap() error {
+	return p.Err
+}
 
-		// Parse the date/time
-		date, err := parseDate(cr)
+// readAllRunes drains an [io.RuneReader] into a string. Read errors are treated as the end of input.
+func readAllRunes(input io.RuneReader) string {
+	out := new(strings.Builder)
+	for {
+		r, _, err := input.ReadRune()
 		if err != nil {
-			return nil, err
+			return out.String()
 		}
-		current.At = date
+		out.WriteRune(r)
+	}
+}
 
-		// Whitespace
-		cr.Eat(" \t")
-		if cr.EOF {
-			return nil, ErrUnexpectedEnd(cr.L)
-		}
+// parseTimeLog parses the input one line at a time. If lenient is set malformed lines are skipped and reported as
+// problems, otherwise the first error aborts the parse.
+func parseTimeLog(input string, lenient bool) (TimeLog, []*Problem, error) {
+	log := make([]*Event, 0, strings.Count(input, "\n")+1)
+	problems := []*Problem{}
+
+	// Older versions of the format are upgraded first, so everything below only has to know the current one.
+	upgraded, err := UpgradeText(input)
+	switch {
+	case err == nil:
+		input = upgraded
+	case !lenient:
+		return nil, nil, err
+	default:
+		problems = append(problems, &Problem{Line: 1, Text: strings.SplitN(input, "\n", 2)[0], Err: err})
+	}
 
-		// Time code
-		if cr.C == '[' {
-			cr.Next()
-			cr.Eat(" \t")
-			desc, err := readUntilTrimmed(cr, "]")
-			if err != nil {
-				return nil, err
+	var last *Event // The event that continuation lines belong to, if any.
+	skipping := false
+	clock := &clockSessions{}
+	for i, line := range strings.Split(input, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		// Indented lines directly following an event continue its description. If the event was malformed, they
+		// belong to the problem and are skipped along with it.
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			// The CharReader drops carriage returns anywhere in a line, these lines don't go through it.
+			line = strings.ReplaceAll(line, "\r", "")
+			if meta, ok := strings.CutPrefix(strings.TrimLeft(line, " \t"), ";"); ok && last != nil {
+				// Metadata, Ledger style.
+				k, v, ok := strings.Cut(strings.ToValidUTF8(meta, "\uFFFD"), ":")
+				k, v = strings.Trim(k, " \t"), strings.Trim(v, " \t")
+				if !ok || k == "" {
+					column := utf8.RuneCountInString(line) - utf8.RuneCountInString(meta) + 1
+					err := ErrMalformed{ErrorContext{Line: i + 1, Column: column, Text: line, Expected: "metadata in 'key: value' form"}}
+					if !lenient {
+						return nil, nil, err
+					}
+					problems = append(problems, &Problem{Line: i + 1, Text: line, Err: err})
+					continue
+				}
+				if last.Meta == nil {
+					last.Meta = map[string]string{}
+				}
+				last.Meta[k] = v
+				continue
+			}
+			if last != nil {
+				// The CharReader cleans up bad UTF-8 for normal lines, these don't go through it.
+				last.Desc += "\n" + strings.Trim(strings.ToValidUTF8(line, "\uFFFD"), " \t")
+				continue
 			}
-			if cr.C == '\n' {
-				return nil, ErrMalformed(cr.L)
+			if skipping {
+				continue
+			}
+		}
+		last, skipping = nil, false
+
+		// Each line gets its own reader, this keeps the line numbers in errors correct and makes it trivial to resync
+		// after a bad line.
+		var current *Event
+		var err error
+		if isClockLine(line) {
+			// These don't go through the CharReader either, so clean them up the same way it does.
+			current, err = clock.parse(strings.ToValidUTF8(strings.ReplaceAll(line, "\r", ""), "\uFFFD"), i+1)
+		} else if fast, ok := parseEventFast(line); ok {
+			current = fast
+		} else {
+			current, err = parseEvent(newLineReader(line, i+1))
+		}
+		if err != nil {
+			if !lenient {
+				return nil, nil, err
 			}
-			current.Code = desc
-			cr.Next()
+			problems = append(problems, &Problem{Line: i + 1, Text: line, Err: err})
+			skipping = true
+			continue
 		}
+		if current != nil {
+			log = append(log, current)
+			last = current
+		}
+	}
+
+	// Blank continuation lines are only meaningful between other lines. Descriptions of one line are trimmed already.
+	for _, item := range log {
+		if strings.Contains(item.Desc, "\n") {
+			item.Desc = strings.Join(descLines(item.Desc), "\n")
+		}
+	}
+
+	return log, problems, nil
+}
+
+// lineReader is a [lex.CharReader] for a single line that also knows where in the line it is. The location tracking
+// in lex isn't good enough for error messages, so we count runes ourselves.
+type lineReader struct {
+	*lex.CharReader
+	counter *runeCounter
+	line    int
+	text    string
+}
+
+func newLineReader(line string, n int) *lineReader {
+	// The newline is put back so every line is terminated the same way, last line or not.
+	counter := &runeCounter{source: strings.NewReader(line + "\n")}
+	return &lineReader{
+		CharReader: lex.NewRawCharReader(counter, uint(n)),
+		counter:    counter,
+		line:       n,
+		text:       line,
+	}
+}
+
+// column returns the 1 based column of the current character.
+func (cr *lineReader) column() int {
+	// The CharReader is always one character ahead of where it says it is.
+	switch {
+	case cr.EOF:
+		return cr.counter.n + 1
+	case cr.NEOF:
+		return cr.counter.n
+	default:
+		return cr.counter.n - 1
+	}
+}
+
+// context returns an [ErrorContext] for the current character.
+func (cr *lineReader) context(expected string) ErrorContext {
+	return cr.contextAt(cr.column(), expected)
+}
+
+// contextAt returns an [ErrorContext] for the given column.
+func (cr *lineReader) contextAt(column int, expected string) ErrorContext {
+	return ErrorContext{Line: cr.line, Column: column, Text: cr.text, Expected: expected}
+}
+
+// runeCounter wraps a [strings.Reader] and counts how many runes have been read, minus carriage returns since the
+// CharReader skips those.
+type runeCounter struct {
+	source *strings.Reader
+	n      int
+}
+
+func (c *runeCounter) ReadRune() (rune, int, error) {
+	r, size, err := c.source.ReadRune()
+	if err == nil && r != '\r' {
+		c.n++
+	}
+	return r, size, err
+}
+
+// parseEvent parses a single line of a time log. Blank lines and comments result in a nil [Event] and no error.
+// A lot of this code comes from my Ledger parser.
+func parseEvent(cr *lineReader) (*Event, error) {
+	// Eat any leading white space, also lines that are blank.
+	cr.Eat(" \t")
+	if cr.C == '\n' {
+		return nil, nil
+	}
+
+	// Consume comments.
+	if cr.C == '#' {
+		return nil, nil
+	}
+
+	current := &Event{}
+
+	// Parse the date/time
+	date, err := parseDate(cr)
+	if err != nil {
+		return nil, err
+	}
+	current.At = date
+
+	// Whitespace
+	cr.Eat(" \t")
+	if cr.EOF {
+		return nil, ErrUnexpectedEnd{cr.context("a time code or description")}
+	}
+
+	// Track, if it isn't the main track.
+	if cr.C == '@' {
+		cr.Next()
+		track, err := readUntilTrimmed(cr, " \t[\n")
+		if err != nil {
+			return nil, err
+		}
+		if track == "" {
+			return nil, ErrMalformed{cr.context("a track name after '@'")}
+		}
+		current.Track = track
 
-		// Even more ws
 		cr.Eat(" \t")
 		if cr.EOF {
-			return nil, ErrUnexpectedEnd(cr.L)
+			return nil, ErrUnexpectedEnd{cr.context("a time code or description")}
 		}
+	}
 
-		// And, to cap it off, the description.
-		desc, err := readUntilTrimmed(cr, "\n")
+	// Time code
+	if cr.C == '[' {
+		cr.Next()
+		cr.Eat(" \t")
+		code, err := readUntilTrimmed(cr, "]\n")
 		if err != nil {
 			return nil, err
 		}
-		current.Desc = desc
+		if cr.C == '\n' {
+			return nil, ErrMalformed{cr.context("']' to close the time code")}
+		}
+		current.Code = code
 		cr.Next()
+	}
+
+	// Even more ws
+	cr.Eat(" \t")
+	if cr.EOF {
+		return nil, ErrUnexpectedEnd{cr.context("a description")}
+	}
+
+	// And, to cap it off, the description.
+	desc, err := readUntilTrimmed(cr, "\n")
+	if err != nil {
+		return nil, err
+	}
+	current.Desc = desc
+
+	return current, nil
+}
 
-		log = append(log, current)
+// parseEventFast parses an ordinary event line without a [lex.CharReader], which is most of the time spent reading a
+// long log. Anything out of the ordinary, including every line parseEvent would reject, is left to parseEvent so the
+// two always agree and errors still say where the problem is.
+func parseEventFast(line string) (*Event, bool) {
+	if len(line) < len(TimeFormat) || !utf8.ValidString(line) || strings.IndexByte(line, '\r') >= 0 {
+		return nil, false
+	}
+	for i, c := range []byte(line[:len(TimeFormat)]) {
+		var ok bool
+		switch i {
+		case 4, 7:
+			ok = c == '/' || c == '-' || c == '.'
+		case 10:
+			ok = c == ' '
+		case 13:
+			ok = c == ':'
+		case 16:
+			ok = c == 'a' || c == 'p' || c == 'A' || c == 'P'
+		case 17:
+			ok = c == 'm' || c == 'M'
+		default:
+			ok = c >= '0' && c <= '9'
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	date := line[:len(TimeFormat)]
+	if date[4] != '/' || date[7] != '/' {
+		date = date[:4] + "/" + date[5:7] + "/" + date[8:]
+	}
+	at, err := time.ParseInLocation(TimeFormat, date, time.Local)
+	if err != nil {
+		return nil, false
 	}
+	current := &Event{At: at}
 
-	return log, nil
+	rest := strings.TrimLeft(line[len(TimeFormat):], " \t")
+	if track, ok := strings.CutPrefix(rest, "@"); ok {
+		end := strings.IndexAny(track, " \t[")
+		if end < 0 {
+			end = len(track)
+		}
+		current.Track = strings.Trim(track[:end], " \t")
+		if current.Track == "" {
+			return nil, false
+		}
+		rest = strings.TrimLeft(track[end:], " \t")
+	}
+	if code, ok := strings.CutPrefix(rest, "["); ok {
+		end := strings.IndexByte(code, ']')
+		if end < 0 {
+			return nil, false
+		}
+		current.Code = strings.Trim(code[:end], " \t")
+		rest = code[end+1:]
+	}
+	current.Desc = strings.Trim(rest, " \t")
+	return current, true
 }
 
 // readUntilTrimmed reads characters from the [lex.CharReader] until one of the characters in `chars` is found.
 // The result then has all the whitespace trimmed from the ends.
-func readUntilTrimmed(cr *lex.CharReader, chars string) (string, error) {
-	ln := []rune{}
+func readUntilTrimmed(cr *lineReader, chars string) (string, error) {
+	ln := make([]rune, 0, 64)
 	ln = cr.ReadUntil(chars, ln)
 	if cr.EOF {
-		return "", ErrUnexpectedEnd(cr.L)
+		return "", ErrUnexpectedEnd{cr.context(fmt.Sprintf("one of %q", chars))}
 	}
 	// Trim trailing ws
 	for i := len(ln) - 1; i > 0; i-- {
@@ -204,20 +600,22 @@ func readUntilTrimmed(cr *lex.CharReader, chars string) (string, error) {
 }
 
 // parseDate reads a date and time (in yyyy/mm/dd hh:mmPM format) from the [lex.CharReader].
-func parseDate(cr *lex.CharReader) (time.Time, error) {
-	date := []rune{}
+func parseDate(cr *lineReader) (time.Time, error) {
+	date := make([]rune, 0, len(TimeFormat))
 	ok := false
 	var t time.Time
 
+	begin := cr.column()
+
 	// "2006"
 	ok, date = cr.ReadMatchLimit("0123456789", date, 4)
 	if !ok {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("a 4 digit year")}
 	}
 
 	// "2006/"
 	if !cr.Match("/-.") {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("'/', '-', or '.' after the year")}
 	}
 	date = append(date, '/')
 	cr.Next()
@@ -225,12 +623,12 @@ func parseDate(cr *lex.CharReader) (time.Time, error) {
 	// "2006/01"
 	ok, date = cr.ReadMatchLimit("0123456789", date, 2)
 	if !ok {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("a 2 digit month")}
 	}
 
 	// "2006/01/"
 	if !cr.Match("/-.") {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("'/', '-', or '.' after the month")}
 	}
 	date = append(date, '/')
 	cr.Next()
@@ -238,12 +636,12 @@ func parseDate(cr *lex.CharReader) (time.Time, error) {
 	// "2006/01/02"
 	ok, date = cr.ReadMatchLimit("0123456789", date, 2)
 	if !ok {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("a 2 digit day")}
 	}
 
 	// "2006/01/02 "
 	if !cr.Match(" ") {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("a single space between the date and the time")}
 	}
 	date = append(date, ' ')
 	cr.Next()
@@ -251,12 +649,12 @@ func parseDate(cr *lex.CharReader) (time.Time, error) {
 	// "2006/01/02 03"
 	ok, date = cr.ReadMatchLimit("0123456789", date, 2)
 	if !ok {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("a 2 digit hour")}
 	}
 
 	// "2006/01/02 03:"
 	if !cr.Match(":") {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("':' after the hour")}
 	}
 	date = append(date, ':')
 	cr.Next()
@@ -264,41 +662,106 @@ func parseDate(cr *lex.CharReader) (time.Time, error) {
 	// "2006/01/02 03:04"
 	ok, date = cr.ReadMatchLimit("0123456789", date, 2)
 	if !ok {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("a 2 digit minute")}
 	}
 
 	// "2006/01/02 03:04P"
 	ok, date = cr.ReadMatchLimit("apAP", date, 1)
 	if !ok {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("AM or PM")}
 	}
 
 	// "2006/01/02 03:04PM"
 	ok, date = cr.ReadMatchLimit("mM", date, 1)
 	if !ok {
-		return t, ErrBadDate(cr.L)
+		return t, ErrBadDate{cr.context("AM or PM")}
 	}
 
-	return time.ParseInLocation(TimeFormat, string(date), time.Local)
+	t, err := time.ParseInLocation(TimeFormat, string(date), time.Local)
+	if err != nil {
+		// Everything was shaped right, but the values are nonsense (month 13, hour 00, etc).
+		return t, ErrBadDate{cr.contextAt(begin, "a real date and 12 hour time")}
+	}
+	return t, nil
+}
+
+// ErrorContext holds the details shared by all the parser errors, where the problem is and what was wanted instead.
+type ErrorContext struct {
+	Line     int    // Line number, starting from 1.
+	Column   int    // Column number, starting from 1.
+	Text     string // The full text of the offending line.
+	Expected string // A short hint describing what the parser was looking for.
+}
+
+func (ctx ErrorContext) String() string {
+	return fmt.Sprintf("line %v, column %v: expected %s", ctx.Line, ctx.Column, ctx.Expected)
+}
+
+// Snippet returns the offending line with a caret under the offending column, suitable for printing in a terminal.
+func (ctx ErrorContext) Snippet() string {
+	// Tabs are kept so the caret lines up no matter how wide the terminal thinks a tab is.
+	pad := []rune{}
+	for i, r := range []rune(ctx.Text) {
+		if i >= ctx.Column-1 {
+			break
+		}
+		if r == '\t' {
+			pad = append(pad, '\t')
+			continue
+		}
+		pad = append(pad, ' ')
+	}
+	for len(pad) < ctx.Column-1 {
+		pad = append(pad, ' ')
+	}
+	return fmt.Sprintf("%s\n%s^", ctx.Text, string(pad))
 }
 
 // ErrBadDate is returned by the parser when it attempts to consume an invalid date.
-type ErrBadDate lex.Location
+type ErrBadDate struct{ ErrorContext }
 
 func (err ErrBadDate) Error() string {
-	return fmt.Sprintf("Malformed event date on line: %v", lex.Location(err))
+	return fmt.Sprintf("Malformed event date on %v", err.ErrorContext)
 }
 
 // ErrUnexpectedEnd is returned by the parser when the end of input is found unexpectedly.
-type ErrUnexpectedEnd lex.Location
+type ErrUnexpectedEnd struct{ ErrorContext }
 
 func (err ErrUnexpectedEnd) Error() string {
-	return fmt.Sprintf("Unexpected end of input on line: %v", lex.Location(err))
+	return fmt.Sprintf("Unexpected end of input on %v", err.ErrorContext)
 }
 
 // ErrMalformed is returned by the parser when it finds a malformed [Event].
-type ErrMalformed lex.Location
+type ErrMalformed struct{ ErrorContext }
 
 func (err ErrMalformed) Error() string {
-	return fmt.Sprintf("Malformed event on line: %v", lex.Location(err))
+	return fmt.Sprintf("Malformed event on %v", err.ErrorContext)
+}
+
+// ErrUnrepresentable is returned by the formatter when an [Event] can't be written such that it would parse back the
+// same way.
+type ErrUnrepresentable struct {
+	Event  *Event
+	Reason string
+}
+
+func (err ErrUnrepresentable) Error() string {
+	return fmt.Sprintf("Cannot write event %q: %s", err.Event.String(), err.Reason)
+}
+
+// ErrWrite is returned by [TimeLog.Format] when writing fails. Event is the one being written when the error turned up,
+// and Index is where it is in the log. Writes are buffered, so some of the events before it may not have made it
+// either.
+type ErrWrite struct {
+	Index int
+	Event *Event
+	Err   error
+}
+
+func (err ErrWrite) Error() string {
+	return fmt.Sprintf("Error writing event %d (%q): %v", err.Index+1, err.Event.String(), err.Err)
+}
+
+func (err ErrWrite) Unwrap() error {
+	return err.Err
 }
@@ -0,0 +1,257 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTrip writes the log and reads it back.
+func roundTrip(t *testing.T, log TimeLog) TimeLog {
+	t.Helper()
+	b := &strings.Builder{}
+	err := log.FormatFile(b)
+	if err != nil {
+		t.Fatalf("formatting: %v", err)
+	}
+	reread, err := ParseTimeLogString(b.String())
+	if err != nil {
+		t.Fatalf("reading back:\n%s\n%v", b.String(), err)
+	}
+	return reread
+}
+
+func TestDescriptionRoundTrip(t *testing.T) {
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		desc string
+		want string // What it reads back as, after Format normalizes it.
+		bad  bool   // Format refuses it.
+	}{
+		{desc: "one line", want: "one line"},
+		{desc: "first\nsecond", want: "first\nsecond"},
+		{desc: "first\n\nthird", want: "first\n\nthird"},
+		{desc: "  padded  \n  lines  \n\n", want: "padded\nlines"},
+		{desc: "first\n# not a comment", want: "first\n# not a comment"},
+		{desc: "first\n2026/10/12 10:00AM [X] not an event", want: "first\n2026/10/12 10:00AM [X] not an event"},
+		{desc: "; fine on the first line", want: "; fine on the first line"},
+		{desc: "\nstarts blank", want: "\nstarts blank"},
+		{desc: "first\n; not meta", bad: true},
+		{desc: "x\n;k: v", bad: true},
+		{desc: "x\n   ;k: v", bad: true},
+	}
+	for _, test := range tests {
+		log := TimeLog{{At: at, Code: "Proj", Desc: test.desc}, {At: at.Add(time.Hour)}}
+		err := log.VerifyFormat()
+		var unrep ErrUnrepresentable
+		if test.bad {
+			if !errors.As(err, &unrep) {
+				t.Errorf("%q: got %v, want ErrUnrepresentable", test.desc, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.desc, err)
+			continue
+		}
+		reread := roundTrip(t, log)
+		if reread[0].Desc != test.want || reread[0].Meta != nil {
+			t.Errorf("%q: read back as %q with meta %v, want %q", test.desc, reread[0].Desc, reread[0].Meta, test.want)
+		}
+	}
+}
+
+// FuzzParseFormat checks that whatever the parser makes of its input can be written, and that writing it again after
+// reading it back changes nothing.
+func FuzzParseFormat(f *testing.F) {
+	seeds := []string{
+		"",
+		"2026/10/12 09:00AM [Proj] fixing bug\n2026/10/12 10:00AM []\n",
+		HeaderPrefix + "1\n2026/10/12 09:00AM @build [Proj] first\n\tsecond\n\t; k: v\n2026/10/12 10:00AM\n",
+		"# comment\n\n2026-10-12 09:00am [ X ]   desc  \r\n\tmore\r\n",
+		"i 2026/10/12 09:00:00 Customer:Dev  Fixing the thing\no 2026/10/12 10:30:00\n",
+		"2026/10/12 09:00AM [Proj] a\n  2026/10/12 10:00AM [Ops] indented\n\t# comment\n",
+		"2026/10/12 09:00AM [\u4e2d\u6587] \U0001F469\u200D\U0001F4BB\n\t;\u00e9: \u00fc\n",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		log, _ := ParseTimeLogLenient(input)
+		first := &strings.Builder{}
+		err := log.FormatFile(first)
+		if err != nil {
+			t.Fatalf("parsed log can't be written: %v", err)
+		}
+		reread, err := ParseTimeLogString(first.String())
+		if err != nil {
+			t.Fatalf("written log doesn't parse:\n%s\n%v", first.String(), err)
+		}
+		if len(reread) != len(log) {
+			t.Fatalf("read back %d events instead of %d:\n%s", len(reread), len(log), first.String())
+		}
+		second := &strings.Builder{}
+		err = reread.FormatFile(second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.String() != second.String() {
+			t.Fatalf("writing again changed the log:\n%s\nbecame\n%s", first.String(), second.String())
+		}
+	})
+}
+
+// pieces are what randomEvent builds text from, picked to include everything the format treats specially.
+var pieces = []string{
+	"fix", "bug", " ", "  ", "\t", "\n", "\n\n", "#", ";", ":", "[", "]", "@", "\r", "2026/10/12 10:00AM", "\u4e2d\u6587",
+	"\U0001F469\u200D\U0001F4BB", "e\u0301", "\xff",
+}
+
+func randomText(r *rand.Rand, n int) string {
+	b := &strings.Builder{}
+	for i := r.Intn(n + 1); i > 0; i-- {
+		b.WriteString(pieces[r.Intn(len(pieces))])
+	}
+	return b.String()
+}
+
+func randomEvent(r *rand.Rand, at time.Time) *Event {
+	e := &Event{At: at, Code: randomText(r, 3), Desc: randomText(r, 8)}
+	if r.Intn(4) == 0 {
+		e.Track = []string{"build", "clock", "x", "bad track", ""}[r.Intn(5)]
+	}
+	if r.Intn(3) == 0 {
+		e.Meta = map[string]string{}
+		for i := r.Intn(3); i >= 0; i-- {
+			e.Meta[randomText(r, 2)] = randomText(r, 3)
+		}
+	}
+	return e
+}
+
+// normalized is the event as Format writes it, which is how it should read back.
+func normalized(e *Event) string {
+	meta := []string{}
+	for k, v := range e.Meta {
+		meta = append(meta, strings.Trim(k, " \t")+"="+strings.Trim(v, " \t"))
+	}
+	sort.Strings(meta)
+	return fmt.Sprintf("%s @%s [%s] %q %q", e.At.Format(TimeFormat), e.Track, strings.Trim(e.Code, " \t"), strings.Join(descLines(e.Desc), "\n"), meta)
+}
+
+// TestRoundTripProperty writes random events, and checks that the ones Validate accepts read back as they were
+// written, and that Format refuses the rest.
+func TestRoundTripProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	accepted := 0
+	for i := 0; i < 5000; i++ {
+		e := randomEvent(r, at.Add(time.Duration(i)*time.Minute))
+		log := TimeLog{e}
+		if e.Validate() != nil {
+			if err := log.FormatFile(&strings.Builder{}); err == nil {
+				t.Fatalf("%q: Validate refused it but Format wrote it", e.String())
+			}
+			continue
+		}
+		accepted++
+		reread := roundTrip(t, log)
+		if len(reread) != 1 || normalized(reread[0]) != normalized(e) {
+			t.Fatalf("%s\nread back as\n%v", normalized(e), reread)
+		}
+	}
+	if accepted < 500 {
+		t.Fatalf("only %d of the random events were valid, the generator needs fixing", accepted)
+	}
+}
+
+// fastLines are event lines for checking parseEventFast against parseEvent, odd ones included.
+var fastLines = []string{
+	"2026/10/12 09:00AM [Acme:Dev] fixing bug",
+	"2026/10/12 09:00am [Acme:Dev] lower case am",
+	"2026-10-12 09:00PM [ Acme:Dev ]   padded  ",
+	"2026.10.12 12:30PM @phone [Acme] on a track",
+	"2026/10/12 09:00AM @phone",
+	"2026/10/12 09:00AM @ [Acme]",
+	"2026/10/12 09:00AM @phone[Acme]desc",
+	"2026/10/12 09:00AM[Acme]desc",
+	"2026/10/12 09:00AM",
+	"2026/10/12 09:00AM   ",
+	"2026/10/12 09:00AM [Acme",
+	"2026/10/12 09:00AM [] no code",
+	"2026/10/12 09:00AM [Acme] [not a code] ]",
+	"2026/10/12 09:00AM\tdesc after a tab",
+	"2026/13/12 09:00AM bad month",
+	"2026/02/30 09:00AM bad day",
+	"2026/10/12 13:00PM bad hour",
+	"2026/10/12 00:30AM zero hour",
+	"2026/10/12 9:00AM short hour",
+	"2026/10/12  09:00AM two spaces",
+	"2026/10/12 09:00XM not AM or PM",
+	"2026/10/12 09:00AM caf\xe9 bad UTF-8",
+	"2026/10/12 09:00AM carriage\rreturn",
+	"2026/10/12 09:00AM [项目] 会议",
+	" 2026/10/12 09:00AM indented",
+	"# comment",
+	"",
+}
+
+func TestParseEventFast(t *testing.T) {
+	for i, line := range fastLines {
+		checkFastParse(t, line, i+1)
+	}
+}
+
+// checkFastParse fails if parseEventFast takes a line parseEvent doesn't, or reads it differently.
+func checkFastParse(t *testing.T, line string, n int) {
+	t.Helper()
+	fast, ok := parseEventFast(line)
+	if !ok {
+		return
+	}
+	slow, err := parseEvent(newLineReader(line, n))
+	if err != nil {
+		t.Fatalf("%q: the fast parser took a line the full one rejects: %v", line, err)
+	}
+	if slow == nil || !fast.At.Equal(slow.At) || fast.Track != slow.Track || fast.Code != slow.Code || fast.Desc != slow.Desc {
+		t.Fatalf("%q: the fast parser read %+v, the full one %+v", line, fast, slow)
+	}
+}
+
+func FuzzParseEventFast(f *testing.F) {
+	for _, line := range fastLines {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		if strings.Contains(line, "\n") {
+			return
+		}
+		checkFastParse(t, line, 1)
+	})
+}
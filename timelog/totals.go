@@ -0,0 +1,139 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Week is a week, numbered like an ISO week as returned by time.Time.ISOWeek.
+type Week struct {
+	Year   int
+	Number int
+}
+
+// Calendar is when days and weeks start, for working out which day or week something counts for. Everything is in
+// local time. The zero Calendar has weeks starting on Sunday, set WeekStart to time.Monday for ISO weeks.
+type Calendar struct {
+	// The day weeks start on. Weeks that don't start on Monday still get the number of the ISO week their Monday is in,
+	// so a week starting on Sunday has the number of the week that starts the day after.
+	WeekStart time.Weekday
+
+	// How long after midnight a day starts, for night owls who want the hours after midnight to count for the day
+	// before. It is wall clock time, so on days when the clocks change a DayStart of 4h is still 4AM.
+	DayStart time.Duration
+}
+
+// ParseWeekday parses the name of a day of the week, eg "sunday" or "Sun".
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if long := strings.ToLower(d.String()); name != "" && (name == long || name == long[:3]) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q, expected a day of the week like monday or sunday", name)
+}
+
+// WeekdayIndex returns how many days into the week the given day is, 0 for WeekStart.
+func (c Calendar) WeekdayIndex(d time.Weekday) int {
+	return (int(d) - int(c.WeekStart) + 7) % 7
+}
+
+// StartOfWeek returns the start of the first day of the week the day t falls on is in, see WeekStart and Day.
+func (c Calendar) StartOfWeek(t time.Time) time.Time {
+	day := c.Day(t)
+	return c.StartOfDay(day.Year(), day.Month(), day.Day()-c.WeekdayIndex(day.Weekday()))
+}
+
+// WeekOf returns the week the day t falls on is in.
+func (c Calendar) WeekOf(t time.Time) Week {
+	start := c.StartOfWeek(t)
+	y, w := start.AddDate(0, 0, c.WeekdayIndex(time.Monday)).ISOWeek()
+	return Week{Year: y, Number: w}
+}
+
+// StartOfDay returns the time the day with the given date starts at. This is midnight unless DayStart is set. If the
+// clocks skip over the day start, the day starts when they jump.
+func (c Calendar) StartOfDay(year int, month time.Month, day int) time.Time {
+	h, m := int(c.DayStart/time.Hour), int(c.DayStart%time.Hour/time.Minute)
+	t := time.Date(year, month, day, h, m, 0, 0, time.Local)
+	if t.Hour() != h || t.Minute() != m {
+		// time.Date picks a time before the jump for wall clock times that don't exist.
+		_, t = t.ZoneBounds()
+	}
+	return t
+}
+
+// Day returns the start of the day t falls on, see StartOfDay. This is the key used by TotalsByDay, and the date of
+// the returned time is the date of the day, even if t is after midnight and before DayStart.
+func (c Calendar) Day(t time.Time) time.Time {
+	t = t.In(time.Local)
+	start := c.StartOfDay(t.Year(), t.Month(), t.Day())
+	if t.Before(start) {
+		start = c.StartOfDay(t.Year(), t.Month(), t.Day()-1)
+	}
+	return start
+}
+
+// Totals adds up the length of the periods for each code. Spellings of the same code (see CodeKey) are added up
+// together, under the first one.
+func Totals(periods []*Period, fold bool) map[string]time.Duration {
+	totals := map[string]time.Duration{}
+	names := CodeNames{Fold: fold}
+	for _, p := range periods {
+		totals[names.Name(p.Code)] += p.Length()
+	}
+	return totals
+}
+
+// TotalsByDay is Totals, split up by the day each period counts for (see [Period.Day]). Periods that run into the next
+// day are not split, they count entirely for the day they began on.
+func TotalsByDay(periods []*Period, cal Calendar, fold bool) map[time.Time]map[string]time.Duration {
+	days := map[time.Time]map[string]time.Duration{}
+	names := CodeNames{Fold: fold}
+	for _, p := range periods {
+		day := p.Day(cal)
+		if days[day] == nil {
+			days[day] = map[string]time.Duration{}
+		}
+		days[day][names.Name(p.Code)] += p.Length()
+	}
+	return days
+}
+
+// TotalsByWeek is Totals, split up by the week (see [Calendar.WeekOf]) of the day each period counts for.
+func TotalsByWeek(periods []*Period, cal Calendar, fold bool) map[Week]map[string]time.Duration {
+	weeks := map[Week]map[string]time.Duration{}
+	names := CodeNames{Fold: fold}
+	for _, p := range periods {
+		week := cal.WeekOf(p.Day(cal))
+		if weeks[week] == nil {
+			weeks[week] = map[string]time.Duration{}
+		}
+		weeks[week][names.Name(p.Code)] += p.Length()
+	}
+	return weeks
+}
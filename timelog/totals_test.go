@@ -0,0 +1,274 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"testing"
+	"time"
+)
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+}
+
+func period(code string, begin, end time.Time) *Period {
+	return &Period{Begin: begin, End: end, Code: code}
+}
+
+func TestTotals(t *testing.T) {
+	periods := []*Period{
+		period("Client:Dev", at(12, 9, 0), at(12, 11, 0)),
+		period("client/dev", at(12, 13, 0), at(12, 13, 30)),
+		period("Client:Dev:Bug", at(12, 14, 0), at(12, 15, 0)),
+		period("", at(12, 15, 0), at(12, 16, 0)),
+	}
+
+	folded := Totals(periods, true)
+	if len(folded) != 3 || folded["Client:Dev"] != 150*time.Minute || folded["Client:Dev:Bug"] != time.Hour || folded[""] != time.Hour {
+		t.Errorf("folded totals are %v", folded)
+	}
+
+	// Without folding the second spelling is a code of its own, with its separators still normalized.
+	kept := Totals(periods, false)
+	if len(kept) != 4 || kept["Client:Dev"] != 2*time.Hour || kept["client:dev"] != 30*time.Minute {
+		t.Errorf("totals without folding are %v", kept)
+	}
+
+	excluded := period("Client:Dev", at(12, 9, 0), at(12, 10, 0))
+	excluded.Excluded = 15 * time.Minute
+	if got := Totals([]*Period{excluded}, true)["Client:Dev"]; got != 45*time.Minute {
+		t.Errorf("excluded time was counted, got %v", got)
+	}
+}
+
+func TestTotalsByDay(t *testing.T) {
+	tests := []struct {
+		name     string
+		cal      Calendar
+		periods  []*Period
+		want     map[time.Time]time.Duration // Total for each day, by the start of the day.
+		wantDays int
+	}{
+		{
+			name: "a period over midnight counts for the day it began",
+			cal:  Calendar{WeekStart: time.Monday},
+			periods: []*Period{
+				period("A", at(12, 22, 0), at(13, 2, 0)),
+				period("A", at(13, 9, 0), at(13, 10, 0)),
+			},
+			want: map[time.Time]time.Duration{at(12, 0, 0): 4 * time.Hour, at(13, 0, 0): time.Hour},
+		},
+		{
+			name: "before the day start is the day before",
+			cal:  Calendar{WeekStart: time.Monday, DayStart: 4 * time.Hour},
+			periods: []*Period{
+				period("A", at(12, 22, 0), at(12, 23, 0)),
+				period("A", at(13, 1, 0), at(13, 3, 0)),
+				period("A", at(13, 4, 0), at(13, 5, 0)),
+			},
+			want: map[time.Time]time.Duration{at(12, 4, 0): 3 * time.Hour, at(13, 4, 0): time.Hour},
+		},
+		{
+			name: "a night shift goes with the day it started",
+			cal:  Calendar{WeekStart: time.Monday},
+			periods: []*Period{
+				{Begin: at(13, 1, 0), End: at(13, 3, 0), Code: "A", Shift: at(12, 0, 0)},
+				period("A", at(13, 9, 0), at(13, 10, 0)),
+			},
+			want: map[time.Time]time.Duration{at(12, 0, 0): 2 * time.Hour, at(13, 0, 0): time.Hour},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			days := TotalsByDay(test.periods, test.cal, true)
+			if len(days) != len(test.want) {
+				t.Errorf("got %d days, want %d: %v", len(days), len(test.want), days)
+			}
+			for day, want := range test.want {
+				if got := days[day]["A"]; got != want {
+					t.Errorf("%s has %v, want %v", day.Format(TimeFormat), got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTotalsByWeek(t *testing.T) {
+	// 2026/10/11 is a Sunday, 2026/10/12 a Monday in ISO week 42, and 2026/10/18 the Sunday after.
+	periods := []*Period{
+		period("A", at(11, 9, 0), at(11, 10, 0)),
+		period("A", at(12, 9, 0), at(12, 11, 0)),
+		period("A", at(17, 23, 0), at(18, 1, 0)),
+		period("A", at(18, 9, 0), at(18, 13, 0)),
+	}
+
+	tests := []struct {
+		cal  Calendar
+		want map[Week]time.Duration
+	}{
+		// Weeks from Monday: Sunday the 11th ends week 41. The period over Saturday midnight stays in week 42.
+		{Calendar{WeekStart: time.Monday}, map[Week]time.Duration{{2026, 41}: time.Hour, {2026, 42}: 8 * time.Hour}},
+
+		// Weeks from Sunday take the number of the ISO week their Monday is in, so the 11th starts week 42 and the 18th
+		// starts week 43.
+		{Calendar{WeekStart: time.Sunday}, map[Week]time.Duration{{2026, 42}: 5 * time.Hour, {2026, 43}: 4 * time.Hour}},
+
+		// Weeks from Saturday: the 17th starts week 43, taking the period over midnight with it.
+		{Calendar{WeekStart: time.Saturday}, map[Week]time.Duration{{2026, 42}: 3 * time.Hour, {2026, 43}: 6 * time.Hour}},
+	}
+	for _, test := range tests {
+		weeks := TotalsByWeek(periods, test.cal, true)
+		if len(weeks) != len(test.want) {
+			t.Errorf("weeks from %s: got %v, want %v", test.cal.WeekStart, weeks, test.want)
+			continue
+		}
+		for week, want := range test.want {
+			if got := weeks[week]["A"]; got != want {
+				t.Errorf("weeks from %s: week %d has %v, want %v", test.cal.WeekStart, week.Number, got, want)
+			}
+		}
+	}
+}
+
+func TestWeekOfYearEnd(t *testing.T) {
+	cal := Calendar{WeekStart: time.Sunday}
+	// Sunday 2026/12/27 starts the week with Monday the 28th in it, which is ISO week 53 of 2026. Sunday 2027/01/03
+	// starts week 1 of 2027.
+	for _, test := range []struct {
+		day  time.Time
+		want Week
+	}{
+		{time.Date(2026, 12, 27, 12, 0, 0, 0, time.Local), Week{2026, 53}},
+		{time.Date(2027, 1, 2, 12, 0, 0, 0, time.Local), Week{2026, 53}},
+		{time.Date(2027, 1, 3, 12, 0, 0, 0, time.Local), Week{2027, 1}},
+	} {
+		if got := cal.WeekOf(test.day); got != test.want {
+			t.Errorf("WeekOf(%s) = %v, want %v", test.day.Format("2006/01/02"), got, test.want)
+		}
+	}
+}
+
+func TestDayStartOverDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	local := time.Local
+	time.Local = loc
+	defer func() { time.Local = local }()
+
+	// In New York the clocks go forward from 2AM to 3AM on 2026/03/08, and back from 2AM to 1AM on 2026/11/01.
+	date := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+	tests := []struct {
+		name     string
+		dayStart time.Duration
+		t        time.Time
+		want     time.Time     // The start of the day t counts for.
+		length   time.Duration // How long that day is.
+	}{
+		{"spring forward, before the change", 4 * time.Hour, date(3, 8, 1, 30), date(3, 7, 4, 0), 23 * time.Hour},
+		{"spring forward, after the change", 4 * time.Hour, date(3, 8, 3, 30), date(3, 7, 4, 0), 23 * time.Hour},
+		{"spring forward, at the day start", 4 * time.Hour, date(3, 8, 4, 0), date(3, 8, 4, 0), 24 * time.Hour},
+		{"spring forward, evening", 4 * time.Hour, date(3, 8, 23, 0), date(3, 8, 4, 0), 24 * time.Hour},
+		{"fall back, first 1:30", 4 * time.Hour, date(11, 1, 1, 30), date(10, 31, 4, 0), 25 * time.Hour},
+		{"fall back, second 1:30", 4 * time.Hour, date(11, 1, 1, 30).Add(time.Hour), date(10, 31, 4, 0), 25 * time.Hour},
+		{"fall back, at the day start", 4 * time.Hour, date(11, 1, 4, 0), date(11, 1, 4, 0), 24 * time.Hour},
+		{"fall back, next morning", 4 * time.Hour, date(11, 2, 3, 59), date(11, 1, 4, 0), 24 * time.Hour},
+
+		// A day start of 2AM doesn't exist on the day the clocks go forward, so that day starts when they jump to 3AM.
+		{"day start skipped", 2 * time.Hour, date(3, 8, 3, 30), date(3, 8, 3, 0), 23 * time.Hour},
+		{"day start skipped, just before", 2 * time.Hour, date(3, 8, 1, 59), date(3, 7, 2, 0), 24 * time.Hour},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cal := Calendar{WeekStart: time.Monday, DayStart: test.dayStart}
+			got := cal.Day(test.t)
+			if !got.Equal(test.want) {
+				t.Fatalf("Day(%s) = %s, want %s", test.t, got, test.want)
+			}
+			next := cal.StartOfDay(got.Year(), got.Month(), got.Day()+1)
+			if length := next.Sub(got); length != test.length {
+				t.Errorf("the day starting %s is %v long, want %v", got, length, test.length)
+			}
+		})
+	}
+
+	// A night worked through the change is counted for the evening it started, at its real length.
+	cal := Calendar{WeekStart: time.Monday, DayStart: 4 * time.Hour}
+	periods := []*Period{
+		period("A", date(3, 7, 22, 0), date(3, 8, 3, 30)),
+		period("A", date(10, 31, 22, 0), date(11, 1, 3, 30)),
+	}
+	days := TotalsByDay(periods, cal, true)
+	if got := days[date(3, 7, 4, 0)]["A"]; got != 4*time.Hour+30*time.Minute {
+		t.Errorf("the night the clocks went forward has %v, want 4h30m", got)
+	}
+	if got := days[date(10, 31, 4, 0)]["A"]; got != 6*time.Hour+30*time.Minute {
+		t.Errorf("the night the clocks went back has %v, want 6h30m", got)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	tests := []struct {
+		name string
+		want time.Weekday
+		err  bool
+	}{
+		{"sunday", time.Sunday, false},
+		{"Monday", time.Monday, false},
+		{" SAT ", time.Saturday, false},
+		{"thu", time.Thursday, false},
+		{"", 0, true},
+		{"mo", 0, true},
+		{"mondays", 0, true},
+		{"1", 0, true},
+	}
+	for _, test := range tests {
+		got, err := ParseWeekday(test.name)
+		if (err != nil) != test.err || got != test.want {
+			t.Errorf("ParseWeekday(%q) = %v, %v, want %v (error %v)", test.name, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestWeekdayIndex(t *testing.T) {
+	for start := time.Sunday; start <= time.Saturday; start++ {
+		cal := Calendar{WeekStart: start}
+		seen := map[int]bool{}
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			i := cal.WeekdayIndex(d)
+			if i < 0 || i > 6 || seen[i] {
+				t.Errorf("weeks starting %v: %v has index %d", start, d, i)
+			}
+			seen[i] = true
+		}
+		if i := cal.WeekdayIndex(start); i != 0 {
+			t.Errorf("weeks starting %v: first day has index %d", start, i)
+		}
+		if i := cal.WeekdayIndex((start + 6) % 7); i != 6 {
+			t.Errorf("weeks starting %v: last day has index %d", start, i)
+		}
+	}
+}
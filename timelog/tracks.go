@@ -0,0 +1,202 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Last returns the last [Event] on the given track, or nil if there are none. The TimeLog should be sorted.
+func (log TimeLog) Last(track string) *Event {
+	for i := len(log) - 1; i >= 0; i-- {
+		if log[i].Track == track {
+			return log[i]
+		}
+	}
+	return nil
+}
+
+// First returns the first [Event] on the given track, or nil if there are none. The TimeLog should be sorted.
+func (log TimeLog) First(track string) *Event {
+	for _, e := range log {
+		if e.Track == track {
+			return e
+		}
+	}
+	return nil
+}
+
+// Tracks returns the names of all the tracks used in the TimeLog, sorted. The main track is included (as "") only if
+// it has events.
+func (log TimeLog) Tracks() []string {
+	found := map[string]bool{}
+	for _, item := range log {
+		found[item.Track] = true
+	}
+
+	out := []string{}
+	for track := range found {
+		out = append(out, track)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// OverlapMode sets how time is counted when periods on different tracks overlap.
+type OverlapMode int
+
+const (
+	// OverlapFull counts every period in full, so overlapping time is counted more than once.
+	OverlapFull OverlapMode = iota
+
+	// OverlapSplit divides overlapping time evenly between all the periods it overlaps.
+	OverlapSplit
+
+	// OverlapMain gives overlapping time to the main track. Other tracks only count when the main track has nothing
+	// going on, and overlaps between them are counted in full.
+	OverlapMain
+)
+
+// ParseOverlapMode converts the name of an overlap mode ("full", "split", or "main") to an OverlapMode.
+func ParseOverlapMode(name string) (OverlapMode, error) {
+	switch name {
+	case "full", "":
+		return OverlapFull, nil
+	case "split":
+		return OverlapSplit, nil
+	case "main":
+		return OverlapMain, nil
+	}
+	return OverlapFull, fmt.Errorf("unknown overlap mode %q, expected 'full', 'split', or 'main'", name)
+}
+
+// AttributeOverlap works out how much of each period is excluded by the overlap mode, and sets [Period.Excluded] to
+// match. Periods without a time code mark time off the clock, so they can't overlap with anything.
+func AttributeOverlap(periods []*Period, mode OverlapMode) {
+	active := []*Period{}
+	bounds := []time.Time{}
+	for _, p := range periods {
+		p.Excluded = 0
+		if mode != OverlapFull && p.Code != "" && p.End.After(p.Begin) {
+			active = append(active, p)
+			bounds = append(bounds, p.Begin, p.End)
+		}
+	}
+	if len(active) < 2 {
+		return
+	}
+
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].Begin.Before(active[j].Begin)
+	})
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i].Before(bounds[j])
+	})
+
+	// Sweep through the time between each pair of boundaries, keeping track of which periods cover it.
+	open := []*Period{}
+	next := 0
+	for i := 0; i+1 < len(bounds); i++ {
+		from, to := bounds[i], bounds[i+1]
+		if !to.After(from) {
+			continue
+		}
+
+		kept := open[:0]
+		for _, p := range open {
+			if p.End.After(from) {
+				kept = append(kept, p)
+			}
+		}
+		open = kept
+		for next < len(active) && !active[next].Begin.After(from) {
+			open = append(open, active[next])
+			next++
+		}
+		if len(open) < 2 {
+			continue
+		}
+
+		span := to.Sub(from)
+		switch mode {
+		case OverlapSplit:
+			share := span / time.Duration(len(open))
+			for _, p := range open {
+				p.Excluded += span - share
+			}
+		case OverlapMain:
+			main := false
+			for _, p := range open {
+				main = main || p.Track == ""
+			}
+			if !main {
+				continue
+			}
+			for _, p := range open {
+				if p.Track != "" {
+					p.Excluded += span
+				}
+			}
+		}
+	}
+}
+
+// Conflicts finds events that happen at the same instant on the same track but were recorded on different hosts (see
+// the "host" metadata). These are almost always the same thing recorded twice on two machines while a synced log was
+// out of date, and only one of each set should be kept. Each set is sorted by host. The log should be sorted.
+func (log TimeLog) Conflicts() [][]*Event {
+	out := [][]*Event{}
+	for i := 0; i < len(log); {
+		j := i + 1
+		for j < len(log) && log[j].At.Equal(log[i].At) {
+			j++
+		}
+
+		// Group everything at this instant by track, then see if more than one host is involved.
+		tracks := map[string][]*Event{}
+		order := []string{}
+		for _, e := range log[i:j] {
+			if _, ok := tracks[e.Track]; !ok {
+				order = append(order, e.Track)
+			}
+			tracks[e.Track] = append(tracks[e.Track], e)
+		}
+		for _, track := range order {
+			set := tracks[track]
+			hosts := map[string]bool{}
+			for _, e := range set {
+				hosts[e.Meta["host"]] = true
+			}
+			if len(hosts) > 1 {
+				sort.SliceStable(set, func(a, b int) bool {
+					return set[a].Meta["host"] < set[b].Meta["host"]
+				})
+				out = append(out, set)
+			}
+		}
+		i = j
+	}
+	return out
+}
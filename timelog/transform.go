@@ -0,0 +1,520 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PeriodTransformer changes periods before they are reported. Transformers are given periods sorted by Begin, and
+// must return them sorted the same way. They may modify the periods they are given, or replace them.
+type PeriodTransformer interface {
+	Transform(periods []*Period) []*Period
+}
+
+// Pipeline runs a list of transformers in order, each one getting the output of the last.
+type Pipeline []PeriodTransformer
+
+func (pl Pipeline) Transform(periods []*Period) []*Period {
+	for _, t := range pl {
+		periods = t.Transform(periods)
+	}
+	return periods
+}
+
+// Transform works out the overlap between tracks, see [AttributeOverlap]. This resets any excluded time, so it needs
+// to come before any transformers that exclude time themselves.
+func (m OverlapMode) Transform(periods []*Period) []*Period {
+	AttributeOverlap(periods, m)
+	return periods
+}
+
+// RoundTo rounds the counted time for each period to the nearest multiple of the unit, by changing the excluded
+// time. The periods still begin and end where they did, but their length is rounded.
+type RoundTo time.Duration
+
+func (unit RoundTo) Transform(periods []*Period) []*Period {
+	rounded := RoundPeriods(periods, time.Duration(unit), false)
+	for _, p := range periods {
+		p.Excluded = p.End.Sub(p.Begin) - rounded[p]
+	}
+	return periods
+}
+
+// SplitMidnight splits periods that run into the next day into a period for each day, so the time counts for the day it
+// was actually spent on. Days start at midnight, or the calendar's DayStart. Excluded time is shared out in proportion
+// to how long each part is.
+type SplitMidnight struct {
+	Calendar Calendar
+}
+
+func (s SplitMidnight) Transform(periods []*Period) []*Period {
+	out := make([]*Period, 0, len(periods))
+	split := false
+	for _, p := range periods {
+		next := s.Calendar.Day(p.Begin).AddDate(0, 0, 1)
+		if !p.End.After(next) {
+			out = append(out, p)
+			continue
+		}
+
+		split = true
+		span := p.End.Sub(p.Begin)
+		excluded := p.Excluded
+		for begin := p.Begin; begin.Before(p.End); next = next.AddDate(0, 0, 1) {
+			part := *p
+			part.Begin = begin
+			if next.Before(p.End) {
+				part.End = next
+				part.Excluded = time.Duration(float64(p.Excluded) * float64(next.Sub(begin)) / float64(span))
+			} else {
+				part.End = p.End
+				part.Excluded = excluded
+			}
+			excluded -= part.Excluded
+			out = append(out, &part)
+			begin = part.End
+		}
+	}
+	if split {
+		sort.SliceStable(out, func(i, j int) bool {
+			return out[i].Begin.Before(out[j].Begin)
+		})
+	}
+	return out
+}
+
+// NightShiftGap is how long a gap has to be to end a shift, when night-shift isn't given one.
+const NightShiftGap = 4 * time.Hour
+
+// NightShift counts each shift for the day it started on, for people who work nights. A shift is a run of periods
+// with a code on the same track, with no gap (time with no code, or no periods at all) longer than Gap. Periods
+// aren't split or moved, they just have [Period.Shift] set, so they still show when they really happened.
+type NightShift struct {
+	Gap      time.Duration
+	Calendar Calendar
+}
+
+func (n NightShift) Transform(periods []*Period) []*Period {
+	type shift struct {
+		day time.Time
+		end time.Time
+	}
+	shifts := map[string]*shift{}
+	for _, p := range periods {
+		if p.Code == "" {
+			continue
+		}
+		s := shifts[p.Track]
+		if s == nil || p.Begin.Sub(s.end) > n.Gap {
+			s = &shift{day: n.Calendar.Day(p.Begin)}
+			shifts[p.Track] = s
+		}
+		if p.End.After(s.end) {
+			s.end = p.End
+		}
+		if !s.day.Equal(n.Calendar.Day(p.Begin)) {
+			p.Shift = s.day
+		}
+	}
+	return periods
+}
+
+// MergeGaps joins periods on the same track with the same code and description, if they are only separated by a
+// period without a code that is no longer than Max. The gap counts as part of the merged period. Periods that follow
+// each other directly are always joined.
+type MergeGaps struct {
+	Max time.Duration
+}
+
+func (m MergeGaps) Transform(periods []*Period) []*Period {
+	// Work through each track on its own, keeping track of the last coded period and any gap since.
+	type state struct {
+		last *Period
+		gap  *Period
+	}
+	tracks := map[string]*state{}
+	dropped := map[*Period]bool{}
+	merged := map[*Period]*Period{}
+
+	for _, p := range periods {
+		s := tracks[p.Track]
+		if s == nil {
+			s = &state{}
+			tracks[p.Track] = s
+		}
+
+		if p.Code == "" {
+			if s.gap != nil || p.End.Sub(p.Begin) > m.Max {
+				s.last = nil
+			}
+			s.gap = p
+			continue
+		}
+
+		if s.last != nil && s.last.Code == p.Code && NormalizeDesc(s.last.Desc) == NormalizeDesc(p.Desc) {
+			into := merged[s.last]
+			if into == nil {
+				copied := *s.last
+				into = &copied
+				merged[s.last] = into
+			}
+			into.End = p.End
+			into.Excluded += p.Excluded
+			dropped[p] = true
+			if s.gap != nil {
+				into.Excluded += s.gap.Excluded
+				dropped[s.gap] = true
+			}
+			s.gap = nil
+			continue
+		}
+
+		s.last = p
+		s.gap = nil
+	}
+
+	out := make([]*Period, 0, len(periods)-len(dropped))
+	for _, p := range periods {
+		if dropped[p] {
+			continue
+		}
+		if into, ok := merged[p]; ok {
+			p = into
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// SubtractBreaks treats periods with a code (or any of its children) as unpaid breaks. The break periods are removed,
+// and any time they overlap with periods on other tracks is excluded from those periods. This way a break can either
+// be logged as a normal event, or on a track of its own over the top of whatever you were doing.
+type SubtractBreaks struct {
+	Code string
+	Fold bool // See CodeKey.
+}
+
+func (b SubtractBreaks) isBreak(p *Period) bool {
+	code, key := CodeKey(p.Code, b.Fold), CodeKey(b.Code, b.Fold)
+	return code == key || strings.HasPrefix(code, key+":")
+}
+
+func (b SubtractBreaks) Transform(periods []*Period) []*Period {
+	out := make([]*Period, 0, len(periods))
+	breaks := []*Period{}
+	for _, p := range periods {
+		if b.isBreak(p) {
+			breaks = append(breaks, p)
+			continue
+		}
+		out = append(out, p)
+	}
+
+	for _, br := range breaks {
+		for _, p := range out {
+			if !p.Begin.Before(br.End) {
+				break
+			}
+			if p.Code == "" || p.Track == br.Track || !p.End.After(br.Begin) {
+				continue
+			}
+
+			from, to := p.Begin, p.End
+			if br.Begin.After(from) {
+				from = br.Begin
+			}
+			if br.End.Before(to) {
+				to = br.End
+			}
+			p.Excluded += to.Sub(from)
+			if p.Length() < 0 {
+				p.Excluded = p.End.Sub(p.Begin)
+			}
+		}
+	}
+	return out
+}
+
+// AutoBreak inserts unpaid breaks for labor rules that require them. With After set, a break of Length is put in once
+// you have worked After without one. Otherwise the break goes in the window From to To (both since midnight) of each
+// day you worked through it, by the days of Calendar. Any gap (a period without a code) at least Length long counts as
+// a break already taken.
+//
+// The breaks are periods without a code, so they aren't counted, and whatever was logged where they go is cut short
+// or split around them.
+type AutoBreak struct {
+	Length   time.Duration
+	After    time.Duration
+	From, To time.Duration
+	Calendar Calendar
+}
+
+func (a AutoBreak) Transform(periods []*Period) []*Period {
+	tracks := map[string][]*Period{}
+	order := []string{}
+	for _, p := range periods {
+		if _, ok := tracks[p.Track]; !ok {
+			order = append(order, p.Track)
+		}
+		tracks[p.Track] = append(tracks[p.Track], p)
+	}
+
+	breaks := []*Period{}
+	for _, track := range order {
+		if a.After > 0 {
+			breaks = append(breaks, a.dueBreaks(tracks[track])...)
+		} else {
+			breaks = append(breaks, a.windowBreaks(tracks[track])...)
+		}
+	}
+	if len(breaks) == 0 {
+		return periods
+	}
+
+	for _, br := range breaks {
+		periods = cutPeriods(periods, br)
+	}
+	periods = append(periods, breaks...)
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].Begin.Before(periods[j].Begin)
+	})
+	return periods
+}
+
+// isBreak is true if a gap is long enough to count as a break.
+func (a AutoBreak) isBreak(p *Period) bool {
+	return p.Code == "" && p.End.Sub(p.Begin) >= a.Length
+}
+
+func (a AutoBreak) newBreak(at time.Time, track string) *Period {
+	return &Period{Begin: at, End: at.Add(a.Length), Track: track, Desc: "Automatic break"}
+}
+
+// dueBreaks finds where breaks go on a track when they are due after a set time. Gaps too short to be a break don't
+// stop the clock.
+func (a AutoBreak) dueBreaks(periods []*Period) []*Period {
+	breaks := []*Period{}
+	var start time.Time
+	for _, p := range periods {
+		if a.isBreak(p) {
+			start = time.Time{}
+			continue
+		}
+		if p.Code == "" {
+			continue
+		}
+		if start.IsZero() {
+			start = p.Begin
+		}
+		for start.Add(a.After).Before(p.End) {
+			br := a.newBreak(start.Add(a.After), p.Track)
+			breaks = append(breaks, br)
+			start = br.End
+		}
+	}
+	return breaks
+}
+
+// windowBreaks finds where breaks go on a track when they have to be taken in a window each day. The break goes as
+// early in the window as you were working, as long as it still fits.
+func (a AutoBreak) windowBreaks(periods []*Period) []*Period {
+	breaks := []*Period{}
+	done := map[time.Time]bool{}
+	for _, p := range periods {
+		if p.Code == "" {
+			continue
+		}
+		for day := a.Calendar.Day(p.Begin); day.Before(p.End); day = day.AddDate(0, 0, 1) {
+			midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+			from, to := midnight.Add(a.From), midnight.Add(a.To)
+			if done[day] || !p.Begin.Before(to) || !p.End.After(from) {
+				continue
+			}
+			done[day] = true
+
+			taken := false
+			for _, gap := range periods {
+				if a.isBreak(gap) && gap.Begin.Before(to) && gap.End.After(from) {
+					taken = true
+					break
+				}
+			}
+			if taken {
+				continue
+			}
+
+			at := from
+			if p.Begin.After(at) {
+				at = p.Begin
+			}
+			if at.Add(a.Length).After(to) {
+				at = to.Add(-a.Length)
+			}
+			breaks = append(breaks, a.newBreak(at, p.Track))
+		}
+	}
+	return breaks
+}
+
+// cutPeriods removes the time covered by a break from the periods on its track, shortening or splitting them. Excluded
+// time is shared out between the parts that are left in proportion to how long they are.
+func cutPeriods(periods []*Period, br *Period) []*Period {
+	out := make([]*Period, 0, len(periods)+1)
+	for _, p := range periods {
+		if p.Track != br.Track || !p.Begin.Before(br.End) || !p.End.After(br.Begin) {
+			out = append(out, p)
+			continue
+		}
+
+		span := p.End.Sub(p.Begin)
+		keep := func(begin, end time.Time) {
+			part := *p
+			part.Begin, part.End = begin, end
+			part.Excluded = time.Duration(float64(p.Excluded) * float64(end.Sub(begin)) / float64(span))
+			out = append(out, &part)
+		}
+		if p.Begin.Before(br.Begin) {
+			keep(p.Begin, br.Begin)
+		}
+		if p.End.After(br.End) {
+			keep(br.End, p.End)
+		}
+	}
+	return out
+}
+
+// ParseTransformers parses a list of transformer specs, each of which is a name with an optional "=argument":
+//
+//   - round=<duration>: [RoundTo]
+//   - split-midnight: [SplitMidnight]
+//   - night-shift or night-shift=<gap>: [NightShift]
+//   - merge-gaps=<duration>: [MergeGaps]
+//   - breaks=<code>: [SubtractBreaks]
+//   - auto-break=<length>/<after> or auto-break=<length>@<from>-<to>: [AutoBreak]
+//
+// Blank specs are skipped. The transformers that work in days use cal, and breaks folds case in codes with fold (see
+// CodeKey).
+func ParseTransformers(specs []string, cal Calendar, fold bool) (Pipeline, error) {
+	out := Pipeline{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		name, arg, hasArg := strings.Cut(spec, "=")
+		name, arg = strings.TrimSpace(name), strings.TrimSpace(arg)
+		duration := func() (time.Duration, error) {
+			d, err := time.ParseDuration(arg)
+			if err != nil || d <= 0 {
+				return 0, fmt.Errorf("transformer %q needs a positive duration, such as %s=15m", name, name)
+			}
+			return d, nil
+		}
+
+		switch name {
+		case "round":
+			d, err := duration()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, RoundTo(d))
+		case "split-midnight":
+			if hasArg {
+				return nil, fmt.Errorf("transformer %q doesn't take an argument", name)
+			}
+			out = append(out, SplitMidnight{Calendar: cal})
+		case "night-shift":
+			gap := NightShiftGap
+			if hasArg {
+				d, err := duration()
+				if err != nil {
+					return nil, err
+				}
+				gap = d
+			}
+			out = append(out, NightShift{Gap: gap, Calendar: cal})
+		case "merge-gaps":
+			d, err := duration()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, MergeGaps{Max: d})
+		case "breaks":
+			if arg == "" {
+				return nil, fmt.Errorf("transformer %q needs a time code, such as breaks=Lunch", name)
+			}
+			out = append(out, SubtractBreaks{Code: arg, Fold: fold})
+		case "auto-break":
+			b, err := parseAutoBreak(arg)
+			if err != nil {
+				return nil, fmt.Errorf("transformer %q: %w", name, err)
+			}
+			b.Calendar = cal
+			out = append(out, b)
+		default:
+			return nil, fmt.Errorf("unknown transformer %q, expected 'round', 'split-midnight', 'night-shift', 'merge-gaps', 'breaks', or 'auto-break'", name)
+		}
+	}
+	return out, nil
+}
+
+// parseAutoBreak parses the argument to the auto-break transformer, either "30m/6h" for a 30 minute break after 6
+// hours of work, or "30m@12:00-14:00" for a 30 minute break between noon and 2PM.
+func parseAutoBreak(arg string) (AutoBreak, error) {
+	usage := errors.New("expected <length>/<after> or <length>@<from>-<to>, such as auto-break=30m/6h or auto-break=30m@12:00-14:00")
+
+	if length, after, ok := strings.Cut(arg, "/"); ok {
+		l, err1 := time.ParseDuration(strings.TrimSpace(length))
+		a, err2 := time.ParseDuration(strings.TrimSpace(after))
+		if err1 != nil || err2 != nil || l <= 0 || a <= 0 {
+			return AutoBreak{}, usage
+		}
+		return AutoBreak{Length: l, After: a}, nil
+	}
+
+	length, window, ok := strings.Cut(arg, "@")
+	from, to, ok2 := strings.Cut(window, "-")
+	if !ok || !ok2 {
+		return AutoBreak{}, usage
+	}
+	l, err := time.ParseDuration(strings.TrimSpace(length))
+	if err != nil || l <= 0 {
+		return AutoBreak{}, usage
+	}
+	clock := func(s string) (time.Duration, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, err
+	}
+	f, err1 := clock(from)
+	t, err2 := clock(to)
+	if err1 != nil || err2 != nil || t-f < l {
+		return AutoBreak{}, errors.New("the window needs to be times like 12:00-14:00, at least as long as the break")
+	}
+	return AutoBreak{Length: l, From: f, To: t}, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTransformers(t *testing.T) {
+	cal := Calendar{WeekStart: time.Monday, DayStart: 4 * time.Hour}
+	tests := []struct {
+		spec string
+		want PeriodTransformer
+	}{
+		{"round=15m", RoundTo(15 * time.Minute)},
+		{" round = 6m ", RoundTo(6 * time.Minute)},
+		{"split-midnight", SplitMidnight{Calendar: cal}},
+		{"night-shift", NightShift{Gap: NightShiftGap, Calendar: cal}},
+		{"night-shift=2h", NightShift{Gap: 2 * time.Hour, Calendar: cal}},
+		{"merge-gaps=5m", MergeGaps{Max: 5 * time.Minute}},
+		{"breaks=Lunch", SubtractBreaks{Code: "Lunch", Fold: true}},
+		{"auto-break=30m/6h", AutoBreak{Length: 30 * time.Minute, After: 6 * time.Hour, Calendar: cal}},
+		{"auto-break=30m@12:00-14:00", AutoBreak{Length: 30 * time.Minute, From: 12 * time.Hour, To: 14 * time.Hour, Calendar: cal}},
+	}
+	for _, test := range tests {
+		pl, err := ParseTransformers([]string{test.spec}, cal, true)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if len(pl) != 1 || pl[0] != test.want {
+			t.Errorf("%q parsed as %#v, want %#v", test.spec, pl, test.want)
+		}
+	}
+
+	pl, err := ParseTransformers([]string{"", "merge-gaps=5m", " ", "round=15m"}, cal, false)
+	if err != nil || len(pl) != 2 || pl[0] != (MergeGaps{Max: 5 * time.Minute}) || pl[1] != RoundTo(15*time.Minute) {
+		t.Errorf("a list parsed as %#v, %v, want merge-gaps then round", pl, err)
+	}
+	if pl, err := ParseTransformers(nil, cal, false); err != nil || len(pl) != 0 {
+		t.Errorf("no specs parsed as %#v, %v", pl, err)
+	}
+}
+
+func TestParseTransformersErrors(t *testing.T) {
+	for _, spec := range []string{
+		"round", "round=", "round=0", "round=-15m", "round=quarter",
+		"split-midnight=4h",
+		"night-shift=", "night-shift=long",
+		"merge-gaps", "merge-gaps=0s",
+		"breaks", "breaks=",
+		"auto-break", "auto-break=30m", "auto-break=30m/", "auto-break=0m/6h", "auto-break=30m@12:00",
+		"auto-break=30m@12:00-12:15", "auto-break=30m@noon-14:00",
+		"Round=15m", "rounding=15m",
+	} {
+		if _, err := ParseTransformers([]string{spec}, Calendar{}, false); err == nil {
+			t.Errorf("%q: no error", spec)
+		}
+	}
+}
+
+// Transformers run in the order given, and rounding before merging isn't the same as merging before rounding.
+func TestTransformerOrder(t *testing.T) {
+	cal := Calendar{WeekStart: time.Monday}
+	periods := func() []*Period {
+		return []*Period{
+			period("A", at(12, 9, 0), at(12, 9, 7)),
+			period("", at(12, 9, 7), at(12, 9, 10)),
+			period("A", at(12, 9, 10), at(12, 9, 17)),
+		}
+	}
+	run := func(specs string) []*Period {
+		pl, err := ParseTransformers(strings.Split(specs, ","), cal, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pl.Transform(periods())
+	}
+
+	// Each 7 minutes rounds down to nothing, and so does the gap, so that is all the merged period has.
+	got := run("round=15m,merge-gaps=5m")
+	if len(got) != 1 || got[0].Length() != 0 {
+		t.Errorf("round then merge gave %v", got)
+	}
+
+	// Merged first, it is 17 minutes, which rounds to 15.
+	got = run("merge-gaps=5m,round=15m")
+	if len(got) != 1 || got[0].Length() != 15*time.Minute || !got[0].End.Equal(at(12, 9, 17)) {
+		t.Errorf("merge then round gave %v", got)
+	}
+}
+
+func TestSplitMidnight(t *testing.T) {
+	tests := []struct {
+		name  string
+		cal   Calendar
+		p     *Period
+		parts []time.Time // Where each part begins, then where the last ends.
+	}{
+		{"midnight", Calendar{}, period("A", at(12, 22, 0), at(13, 2, 0)), []time.Time{at(12, 22, 0), at(13, 0, 0), at(13, 2, 0)}},
+		{"before the day start", Calendar{DayStart: 4 * time.Hour}, period("A", at(12, 22, 0), at(13, 2, 0)), []time.Time{at(12, 22, 0), at(13, 2, 0)}},
+		{"over the day start", Calendar{DayStart: 4 * time.Hour}, period("A", at(12, 22, 0), at(13, 6, 0)), []time.Time{at(12, 22, 0), at(13, 4, 0), at(13, 6, 0)}},
+		{"after midnight, before the day start", Calendar{DayStart: 4 * time.Hour}, period("A", at(13, 1, 0), at(13, 5, 0)), []time.Time{at(13, 1, 0), at(13, 4, 0), at(13, 5, 0)}},
+		{"two days", Calendar{}, period("A", at(12, 20, 0), at(14, 1, 0)), []time.Time{at(12, 20, 0), at(13, 0, 0), at(14, 0, 0), at(14, 1, 0)}},
+	}
+	for _, test := range tests {
+		total := test.p.Length()
+		got := SplitMidnight{Calendar: test.cal}.Transform([]*Period{test.p})
+		if len(got) != len(test.parts)-1 {
+			t.Errorf("%s: got %d parts, want %d", test.name, len(got), len(test.parts)-1)
+			continue
+		}
+		var sum time.Duration
+		for i, p := range got {
+			if !p.Begin.Equal(test.parts[i]) || !p.End.Equal(test.parts[i+1]) || p.Code != "A" {
+				t.Errorf("%s: part %d is %v", test.name, i, p)
+			}
+			sum += p.Length()
+		}
+		if sum != total {
+			t.Errorf("%s: parts add up to %v, want %v", test.name, sum, total)
+		}
+	}
+
+	// Excluded time is shared out by length, and none of it is lost.
+	p := &Period{Code: "A", Begin: at(12, 23, 0), End: at(13, 2, 0), Excluded: 30 * time.Minute}
+	got := SplitMidnight{}.Transform([]*Period{p})
+	if len(got) != 2 || got[0].Excluded != 10*time.Minute || got[1].Excluded != 20*time.Minute {
+		t.Errorf("excluded time split as %v", got)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KilometersPerMile is for converting trips logged in one unit to the other.
+const KilometersPerMile = 1.609344
+
+// Trip is travel for a code, recorded alongside the time. Like an [Expense] it is attached to an event, or to a
+// whole day.
+type Trip struct {
+	At       time.Time // The event it is attached to, or the start of the day.
+	Day      bool      // Attached to the day rather than an event.
+	Code     string
+	Distance float64
+	Unit     string // "km" or "mi".
+	From     string
+	To       string
+	Note     string
+}
+
+// Client is the top level of the trip's code, which is who pays for it.
+func (t *Trip) Client() string {
+	client, _, _ := strings.Cut(t.Code, ":")
+	return client
+}
+
+// In is the distance in the given unit, "km" or "mi".
+func (t *Trip) In(unit string) float64 {
+	switch {
+	case t.Unit == unit:
+		return t.Distance
+	case unit == "mi":
+		return t.Distance / KilometersPerMile
+	default:
+		return t.Distance * KilometersPerMile
+	}
+}
+
+// Route describes where the trip went, eg "Home to Acme", or is blank if that wasn't recorded.
+func (t *Trip) Route() string {
+	switch {
+	case t.From != "" && t.To != "":
+		return t.From + " to " + t.To
+	case t.To != "":
+		return "to " + t.To
+	case t.From != "":
+		return "from " + t.From
+	}
+	return ""
+}
+
+// String formats a trip for listing.
+func (t *Trip) String() string {
+	at := t.At.Format(TimeFormat)
+	if t.Day {
+		at = t.At.Format("2006/01/02")
+	}
+	s := fmt.Sprintf("%s [%s] %g %s", at, t.Code, t.Distance, t.Unit)
+	for _, part := range []string{t.Route(), t.Note} {
+		if part != "" {
+			s += ", " + part
+		}
+	}
+	return s
+}
+
+// ParseTrips reads a travel log, with one trip per line and the fields separated by tabs: the time, "event" or "day",
+// the code, the distance, the unit, where from, where to, and the note. The code, places, and note are quoted, and the
+// distance can't be negative. Blank lines and lines starting with '#' are skipped. The result is sorted by time.
+func ParseTrips(content string) ([]*Trip, error) {
+	trips := []*Trip{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 8 {
+			return nil, fmt.Errorf("line %v: expected 8 fields, found %v", i+1, len(fields))
+		}
+
+		t := &Trip{Unit: fields[4]}
+		var err error
+		t.At, err = time.ParseInLocation(TimeFormat, fields[0], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", i+1, err)
+		}
+		switch fields[1] {
+		case "event":
+		case "day":
+			t.Day = true
+		default:
+			return nil, fmt.Errorf("line %v: expected 'event' or 'day', found %q", i+1, fields[1])
+		}
+		t.Distance, err = strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: malformed distance: %w", i+1, err)
+		}
+		if t.Distance < 0 || math.IsNaN(t.Distance) || math.IsInf(t.Distance, 0) {
+			return nil, fmt.Errorf("line %v: malformed distance: %q", i+1, fields[3])
+		}
+		if t.Unit != "km" && t.Unit != "mi" {
+			return nil, fmt.Errorf("line %v: expected 'km' or 'mi', found %q", i+1, t.Unit)
+		}
+		for _, f := range []struct {
+			to   *string
+			from string
+			name string
+		}{{&t.Code, fields[2], "code"}, {&t.From, fields[5], "from"}, {&t.To, fields[6], "to"}, {&t.Note, fields[7], "note"}} {
+			*f.to, err = strconv.Unquote(f.from)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: malformed %s: %w", i+1, f.name, err)
+			}
+		}
+		trips = append(trips, t)
+	}
+	sort.SliceStable(trips, func(i, j int) bool {
+		return trips[i].At.Before(trips[j].At)
+	})
+	return trips, nil
+}
+
+// FormatTrips writes trips in the format read by ParseTrips.
+func FormatTrips(trips []*Trip) string {
+	b := &strings.Builder{}
+	for _, t := range trips {
+		kind := "event"
+		if t.Day {
+			kind = "day"
+		}
+		fmt.Fprintf(b, "%s\t%s\t%q\t%s\t%s\t%q\t%q\t%q\n", t.At.Format(TimeFormat), kind, t.Code, strconv.FormatFloat(t.Distance, 'f', -1, 64), t.Unit, t.From, t.To, t.Note)
+	}
+	return b.String()
+}
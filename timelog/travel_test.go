@@ -0,0 +1,114 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTrips(t *testing.T) {
+	content := "2026/10/13 02:30PM\tevent\t\"Acme:Dev\"\t26.1\tmi\t\"Home\"\t\"Acme\"\t\"\"\n" +
+		"# Travel\n" +
+		"2026/10/12 12:00AM\tday\t\"Bank\"\t42\tkm\t\"\"\t\"Bank\"\t\"Audit\"\n"
+	trips, err := ParseTrips(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Trip{
+		{At: time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local), Day: true, Code: "Bank", Distance: 42, Unit: "km", To: "Bank", Note: "Audit"},
+		{At: time.Date(2026, 10, 13, 14, 30, 0, 0, time.Local), Code: "Acme:Dev", Distance: 26.1, Unit: "mi", From: "Home", To: "Acme"},
+	}
+	if len(trips) != len(want) {
+		t.Fatalf("got %d trips, want %d", len(trips), len(want))
+	}
+	for i, w := range want {
+		if g := *trips[i]; !g.At.Equal(w.At) || g.Day != w.Day || g.Code != w.Code || g.Distance != w.Distance || g.Unit != w.Unit || g.From != w.From || g.To != w.To || g.Note != w.Note {
+			t.Errorf("trip %d is %+v, want %+v", i, g, w)
+		}
+	}
+	if trips[0].Route() != "to Bank" || trips[1].Route() != "Home to Acme" {
+		t.Errorf("routes are %q and %q", trips[0].Route(), trips[1].Route())
+	}
+
+	again, err := ParseTrips(FormatTrips(trips))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if FormatTrips(again) != FormatTrips(trips) {
+		t.Errorf("round trip gave\n%s\nwant\n%s", FormatTrips(again), FormatTrips(trips))
+	}
+}
+
+func TestParseTripsErrors(t *testing.T) {
+	good := []string{"2026/10/13 02:30PM", "day", `"Acme"`, "42", "km", `""`, `""`, `""`}
+	tests := []struct {
+		field int
+		value string
+		err   string
+	}{
+		{0, "yesterday", "line 1: parsing time"},
+		{1, "", "line 1: expected 'event' or 'day'"},
+		{3, "42km", "line 1: malformed distance"},
+		{3, "-5", "line 1: malformed distance"},
+		{3, "NaN", "line 1: malformed distance"},
+		{3, "+Inf", "line 1: malformed distance"},
+		{4, "miles", "line 1: expected 'km' or 'mi'"},
+		{4, "KM", "line 1: expected 'km' or 'mi'"},
+		{4, "", "line 1: expected 'km' or 'mi'"},
+		{5, "Home", "line 1: malformed from"},
+		{6, `"Acme`, "line 1: malformed to"},
+		{7, `x"`, "line 1: malformed note"},
+	}
+	for _, test := range tests {
+		fields := append([]string{}, good...)
+		fields[test.field] = test.value
+		_, err := ParseTrips(strings.Join(fields, "\t"))
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("field %d as %q gave %v, want %q", test.field, test.value, err, test.err)
+		}
+	}
+	if _, err := ParseTrips(strings.Join(good[:7], "\t")); err == nil || !strings.Contains(err.Error(), "expected 8 fields") {
+		t.Errorf("7 fields gave %v", err)
+	}
+}
+
+func TestTripIn(t *testing.T) {
+	tests := []struct {
+		trip Trip
+		unit string
+		want float64
+	}{
+		{Trip{Distance: 10, Unit: "km"}, "km", 10},
+		{Trip{Distance: 10, Unit: "mi"}, "mi", 10},
+		{Trip{Distance: 10, Unit: "mi"}, "km", 16.09344},
+		{Trip{Distance: 16.09344, Unit: "km"}, "mi", 10},
+	}
+	for _, test := range tests {
+		if got := test.trip.In(test.unit); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%v %s in %s is %v, want %v", test.trip.Distance, test.trip.Unit, test.unit, got, test.want)
+		}
+	}
+}
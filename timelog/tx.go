@@ -0,0 +1,146 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"maps"
+)
+
+// Tx stages a change to a timelog that takes more than one step, like an import or a batch of edits, so that either
+// all of it happens or none of it does. The steps are made to a copy of the log, and nothing is saved until Commit,
+// which checks the result first.
+//
+// Events are picked by their index in the staged log as it is after the steps so far, see Log.
+type Tx struct {
+	log TimeLog
+}
+
+// Begin starts a transaction on a copy of the log. The log itself is never changed by the transaction.
+func Begin(log TimeLog) *Tx {
+	return &Tx{log: log.Clone()}
+}
+
+// Clone returns a copy of the log with copies of the events, so changing one doesn't change the other.
+func (log TimeLog) Clone() TimeLog {
+	out := make(TimeLog, len(log))
+	for i, e := range log {
+		out[i] = e.clone()
+	}
+	return out
+}
+
+func (e *Event) clone() *Event {
+	c := *e
+	if e.Meta != nil {
+		c.Meta = maps.Clone(e.Meta)
+	}
+	return &c
+}
+
+// Log returns the staged log. Change it through the transaction, not directly.
+func (tx *Tx) Log() TimeLog {
+	return tx.log
+}
+
+// Append adds events to the end of the staged log. Copies are added, so changing the events afterwards does nothing.
+func (tx *Tx) Append(events ...*Event) {
+	for _, e := range events {
+		tx.log = append(tx.log, e.clone())
+	}
+}
+
+// Edit changes the event at index i with the given function.
+func (tx *Tx) Edit(i int, edit func(e *Event)) error {
+	if i < 0 || i >= len(tx.log) {
+		return fmt.Errorf("no event %d to edit, there are %d", i, len(tx.log))
+	}
+	edit(tx.log[i])
+	return nil
+}
+
+// Delete removes the event at index i. Every event after it moves down one.
+func (tx *Tx) Delete(i int) error {
+	if i < 0 || i >= len(tx.log) {
+		return fmt.Errorf("no event %d to delete, there are %d", i, len(tx.log))
+	}
+	tx.log = append(tx.log[:i], tx.log[i+1:]...)
+	return nil
+}
+
+// Retag changes the time code of the event at index i.
+func (tx *Tx) Retag(i int, code string) error {
+	return tx.Edit(i, func(e *Event) {
+		e.Code = code
+	})
+}
+
+// Sort puts the staged events in order, for when a step is allowed to move an event past others. Otherwise, Validate
+// refuses a log that is out of order.
+func (tx *Tx) Sort() {
+	tx.log.Sort()
+}
+
+// Insert adds an event to the staged log in order, like [TimeLog.Insert], and returns the index it ended up at.
+func (tx *Tx) Insert(e *Event) int {
+	var i int
+	tx.log, i = tx.log.Insert(e.clone())
+	return i
+}
+
+// Validate checks the staged log can be committed: the events are in order, and the log reads back the same once it is
+// written (see [TimeLog.VerifyFormat]). Problems are returned as [ErrInvalid].
+func (tx *Tx) Validate() error {
+	if moved := tx.log.OutOfOrder(); len(moved) > 0 {
+		i := moved[0]
+		return ErrInvalid{fmt.Errorf("%s is before the event preceding it, %s", tx.log[i].String(), tx.log[i-1].String())}
+	}
+	err := tx.log.VerifyFormat()
+	if err != nil {
+		return ErrInvalid{err}
+	}
+	return nil
+}
+
+// Commit validates the staged log and saves it to the store. If anything is wrong nothing is saved.
+func (tx *Tx) Commit(store Store) error {
+	err := tx.Validate()
+	if err != nil {
+		return err
+	}
+	return store.Save(tx.log)
+}
+
+// ErrInvalid is returned by [Tx.Validate] and [Tx.Commit] when the staged log can't be committed, as opposed to an
+// error from the store.
+type ErrInvalid struct {
+	Err error
+}
+
+func (err ErrInvalid) Error() string {
+	return fmt.Sprintf("Can't commit the timelog: %v", err.Err)
+}
+
+func (err ErrInvalid) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTxCommit(t *testing.T) {
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	log := TimeLog{{At: at, Code: "Proj", Desc: "start"}, {At: at.Add(time.Hour)}}
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "sctime.log")}
+	err := store.Save(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		steps func(tx *Tx)
+		want  string // The log in the store after. Empty means Commit has to refuse, and the store is unchanged.
+	}{
+		{"append", func(tx *Tx) {
+			tx.Append(&Event{At: at.Add(2 * time.Hour), Code: "Proj"})
+		}, "start\n\n\n"},
+		{"insert", func(tx *Tx) {
+			tx.Insert(&Event{At: at.Add(30 * time.Minute), Desc: "lunch"})
+		}, "start\nlunch\n\n"},
+		{"out of order", func(tx *Tx) {
+			tx.Append(&Event{At: at.Add(-time.Hour)})
+		}, ""},
+		{"sorted", func(tx *Tx) {
+			tx.Append(&Event{At: at.Add(-time.Hour), Desc: "early"})
+			tx.Sort()
+		}, "early\nstart\n\n"},
+		{"unreadable", func(tx *Tx) {
+			tx.Edit(0, func(e *Event) { e.Desc = "first\n;looks like metadata" })
+		}, ""},
+		{"delete", func(tx *Tx) {
+			tx.Delete(0)
+		}, "\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := store.Save(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx := Begin(log)
+			test.steps(tx)
+			err = tx.Commit(store)
+			if test.want == "" {
+				if !errors.As(err, &ErrInvalid{}) {
+					t.Fatalf("Commit returned %v, want ErrInvalid", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			saved, err := store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			descs := &strings.Builder{}
+			for _, e := range saved {
+				descs.WriteString(e.Desc + "\n")
+			}
+			want := test.want
+			if want == "" {
+				want = "start\n\n"
+			}
+			if descs.String() != want {
+				t.Errorf("saved descriptions %q, want %q", descs.String(), want)
+			}
+			if len(log) != 2 || log[0].Desc != "start" {
+				t.Errorf("the transaction changed the log it began on:\n%s", log.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// CodeUsage is how much a time code has been used, see [TimeLog.Usage].
+type CodeUsage struct {
+	Code   string
+	Last   time.Time // The last event with the code.
+	Events int
+
+	Total  time.Duration // All the time on the code.
+	Recent time.Duration // Time in periods that began at or after the time given to Usage.
+}
+
+// Usage works out how much each time code in the log has been used, sorted by code. Only finished periods count toward
+// the totals, so time since the last event on a track isn't included. If it is not already, the TimeLog will be sorted!
+func (log TimeLog) Usage(since time.Time) []*CodeUsage {
+	found := map[string]*CodeUsage{}
+	for _, item := range log {
+		if strings.TrimSpace(item.Code) == "" {
+			continue
+		}
+		u := found[item.Code]
+		if u == nil {
+			u = &CodeUsage{Code: item.Code}
+			found[item.Code] = u
+		}
+		u.Events++
+		if item.At.After(u.Last) {
+			u.Last = item.At
+		}
+	}
+
+	for _, p := range log.Periods() {
+		u := found[p.Code]
+		if u == nil {
+			continue
+		}
+		u.Total += p.Length()
+		if !p.Begin.Before(since) {
+			u.Recent += p.Length()
+		}
+	}
+
+	out := make([]*CodeUsage, 0, len(found))
+	for _, u := range found {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Code < out[j].Code
+	})
+	return out
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FormatVersion is the version of the timelog format this package writes. It goes up whenever the format changes in a
+// way an older version would misread, so a log can say which version it was written in (see [TimeLog.FormatFile]).
+const FormatVersion = 1
+
+// HeaderPrefix starts the comment on the first line of a timelog that says which format version it is in. Being a
+// comment, versions from before there was a header just skip it.
+const HeaderPrefix = "# timeclock format "
+
+// FormatFile is [TimeLog.Format] for a whole timelog file, with a header giving the format version first.
+func (log TimeLog) FormatFile(w io.Writer) error {
+	for _, item := range log {
+		err := item.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s%d\n", HeaderPrefix, FormatVersion)
+	if err != nil {
+		return err
+	}
+	return log.Format(w)
+}
+
+// FormatVersionOf returns the format version from the header of a timelog, or 0 if it has none, which means it was
+// written before there were versions (or by hand).
+func FormatVersionOf(input string) int {
+	line, _, _ := strings.Cut(input, "\n")
+	version, ok := strings.CutPrefix(strings.TrimSuffix(line, "\r"), HeaderPrefix)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(version))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// DisplayWidth returns how many columns text takes up in a terminal. Wide characters (most CJK, and most emoji) take
+// two, combining marks and other invisible characters (like the joiners in emoji sequences) take none, and everything
+// else takes one. An emoji joined on to the one before it by a zero width joiner, or a skin tone after another
+// character, is drawn as part of it, so it takes none either. Terminals don't all agree on the odd cases, but this is what most of them do.
+func DisplayWidth(text string) int {
+	n := 0
+	prev := rune(0)
+	for _, r := range text {
+		if prev != zeroWidthJoiner && !(prev != 0 && isSkinTone(r)) {
+			n += RuneWidth(r)
+		}
+		prev = r
+	}
+	return n
+}
+
+// zeroWidthJoiner joins emoji into sequences that are drawn as one, like 👩‍💻.
+const zeroWidthJoiner = '\u200d'
+
+// isSkinTone is true for the emoji modifiers that change the skin tone of the emoji before them, like 👍🏽.
+func isSkinTone(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+// RuneWidth returns how many columns a single rune takes up on its own, see DisplayWidth.
+func RuneWidth(r rune) int {
+	if r < 0x20 || (r >= 0x7f && r < 0xa0) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// PadLeft right aligns text in n columns, see DisplayWidth. Text that is already wider is left alone.
+func PadLeft(text string, n int) string {
+	if pad := n - DisplayWidth(text); pad > 0 {
+		return strings.Repeat(" ", pad) + text
+	}
+	return text
+}
+
+// PadRight left aligns text in n columns, see DisplayWidth. Text that is already wider is left alone.
+func PadRight(text string, n int) string {
+	if pad := n - DisplayWidth(text); pad > 0 {
+		return text + strings.Repeat(" ", pad)
+	}
+	return text
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package timelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ASCII", "Client:Dev", 10},
+		{"precomposed accent", "Café", 4},
+		{"combining accent", "Cafe\u0301", 4},
+		{"two combining marks", "a\u0308\u0323", 1},
+		{"CJK with an ASCII colon", "项目:开发", 9},
+		{"halfwidth katakana", "ｶﾀｶﾅ", 4},
+		{"fullwidth Latin", "ＡＢ", 4},
+		{"Hangul", "한국어", 6},
+		{"emoji", "🎉", 2},
+		{"ZWJ sequence", "👩\u200d💻", 2},
+		{"long ZWJ sequence", "👨\u200d👩\u200d👧\u200d👦", 2},
+		{"skin tone", "👍🏽", 2},
+		{"skin tone and ZWJ", "👩🏽\u200d💻 ok", 5},
+		{"lone skin tone", "🏽", 2},
+		{"zero width space", "x\u200by", 2},
+		{"control character", "a\tb", 2},
+	}
+	for _, test := range tests {
+		if got := DisplayWidth(test.text); got != test.want {
+			t.Errorf("%s: DisplayWidth(%q) = %d, want %d", test.name, test.text, got, test.want)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		text        string
+		n           int
+		left, right string
+	}{
+		{"abc", 5, "  abc", "abc  "},
+		{"开发", 5, " 开发", "开发 "},
+		{"Cafe\u0301", 6, "  Cafe\u0301", "Cafe\u0301  "},
+		{"👩\u200d💻", 3, " 👩\u200d💻", "👩\u200d💻 "},
+		{"项目:开发", 4, "项目:开发", "项目:开发"},
+	}
+	for _, test := range tests {
+		if got := PadLeft(test.text, test.n); got != test.left {
+			t.Errorf("PadLeft(%q, %d) = %q, want %q", test.text, test.n, got, test.left)
+		}
+		if got := PadRight(test.text, test.n); got != test.right {
+			t.Errorf("PadRight(%q, %d) = %q, want %q", test.text, test.n, got, test.right)
+		}
+	}
+}
+
+// The closing brackets of the codes in a formatted log should all be in the same column on a terminal, whatever the
+// codes are written in.
+func TestFormatAlignsCodes(t *testing.T) {
+	at := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	log := TimeLog{
+		{At: at, Code: "Dev"},
+		{At: at.Add(time.Hour), Code: "项目:开发", Track: "仕事"},
+		{At: at.Add(2 * time.Hour), Code: "Cafe\u0301:Menu"},
+		{At: at.Add(3 * time.Hour), Code: "👩\u200d💻:Review", Track: "work"},
+	}
+	if got, want := log.CodeLen(), 9; got != want {
+		t.Errorf("CodeLen() = %d, want %d", got, want)
+	}
+
+	buf := &strings.Builder{}
+	err := log.Format(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(log) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(log), buf)
+	}
+	for _, line := range lines {
+		if got, want := DisplayWidth(line[:strings.Index(line, "]")]), DisplayWidth(lines[0][:strings.Index(lines[0], "]")]); got != want {
+			t.Errorf("code ends in column %d, not %d:\n%s", got, want, buf)
+		}
+		if got, want := DisplayWidth(line[:strings.Index(line, "[")]), DisplayWidth(lines[0][:strings.Index(lines[0], "[")]); got != want {
+			t.Errorf("code starts in column %d, not %d:\n%s", got, want, buf)
+		}
+	}
+
+	parsed, err := ParseTimeLog(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range log {
+		if parsed[i].Code != log[i].Code || parsed[i].Track != log[i].Track {
+			t.Errorf("event %d read back as @%s [%s], want @%s [%s]", i, parsed[i].Track, parsed[i].Code, log[i].Track, log[i].Code)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milochristiansen/timeclock/report"
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// TotalsCache keeps per-day totals around between runs, so something like a status bar asking for today's total every
+// few seconds doesn't have to parse the whole timelog every time. Everything in it is only good for the exact timelog
+// it was worked out from, see Fingerprint.
+type TotalsCache struct {
+	Log   string   // Fingerprint of the timelog.
+	Codes []string // The codes in the timelog, for matching the ones asked for.
+
+	// Per-day totals for each set of filters. Keyed by the filters, then the day (2006-01-02), then the code.
+	Totals map[string]map[string]map[string]time.Duration
+}
+
+// Fingerprint returns a hash of the timelog and its archives, which changes whenever anything in them does.
+func Fingerprint(content []byte, archives []timelog.Shard) string {
+	h := sha256.New()
+	for _, shard := range archives {
+		fmt.Fprintf(h, "%q %d\n", shard.Name, len(shard.Content))
+		h.Write([]byte(shard.Content))
+	}
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// LoadTotalsCache reads the cache file. If the file doesn't exist, can't be read, or is for a different timelog, an
+// empty cache is returned instead. A broken cache is never worth stopping for.
+func LoadTotalsCache(path, fingerprint string) *TotalsCache {
+	cache := &TotalsCache{}
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(raw, cache)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "Ignoring unreadable totals cache:", err)
+	}
+	if err != nil || cache.Log != fingerprint {
+		cache = &TotalsCache{Log: fingerprint}
+	}
+	if cache.Totals == nil {
+		cache.Totals = map[string]map[string]map[string]time.Duration{}
+	}
+	return cache
+}
+
+// Save writes the cache file, creating the directory it goes in if needed.
+func (cache *TotalsCache) Save(path string) error {
+	raw, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		return err
+	}
+
+	// Write to the side and move it into place, two status bars at once shouldn't be able to break it.
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, raw, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// TotalsKey is what a set of cached totals is kept under: the codes and filters asked for, and every setting that
+// changes what the periods are or which codes match. Anything that changes the totals without changing the timelog
+// has to be in here, or the cache will keep answering with the old ones.
+func TotalsKey(codes []string, filters *ReportFilters, config map[string]string) string {
+	return fmt.Sprintf("codes=%q daystart=%q overlappolicy=%q foldcodecase=%q %s", codes, config["daystart"],
+		config["overlappolicy"], config["foldcodecase"], filters.Key)
+}
+
+// TotalCommand prints the total time for a range of whole days, from the day of the first time given to the day of
+// the second (or today). Periods count for the day they begin on.
+func TotalCommand(args []string, content []byte, archives []timelog.Shard, config map[string]string) {
+	args, filters := TakeReportFilters(args, config)
+
+	// Only parse the timelog if the cache can't cover everything.
+	var log timelog.TimeLog
+	parse := func() timelog.TimeLog {
+		if log == nil {
+			var problems []*timelog.Problem
+			log, problems = timelog.ParseShards(append(archives, timelog.Shard{Content: string(content)}), 0)
+			for _, problem := range problems {
+				PrintParseError(problem)
+			}
+		}
+		return log
+	}
+
+	path := config["cachefile"]
+	cache := &TotalsCache{Totals: map[string]map[string]map[string]time.Duration{}}
+	if path != "" {
+		cache = LoadTotalsCache(path, Fingerprint(content, archives))
+	}
+	dirty := false
+	if cache.Codes == nil {
+		cache.Codes = parse().Codes()
+		sort.Strings(cache.Codes)
+		dirty = true
+	}
+
+	args, codes := TakeCodePatterns(args, cache.Codes)
+	begin, end := ParseTimeRange(args)
+	found, _ := FindAllTimecodes(args, append(cache.Codes, "empty", "all"))
+	for _, f := range found {
+		codes = append(codes, f[0].Code)
+	}
+	sort.Strings(codes)
+
+	key := TotalsKey(codes, filters, config)
+	days, ok := cache.Totals[key]
+	Debug.Debug("totals cache", "file", path, "key", key, "hit", ok)
+	if !ok {
+		days = map[string]map[string]time.Duration{}
+		for day, totals := range timelog.TotalsByDay(filters.Apply(parse().PeriodsWith(OverlapPolicy), report.CodeFilter(codes, FoldCodeCase)), Calendar, FoldCodeCase) {
+			days[day.Format("2006-01-02")] = totals
+		}
+		cache.Totals[key] = days
+		dirty = true
+	}
+
+	if dirty && path != "" {
+		err := cache.Save(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing totals cache:", err)
+		}
+	}
+
+	last := Clock.Now()
+	if end != nil {
+		last = *end
+	}
+	var total time.Duration
+	for day := Calendar.Day(*begin); !day.After(last); day = day.AddDate(0, 0, 1) {
+		for _, d := range days[day.Format("2006-01-02")] {
+			total += d
+		}
+	}
+
+	if len(codes) > 0 {
+		fmt.Fprintf(os.Stderr, "Timecodes: %v\n", strings.Join(codes, ", "))
+	}
+	fmt.Println(timelog.FormatDuration(total, Durations))
+}
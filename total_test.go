@@ -0,0 +1,46 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestTotalsKeySettings(t *testing.T) {
+	config := map[string]string{"daystart": "", "overlappolicy": "clip", "foldcodecase": "true"}
+	filters := &ReportFilters{Key: "overlap=0"}
+	base := TotalsKey([]string{"Proj"}, filters, config)
+
+	// Each of these changes the totals without changing the timelog, so it has to change the key.
+	for k, v := range map[string]string{"daystart": "4h", "overlappolicy": "error", "foldcodecase": "false"} {
+		changed := maps.Clone(config)
+		changed[k] = v
+		if TotalsKey([]string{"Proj"}, filters, changed) == base {
+			t.Errorf("changing %s to %q doesn't change the totals key", k, v)
+		}
+	}
+	if TotalsKey([]string{"Ops"}, filters, config) == base {
+		t.Error("the codes don't change the totals key")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// LoadTrips reads the travel log, see [timelog.ParseTrips]. A missing log is just empty.
+func LoadTrips(path string) ([]*timelog.Trip, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*timelog.Trip{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return timelog.ParseTrips(string(content))
+}
+
+// SaveTrips writes the travel log.
+func SaveTrips(path string, trips []*timelog.Trip) error {
+	return os.WriteFile(path, []byte(timelog.FormatTrips(trips)), 0666)
+}
+
+// parseDistance reads a distance like "42", "42km", or "26.1mi", in unit if it doesn't say.
+func parseDistance(text, unit string) (float64, string, bool) {
+	for _, u := range []string{"km", "mi"} {
+		if n, ok := strings.CutSuffix(text, u); ok {
+			text, unit = n, u
+			break
+		}
+	}
+	d, err := strconv.ParseFloat(text, 64)
+	return d, unit, err == nil && d > 0 && !math.IsInf(d, 1)
+}
+
+// TravelCommand handles 'travel'. 'travel list' lists the trips, in a time range if one is given. Otherwise the
+// arguments are a distance, an optional :code, and a note, with --from and --to for where the trip went. Trips are
+// attached like expenses, see [Attach].
+func TravelCommand(args []string, log timelog.TimeLog, path, unit string) {
+	trips, err := LoadTrips(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading travel log:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(10)
+	}
+
+	if len(args) > 0 && args[0] == "list" {
+		shown := trips
+		if len(args) > 1 {
+			begin, end := ParseTimeRange(args[1:])
+			shown = []*timelog.Trip{}
+			for _, t := range trips {
+				if !t.At.Before(*begin) && (end == nil || t.At.Before(*end)) {
+					shown = append(shown, t)
+				}
+			}
+		}
+		if len(shown) == 0 {
+			fmt.Fprintln(os.Stderr, "No trips.")
+			return
+		}
+		total := 0.0
+		for _, t := range shown {
+			fmt.Println(t.String())
+			total += t.In(unit)
+		}
+		fmt.Printf("Total: %.1f %s\n", total, unit)
+		return
+	}
+
+	args, from := TakeFlagValue(args, "--from")
+	args, to := TakeFlagValue(args, "--to")
+	args, atflag := TakeFlagValue(args, "--at")
+	args, dayflag := TakeFlagValue(args, "--day")
+	if atflag != "" && dayflag != "" {
+		fmt.Fprintln(os.Stderr, "A trip goes on an event (--at) or a day (--day), not both.")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "'travel' needs a distance, eg 'travel 42km :Acme --from Home --to Office'.")
+		os.Exit(2)
+	}
+
+	t := &timelog.Trip{From: from, To: to}
+	var ok bool
+	t.Distance, t.Unit, ok = parseDistance(args[0], unit)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Invalid distance %q, expected a number like 42, 42km, or 26mi.\n", args[0])
+		os.Exit(2)
+	}
+	args = args[1:]
+	if len(args) > 0 && (args[0] == "km" || args[0] == "mi") {
+		t.Unit, args = args[0], args[1:]
+	}
+	if len(args) > 0 && strings.HasPrefix(args[0], ":") {
+		t.Code, args = strings.TrimPrefix(args[0], ":"), args[1:]
+	}
+	t.Note = strings.Join(args, " ")
+	t.At, t.Day, t.Code = Attach(log, atflag, dayflag, t.Code, "trip")
+
+	err = SaveTrips(path, append(trips, t))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing travel log:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(10)
+	}
+	fmt.Println("Added trip:", t.String())
+}
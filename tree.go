@@ -0,0 +1,89 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// PrintCodeTree writes the time code tree with the time on each code, including everything under it, drawn with box
+// drawing characters. Codes that only exist as a parent of other codes get the time of their children.
+func PrintCodeTree(w io.Writer, totals map[string]time.Duration) {
+	// The tree uses the first spelling of each part of a code, so totals are looked up by key (see timelog.CodeKey).
+	codes := make([]string, 0, len(totals))
+	bykey := map[string]time.Duration{}
+	for code, d := range totals {
+		if code != "" {
+			codes = append(codes, code)
+			bykey[timelog.CodeKey(code, FoldCodeCase)] += d
+		}
+	}
+	sort.Strings(codes)
+	tree := timelog.GenerateTimecodeTree(codes, FoldCodeCase)
+	totals = bykey
+
+	tw := tabwriter.NewWriter(w, 2, 4, 2, ' ', 0)
+	var all time.Duration
+	for _, name := range treeKids(tree) {
+		all += printCodeNode(tw, tree.Kids[name], name, "", "", totals)
+	}
+	fmt.Fprintf(tw, "Total\t%s\n", timelog.FormatDuration(all, Durations))
+	tw.Flush()
+}
+
+// printCodeNode writes a node and everything under it, returning the node's total. first is the prefix for the node's
+// own line, and rest is the prefix for the lines under it.
+func printCodeNode(w io.Writer, n *timelog.TimecodeTreeNode, name, first, rest string, totals map[string]time.Duration) time.Duration {
+	// The total isn't known until the children are done, so they are written to a buffer first.
+	kids := &bytes.Buffer{}
+	total := totals[timelog.CodeKey(n.Self, FoldCodeCase)]
+	names := treeKids(n)
+	for i, kid := range names {
+		if i == len(names)-1 {
+			total += printCodeNode(kids, n.Kids[kid], kid, rest+"└── ", rest+"    ", totals)
+		} else {
+			total += printCodeNode(kids, n.Kids[kid], kid, rest+"├── ", rest+"│   ", totals)
+		}
+	}
+
+	fmt.Fprintf(w, "%s%s\t%s\n", first, name, timelog.FormatDuration(total, Durations))
+	w.Write(kids.Bytes())
+	return total
+}
+
+// treeKids returns the names of a node's children, sorted.
+func treeKids(n *timelog.TimecodeTreeNode) []string {
+	names := make([]string, 0, len(n.Kids))
+	for name := range n.Kids {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
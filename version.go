@@ -0,0 +1,96 @@
+/*
+Copyright 2026 by Milo Christiansen
+
+This software is provided 'as-is', without any express or implied warranty. In
+no event will the authors be held liable for any damages arising from the use of
+this software.
+
+Permission is granted to anyone to use this software for any purpose, including
+commercial applications, and to alter it and redistribute it freely, subject to
+the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not claim
+that you wrote the original software. If you use this software in a product, an
+acknowledgment in the product documentation would be appreciated but is not
+required.
+
+2. Altered source versions must be plainly marked as such, and must not be
+misrepresented as being the original software.
+
+3. This notice may not be removed or altered from any source distribution.
+*/
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/milochristiansen/timeclock/timelog"
+)
+
+// Version, BuildCommit, and BuildDate describe this build. They are filled in from what the Go toolchain records in
+// the binary, but can be set with -ldflags "-X main.Version=1.2.0" and so on when that isn't there, eg in a tarball
+// build.
+var (
+	Version     = ""
+	BuildCommit = ""
+	BuildDate   = ""
+)
+
+// BuildVersion returns the version, commit, and build date, with anything not set at link time taken from the build
+// info. modified is true if the commit had uncommitted changes on top of it.
+func BuildVersion() (version, commit, date string, modified bool) {
+	version, commit, date = Version, BuildCommit, BuildDate
+
+	info, ok := debug.ReadBuildInfo()
+	if ok {
+		if version == "" && info.Main.Version != "" {
+			version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if commit == "" {
+					commit = setting.Value
+				}
+			case "vcs.time":
+				if date == "" {
+					date = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if version == "" {
+		version = "(devel)"
+	}
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		date = t.Local().Format(timelog.TimeFormat)
+	}
+	return version, commit, date, modified
+}
+
+// VersionCommand is 'version'.
+func VersionCommand() {
+	version, commit, date, modified := BuildVersion()
+	fmt.Printf("timeclock %s\n", version)
+	if commit != "" {
+		if modified {
+			commit += " (modified)"
+		}
+		fmt.Printf("commit %s\n", commit)
+	}
+	if date != "" {
+		fmt.Printf("built %s\n", date)
+	}
+	fmt.Printf("timelog format %d\n", timelog.FormatVersion)
+	fmt.Printf("%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}